@@ -1,9 +1,14 @@
 package config
 
+import "time"
+
 type Config struct {
-	App    App
-	DB     DB
-	JwtKey []byte
+	App      App
+	DB       DB
+	Storage  Storage
+	PhotoGC  PhotoGC
+	JwtKey   []byte
+	MediaKey []byte
 }
 
 type App struct {
@@ -19,3 +24,21 @@ type DB struct {
 	Password string
 	SslMode  string
 }
+
+type Storage struct {
+	Driver    string
+	Dir       string
+	BaseURL   string
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+// PhotoGC configures the photo garbage collector. With DryRun set it only
+// logs what it would remove.
+type PhotoGC struct {
+	DryRun    bool
+	OlderThan time.Duration
+}
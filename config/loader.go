@@ -1,8 +1,12 @@
 package config
 
 import (
+	"cmp"
+	"crypto/hmac"
+	"crypto/sha256"
 	"log"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -26,6 +30,47 @@ func Load() *Config {
 			Password: os.Getenv("DB_PASSWORD"),
 			SslMode:  os.Getenv("DB_SSLMODE"),
 		},
-		JwtKey: []byte(os.Getenv("JWT_KEY")),
+		Storage: Storage{
+			Driver:    os.Getenv("STORAGE_DRIVER"),
+			Dir:       cmp.Or(os.Getenv("STORAGE_DIR"), "uploads"),
+			BaseURL:   os.Getenv("STORAGE_BASE_URL"),
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		},
+		PhotoGC: PhotoGC{
+			DryRun:    os.Getenv("PHOTO_GC_DRY_RUN") == "true",
+			OlderThan: durationOr(os.Getenv("PHOTO_GC_OLDER_THAN"), 24*time.Hour),
+		},
+		JwtKey:   []byte(os.Getenv("JWT_KEY")),
+		MediaKey: mediaKey(os.Getenv("MEDIA_KEY"), os.Getenv("JWT_KEY")),
+	}
+}
+
+// mediaKey is the key media links are signed with. Without MEDIA_KEY it is
+// derived from the JWT key, so a signed media link never doubles as a token
+// signature or the other way round.
+func mediaKey(value, jwtKey string) []byte {
+	if value != "" {
+		return []byte(value)
+	}
+	if jwtKey == "" {
+		log.Fatal("MEDIA_KEY or JWT_KEY must be set")
+	}
+
+	h := hmac.New(sha256.New, []byte(jwtKey))
+	h.Write([]byte("media"))
+	return h.Sum(nil)
+}
+
+// durationOr parses value as a duration and falls back to def when it is
+// empty or invalid.
+func durationOr(value string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return def
 	}
+	return d
 }
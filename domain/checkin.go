@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"context"
+	"time"
+	"timo/dto"
+	"timo/models"
+)
+
+type CheckinRepository interface {
+	GetListByUserID(ctx context.Context, userID int64, filter *models.CheckinFilter) ([]models.MoodCheckin, error)
+	GetByID(ctx context.Context, uid string) (*models.MoodCheckin, error)
+	Create(ctx context.Context, checkin *models.MoodCheckin) error
+	Update(ctx context.Context, checkin *models.MoodCheckin) error
+	Delete(ctx context.Context, uid string) error
+}
+
+type CheckinService interface {
+	GetList(ctx context.Context, userID int64, query *dto.CheckinListQuery) ([]dto.CheckinResponse, error)
+	GetByID(ctx context.Context, userID int64, uid string) (*dto.CheckinResponse, error)
+	Create(ctx context.Context, userID int64, loc *time.Location, req *dto.CheckinRequest) (*dto.CheckinResponse, error)
+	Update(ctx context.Context, userID int64, loc *time.Location, uid string, req *dto.CheckinRequest) (*dto.CheckinResponse, error)
+	Delete(ctx context.Context, userID int64, uid string) error
+}
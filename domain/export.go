@@ -0,0 +1,10 @@
+package domain
+
+import (
+	"context"
+	"timo/dto"
+)
+
+type ExportService interface {
+	Export(ctx context.Context, userID int64, query *dto.ExportQuery) (*dto.Export, error)
+}
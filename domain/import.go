@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"context"
+	"time"
+	"timo/dto"
+	"timo/models"
+)
+
+type ImportRepository interface {
+	Create(ctx context.Context, imp *models.Import) error
+	GetByUid(ctx context.Context, uid string) (*models.Import, error)
+	GetListByUserID(ctx context.Context, userID int64, limit int) ([]models.Import, error)
+	ClaimPending(ctx context.Context, limit int, staleAfter time.Duration) ([]models.Import, error)
+	SetTotal(ctx context.Context, id int64, total int) error
+	AddEntry(ctx context.Context, entry *models.ImportEntry) error
+	FindImported(ctx context.Context, userID int64, sourceIDs []string) (map[string]string, error)
+	Finish(ctx context.Context, id int64, status string, message *string) error
+}
+
+type ImportService interface {
+	Create(ctx context.Context, userID int64, upload *dto.ImportUpload) (*dto.ImportResponse, error)
+	GetList(ctx context.Context, userID int64) ([]dto.ImportResponse, error)
+	GetByID(ctx context.Context, userID int64, uid string) (*dto.ImportResponse, error)
+}
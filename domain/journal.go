@@ -2,13 +2,42 @@ package domain
 
 import (
 	"context"
+	"errors"
+	"time"
+	"timo/dto"
 	"timo/models"
 )
 
+var ErrVersionMismatch = errors.New("journal version mismatch")
+
 type JournalRepository interface {
-	GetListByUserID(ctx context.Context, userID int64) ([]models.Journal, error)
+	GetListByUserID(ctx context.Context, userID int64, filter *models.JournalFilter) ([]models.Journal, error)
 	GetByID(ctx context.Context, uid string) (*models.Journal, error)
+	GetCalendar(ctx context.Context, userID int64, from, to time.Time) ([]models.CalendarDay, error)
+	GetOnThisDay(ctx context.Context, userID int64, date time.Time) ([]models.Memory, error)
+	GetForExport(ctx context.Context, userID int64, from, to *time.Time) ([]models.Journal, error)
 	Create(ctx context.Context, journal *models.Journal) error
 	Update(ctx context.Context, journal *models.Journal) error
-	Delete(ctx context.Context, uid string) error
+	Patch(ctx context.Context, uid string, version int64, patch *models.JournalPatch) error
+	Autosave(ctx context.Context, uid string, patch *models.JournalPatch, savedAt time.Time) error
+	Publish(ctx context.Context, uid string) error
+	Delete(ctx context.Context, uid string, version int64) error
+	GetRevisions(ctx context.Context, journalID int64) ([]models.JournalRevision, error)
+	GetRevision(ctx context.Context, journalID int64, revision int) (*models.JournalRevision, error)
+}
+
+type JournalService interface {
+	GetList(ctx context.Context, userID int64, query *dto.JournalListQuery) ([]dto.JournalResponse, error)
+	GetByID(ctx context.Context, userID int64, uid string) (*dto.JournalResponse, error)
+	GetCalendar(ctx context.Context, userID int64, loc *time.Location, query *dto.CalendarQuery) (*dto.CalendarResponse, error)
+	GetMemories(ctx context.Context, userID int64, loc *time.Location) ([]dto.MemoryResponse, error)
+	Create(ctx context.Context, userID int64, loc *time.Location, req *dto.JournalRequest) (*dto.JournalResponse, error)
+	Update(ctx context.Context, userID int64, uid string, version int64, req *dto.JournalRequest) (*dto.JournalResponse, error)
+	Patch(ctx context.Context, userID int64, uid string, version int64, req *dto.JournalPatchRequest) (*dto.JournalResponse, error)
+	Delete(ctx context.Context, userID int64, uid string, version int64) error
+	Autosave(ctx context.Context, userID int64, uid string, req *dto.JournalPatchRequest) error
+	Publish(ctx context.Context, userID int64, uid string) (*dto.JournalResponse, error)
+	GetRevisions(ctx context.Context, userID int64, uid string) ([]dto.JournalRevisionResponse, error)
+	Diff(ctx context.Context, userID int64, uid string, from, to int) (*dto.JournalDiffResponse, error)
+	Revert(ctx context.Context, userID int64, uid string, revision int) (*dto.JournalResponse, error)
 }
@@ -0,0 +1,10 @@
+package domain
+
+import (
+	"context"
+	"timo/dto"
+)
+
+type MediaService interface {
+	Open(ctx context.Context, key, expires, sig string) (*dto.MediaObject, error)
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"timo/dto"
+	"timo/models"
+)
+
+var (
+	ErrMoodInUse     = errors.New("mood is referenced by journals or check-ins")
+	ErrMoodDuplicate = errors.New("mood label already exists")
+)
+
+type MoodRepository interface {
+	GetVisible(ctx context.Context, userID int64, includeArchived bool, locales []string) ([]models.Mood, error)
+	GetByID(ctx context.Context, id int64) (*models.Mood, error)
+	CountSelectable(ctx context.Context, userID int64, ids []int64) (int, error)
+	Create(ctx context.Context, mood *models.Mood) error
+	Update(ctx context.Context, mood *models.Mood) error
+	Reorder(ctx context.Context, userID int64, ids []int64) error
+	Delete(ctx context.Context, id, reassignTo int64) error
+}
+
+type MoodService interface {
+	GetList(ctx context.Context, userID int64, locales []string, query *dto.MoodListQuery) ([]dto.MoodResponse, error)
+	Create(ctx context.Context, userID int64, req *dto.MoodRequest) (*dto.MoodResponse, error)
+	Update(ctx context.Context, userID, id int64, req *dto.MoodUpdateRequest) (*dto.MoodResponse, error)
+	Reorder(ctx context.Context, userID int64, req *dto.MoodOrderRequest) ([]dto.MoodResponse, error)
+	Delete(ctx context.Context, userID, id int64, query *dto.MoodDeleteQuery) error
+}
@@ -2,11 +2,39 @@ package domain
 
 import (
 	"context"
+	"errors"
+	"time"
+	"timo/dto"
 	"timo/models"
 )
 
+var (
+	ErrQuotaExceeded = errors.New("storage quota exceeded")
+	ErrPhotoLimit    = errors.New("journal photo limit reached")
+)
+
 type PhotoRepository interface {
 	GetByJournalID(ctx context.Context, journalID int64) ([]models.Photo, error)
-	Create(ctx context.Context, photo *models.Photo) error
-	Delete(ctx context.Context, id int64) error
+	GetByJournalIDs(ctx context.Context, journalIDs []int64) ([]models.Photo, error)
+	GetByID(ctx context.Context, id int64) (*models.Photo, error)
+	GetBlob(ctx context.Context, userID int64, checksum string) (*models.PhotoBlob, error)
+	Create(ctx context.Context, photo *models.Photo, limit int, uploadID int64) (bool, error)
+	Delete(ctx context.Context, id int64) (*models.PhotoBlob, error)
+	Update(ctx context.Context, id int64, patch *models.PhotoPatch) error
+	Reorder(ctx context.Context, journalID int64, ids []int64) error
+	CountByJournalID(ctx context.Context, journalID int64) (int, error)
+	ReserveStorage(ctx context.Context, userID, size, quota int64) (int64, error)
+	ReleaseStorage(ctx context.Context, userID, size int64) error
+	ClaimPending(ctx context.Context, limit int, staleAfter time.Duration) ([]models.PhotoBlob, error)
+	SaveProcessed(ctx context.Context, blob *models.PhotoBlob) error
+	MarkFailed(ctx context.Context, blobID int64) error
+	UnreferencedKeys(ctx context.Context, keys []string) ([]string, error)
+}
+
+type PhotoService interface {
+	GetList(ctx context.Context, userID int64, journalUid string) ([]dto.PhotoResponse, error)
+	Upload(ctx context.Context, userID int64, journalUid string, upload *dto.PhotoUpload) (*dto.PhotoResponse, error)
+	Update(ctx context.Context, userID int64, journalUid string, id int64, req *dto.PhotoUpdateRequest) (*dto.PhotoResponse, error)
+	Reorder(ctx context.Context, userID int64, journalUid string, req *dto.PhotoOrderRequest) ([]dto.PhotoResponse, error)
+	Delete(ctx context.Context, userID int64, journalUid string, id int64) error
 }
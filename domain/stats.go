@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"context"
+	"time"
+	"timo/dto"
+	"timo/models"
+)
+
+type StatsRepository interface {
+	GetStreak(ctx context.Context, userID int64) (*models.Streak, error)
+	AdvanceStreak(ctx context.Context, userID int64, date time.Time) (bool, error)
+	RecomputeStreak(ctx context.Context, userID int64) (*models.Streak, error)
+	CountEntries(ctx context.Context, userID int64, from, to time.Time) (int, error)
+	GetMoodDistribution(ctx context.Context, userID int64, from, to time.Time) ([]models.MoodCount, error)
+	GetMoodTrend(ctx context.Context, userID int64, from, to time.Time, interval string) ([]models.MoodTrendPoint, error)
+	GetMoodByWeekday(ctx context.Context, userID int64, from, to time.Time) ([]models.MoodWeekday, error)
+}
+
+type StatsService interface {
+	GetStats(ctx context.Context, userID int64, loc *time.Location, weeklyGoal int) (*dto.StatsResponse, error)
+	GetMoodDistribution(ctx context.Context, userID int64, loc *time.Location, query *dto.MoodAnalyticsQuery) (*dto.MoodDistributionResponse, error)
+	GetMoodTrend(ctx context.Context, userID int64, loc *time.Location, query *dto.MoodAnalyticsQuery) (*dto.MoodTrendResponse, error)
+	GetMoodByWeekday(ctx context.Context, userID int64, loc *time.Location, query *dto.MoodAnalyticsQuery) ([]dto.MoodWeekdayResponse, error)
+}
@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"context"
+	"time"
+	"timo/dto"
+	"timo/models"
+)
+
+type SyncRepository interface {
+	Sync(ctx context.Context, userID int64, since int64, changes []models.JournalChange) (*models.SyncResult, error)
+}
+
+type SyncService interface {
+	Sync(ctx context.Context, userID int64, loc *time.Location, req *dto.SyncRequest) (*dto.SyncResponse, error)
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+	"timo/dto"
+	"timo/models"
+)
+
+var (
+	ErrUploadOffset = errors.New("upload offset mismatch")
+	ErrUploadLimit  = errors.New("too many uploads in progress")
+	ErrUploadGone   = errors.New("upload no longer exists")
+)
+
+type UploadRepository interface {
+	Create(ctx context.Context, upload *models.PhotoUpload, limit int) error
+	GetByUid(ctx context.Context, uid string) (*models.PhotoUpload, error)
+	AddPart(ctx context.Context, uploadID int64, part *models.PhotoUploadPart) (int64, error)
+	Claim(ctx context.Context, id int64, staleAfter time.Duration) error
+	Unclaim(ctx context.Context, id int64) error
+	DeleteExpired(ctx context.Context, id int64, now time.Time, staleAfter time.Duration) error
+	GetExpired(ctx context.Context, now time.Time) ([]models.PhotoUpload, error)
+}
+
+type UploadService interface {
+	Create(ctx context.Context, userID int64, journalUid string, req *dto.UploadCreateRequest) (*dto.UploadResponse, error)
+	Get(ctx context.Context, userID int64, journalUid, uid string) (*dto.UploadResponse, error)
+	Append(ctx context.Context, userID int64, journalUid, uid string, chunk *dto.UploadChunk) (*dto.UploadResponse, error)
+	Finalize(ctx context.Context, userID int64, journalUid, uid string) (*dto.PhotoResponse, error)
+}
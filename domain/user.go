@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"context"
+	"time"
+	"timo/dto"
+	"timo/models"
+)
+
+type UserRepository interface {
+	GetByUID(ctx context.Context, uid string) (*models.User, error)
+	UpdateSettings(ctx context.Context, uid string, settings *models.UserSettings) error
+	GetDigestSubscribers(ctx context.Context) ([]models.User, error)
+	ClaimDigest(ctx context.Context, userID int64, date time.Time) (bool, error)
+	ReleaseDigest(ctx context.Context, userID int64, date time.Time) error
+}
+
+type UserService interface {
+	GetProfile(ctx context.Context, uid string) (*dto.UserResponse, error)
+	UpdateSettings(ctx context.Context, uid string, req *dto.UserSettingsRequest) (*dto.UserResponse, error)
+}
@@ -0,0 +1,27 @@
+package dto
+
+import "time"
+
+type CheckinRequest struct {
+	MoodID    int64      `json:"mood_id" binding:"required,gte=1"`
+	Intensity *int       `json:"intensity" binding:"omitempty,gte=1,lte=5"`
+	Note      *string    `json:"note" binding:"omitempty,max=500"`
+	CheckedAt *time.Time `json:"checked_at"`
+}
+
+type CheckinListQuery struct {
+	From string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To   string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+}
+
+type CheckinResponse struct {
+	Uid       string    `json:"uid"`
+	MoodID    int64     `json:"mood_id"`
+	MoodLabel string    `json:"mood_label"`
+	Intensity *int      `json:"intensity"`
+	Note      *string   `json:"note"`
+	CheckedAt time.Time `json:"checked_at"`
+	EntryDate string    `json:"entry_date"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,54 @@
+package dto
+
+import (
+	"io"
+	"time"
+)
+
+type ExportQuery struct {
+	Format string `form:"format" binding:"required,oneof=markdown json html"`
+	From   string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To     string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// Export is a ZIP archive that is only rendered by Write, once the response
+// headers are out.
+type Export struct {
+	Filename string
+	Write    func(w io.Writer) error
+}
+
+// ExportDocument is the journals.json of a JSON export.
+type ExportDocument struct {
+	ExportedAt time.Time       `json:"exported_at"`
+	From       string          `json:"from,omitempty"`
+	To         string          `json:"to,omitempty"`
+	Journals   []ExportJournal `json:"journals"`
+}
+
+type ExportJournal struct {
+	Uid         string                `json:"uid"`
+	Title       string                `json:"title"`
+	Text        string                `json:"text"`
+	EntryDate   string                `json:"entry_date"`
+	Mood        string                `json:"mood"`
+	Moods       []JournalMoodResponse `json:"moods"`
+	Tags        []string              `json:"tags"`
+	Attachments []ExportAttachment    `json:"attachments"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+// ExportAttachment points at the attachment's file inside the archive.
+type ExportAttachment struct {
+	MediaType   string     `json:"media_type"`
+	File        string     `json:"file"`
+	ContentType string     `json:"content_type"`
+	Caption     *string    `json:"caption"`
+	AltText     *string    `json:"alt_text"`
+	Width       *int       `json:"width,omitempty"`
+	Height      *int       `json:"height,omitempty"`
+	TakenAt     *time.Time `json:"taken_at,omitempty"`
+	DurationMs  *int       `json:"duration_ms,omitempty"`
+	Peaks       []int      `json:"peaks,omitempty"`
+}
@@ -0,0 +1,45 @@
+package dto
+
+import (
+	"io"
+	"time"
+)
+
+// ImportRequest holds the form fields sent along with the file. Entries
+// without a mood get the mood DefaultMoodID, if set.
+type ImportRequest struct {
+	Format        string `form:"format" binding:"required,oneof=dayone markdown csv"`
+	DefaultMoodID int64  `form:"default_mood_id" binding:"omitempty,gte=1"`
+}
+
+// ImportUpload is the file of an import taken from a multipart request.
+type ImportUpload struct {
+	ImportRequest
+	Filename string
+	Size     int64
+	Content  io.Reader
+}
+
+type ImportResponse struct {
+	Uid        string                `json:"uid"`
+	Format     string                `json:"format"`
+	Filename   string                `json:"filename"`
+	Status     string                `json:"status"`
+	Total      *int                  `json:"total"`
+	Imported   int                   `json:"imported"`
+	Skipped    int                   `json:"skipped"`
+	Failed     int                   `json:"failed"`
+	Error      *string               `json:"error,omitempty"`
+	Entries    []ImportEntryResponse `json:"entries,omitempty"`
+	CreatedAt  time.Time             `json:"created_at"`
+	FinishedAt *time.Time            `json:"finished_at,omitempty"`
+}
+
+type ImportEntryResponse struct {
+	Position   int     `json:"position"`
+	SourceID   string  `json:"source_id"`
+	Title      string  `json:"title"`
+	Status     string  `json:"status"`
+	JournalUid *string `json:"journal_uid,omitempty"`
+	Message    *string `json:"message,omitempty"`
+}
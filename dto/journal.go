@@ -0,0 +1,115 @@
+package dto
+
+import (
+	"time"
+	"timo/helper"
+)
+
+// JournalRequest takes either a single mood_id, as older clients send it, or
+// a list of moods with their intensity. When both are given, mood_id picks the
+// primary mood; otherwise the first mood of the list is the primary one.
+type JournalRequest struct {
+	Title     string               `json:"title" binding:"required"`
+	Text      string               `json:"text" binding:"required"`
+	MoodID    int64                `json:"mood_id" binding:"required_without=Moods,omitempty,gte=1"`
+	Moods     []JournalMoodRequest `json:"moods" binding:"omitempty,max=8,unique=MoodID,dive"`
+	EntryDate string               `json:"entry_date" binding:"omitempty,datetime=2006-01-02"`
+	Draft     bool                 `json:"draft"`
+	// Uid picks the uid of a new journal instead of the database. Clients
+	// can't set it; the import job does, to know it before the journal is
+	// created.
+	Uid string `json:"-"`
+}
+
+type JournalMoodRequest struct {
+	MoodID    int64 `json:"mood_id" binding:"required,gte=1"`
+	Intensity int   `json:"intensity" binding:"required,gte=1,lte=5"`
+}
+
+type JournalPatchRequest struct {
+	Title     *string               `json:"title" binding:"omitempty,min=1"`
+	Text      *string               `json:"text" binding:"omitempty,min=1"`
+	MoodID    *int64                `json:"mood_id" binding:"omitempty,gte=1"`
+	Moods     *[]JournalMoodRequest `json:"moods" binding:"omitempty,min=1,max=8,unique=MoodID,dive"`
+	EntryDate *string               `json:"entry_date" binding:"omitempty,datetime=2006-01-02"`
+}
+
+type JournalListQuery struct {
+	Status       string `form:"status" binding:"omitempty,oneof=draft published"`
+	From         string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To           string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+	MoodID       int64  `form:"mood_id" binding:"omitempty,gte=1"`
+	MinIntensity int    `form:"min_intensity" binding:"omitempty,gte=1,lte=5"`
+}
+
+type CalendarQuery struct {
+	Month string `form:"month" binding:"omitempty,datetime=2006-01"`
+}
+
+type CalendarDayResponse struct {
+	Date         string   `json:"date"`
+	Count        int      `json:"count"`
+	DominantMood string   `json:"dominant_mood"`
+	JournalUids  []string `json:"journal_uids"`
+	CheckinCount int      `json:"checkin_count"`
+}
+
+type CalendarResponse struct {
+	Month string                `json:"month"`
+	Days  []CalendarDayResponse `json:"days"`
+}
+
+type MemoryResponse struct {
+	Uid          string `json:"uid"`
+	Title        string `json:"title"`
+	MoodLabel    string `json:"mood_label"`
+	EntryDate    string `json:"entry_date"`
+	YearsAgo     int    `json:"years_ago"`
+	ThumbnailUrl string `json:"thumbnail_url,omitempty"`
+}
+
+type JournalResponse struct {
+	Uid         string                `json:"uid"`
+	Title       string                `json:"title"`
+	Text        string                `json:"text"`
+	MoodID      int64                 `json:"mood_id,omitempty"`
+	MoodLabel   string                `json:"mood_label,omitempty"`
+	Moods       []JournalMoodResponse `json:"moods,omitempty"`
+	Cover       *JournalCoverResponse `json:"cover,omitempty"`
+	Attachments []PhotoResponse       `json:"attachments,omitempty"`
+	Version     int64                 `json:"version"`
+	Status      string                `json:"status"`
+	EntryDate   string                `json:"entry_date"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+type JournalCoverResponse struct {
+	PhotoID int64   `json:"photo_id"`
+	Url     string  `json:"url"`
+	AltText *string `json:"alt_text"`
+}
+
+type JournalMoodResponse struct {
+	MoodID    int64  `json:"mood_id"`
+	MoodLabel string `json:"mood_label"`
+	Intensity int    `json:"intensity"`
+}
+
+type JournalRevisionResponse struct {
+	Revision  int       `json:"revision"`
+	Title     string    `json:"title"`
+	Text      string    `json:"text"`
+	MoodID    int64     `json:"mood_id"`
+	MoodLabel string    `json:"mood_label"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type JournalDiffResponse struct {
+	From     int               `json:"from"`
+	To       int               `json:"to"`
+	Title    []helper.DiffLine `json:"title"`
+	Text     []helper.DiffLine `json:"text"`
+	FromMood string            `json:"from_mood"`
+	ToMood   string            `json:"to_mood"`
+}
@@ -0,0 +1,16 @@
+package dto
+
+import (
+	"io"
+	"time"
+)
+
+// MediaObject is a stored file opened for a download.
+type MediaObject struct {
+	Content     io.ReadSeekCloser
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+	ETag        string
+	Expires     time.Time
+}
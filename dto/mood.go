@@ -0,0 +1,46 @@
+package dto
+
+import "timo/helper"
+
+type MoodListQuery struct {
+	IncludeArchived bool `form:"include_archived"`
+}
+
+type MoodRequest struct {
+	Label   string   `json:"label" binding:"required,max=32"`
+	Emoji   *string  `json:"emoji" binding:"omitempty,max=16"`
+	Color   *string  `json:"color" binding:"omitempty,hexcolor,len=7"`
+	Valence *float64 `json:"valence" binding:"omitempty,gte=-1,lte=1"`
+	Energy  *float64 `json:"energy" binding:"omitempty,gte=-1,lte=1"`
+}
+
+// MoodUpdateRequest is a merge patch; null removes the emoji, color, valence
+// or energy.
+type MoodUpdateRequest struct {
+	Label    *string                  `json:"label" binding:"omitempty,min=1,max=32"`
+	Emoji    helper.Optional[string]  `json:"emoji" binding:"omitempty,max=16"`
+	Color    helper.Optional[string]  `json:"color" binding:"omitempty,hexcolor,len=7"`
+	Valence  helper.Optional[float64] `json:"valence" binding:"omitempty,gte=-1,lte=1"`
+	Energy   helper.Optional[float64] `json:"energy" binding:"omitempty,gte=-1,lte=1"`
+	Archived *bool                    `json:"archived"`
+}
+
+type MoodOrderRequest struct {
+	IDs []int64 `json:"ids" binding:"required,dive,gte=1"`
+}
+
+type MoodDeleteQuery struct {
+	ReassignTo int64 `form:"reassign_to" binding:"omitempty,gte=1"`
+}
+
+type MoodResponse struct {
+	ID       int64    `json:"id"`
+	Label    string   `json:"label"`
+	Emoji    *string  `json:"emoji"`
+	Color    *string  `json:"color"`
+	Valence  *float64 `json:"valence"`
+	Energy   *float64 `json:"energy"`
+	Custom   bool     `json:"custom"`
+	Position int      `json:"position"`
+	Archived bool     `json:"archived"`
+}
@@ -0,0 +1,83 @@
+package dto
+
+import (
+	"io"
+	"time"
+	"timo/helper"
+)
+
+// PhotoUpload is a file taken from a multipart request. Content is nil when
+// the client only sent the Checksum of a file it uploaded before. Peaks is
+// the waveform a client drew from a recording, kept for the player.
+// UploadID is set for the file of a resumable upload, whose Size is already
+// reserved in the user's quota. The upload is deleted with the photo saved
+// from it, which uses or releases the reservation; when that fails both stay
+// with the caller.
+type PhotoUpload struct {
+	Filename    string
+	ContentType string
+	Size        int64
+	Content     io.ReadSeeker
+	Checksum    string
+	Peaks       []int
+	Caption     string
+	AltText     string
+	UploadID    int64
+}
+
+// PhotoUpdateRequest is a merge patch. A null caption or alt text removes
+// it, and so does an empty one.
+type PhotoUpdateRequest struct {
+	Caption helper.Optional[string] `json:"caption" binding:"omitempty,max=500"`
+	AltText helper.Optional[string] `json:"alt_text" binding:"omitempty,max=500"`
+	Cover   *bool                   `json:"cover"`
+}
+
+type PhotoOrderRequest struct {
+	IDs []int64 `json:"ids" binding:"required,dive,gte=1"`
+}
+
+type PhotoResponse struct {
+	ID          int64                  `json:"id"`
+	MediaType   string                 `json:"media_type"`
+	Url         string                 `json:"url"`
+	ContentType string                 `json:"content_type,omitempty"`
+	Size        int64                  `json:"size,omitempty"`
+	Checksum    string                 `json:"checksum,omitempty"`
+	Status      string                 `json:"status"`
+	Width       *int                   `json:"width,omitempty"`
+	Height      *int                   `json:"height,omitempty"`
+	TakenAt     *time.Time             `json:"taken_at,omitempty"`
+	DurationMs  *int                   `json:"duration_ms,omitempty"`
+	Peaks       []int                  `json:"peaks,omitempty"`
+	Variants    []PhotoVariantResponse `json:"variants,omitempty"`
+	Position    int                    `json:"position"`
+	Caption     *string                `json:"caption"`
+	AltText     *string                `json:"alt_text"`
+	Cover       bool                   `json:"cover"`
+	CreatedAt   time.Time              `json:"created_at"`
+}
+
+type PhotoVariantResponse struct {
+	Name   string `json:"name"`
+	Url    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// StorageUsage is attached to QUOTA_EXCEEDED errors.
+type StorageUsage struct {
+	Used      int64 `json:"used"`
+	Quota     int64 `json:"quota"`
+	Requested int64 `json:"requested"`
+}
+
+// PhotoGCReport lists what a garbage collection pass removed, or would
+// remove in a dry run.
+type PhotoGCReport struct {
+	DryRun           bool     `json:"dry_run"`
+	OrphanObjects    []string `json:"orphan_objects"`
+	AbandonedUploads []string `json:"abandoned_uploads"`
+	ExpiredUploads   []string `json:"expired_uploads"`
+	Bytes            int64    `json:"bytes"`
+}
@@ -0,0 +1,69 @@
+package dto
+
+type StatsResponse struct {
+	CurrentStreak int     `json:"current_streak"`
+	LongestStreak int     `json:"longest_streak"`
+	LastEntryDate string  `json:"last_entry_date,omitempty"`
+	WeeklyGoal    int     `json:"weekly_goal"`
+	WeekStart     string  `json:"week_start"`
+	WeekEntries   int     `json:"week_entries"`
+	GoalProgress  float64 `json:"goal_progress"`
+	GoalMet       bool    `json:"goal_met"`
+}
+
+type MoodAnalyticsQuery struct {
+	From     string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To       string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+	Interval string `form:"interval" binding:"omitempty,oneof=week month"`
+}
+
+type MoodCountResponse struct {
+	MoodID    int64   `json:"mood_id"`
+	MoodLabel string  `json:"mood_label"`
+	Count     int     `json:"count"`
+	Weight    float64 `json:"weight"`
+}
+
+type MoodShareResponse struct {
+	MoodID         int64   `json:"mood_id"`
+	MoodLabel      string  `json:"mood_label"`
+	Count          int     `json:"count"`
+	Weight         float64 `json:"weight"`
+	Share          float64 `json:"share"`
+	PreviousCount  int     `json:"previous_count"`
+	PreviousWeight float64 `json:"previous_weight"`
+	PreviousShare  float64 `json:"previous_share"`
+	Change         int     `json:"change"`
+}
+
+type MoodDistributionResponse struct {
+	From            string              `json:"from"`
+	To              string              `json:"to"`
+	PreviousFrom    string              `json:"previous_from"`
+	PreviousTo      string              `json:"previous_to"`
+	Total           int                 `json:"total"`
+	PreviousTotal   int                 `json:"previous_total"`
+	Valence         *float64            `json:"valence"`
+	PreviousValence *float64            `json:"previous_valence"`
+	Moods           []MoodShareResponse `json:"moods"`
+}
+
+type MoodTrendPointResponse struct {
+	PeriodStart string              `json:"period_start"`
+	Total       int                 `json:"total"`
+	Valence     *float64            `json:"valence"`
+	Moods       []MoodCountResponse `json:"moods"`
+}
+
+type MoodTrendResponse struct {
+	Interval string                   `json:"interval"`
+	Points   []MoodTrendPointResponse `json:"points"`
+}
+
+type MoodWeekdayResponse struct {
+	Weekday int                 `json:"weekday"`
+	Name    string              `json:"name"`
+	Total   int                 `json:"total"`
+	Valence *float64            `json:"valence"`
+	Moods   []MoodCountResponse `json:"moods"`
+}
@@ -0,0 +1,44 @@
+package dto
+
+import "time"
+
+type SyncRequest struct {
+	Token   string       `json:"token"`
+	Changes []SyncChange `json:"changes" binding:"dive"`
+}
+
+type SyncChange struct {
+	Op          string `json:"op" binding:"required,oneof=create update delete"`
+	Uid         string `json:"uid" binding:"required,uuid"`
+	BaseVersion int64  `json:"base_version"`
+	Title       string `json:"title"`
+	Text        string `json:"text"`
+	MoodID      int64  `json:"mood_id"`
+	EntryDate   string `json:"entry_date" binding:"omitempty,datetime=2006-01-02"`
+}
+
+type SyncResponse struct {
+	Token     string             `json:"token"`
+	Applied   []SyncApplied      `json:"applied"`
+	Conflicts []SyncConflict     `json:"conflicts"`
+	Changes   []JournalResponse  `json:"changes"`
+	Deleted   []SyncDeletedEntry `json:"deleted"`
+}
+
+type SyncApplied struct {
+	Uid     string `json:"uid"`
+	Op      string `json:"op"`
+	Version int64  `json:"version,omitempty"`
+}
+
+type SyncConflict struct {
+	Uid    string           `json:"uid"`
+	Op     string           `json:"op"`
+	Reason string           `json:"reason"`
+	Server *JournalResponse `json:"server,omitempty"`
+}
+
+type SyncDeletedEntry struct {
+	Uid       string    `json:"uid"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
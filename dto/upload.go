@@ -0,0 +1,35 @@
+package dto
+
+import (
+	"io"
+	"time"
+)
+
+type UploadCreateRequest struct {
+	Filename string  `json:"filename" binding:"max=255"`
+	Size     int64   `json:"size" binding:"required,gte=1"`
+	Checksum string  `json:"sha256" binding:"omitempty,hexadecimal,len=64"`
+	Caption  *string `json:"caption" binding:"omitempty,max=500"`
+	AltText  *string `json:"alt_text" binding:"omitempty,max=500"`
+	Peaks    []int   `json:"peaks" binding:"omitempty,max=512,dive,gte=0,lte=255"`
+}
+
+// UploadChunk is the body of a PATCH, to be stored at Offset.
+type UploadChunk struct {
+	Offset  int64
+	Size    int64
+	Content io.Reader
+}
+
+type UploadResponse struct {
+	ID        string    `json:"id"`
+	Offset    int64     `json:"offset"`
+	Size      int64     `json:"size"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// UploadOffset is attached to CONFLICT errors so the client can resume from
+// the offset the server has.
+type UploadOffset struct {
+	Offset int64 `json:"offset"`
+}
@@ -0,0 +1,16 @@
+package dto
+
+type UserSettingsRequest struct {
+	Timezone     *string `json:"timezone" binding:"omitempty,timezone"`
+	MemoryDigest *bool   `json:"memory_digest"`
+	WeeklyGoal   *int    `json:"weekly_goal" binding:"omitempty,gte=0,lte=100"`
+}
+
+type UserResponse struct {
+	Uid          string `json:"uid"`
+	Name         string `json:"name"`
+	Email        string `json:"email"`
+	Timezone     string `json:"timezone"`
+	MemoryDigest bool   `json:"memory_digest"`
+	WeeklyGoal   int    `json:"weekly_goal"`
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"net/http"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Checkin struct {
+	svc domain.CheckinService
+}
+
+func NewCheckin(svc domain.CheckinService) *Checkin {
+	return &Checkin{svc: svc}
+}
+
+func (ch *Checkin) GetList(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	var query dto.CheckinListQuery
+	if details, err := helper.BindQuery(c, &query); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "query validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	resp, err := ch.svc.GetList(c.Request.Context(), user.ID, &query)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
+
+func (ch *Checkin) GetByID(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	resp, err := ch.svc.GetByID(c.Request.Context(), user.ID, c.Param("uid"))
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
+
+func (ch *Checkin) Create(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	var req dto.CheckinRequest
+	if details, err := helper.BindValidate(c, &req); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "payload validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	resp, err := ch.svc.Create(c.Request.Context(), user.ID, helper.UserLocation(user.Timezone), &req)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
+
+func (ch *Checkin) Update(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	var req dto.CheckinRequest
+	if details, err := helper.BindValidate(c, &req); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "payload validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	resp, err := ch.svc.Update(c.Request.Context(), user.ID, helper.UserLocation(user.Timezone), c.Param("uid"), &req)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
+
+func (ch *Checkin) Delete(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	if err := ch.svc.Delete(c.Request.Context(), user.ID, c.Param("uid")); err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, gin.H{"uid": c.Param("uid")})
+}
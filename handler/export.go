@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"log"
+	"mime"
+	"net/http"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Export struct {
+	svc domain.ExportService
+}
+
+func NewExport(svc domain.ExportService) *Export {
+	return &Export{svc: svc}
+}
+
+// Export streams the journals as a ZIP archive. Once the archive has
+// started the status can't change any more, so a failure while writing cuts
+// the download short and is only logged.
+func (e *Export) Export(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	var query dto.ExportQuery
+	if details, err := helper.BindQuery(c, &query); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "query validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	export, err := e.svc.Export(c.Request.Context(), user.ID, &query)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": export.Filename}))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	if err := export.Write(c.Writer); err != nil {
+		log.Printf("export for user %d failed: %v", user.ID, err)
+		c.Abort()
+	}
+}
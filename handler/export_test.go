@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"timo/dto"
+	"timo/helper"
+	"timo/middleware"
+	"timo/mocks"
+	"timo/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestExportHandler_Export(t *testing.T) {
+	tests := []struct {
+		name            string
+		query           string
+		setupMocks      func(svc *mocks.ExportServiceMock)
+		wantCode        int
+		wantBody        string
+		wantDisposition string
+	}{
+		{
+			name:       "missing format",
+			query:      "",
+			setupMocks: func(svc *mocks.ExportServiceMock) {},
+			wantCode:   http.StatusBadRequest,
+			wantBody:   helper.VALIDATION_ERROR,
+		},
+		{
+			name:       "invalid date",
+			query:      "format=json&from=01-03-2024",
+			setupMocks: func(svc *mocks.ExportServiceMock) {},
+			wantCode:   http.StatusBadRequest,
+			wantBody:   "2006-01-02",
+		},
+		{
+			name:  "service error",
+			query: "format=json",
+			setupMocks: func(svc *mocks.ExportServiceMock) {
+				svc.On("Export", mock.Anything, int64(1), &dto.ExportQuery{Format: "json"}).
+					Return(nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get journals", nil))
+			},
+			wantCode: http.StatusInternalServerError,
+			wantBody: helper.INTERNAL_ERROR,
+		},
+		{
+			name:  "success",
+			query: "format=markdown&from=2024-03-01",
+			setupMocks: func(svc *mocks.ExportServiceMock) {
+				svc.On("Export", mock.Anything, int64(1), &dto.ExportQuery{Format: "markdown", From: "2024-03-01"}).
+					Return(&dto.Export{
+						Filename: "timo-journals-from-2024-03-01.zip",
+						Write: func(w io.Writer) error {
+							_, err := io.WriteString(w, "PK")
+							return err
+						},
+					}, nil)
+			},
+			wantCode:        http.StatusOK,
+			wantBody:        "PK",
+			wantDisposition: "attachment; filename=timo-journals-from-2024-03-01.zip",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+
+			svc := new(mocks.ExportServiceMock)
+			tt.setupMocks(svc)
+
+			req := httptest.NewRequest(http.MethodGet, "/journals/export?"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			c.Set(middleware.UserKey, &models.User{ID: 1})
+
+			h := NewExport(svc)
+			h.Export(c)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+			assert.Equal(t, tt.wantDisposition, w.Header().Get("Content-Disposition"))
+			svc.AssertExpectations(t)
+		})
+	}
+}
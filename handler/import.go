@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"net/http"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+type Import struct {
+	svc     domain.ImportService
+	maxSize int64
+}
+
+// NewImport takes the size of the largest file an import may carry.
+func NewImport(svc domain.ImportService, maxSize int64) *Import {
+	return &Import{svc: svc, maxSize: maxSize}
+}
+
+// Create takes the export of another journaling app in the "file" field and
+// its "format". Entries are imported in the background; the response points
+// at the import to poll for progress.
+func (i *Import) Create(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	if !helper.LimitMultipart(c, i.maxSize) {
+		return
+	}
+
+	var req dto.ImportRequest
+	if details, err := helper.BindForm(c, &req); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "payload validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		helper.Fail(c, http.StatusBadRequest, "payload validation failed", helper.VALIDATION_ERROR,
+			[]helper.ValidatorError{{Field: "file", Message: "is required"}})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		helper.Fail(c, http.StatusBadRequest, "payload validation failed", helper.VALIDATION_ERROR,
+			[]helper.ValidatorError{{Field: "file", Message: "could not be read"}})
+		return
+	}
+	defer file.Close()
+
+	upload := &dto.ImportUpload{
+		ImportRequest: req,
+		Filename:      header.Filename,
+		Size:          header.Size,
+		Content:       file,
+	}
+
+	resp, svcErr := i.svc.Create(c.Request.Context(), user.ID, upload)
+	if svcErr != nil {
+		svcErr.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	c.Header("Location", c.Request.URL.Path+"/"+resp.Uid)
+	helper.Accepted(c, resp)
+}
+
+func (i *Import) GetList(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	resp, err := i.svc.GetList(c.Request.Context(), user.ID)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
+
+func (i *Import) GetByID(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	uid := c.Param("uid")
+	if err := binding.Validator.Engine().(*validator.Validate).Var(uid, "uuid"); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "invalid import id", helper.VALIDATION_ERROR, nil)
+		return
+	}
+
+	resp, err := i.svc.GetByID(c.Request.Context(), user.ID, uid)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	helper.Ok(c, resp)
+}
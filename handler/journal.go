@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Journal struct {
+	svc domain.JournalService
+}
+
+func NewJournal(svc domain.JournalService) *Journal {
+	return &Journal{svc: svc}
+}
+
+func (j *Journal) GetList(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	var query dto.JournalListQuery
+	if details, err := helper.BindQuery(c, &query); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "query validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	resp, err := j.svc.GetList(c.Request.Context(), user.ID, &query)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
+
+func (j *Journal) GetCalendar(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	var query dto.CalendarQuery
+	if details, err := helper.BindQuery(c, &query); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "query validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	resp, err := j.svc.GetCalendar(c.Request.Context(), user.ID, helper.UserLocation(user.Timezone), &query)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
+
+func (j *Journal) GetMemories(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	resp, err := j.svc.GetMemories(c.Request.Context(), user.ID, helper.UserLocation(user.Timezone))
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
+
+func (j *Journal) GetByID(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	resp, err := j.svc.GetByID(c.Request.Context(), user.ID, c.Param("uid"))
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	writeJournal(c, resp)
+}
+
+func (j *Journal) Create(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	var req dto.JournalRequest
+	if details, err := helper.BindValidate(c, &req); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "payload validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	resp, err := j.svc.Create(c.Request.Context(), user.ID, helper.UserLocation(user.Timezone), &req)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	writeJournal(c, resp)
+}
+
+func (j *Journal) Update(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	version, ok := ifMatch(c)
+	if !ok {
+		return
+	}
+
+	var req dto.JournalRequest
+	if details, err := helper.BindValidate(c, &req); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "payload validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	resp, err := j.svc.Update(c.Request.Context(), user.ID, c.Param("uid"), version, &req)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	writeJournal(c, resp)
+}
+
+func (j *Journal) Patch(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	version, ok := ifMatch(c)
+	if !ok {
+		return
+	}
+
+	var req dto.JournalPatchRequest
+	if details, err := helper.BindMergePatch(c, &req); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "payload validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	resp, err := j.svc.Patch(c.Request.Context(), user.ID, c.Param("uid"), version, &req)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	writeJournal(c, resp)
+}
+
+func (j *Journal) Delete(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	version, ok := ifMatch(c)
+	if !ok {
+		return
+	}
+
+	err := j.svc.Delete(c.Request.Context(), user.ID, c.Param("uid"), version)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, gin.H{"uid": c.Param("uid")})
+}
+
+func (j *Journal) Autosave(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	var req dto.JournalPatchRequest
+	if details, err := helper.BindMergePatch(c, &req); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "payload validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	if err := j.svc.Autosave(c.Request.Context(), user.ID, c.Param("uid"), &req); err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	// No ETag: the version is only known once the write has run.
+	helper.Accepted(c, gin.H{"uid": c.Param("uid")})
+}
+
+func (j *Journal) Publish(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	resp, err := j.svc.Publish(c.Request.Context(), user.ID, c.Param("uid"))
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	writeJournal(c, resp)
+}
+
+func (j *Journal) GetRevisions(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	resp, err := j.svc.GetRevisions(c.Request.Context(), user.ID, c.Param("uid"))
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
+
+func (j *Journal) Diff(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	from, errFrom := strconv.Atoi(c.Query("from"))
+	to, errTo := strconv.Atoi(c.DefaultQuery("to", "0"))
+	if errFrom != nil || errTo != nil || from < 1 || to < 0 {
+		helper.Fail(c, http.StatusBadRequest, "from and to must be revision numbers", helper.VALIDATION_ERROR, nil)
+		return
+	}
+
+	resp, err := j.svc.Diff(c.Request.Context(), user.ID, c.Param("uid"), from, to)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
+
+func (j *Journal) Revert(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || revision < 1 {
+		helper.Fail(c, http.StatusBadRequest, "revision must be a positive number", helper.VALIDATION_ERROR, nil)
+		return
+	}
+
+	resp, err := j.svc.Revert(c.Request.Context(), user.ID, c.Param("uid"), revision)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	writeJournal(c, resp)
+}
+
+func writeJournal(c *gin.Context, resp *dto.JournalResponse) {
+	c.Header("ETag", helper.ETag(resp.Version))
+	helper.Ok(c, resp)
+}
+
+func ifMatch(c *gin.Context) (int64, bool) {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		helper.Fail(c, http.StatusPreconditionRequired, "If-Match header is required", helper.PRECONDITION_REQUIRED, nil)
+		return 0, false
+	}
+
+	version, ok := helper.ParseIfMatch(header)
+	if !ok {
+		helper.Fail(c, http.StatusPreconditionFailed, "If-Match header is not a valid journal ETag", helper.PRECONDITION_FAILED, nil)
+		return 0, false
+	}
+
+	return version, true
+}
@@ -0,0 +1,345 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"timo/dto"
+	"timo/helper"
+	"timo/middleware"
+	"timo/mocks"
+	"timo/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestJournalHandler_Diff(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		setupMocks func(svc *mocks.JournalServiceMock)
+		wantCode   int
+		wantBody   string
+	}{
+		{
+			name:       "invalid revision",
+			query:      "?from=abc",
+			setupMocks: func(svc *mocks.JournalServiceMock) {},
+			wantCode:   http.StatusBadRequest,
+			wantBody:   helper.VALIDATION_ERROR,
+		},
+		{
+			name:  "service return error",
+			query: "?from=1&to=2",
+			setupMocks: func(svc *mocks.JournalServiceMock) {
+				svc.On("Diff", mock.Anything, int64(1), "journalUID", 1, 2).
+					Return(nil, helper.NewAppError(helper.NOT_FOUND, "revision not found", nil))
+			},
+			wantCode: http.StatusNotFound,
+			wantBody: helper.NOT_FOUND,
+		},
+		{
+			name:  "success",
+			query: "?from=1",
+			setupMocks: func(svc *mocks.JournalServiceMock) {
+				svc.On("Diff", mock.Anything, int64(1), "journalUID", 1, 0).
+					Return(&dto.JournalDiffResponse{From: 1, Text: []helper.DiffLine{{Op: helper.DIFF_INSERT, Text: "new line"}}}, nil)
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"op":"insert","text":"new line"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+
+			svc := new(mocks.JournalServiceMock)
+			tt.setupMocks(svc)
+
+			req := httptest.NewRequest(http.MethodGet, "/journals/journalUID/revisions/diff"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			c.Params = gin.Params{{Key: "uid", Value: "journalUID"}}
+			c.Set(middleware.UserKey, &models.User{ID: 1})
+
+			h := NewJournal(svc)
+			h.Diff(c)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestJournalHandler_GetCalendar(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		setupMocks func(svc *mocks.JournalServiceMock)
+		wantCode   int
+		wantBody   string
+	}{
+		{
+			name:       "invalid month",
+			query:      "?month=2024-13",
+			setupMocks: func(svc *mocks.JournalServiceMock) {},
+			wantCode:   http.StatusBadRequest,
+			wantBody:   helper.VALIDATION_ERROR,
+		},
+		{
+			name:  "success",
+			query: "?month=2024-02",
+			setupMocks: func(svc *mocks.JournalServiceMock) {
+				svc.On("GetCalendar", mock.Anything, int64(1), mock.Anything, &dto.CalendarQuery{Month: "2024-02"}).
+					Return(&dto.CalendarResponse{Month: "2024-02", Days: []dto.CalendarDayResponse{{Date: "2024-02-29", Count: 1, DominantMood: "happy"}}}, nil)
+			},
+			wantCode: http.StatusOK,
+			wantBody: `"dominant_mood":"happy"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+
+			svc := new(mocks.JournalServiceMock)
+			tt.setupMocks(svc)
+
+			req := httptest.NewRequest(http.MethodGet, "/journals/calendar"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			c.Set(middleware.UserKey, &models.User{ID: 1})
+
+			h := NewJournal(svc)
+			h.GetCalendar(c)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestJournalHandler_Revert(t *testing.T) {
+	tests := []struct {
+		name       string
+		revision   string
+		setupMocks func(svc *mocks.JournalServiceMock)
+		wantCode   int
+		wantBody   string
+	}{
+		{
+			name:       "invalid revision",
+			revision:   "0",
+			setupMocks: func(svc *mocks.JournalServiceMock) {},
+			wantCode:   http.StatusBadRequest,
+			wantBody:   helper.VALIDATION_ERROR,
+		},
+		{
+			name:     "success",
+			revision: "2",
+			setupMocks: func(svc *mocks.JournalServiceMock) {
+				svc.On("Revert", mock.Anything, int64(1), "journalUID", 2).
+					Return(&dto.JournalResponse{Uid: "journalUID", Title: "old title"}, nil)
+			},
+			wantCode: http.StatusOK,
+			wantBody: `"title":"old title"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+
+			svc := new(mocks.JournalServiceMock)
+			tt.setupMocks(svc)
+
+			req := httptest.NewRequest(http.MethodPost, "/journals/journalUID/revisions/"+tt.revision+"/revert", nil)
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			c.Params = gin.Params{{Key: "uid", Value: "journalUID"}, {Key: "revision", Value: tt.revision}}
+			c.Set(middleware.UserKey, &models.User{ID: 1})
+
+			h := NewJournal(svc)
+			h.Revert(c)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestJournalHandler_Update(t *testing.T) {
+	tests := []struct {
+		name       string
+		ifMatch    string
+		body       string
+		setupMocks func(svc *mocks.JournalServiceMock)
+		wantCode   int
+		wantBody   string
+		wantETag   string
+	}{
+		{
+			name:       "missing if-match",
+			body:       `{"title": "title", "text": "text", "mood_id": 1}`,
+			setupMocks: func(svc *mocks.JournalServiceMock) {},
+			wantCode:   http.StatusPreconditionRequired,
+			wantBody:   helper.PRECONDITION_REQUIRED,
+		},
+		{
+			name:       "malformed if-match",
+			ifMatch:    "abc",
+			body:       `{"title": "title", "text": "text", "mood_id": 1}`,
+			setupMocks: func(svc *mocks.JournalServiceMock) {},
+			wantCode:   http.StatusPreconditionFailed,
+			wantBody:   helper.PRECONDITION_FAILED,
+		},
+		{
+			name:    "version mismatch",
+			ifMatch: `"1"`,
+			body:    `{"title": "title", "text": "text", "mood_id": 1}`,
+			setupMocks: func(svc *mocks.JournalServiceMock) {
+				svc.On("Update", mock.Anything, int64(1), "journalUID", int64(1), mock.AnythingOfType("*dto.JournalRequest")).
+					Return(nil, helper.NewAppError(helper.PRECONDITION_FAILED, "journal has been modified", nil).
+						WithDetails(dto.JournalResponse{Uid: "journalUID", Version: 2}))
+			},
+			wantCode: http.StatusPreconditionFailed,
+			wantBody: `"version":2`,
+		},
+		{
+			name:    "success",
+			ifMatch: `"2"`,
+			body:    `{"title": "title", "text": "text", "mood_id": 1}`,
+			setupMocks: func(svc *mocks.JournalServiceMock) {
+				svc.On("Update", mock.Anything, int64(1), "journalUID", int64(2), mock.AnythingOfType("*dto.JournalRequest")).
+					Return(&dto.JournalResponse{Uid: "journalUID", Version: 3}, nil)
+			},
+			wantCode: http.StatusOK,
+			wantBody: `"version":3`,
+			wantETag: `"3"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+
+			svc := new(mocks.JournalServiceMock)
+			tt.setupMocks(svc)
+
+			req := httptest.NewRequest(http.MethodPut, "/journals/journalUID", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			c.Params = gin.Params{{Key: "uid", Value: "journalUID"}}
+			c.Set(middleware.UserKey, &models.User{ID: 1})
+
+			h := NewJournal(svc)
+			h.Update(c)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+			assert.Equal(t, tt.wantETag, w.Header().Get("ETag"))
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestJournalHandler_Patch(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		setupMocks  func(svc *mocks.JournalServiceMock)
+		wantCode    int
+		wantBody    string
+	}{
+		{
+			name:        "unsupported content type",
+			contentType: "text/plain",
+			body:        `{"mood_id": 2}`,
+			setupMocks:  func(svc *mocks.JournalServiceMock) {},
+			wantCode:    http.StatusBadRequest,
+			wantBody:    helper.MERGE_PATCH_CONTENT_TYPE,
+		},
+		{
+			name:        "null removes required field",
+			contentType: helper.MERGE_PATCH_CONTENT_TYPE,
+			body:        `{"title": null}`,
+			setupMocks:  func(svc *mocks.JournalServiceMock) {},
+			wantCode:    http.StatusBadRequest,
+			wantBody:    `"field":"title","message":"cannot be removed"`,
+		},
+		{
+			name:        "unknown field",
+			contentType: helper.MERGE_PATCH_CONTENT_TYPE,
+			body:        `{"author": "someone"}`,
+			setupMocks:  func(svc *mocks.JournalServiceMock) {},
+			wantCode:    http.StatusBadRequest,
+			wantBody:    helper.VALIDATION_ERROR,
+		},
+		{
+			name:        "invalid supplied field",
+			contentType: helper.MERGE_PATCH_CONTENT_TYPE,
+			body:        `{"mood_id": 0}`,
+			setupMocks:  func(svc *mocks.JournalServiceMock) {},
+			wantCode:    http.StatusBadRequest,
+			wantBody:    `"field":"MoodID"`,
+		},
+		{
+			name:        "success",
+			contentType: helper.MERGE_PATCH_CONTENT_TYPE,
+			body:        `{"mood_id": 2}`,
+			setupMocks: func(svc *mocks.JournalServiceMock) {
+				svc.On("Patch", mock.Anything, int64(1), "journalUID", int64(2), mock.MatchedBy(func(req *dto.JournalPatchRequest) bool {
+					return req.Title == nil && req.Text == nil && *req.MoodID == 2
+				})).Return(&dto.JournalResponse{Uid: "journalUID", MoodID: 2, Version: 3}, nil)
+			},
+			wantCode: http.StatusOK,
+			wantBody: `"mood_id":2`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+
+			svc := new(mocks.JournalServiceMock)
+			tt.setupMocks(svc)
+
+			req := httptest.NewRequest(http.MethodPatch, "/journals/journalUID", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req.Header.Set("If-Match", `"2"`)
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			c.Params = gin.Params{{Key: "uid", Value: "journalUID"}}
+			c.Set(middleware.UserKey, &models.User{ID: 1})
+
+			h := NewJournal(svc)
+			h.Patch(c)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+			svc.AssertExpectations(t)
+		})
+	}
+}
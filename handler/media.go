@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"timo/domain"
+	"timo/helper"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Media struct {
+	svc domain.MediaService
+}
+
+func NewMedia(svc domain.MediaService) *Media {
+	return &Media{svc: svc}
+}
+
+// Download serves a file behind a signed link. http.ServeContent takes care
+// of Range, If-None-Match and If-Modified-Since.
+func (m *Media) Download(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	object, err := m.svc.Open(c.Request.Context(), key, c.Query("expires"), c.Query("sig"))
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+	defer object.Content.Close()
+
+	header := c.Writer.Header()
+	if object.ContentType != "" {
+		header.Set("Content-Type", object.ContentType)
+	}
+	if object.ETag != "" {
+		header.Set("ETag", object.ETag)
+	}
+	// Stored objects never change, but the link does expire, so caches may
+	// keep the response exactly as long as the link is valid.
+	maxAge := max(int(time.Until(object.Expires).Seconds()), 0)
+	header.Set("Cache-Control", fmt.Sprintf("private, max-age=%d, immutable", maxAge))
+	header.Set("X-Content-Type-Options", "nosniff")
+
+	http.ServeContent(c.Writer, c.Request, path.Base(key), object.ModTime, object.Content)
+}
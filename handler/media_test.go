@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"timo/dto"
+	"timo/helper"
+	"timo/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error { return nil }
+
+func TestMediaHandler_Download(t *testing.T) {
+	object := func() *dto.MediaObject {
+		return &dto.MediaObject{
+			Content:     nopSeekCloser{strings.NewReader("0123456789")},
+			Size:        10,
+			ContentType: "image/jpeg",
+			ETag:        `"abc"`,
+			Expires:     time.Now().Add(time.Hour),
+		}
+	}
+
+	tests := []struct {
+		name       string
+		header     map[string]string
+		setupMocks func(svc *mocks.MediaServiceMock)
+		wantCode   int
+		wantBody   string
+	}{
+		{
+			name: "bad signature",
+			setupMocks: func(svc *mocks.MediaServiceMock) {
+				svc.On("Open", mock.Anything, "users/1/photos/a.jpg", "123", "sig").
+					Return(nil, helper.NewAppError(helper.FORBIDDEN, "link is invalid or has expired", helper.ErrSignatureInvalid))
+			},
+			wantCode: http.StatusForbidden,
+			wantBody: helper.FORBIDDEN,
+		},
+		{
+			name: "full content",
+			setupMocks: func(svc *mocks.MediaServiceMock) {
+				svc.On("Open", mock.Anything, "users/1/photos/a.jpg", "123", "sig").Return(object(), nil)
+			},
+			wantCode: http.StatusOK,
+			wantBody: "0123456789",
+		},
+		{
+			name:   "range",
+			header: map[string]string{"Range": "bytes=2-4"},
+			setupMocks: func(svc *mocks.MediaServiceMock) {
+				svc.On("Open", mock.Anything, "users/1/photos/a.jpg", "123", "sig").Return(object(), nil)
+			},
+			wantCode: http.StatusPartialContent,
+			wantBody: "234",
+		},
+		{
+			name:   "not modified",
+			header: map[string]string{"If-None-Match": `"abc"`},
+			setupMocks: func(svc *mocks.MediaServiceMock) {
+				svc.On("Open", mock.Anything, "users/1/photos/a.jpg", "123", "sig").Return(object(), nil)
+			},
+			wantCode: http.StatusNotModified,
+			wantBody: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+
+			svc := new(mocks.MediaServiceMock)
+			tt.setupMocks(svc)
+
+			r := gin.New()
+			r.GET("/media/*key", NewMedia(svc).Download)
+
+			req := httptest.NewRequest(http.MethodGet, "/media/users/1/photos/a.jpg?expires=123&sig=sig", nil)
+			for name, value := range tt.header {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+			if w.Code == http.StatusOK {
+				assert.Equal(t, "image/jpeg", w.Header().Get("Content-Type"))
+				assert.Contains(t, w.Header().Get("Cache-Control"), "private, max-age=")
+				assert.Equal(t, "bytes", w.Header().Get("Accept-Ranges"))
+			}
+			svc.AssertExpectations(t)
+		})
+	}
+}
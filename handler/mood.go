@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Mood struct {
+	svc domain.MoodService
+}
+
+func NewMood(svc domain.MoodService) *Mood {
+	return &Mood{svc: svc}
+}
+
+func (m *Mood) GetList(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	var query dto.MoodListQuery
+	if details, err := helper.BindQuery(c, &query); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "query validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	locales := helper.AcceptLanguage(c.GetHeader("Accept-Language"))
+
+	resp, err := m.svc.GetList(c.Request.Context(), user.ID, locales, &query)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	c.Header("Vary", "Accept-Language")
+	helper.Ok(c, resp)
+}
+
+func (m *Mood) Create(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	var req dto.MoodRequest
+	if details, err := helper.BindValidate(c, &req); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "payload validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	resp, err := m.svc.Create(c.Request.Context(), user.ID, &req)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
+
+func (m *Mood) Update(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	id, ok := moodID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.MoodUpdateRequest
+	if details, err := helper.BindMergePatch(c, &req); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "payload validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	resp, err := m.svc.Update(c.Request.Context(), user.ID, id, &req)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
+
+func (m *Mood) Reorder(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	var req dto.MoodOrderRequest
+	if details, err := helper.BindValidate(c, &req); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "payload validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	resp, err := m.svc.Reorder(c.Request.Context(), user.ID, &req)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
+
+func (m *Mood) Delete(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	id, ok := moodID(c)
+	if !ok {
+		return
+	}
+
+	var query dto.MoodDeleteQuery
+	if details, err := helper.BindQuery(c, &query); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "query validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	if err := m.svc.Delete(c.Request.Context(), user.ID, id, &query); err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, gin.H{"id": id})
+}
+
+func moodID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id < 1 {
+		helper.Fail(c, http.StatusBadRequest, "invalid mood id", helper.VALIDATION_ERROR, nil)
+		return 0, false
+	}
+	return id, true
+}
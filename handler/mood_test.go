@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"timo/dto"
+	"timo/helper"
+	"timo/middleware"
+	"timo/mocks"
+	"timo/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMoodHandler_GetList(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	svc := new(mocks.MoodServiceMock)
+	svc.On("GetList", mock.Anything, int64(1), []string{"id-id", "id", "en"}, &dto.MoodListQuery{}).
+		Return([]dto.MoodResponse{{ID: 1, Label: "senang", Emoji: helper.Ptr("😊")}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/moods", nil)
+	req.Header.Set("Accept-Language", "id-ID,en;q=0.8")
+	w := httptest.NewRecorder()
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set(middleware.UserKey, &models.User{ID: 1})
+
+	h := NewMood(svc)
+	h.GetList(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Accept-Language", w.Header().Get("Vary"))
+	assert.Contains(t, w.Body.String(), `"label":"senang"`)
+	svc.AssertExpectations(t)
+}
+
+func TestMoodHandler_Create(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		setupMocks func(svc *mocks.MoodServiceMock)
+		wantCode   int
+		wantBody   string
+	}{
+		{
+			name:       "invalid color",
+			body:       `{"label":"calm","color":"blue"}`,
+			setupMocks: func(svc *mocks.MoodServiceMock) {},
+			wantCode:   http.StatusBadRequest,
+			wantBody:   helper.VALIDATION_ERROR,
+		},
+		{
+			name:       "valence out of range",
+			body:       `{"label":"calm","valence":2}`,
+			setupMocks: func(svc *mocks.MoodServiceMock) {},
+			wantCode:   http.StatusBadRequest,
+			wantBody:   helper.VALIDATION_ERROR,
+		},
+		{
+			name: "success",
+			body: `{"label":"calm","color":"#88CCAA","valence":0.4}`,
+			setupMocks: func(svc *mocks.MoodServiceMock) {
+				svc.On("Create", mock.Anything, int64(1), mock.AnythingOfType("*dto.MoodRequest")).
+					Return(&dto.MoodResponse{ID: 10, Label: "calm", Custom: true}, nil)
+			},
+			wantCode: http.StatusOK,
+			wantBody: `"custom":true`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+
+			svc := new(mocks.MoodServiceMock)
+			tt.setupMocks(svc)
+
+			req := httptest.NewRequest(http.MethodPost, "/moods", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			c.Set(middleware.UserKey, &models.User{ID: 1})
+
+			h := NewMood(svc)
+			h.Create(c)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMoodHandler_Update(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		setupMocks  func(svc *mocks.MoodServiceMock)
+		wantCode    int
+		wantBody    string
+	}{
+		{
+			name:        "unsupported content type",
+			contentType: "text/plain",
+			body:        `{"label":"calm"}`,
+			setupMocks:  func(svc *mocks.MoodServiceMock) {},
+			wantCode:    http.StatusBadRequest,
+			wantBody:    helper.MERGE_PATCH_CONTENT_TYPE,
+		},
+		{
+			name:        "null removes field",
+			contentType: helper.MERGE_PATCH_CONTENT_TYPE,
+			body:        `{"label":null}`,
+			setupMocks:  func(svc *mocks.MoodServiceMock) {},
+			wantCode:    http.StatusBadRequest,
+			wantBody:    `"field":"label","message":"cannot be removed"`,
+		},
+		{
+			name:        "null clears emoji",
+			contentType: helper.MERGE_PATCH_CONTENT_TYPE,
+			body:        `{"emoji":null,"valence":-0.5}`,
+			setupMocks: func(svc *mocks.MoodServiceMock) {
+				svc.On("Update", mock.Anything, int64(1), int64(10), mock.MatchedBy(func(req *dto.MoodUpdateRequest) bool {
+					return req.Emoji.Set && req.Emoji.Value == nil && *req.Valence.Value == -0.5 && !req.Color.Set
+				})).Return(&dto.MoodResponse{ID: 10, Label: "calm", Custom: true}, nil)
+			},
+			wantCode: http.StatusOK,
+			wantBody: `"emoji":null`,
+		},
+		{
+			name:        "invalid color",
+			contentType: helper.MERGE_PATCH_CONTENT_TYPE,
+			body:        `{"color":"blue"}`,
+			setupMocks:  func(svc *mocks.MoodServiceMock) {},
+			wantCode:    http.StatusBadRequest,
+			wantBody:    `"field":"Color","message":"must be a hex color"`,
+		},
+		{
+			name:        "empty label",
+			contentType: helper.MERGE_PATCH_CONTENT_TYPE,
+			body:        `{"label":""}`,
+			setupMocks:  func(svc *mocks.MoodServiceMock) {},
+			wantCode:    http.StatusBadRequest,
+			wantBody:    helper.VALIDATION_ERROR,
+		},
+		{
+			name:        "success",
+			contentType: helper.MERGE_PATCH_CONTENT_TYPE,
+			body:        `{"archived":true}`,
+			setupMocks: func(svc *mocks.MoodServiceMock) {
+				svc.On("Update", mock.Anything, int64(1), int64(10), mock.MatchedBy(func(req *dto.MoodUpdateRequest) bool {
+					return req.Label == nil && req.Archived != nil && *req.Archived
+				})).Return(&dto.MoodResponse{ID: 10, Label: "calm", Custom: true, Archived: true}, nil)
+			},
+			wantCode: http.StatusOK,
+			wantBody: `"archived":true`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+
+			svc := new(mocks.MoodServiceMock)
+			tt.setupMocks(svc)
+
+			req := httptest.NewRequest(http.MethodPatch, "/moods/10", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			c.Params = gin.Params{{Key: "id", Value: "10"}}
+			c.Set(middleware.UserKey, &models.User{ID: 1})
+
+			h := NewMood(svc)
+			h.Update(c)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+			svc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Photo struct {
+	svc     domain.PhotoService
+	maxSize int64
+}
+
+// NewPhoto takes the size of the largest file an upload may carry.
+func NewPhoto(svc domain.PhotoService, maxSize int64) *Photo {
+	return &Photo{svc: svc, maxSize: maxSize}
+}
+
+func (p *Photo) GetList(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	resp, err := p.svc.GetList(c.Request.Context(), user.ID, c.Param("uid"))
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
+
+// Upload takes the image or recording in the "photo" field. A client that
+// already knows the file's SHA-256 may send it as "sha256" without the file,
+// and only has to upload it when the server answers with not found. The
+// waveform of a recording goes in "peaks" as a JSON array.
+func (p *Photo) Upload(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	if !helper.LimitMultipart(c, p.maxSize) {
+		return
+	}
+
+	upload := &dto.PhotoUpload{
+		Checksum: strings.ToLower(c.PostForm("sha256")),
+		Caption:  c.PostForm("caption"),
+		AltText:  c.PostForm("alt_text"),
+	}
+	if upload.Checksum != "" {
+		if sum, err := hex.DecodeString(upload.Checksum); err != nil || len(sum) != sha256.Size {
+			helper.Fail(c, http.StatusBadRequest, "payload validation failed", helper.VALIDATION_ERROR,
+				[]helper.ValidatorError{{Field: "sha256", Message: "must be a hex encoded SHA-256 digest"}})
+			return
+		}
+	}
+
+	if peaks := c.PostForm("peaks"); peaks != "" {
+		if err := json.Unmarshal([]byte(peaks), &upload.Peaks); err != nil {
+			helper.Fail(c, http.StatusBadRequest, "payload validation failed", helper.VALIDATION_ERROR,
+				[]helper.ValidatorError{{Field: "peaks", Message: "must be a JSON array of integers"}})
+			return
+		}
+	}
+
+	header, err := c.FormFile("photo")
+	if err != nil && upload.Checksum == "" {
+		helper.Fail(c, http.StatusBadRequest, "payload validation failed", helper.VALIDATION_ERROR,
+			[]helper.ValidatorError{{Field: "photo", Message: "is required"}})
+		return
+	}
+
+	if header != nil {
+		file, err := header.Open()
+		if err != nil {
+			helper.Fail(c, http.StatusBadRequest, "payload validation failed", helper.VALIDATION_ERROR,
+				[]helper.ValidatorError{{Field: "photo", Message: "could not be read"}})
+			return
+		}
+		defer file.Close()
+
+		upload.Filename = header.Filename
+		upload.ContentType = header.Header.Get("Content-Type")
+		upload.Size = header.Size
+		upload.Content = file
+	}
+
+	resp, svcErr := p.svc.Upload(c.Request.Context(), user.ID, c.Param("uid"), upload)
+	if svcErr != nil {
+		svcErr.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
+
+func (p *Photo) Update(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	id, ok := photoID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.PhotoUpdateRequest
+	if details, err := helper.BindMergePatch(c, &req); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "payload validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	resp, err := p.svc.Update(c.Request.Context(), user.ID, c.Param("uid"), id, &req)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
+
+func (p *Photo) Reorder(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	var req dto.PhotoOrderRequest
+	if details, err := helper.BindValidate(c, &req); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "payload validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	resp, err := p.svc.Reorder(c.Request.Context(), user.ID, c.Param("uid"), &req)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
+
+func (p *Photo) Delete(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	id, ok := photoID(c)
+	if !ok {
+		return
+	}
+
+	if err := p.svc.Delete(c.Request.Context(), user.ID, c.Param("uid"), id); err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, gin.H{"id": id})
+}
+
+func photoID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id < 1 {
+		helper.Fail(c, http.StatusBadRequest, "invalid photo id", helper.VALIDATION_ERROR, nil)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"timo/dto"
+	"timo/helper"
+	"timo/middleware"
+	"timo/mocks"
+	"timo/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPhotoHandler_Upload(t *testing.T) {
+	const maxSize = 1 << 10
+
+	tests := []struct {
+		name       string
+		size       int
+		setupMocks func(svc *mocks.PhotoServiceMock)
+		wantCode   int
+		wantBody   string
+	}{
+		{
+			name: "within the limit",
+			size: maxSize,
+			setupMocks: func(svc *mocks.PhotoServiceMock) {
+				svc.On("Upload", mock.Anything, int64(1), "journalUID", mock.MatchedBy(func(u *dto.PhotoUpload) bool {
+					return u.Size == maxSize && u.Caption == "beach"
+				})).Return(&dto.PhotoResponse{ID: 3}, nil)
+			},
+			wantCode: http.StatusOK,
+			wantBody: `"id":3`,
+		},
+		{
+			// The body is cut off while it is parsed, before the service
+			// sees the file.
+			name:       "body far over the limit",
+			size:       4 << 20,
+			setupMocks: func(svc *mocks.PhotoServiceMock) {},
+			wantCode:   http.StatusRequestEntityTooLarge,
+			wantBody:   helper.FILE_TOO_LARGE,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+
+			svc := new(mocks.PhotoServiceMock)
+			tt.setupMocks(svc)
+
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			form.WriteField("caption", "beach")
+			part, _ := form.CreateFormFile("photo", "beach.jpg")
+			part.Write(make([]byte, tt.size))
+			form.Close()
+
+			req := httptest.NewRequest(http.MethodPost, "/journals/journalUID/photos", &body)
+			req.Header.Set("Content-Type", form.FormDataContentType())
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			c.Params = gin.Params{{Key: "uid", Value: "journalUID"}}
+			c.Set(middleware.UserKey, &models.User{ID: 1})
+
+			h := NewPhoto(svc, maxSize)
+			h.Upload(c)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+			svc.AssertExpectations(t)
+		})
+	}
+}
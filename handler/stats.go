@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"net/http"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Stats struct {
+	svc domain.StatsService
+}
+
+func NewStats(svc domain.StatsService) *Stats {
+	return &Stats{svc: svc}
+}
+
+func (s *Stats) GetStats(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	resp, err := s.svc.GetStats(c.Request.Context(), user.ID, helper.UserLocation(user.Timezone), user.WeeklyGoal)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
+
+func (s *Stats) GetMoodDistribution(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	var query dto.MoodAnalyticsQuery
+	if details, err := helper.BindQuery(c, &query); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "query validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	resp, err := s.svc.GetMoodDistribution(c.Request.Context(), user.ID, helper.UserLocation(user.Timezone), &query)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
+
+func (s *Stats) GetMoodTrend(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	var query dto.MoodAnalyticsQuery
+	if details, err := helper.BindQuery(c, &query); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "query validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	resp, err := s.svc.GetMoodTrend(c.Request.Context(), user.ID, helper.UserLocation(user.Timezone), &query)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
+
+func (s *Stats) GetMoodByWeekday(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	var query dto.MoodAnalyticsQuery
+	if details, err := helper.BindQuery(c, &query); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "query validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	resp, err := s.svc.GetMoodByWeekday(c.Request.Context(), user.ID, helper.UserLocation(user.Timezone), &query)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Sync struct {
+	svc domain.SyncService
+}
+
+func NewSync(svc domain.SyncService) *Sync {
+	return &Sync{svc: svc}
+}
+
+func (s *Sync) Sync(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	var req dto.SyncRequest
+	if details, err := helper.BindValidate(c, &req); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "payload validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	resp, err := s.svc.Sync(c.Request.Context(), user.ID, helper.UserLocation(user.Timezone), &req)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Upload serves resumable uploads: create one, PATCH chunks at the offset
+// the server reports in Upload-Offset, ask for that offset with HEAD after a
+// dropped connection, and finalize once every byte has arrived.
+type Upload struct {
+	svc domain.UploadService
+}
+
+func NewUpload(svc domain.UploadService) *Upload {
+	return &Upload{svc: svc}
+}
+
+func (u *Upload) Create(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	var req dto.UploadCreateRequest
+	if details, err := helper.BindValidate(c, &req); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "payload validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	resp, err := u.svc.Create(c.Request.Context(), user.ID, c.Param("uid"), &req)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	c.Header("Location", c.Request.URL.Path+"/"+resp.ID)
+	writeOffset(c, resp)
+	helper.Ok(c, resp)
+}
+
+// Offset answers HEAD requests with the upload's progress in headers only.
+func (u *Upload) Offset(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	id, ok := uploadID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := u.svc.Get(c.Request.Context(), user.ID, c.Param("uid"), id)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	writeOffset(c, resp)
+	c.Status(http.StatusOK)
+}
+
+func (u *Upload) Append(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	id, ok := uploadID(c)
+	if !ok {
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		helper.Fail(c, http.StatusBadRequest, "payload validation failed", helper.VALIDATION_ERROR,
+			[]helper.ValidatorError{{Field: "Upload-Offset", Message: "must be a non-negative byte offset"}})
+		return
+	}
+	if c.Request.ContentLength < 0 {
+		helper.Fail(c, http.StatusBadRequest, "payload validation failed", helper.VALIDATION_ERROR,
+			[]helper.ValidatorError{{Field: "Content-Length", Message: "is required"}})
+		return
+	}
+
+	chunk := &dto.UploadChunk{Offset: offset, Size: c.Request.ContentLength, Content: c.Request.Body}
+	resp, svcErr := u.svc.Append(c.Request.Context(), user.ID, c.Param("uid"), id, chunk)
+	if svcErr != nil {
+		svcErr.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	writeOffset(c, resp)
+	helper.Ok(c, resp)
+}
+
+func (u *Upload) Finalize(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	id, ok := uploadID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := u.svc.Finalize(c.Request.Context(), user.ID, c.Param("uid"), id)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
+
+func writeOffset(c *gin.Context, resp *dto.UploadResponse) {
+	c.Header("Upload-Offset", strconv.FormatInt(resp.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(resp.Size, 10))
+}
+
+func uploadID(c *gin.Context) (string, bool) {
+	id := c.Param("id")
+	if err := binding.Validator.Engine().(*validator.Validate).Var(id, "uuid"); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "invalid upload id", helper.VALIDATION_ERROR, nil)
+		return "", false
+	}
+	return id, true
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"timo/dto"
+	"timo/helper"
+	"timo/middleware"
+	"timo/mocks"
+	"timo/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUploadHandler_Append(t *testing.T) {
+	const id = "3f1c2a0e-8c47-4a8e-9a53-2f4f0d1f7b10"
+
+	tests := []struct {
+		name       string
+		id         string
+		offset     string
+		setupMocks func(svc *mocks.UploadServiceMock)
+		wantCode   int
+		wantBody   string
+		wantOffset string
+	}{
+		{
+			name:       "invalid upload id",
+			id:         "abc",
+			offset:     "0",
+			setupMocks: func(svc *mocks.UploadServiceMock) {},
+			wantCode:   http.StatusBadRequest,
+			wantBody:   helper.VALIDATION_ERROR,
+		},
+		{
+			name:       "missing offset",
+			id:         id,
+			setupMocks: func(svc *mocks.UploadServiceMock) {},
+			wantCode:   http.StatusBadRequest,
+			wantBody:   "Upload-Offset",
+		},
+		{
+			name:   "offset mismatch",
+			id:     id,
+			offset: "0",
+			setupMocks: func(svc *mocks.UploadServiceMock) {
+				svc.On("Append", mock.Anything, int64(1), "journalUID", id, int64(0), "chunk").
+					Return(nil, helper.NewAppError(helper.CONFLICT, "chunk does not start at the upload offset", nil).
+						WithDetails(dto.UploadOffset{Offset: 5}))
+			},
+			wantCode: http.StatusConflict,
+			wantBody: `"offset":5`,
+		},
+		{
+			name:   "success",
+			id:     id,
+			offset: "5",
+			setupMocks: func(svc *mocks.UploadServiceMock) {
+				svc.On("Append", mock.Anything, int64(1), "journalUID", id, int64(5), "chunk").
+					Return(&dto.UploadResponse{ID: id, Offset: 10, Size: 20}, nil)
+			},
+			wantCode:   http.StatusOK,
+			wantBody:   `"offset":10`,
+			wantOffset: "10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+
+			svc := new(mocks.UploadServiceMock)
+			tt.setupMocks(svc)
+
+			req := httptest.NewRequest(http.MethodPatch, "/journals/journalUID/uploads/"+tt.id, strings.NewReader("chunk"))
+			req.Header.Set("Content-Type", "application/offset+octet-stream")
+			if tt.offset != "" {
+				req.Header.Set("Upload-Offset", tt.offset)
+			}
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			c.Params = gin.Params{{Key: "uid", Value: "journalUID"}, {Key: "id", Value: tt.id}}
+			c.Set(middleware.UserKey, &models.User{ID: 1})
+
+			h := NewUpload(svc)
+			h.Append(c)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+			assert.Equal(t, tt.wantOffset, w.Header().Get("Upload-Offset"))
+			svc.AssertExpectations(t)
+		})
+	}
+}
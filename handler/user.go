@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"net/http"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type User struct {
+	svc domain.UserService
+}
+
+func NewUser(svc domain.UserService) *User {
+	return &User{svc: svc}
+}
+
+func (u *User) GetProfile(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	resp, err := u.svc.GetProfile(c.Request.Context(), user.Uid)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
+
+func (u *User) UpdateSettings(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+
+	var req dto.UserSettingsRequest
+	if details, err := helper.BindMergePatch(c, &req); err != nil {
+		helper.Fail(c, http.StatusBadRequest, "payload validation failed", helper.VALIDATION_ERROR, details)
+		return
+	}
+
+	resp, err := u.svc.UpdateSettings(c.Request.Context(), user.Uid, &req)
+	if err != nil {
+		err.(*helper.AppError).WriteError(c)
+		return
+	}
+
+	helper.Ok(c, resp)
+}
@@ -0,0 +1,504 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"iter"
+	"time"
+)
+
+var ErrAudioInvalid = errors.New("invalid audio file")
+
+// AudioInfo is what ReadAudioInfo learns from a container header.
+type AudioInfo struct {
+	ContentType string
+	Duration    time.Duration
+}
+
+// audioSignatures are the leading bytes of the containers ReadAudioInfo
+// reads. MP3 files without an ID3 tag start right at a frame and are
+// recognised by its sync bits instead.
+var audioSignatures = []struct {
+	magic       string
+	offset      int
+	contentType string
+	extension   string
+}{
+	{magic: "OggS", contentType: "audio/ogg", extension: ".ogg"},
+	{magic: "ftyp", offset: 4, contentType: "audio/mp4", extension: ".m4a"},
+	{magic: "ID3", contentType: "audio/mpeg", extension: ".mp3"},
+}
+
+// SniffAudio reports the content type of an audio file from its magic
+// bytes, whatever the client claimed. ok is false for formats ReadAudioInfo
+// can't read.
+func SniffAudio(header []byte) (contentType string, ok bool) {
+	for _, sig := range audioSignatures {
+		if len(header) >= sig.offset+len(sig.magic) && string(header[sig.offset:sig.offset+len(sig.magic)]) == sig.magic {
+			return sig.contentType, true
+		}
+	}
+	if len(header) >= 4 {
+		if _, ok := parseMP3Frame(header); ok {
+			return "audio/mpeg", true
+		}
+	}
+	return "", false
+}
+
+// MediaExtension is the file extension for a content type SniffImage or
+// SniffAudio returns, or "" for anything else.
+func MediaExtension(contentType string) string {
+	if ext := ImageExtension(contentType); ext != "" {
+		return ext
+	}
+	for _, sig := range audioSignatures {
+		if sig.contentType == contentType {
+			return sig.extension
+		}
+	}
+	return ""
+}
+
+// ReadAudioInfo sniffs an M4A, Ogg (Vorbis or Opus) or MP3 file and reads
+// its duration from the container, seeking past the audio data rather than
+// decoding it. It returns ErrAudioInvalid for anything it can't make sense
+// of. r is left at an arbitrary position.
+func ReadAudioInfo(r io.ReadSeeker, size int64) (*AudioInfo, error) {
+	header := make([]byte, 12)
+	n, err := io.ReadFull(r, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrAudioInvalid
+		}
+		return nil, err
+	}
+
+	contentType, ok := SniffAudio(header[:n])
+	if !ok {
+		return nil, ErrAudioInvalid
+	}
+
+	var duration time.Duration
+	switch contentType {
+	case "audio/mp4":
+		duration, err = mp4Duration(r, size)
+	case "audio/ogg":
+		duration, err = oggDuration(r, size)
+	case "audio/mpeg":
+		duration, err = mp3Duration(r, size)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if duration <= 0 {
+		return nil, ErrAudioInvalid
+	}
+
+	return &AudioInfo{ContentType: contentType, Duration: duration}, nil
+}
+
+// mp4Duration walks the top-level boxes to moov, which may come after the
+// media data, and reads the movie duration from its mvhd box. Files with a
+// video track are rejected.
+func mp4Duration(r io.ReadSeeker, size int64) (time.Duration, error) {
+	moov, err := mp4FindBox(r, 0, size, "moov")
+	if err != nil {
+		return 0, err
+	}
+	if moov.size > 16<<20 {
+		return 0, ErrAudioInvalid
+	}
+
+	data := make([]byte, moov.size)
+	if _, err := r.Seek(moov.start, io.SeekStart); err != nil {
+		return 0, err
+	}
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, ErrAudioInvalid
+	}
+
+	var duration time.Duration
+	sound := false
+	for box := range mp4Boxes(data) {
+		switch box.kind {
+		case "mvhd":
+			duration, err = mp4MovieDuration(box.data)
+			if err != nil {
+				return 0, err
+			}
+		case "trak":
+			switch mp4Handler(box.data) {
+			case "soun":
+				sound = true
+			case "vide":
+				return 0, ErrAudioInvalid
+			}
+		}
+	}
+	if !sound {
+		return 0, ErrAudioInvalid
+	}
+
+	return duration, nil
+}
+
+type mp4Box struct {
+	kind  string
+	start int64 // offset of the payload
+	size  int64 // size of the payload
+	data  []byte
+}
+
+// mp4FindBox scans the boxes between start and end for the first of kind
+// without reading their payloads.
+func mp4FindBox(r io.ReadSeeker, start, end int64, kind string) (*mp4Box, error) {
+	header := make([]byte, 16)
+	for offset := start; offset+8 <= end; {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			return nil, ErrAudioInvalid
+		}
+
+		boxSize := int64(binary.BigEndian.Uint32(header))
+		headerSize := int64(8)
+		switch boxSize {
+		case 0:
+			boxSize = end - offset
+		case 1:
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return nil, ErrAudioInvalid
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:]))
+			headerSize = 16
+		}
+		if boxSize < headerSize || offset+boxSize > end {
+			return nil, ErrAudioInvalid
+		}
+
+		if string(header[4:8]) == kind {
+			return &mp4Box{kind: kind, start: offset + headerSize, size: boxSize - headerSize}, nil
+		}
+		offset += boxSize
+	}
+	return nil, ErrAudioInvalid
+}
+
+// mp4Boxes yields the boxes directly inside data, stopping at the first one
+// that doesn't fit.
+func mp4Boxes(data []byte) iter.Seq[mp4Box] {
+	return func(yield func(mp4Box) bool) {
+		for len(data) >= 8 {
+			size := int64(binary.BigEndian.Uint32(data))
+			headerSize := int64(8)
+			switch size {
+			case 0:
+				size = int64(len(data))
+			case 1:
+				if len(data) < 16 {
+					return
+				}
+				size = int64(binary.BigEndian.Uint64(data[8:]))
+				headerSize = 16
+			}
+			if size < headerSize || size > int64(len(data)) {
+				return
+			}
+			if !yield(mp4Box{kind: string(data[4:8]), data: data[headerSize:size]}) {
+				return
+			}
+			data = data[size:]
+		}
+	}
+}
+
+// mp4MovieDuration reads the timescale and duration of an mvhd payload.
+func mp4MovieDuration(data []byte) (time.Duration, error) {
+	var timescale, duration uint64
+	switch {
+	case len(data) >= 20 && data[0] == 0:
+		timescale = uint64(binary.BigEndian.Uint32(data[12:]))
+		duration = uint64(binary.BigEndian.Uint32(data[16:]))
+	case len(data) >= 32 && data[0] == 1:
+		timescale = uint64(binary.BigEndian.Uint32(data[20:]))
+		duration = binary.BigEndian.Uint64(data[24:])
+	default:
+		return 0, ErrAudioInvalid
+	}
+	if timescale == 0 || duration == 0 || duration == 1<<32-1 {
+		return 0, ErrAudioInvalid
+	}
+	return samplesDuration(duration, timescale), nil
+}
+
+// mp4Handler returns the handler type of a trak payload, e.g. "soun".
+func mp4Handler(trak []byte) string {
+	for box := range mp4Boxes(trak) {
+		if box.kind != "mdia" {
+			continue
+		}
+		for box := range mp4Boxes(box.data) {
+			if box.kind == "hdlr" && len(box.data) >= 12 {
+				return string(box.data[8:12])
+			}
+		}
+	}
+	return ""
+}
+
+// oggPageMax is the largest possible Ogg page: the 27 byte header, a full
+// segment table and 255 segments of 255 bytes.
+const oggPageMax = 27 + 255 + 255*255
+
+// oggDuration reads the sample rate from the identification header on the
+// first page and the granule position of the stream's last page, which
+// counts the samples up to its end.
+func oggDuration(r io.ReadSeeker, size int64) (time.Duration, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	first := make([]byte, min(size, oggPageMax))
+	if _, err := io.ReadFull(r, first); err != nil {
+		return 0, ErrAudioInvalid
+	}
+
+	page, ok := parseOggPage(first)
+	if !ok {
+		return 0, ErrAudioInvalid
+	}
+
+	var rate, preSkip uint64
+	switch packet := page.payload; {
+	case len(packet) >= 16 && bytes.HasPrefix(packet, []byte("\x01vorbis")):
+		rate = uint64(binary.LittleEndian.Uint32(packet[12:]))
+	case len(packet) >= 12 && bytes.HasPrefix(packet, []byte("OpusHead")):
+		// Opus granule positions always count 48 kHz samples, including
+		// the pre-skip the decoder drops at the start.
+		rate = 48000
+		preSkip = uint64(binary.LittleEndian.Uint16(packet[10:]))
+	default:
+		return 0, ErrAudioInvalid
+	}
+	if rate == 0 {
+		return 0, ErrAudioInvalid
+	}
+
+	tailSize := min(size, oggPageMax)
+	if _, err := r.Seek(size-tailSize, io.SeekStart); err != nil {
+		return 0, err
+	}
+	tail := make([]byte, tailSize)
+	if _, err := io.ReadFull(r, tail); err != nil {
+		return 0, ErrAudioInvalid
+	}
+
+	// The capture pattern can show up inside packet data, so only a page
+	// that parses and belongs to the stream counts.
+	for i := bytes.LastIndex(tail, []byte("OggS")); i >= 0; i = bytes.LastIndex(tail[:i], []byte("OggS")) {
+		last, ok := parseOggPage(tail[i:])
+		if !ok || last.serial != page.serial || last.granule == 1<<64-1 {
+			continue
+		}
+		if last.granule <= preSkip {
+			return 0, ErrAudioInvalid
+		}
+		return samplesDuration(last.granule-preSkip, rate), nil
+	}
+
+	return 0, ErrAudioInvalid
+}
+
+type oggPage struct {
+	granule uint64
+	serial  uint32
+	payload []byte
+}
+
+// parseOggPage reads the page at the start of data.
+func parseOggPage(data []byte) (*oggPage, bool) {
+	if len(data) < 27 || string(data[:4]) != "OggS" || data[4] != 0 {
+		return nil, false
+	}
+
+	segments := int(data[26])
+	if len(data) < 27+segments {
+		return nil, false
+	}
+	length := 0
+	for _, s := range data[27 : 27+segments] {
+		length += int(s)
+	}
+	start := 27 + segments
+	if len(data) < start+length {
+		return nil, false
+	}
+
+	return &oggPage{
+		granule: binary.LittleEndian.Uint64(data[6:]),
+		serial:  binary.LittleEndian.Uint32(data[14:]),
+		payload: data[start : start+length],
+	}, true
+}
+
+// mp3Bitrates are the Layer III bitrates in kbit/s by bitrate index, for
+// MPEG-1 and for MPEG-2 and 2.5.
+var mp3Bitrates = [2][15]int{
+	{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+}
+
+// mp3SampleRates are indexed by the version bits and the rate index.
+var mp3SampleRates = map[byte][3]int{
+	3: {44100, 48000, 32000}, // MPEG-1
+	2: {22050, 24000, 16000}, // MPEG-2
+	0: {11025, 12000, 8000},  // MPEG-2.5
+}
+
+type mp3Frame struct {
+	mpeg1      bool
+	mono       bool
+	bitrate    int // bit/s
+	sampleRate int
+	length     int
+}
+
+func (f *mp3Frame) samples() uint64 {
+	if f.mpeg1 {
+		return 1152
+	}
+	return 576
+}
+
+// parseMP3Frame reads a Layer III frame header at the start of data.
+func parseMP3Frame(data []byte) (*mp3Frame, bool) {
+	if len(data) < 4 || data[0] != 0xFF || data[1]&0xE0 != 0xE0 {
+		return nil, false
+	}
+
+	version := data[1] >> 3 & 0x03
+	layer := data[1] >> 1 & 0x03
+	bitrateIndex := data[2] >> 4
+	rateIndex := data[2] >> 2 & 0x03
+	rates, ok := mp3SampleRates[version]
+	if !ok || layer != 1 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return nil, false
+	}
+
+	frame := &mp3Frame{
+		mpeg1:      version == 3,
+		mono:       data[3]>>6 == 3,
+		sampleRate: rates[rateIndex],
+	}
+	table := 1
+	coefficient := 72
+	if frame.mpeg1 {
+		table = 0
+		coefficient = 144
+	}
+	frame.bitrate = mp3Bitrates[table][bitrateIndex] * 1000
+	frame.length = coefficient*frame.bitrate/frame.sampleRate + int(data[2]>>1&0x01)
+
+	return frame, true
+}
+
+// mp3Scan is how far past the ID3 tag the first frame is looked for.
+const mp3Scan = 64 << 10
+
+// mp3Duration skips an ID3v2 tag and finds the first frame, confirmed by the
+// frame that follows it. A Xing, Info or VBRI header in that frame gives
+// the frame count; without one the file is taken to be constant bitrate.
+func mp3Duration(r io.ReadSeeker, size int64) (time.Duration, error) {
+	var start int64
+	header := make([]byte, 10)
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	if _, err := io.ReadFull(r, header); err == nil && string(header[:3]) == "ID3" {
+		start = 10 + (int64(header[6]&0x7F)<<21 | int64(header[7]&0x7F)<<14 | int64(header[8]&0x7F)<<7 | int64(header[9]&0x7F))
+		if header[5]&0x10 != 0 {
+			start += 10
+		}
+	}
+	if start >= size {
+		return 0, ErrAudioInvalid
+	}
+
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return 0, err
+	}
+	data := make([]byte, min(size-start, mp3Scan))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, ErrAudioInvalid
+	}
+
+	for i := 0; i+4 <= len(data); i++ {
+		frame, ok := parseMP3Frame(data[i:])
+		if !ok {
+			continue
+		}
+		next := i + frame.length
+		if next+4 <= len(data) {
+			if _, ok := parseMP3Frame(data[next:]); !ok {
+				continue
+			}
+		} else if end := start + int64(next); end != size && end != size-128 {
+			continue
+		}
+
+		if frames := mp3FrameCount(data[i:], frame); frames > 0 {
+			return samplesDuration(frames*frame.samples(), uint64(frame.sampleRate)), nil
+		}
+
+		audio := size - start - int64(i)
+		if tag, err := readAt(r, size-128, 3); err == nil && string(tag) == "TAG" {
+			audio -= 128
+		}
+		return time.Duration(float64(audio) * 8 / float64(frame.bitrate) * float64(time.Second)), nil
+	}
+
+	return 0, ErrAudioInvalid
+}
+
+// mp3FrameCount reads the frame count from a Xing/Info or VBRI header in
+// the first frame, or returns 0 when there is none.
+func mp3FrameCount(data []byte, frame *mp3Frame) uint64 {
+	sideInfo := 17
+	switch {
+	case frame.mpeg1 && !frame.mono:
+		sideInfo = 32
+	case !frame.mpeg1 && frame.mono:
+		sideInfo = 9
+	}
+
+	if xing := data[min(4+sideInfo, len(data)):]; len(xing) >= 12 &&
+		(string(xing[:4]) == "Xing" || string(xing[:4]) == "Info") && xing[7]&0x01 != 0 {
+		return uint64(binary.BigEndian.Uint32(xing[8:]))
+	}
+	if vbri := data[min(36, len(data)):]; len(vbri) >= 18 && string(vbri[:4]) == "VBRI" {
+		return uint64(binary.BigEndian.Uint32(vbri[14:]))
+	}
+	return 0
+}
+
+// samplesDuration converts a sample count at rate into a duration.
+func samplesDuration(samples, rate uint64) time.Duration {
+	return time.Duration(float64(samples) / float64(rate) * float64(time.Second))
+}
+
+func readAt(r io.ReadSeeker, offset int64, n int) ([]byte, error) {
+	if offset < 0 {
+		return nil, ErrAudioInvalid
+	}
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
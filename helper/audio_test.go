@@ -0,0 +1,257 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mp4Fixture builds a box of kind around the concatenated payloads.
+func mp4Fixture(kind string, payloads ...[]byte) []byte {
+	payload := bytes.Join(payloads, nil)
+	box := binary.BigEndian.AppendUint32(nil, uint32(8+len(payload)))
+	return append(append(box, kind...), payload...)
+}
+
+// mvhdFixture is a version 0 movie header, or version 1 when long is set.
+func mvhdFixture(long bool, timescale uint32, duration uint64) []byte {
+	if long {
+		data := append([]byte{1, 0, 0, 0}, make([]byte, 16)...)
+		data = binary.BigEndian.AppendUint32(data, timescale)
+		return mp4Fixture("mvhd", binary.BigEndian.AppendUint64(data, duration), make([]byte, 80))
+	}
+	data := make([]byte, 12)
+	data = binary.BigEndian.AppendUint32(data, timescale)
+	return mp4Fixture("mvhd", binary.BigEndian.AppendUint32(data, uint32(duration)), make([]byte, 80))
+}
+
+func trakFixture(handler string) []byte {
+	hdlr := append(make([]byte, 8), handler...)
+	return mp4Fixture("trak", mp4Fixture("mdia", mp4Fixture("hdlr", hdlr, make([]byte, 13))))
+}
+
+// oggFixture builds a page of the stream with a single packet.
+func oggFixture(serial uint32, granule uint64, packet []byte) []byte {
+	page := []byte("OggS\x00\x00")
+	page = binary.LittleEndian.AppendUint64(page, granule)
+	page = binary.LittleEndian.AppendUint32(page, serial)
+	page = append(page, make([]byte, 8)...) // sequence number and checksum
+	var segments []byte
+	for n := len(packet); ; n -= 255 {
+		segments = append(segments, byte(min(n, 255)))
+		if n < 255 {
+			break
+		}
+	}
+	page = append(page, byte(len(segments)))
+	return append(append(page, segments...), packet...)
+}
+
+var (
+	vorbisHead = binary.LittleEndian.AppendUint32([]byte("\x01vorbis\x00\x00\x00\x00\x02"), 44100)
+	opusHead   = []byte("OpusHead\x01\x02\x38\x01\x80\xbb\x00\x00\x00\x00\x00")
+)
+
+// mp3Frames is n MPEG-1 Layer III frames at 128 kbit/s and 44.1 kHz, 417
+// bytes each. The first frame starts with info when given.
+func mp3Frames(n int, info []byte) []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	data := bytes.Repeat(frame, n)
+	copy(data[4:], info)
+	return data
+}
+
+// id3Fixture is an ID3v2 tag of size bytes after its header, with a footer
+// when flagged.
+func id3Fixture(size int, footer bool) []byte {
+	var flags byte
+	if footer {
+		flags = 0x10
+	}
+	tag := []byte{'I', 'D', '3', 4, 0, flags, byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}
+	tag = append(tag, make([]byte, size)...)
+	if footer {
+		tag = append(tag, make([]byte, 10)...)
+	}
+	return tag
+}
+
+func TestReadAudioInfo(t *testing.T) {
+	ftyp := mp4Fixture("ftyp", []byte("M4A \x00\x00\x00\x00M4A isom"))
+	mdat := mp4Fixture("mdat", make([]byte, 1000))
+	// A mdat box with a 64-bit size.
+	largeMdat := append(binary.BigEndian.AppendUint64([]byte("\x00\x00\x00\x01mdat"), 16+100), make([]byte, 100)...)
+
+	xing := append(make([]byte, 32), "Xing\x00\x00\x00\x01\x00\x00\x03\xe8"...)
+	vbri := append(make([]byte, 32), "VBRI\x00\x01\x00\x00\x00\x50\x00\x00\x10\x00\x00\x00\x01\xf4"...)
+	cbr := 417 * 10 * 8 * time.Second / 128000
+
+	tests := []struct {
+		name     string
+		data     []byte
+		wantType string
+		want     time.Duration
+		wantErr  bool
+	}{
+		{
+			name:     "m4a with moov after the media data",
+			data:     bytes.Join([][]byte{ftyp, mdat, mp4Fixture("moov", mvhdFixture(false, 1000, 5000), trakFixture("soun"))}, nil),
+			wantType: "audio/mp4",
+			want:     5 * time.Second,
+		},
+		{
+			name:     "m4a with a 64-bit movie header and media size",
+			data:     bytes.Join([][]byte{ftyp, mp4Fixture("moov", trakFixture("soun"), mvhdFixture(true, 44100, 3*44100)), largeMdat}, nil),
+			wantType: "audio/mp4",
+			want:     3 * time.Second,
+		},
+		{
+			name:    "m4a with a video track",
+			data:    bytes.Join([][]byte{ftyp, mp4Fixture("moov", mvhdFixture(false, 1000, 5000), trakFixture("soun"), trakFixture("vide"))}, nil),
+			wantErr: true,
+		},
+		{
+			name:    "m4a without a sound track",
+			data:    bytes.Join([][]byte{ftyp, mp4Fixture("moov", mvhdFixture(false, 1000, 5000))}, nil),
+			wantErr: true,
+		},
+		{
+			name:    "m4a without moov",
+			data:    bytes.Join([][]byte{ftyp, mdat}, nil),
+			wantErr: true,
+		},
+		{
+			name:    "m4a with a truncated moov",
+			data:    bytes.Join([][]byte{ftyp, mp4Fixture("moov", mvhdFixture(false, 1000, 5000), trakFixture("soun"))}, nil)[:len(ftyp)+60],
+			wantErr: true,
+		},
+		{
+			name:    "m4a with a box smaller than its header",
+			data:    append(ftyp, 0, 0, 0, 4, 'm', 'o', 'o', 'v'),
+			wantErr: true,
+		},
+		{
+			name:    "m4a with a zero timescale",
+			data:    bytes.Join([][]byte{ftyp, mp4Fixture("moov", mvhdFixture(false, 0, 5000), trakFixture("soun"))}, nil),
+			wantErr: true,
+		},
+		{
+			name:    "m4a with a short movie header",
+			data:    bytes.Join([][]byte{ftyp, mp4Fixture("moov", mp4Fixture("mvhd", make([]byte, 10)), trakFixture("soun"))}, nil),
+			wantErr: true,
+		},
+		{
+			name:     "ogg vorbis",
+			data:     bytes.Join([][]byte{oggFixture(7, 0, vorbisHead), oggFixture(7, 44100, make([]byte, 300)), oggFixture(7, 2*44100, make([]byte, 40))}, nil),
+			wantType: "audio/ogg",
+			want:     2 * time.Second,
+		},
+		{
+			name:     "ogg opus without the pre-skip",
+			data:     bytes.Join([][]byte{oggFixture(7, 0, opusHead), oggFixture(7, 4*48000+312, make([]byte, 40))}, nil),
+			wantType: "audio/ogg",
+			want:     4 * time.Second,
+		},
+		{
+			name: "ogg ignores the pages of other streams",
+			data: bytes.Join([][]byte{oggFixture(7, 0, vorbisHead), oggFixture(7, 44100, make([]byte, 40)),
+				oggFixture(8, 1<<40, make([]byte, 40))}, nil),
+			wantType: "audio/ogg",
+			want:     time.Second,
+		},
+		{
+			name:    "ogg of an unknown codec",
+			data:    bytes.Join([][]byte{oggFixture(7, 0, []byte("\x80theora........")), oggFixture(7, 44100, make([]byte, 40))}, nil),
+			wantErr: true,
+		},
+		{
+			name:    "ogg with a truncated first page",
+			data:    oggFixture(7, 0, vorbisHead)[:35],
+			wantErr: true,
+		},
+		{
+			name:    "ogg opus shorter than its pre-skip",
+			data:    bytes.Join([][]byte{oggFixture(7, 0, opusHead), oggFixture(7, 100, make([]byte, 40))}, nil),
+			wantErr: true,
+		},
+		{
+			name:     "constant bitrate mp3",
+			data:     mp3Frames(10, nil),
+			wantType: "audio/mpeg",
+			want:     cbr,
+		},
+		{
+			name:     "mp3 after an ID3v2 tag",
+			data:     append(id3Fixture(300, false), mp3Frames(10, nil)...),
+			wantType: "audio/mpeg",
+			want:     cbr,
+		},
+		{
+			name:     "mp3 after an ID3v2 tag with footer",
+			data:     append(id3Fixture(300, true), mp3Frames(10, nil)...),
+			wantType: "audio/mpeg",
+			want:     cbr,
+		},
+		{
+			name:     "mp3 with an ID3v1 tag at the end",
+			data:     append(mp3Frames(10, nil), append([]byte("TAG"), make([]byte, 125)...)...),
+			wantType: "audio/mpeg",
+			want:     cbr,
+		},
+		{
+			name:     "mp3 with a Xing header",
+			data:     mp3Frames(10, xing),
+			wantType: "audio/mpeg",
+			want:     1000 * 1152 * time.Second / 44100,
+		},
+		{
+			name:     "mp3 with a VBRI header",
+			data:     mp3Frames(10, vbri),
+			wantType: "audio/mpeg",
+			want:     500 * 1152 * time.Second / 44100,
+		},
+		{
+			name:    "ID3v2 tag past the end of the file",
+			data:    id3Fixture(300, false)[:100],
+			wantErr: true,
+		},
+		{
+			name:    "ID3v2 tag without frames",
+			data:    append(id3Fixture(20, false), make([]byte, 2000)...),
+			wantErr: true,
+		},
+		{
+			name:    "frame sync without a following frame",
+			data:    append([]byte{0xFF, 0xFB, 0x90, 0x00}, make([]byte, 1000)...),
+			wantErr: true,
+		},
+		{
+			name:    "empty file",
+			wantErr: true,
+		},
+		{
+			name:    "not audio",
+			data:    []byte("RIFF\x24\x00\x00\x00WAVEfmt "),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := ReadAudioInfo(bytes.NewReader(tt.data), int64(len(tt.data)))
+
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrAudioInvalid)
+				assert.Nil(t, info)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantType, info.ContentType)
+			assert.InDelta(t, tt.want.Seconds(), info.Duration.Seconds(), 0.001)
+		})
+	}
+}
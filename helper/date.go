@@ -0,0 +1,28 @@
+package helper
+
+import "time"
+
+const (
+	DATE_LAYOUT  = "2006-01-02"
+	MONTH_LAYOUT = "2006-01"
+)
+
+// UserLocation resolves a stored IANA timezone, falling back to UTC.
+func UserLocation(timezone string) *time.Location {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// LocalDate returns the calendar date of t in loc as midnight UTC, the form
+// pgx uses for date columns.
+func LocalDate(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func ParseDate(s string) (time.Time, error) {
+	return time.Parse(DATE_LAYOUT, s)
+}
@@ -0,0 +1,89 @@
+package helper
+
+import (
+	"sync"
+	"time"
+)
+
+// Debouncer coalesces bursts of calls per key into a single call that runs
+// once the key has been quiet for delay, or at the latest maxWait after the
+// first call of the burst.
+type Debouncer struct {
+	delay   time.Duration
+	maxWait time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*debounced
+}
+
+type debounced struct {
+	fn    func()
+	first time.Time
+	timer *time.Timer
+}
+
+func NewDebouncer(delay, maxWait time.Duration) *Debouncer {
+	return &Debouncer{delay: delay, maxWait: maxWait, pending: make(map[string]*debounced)}
+}
+
+// Trigger schedules fn for key, replacing any call still pending for it.
+func (d *Debouncer) Trigger(key string, fn func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entry, ok := d.pending[key]
+	if !ok {
+		entry = &debounced{first: time.Now()}
+		d.pending[key] = entry
+	} else {
+		entry.timer.Stop()
+	}
+	entry.fn = fn
+
+	wait := min(d.delay, d.maxWait-time.Since(entry.first))
+	entry.timer = time.AfterFunc(max(wait, 0), func() { d.fire(key, entry) })
+}
+
+// Flush runs the call pending for key right away, if there is one.
+func (d *Debouncer) Flush(key string) {
+	d.mu.Lock()
+	entry, ok := d.pending[key]
+	if ok {
+		entry.timer.Stop()
+		delete(d.pending, key)
+	}
+	d.mu.Unlock()
+
+	if ok {
+		entry.fn()
+	}
+}
+
+// FlushAll runs every pending call right away, e.g. before shutting down.
+func (d *Debouncer) FlushAll() {
+	d.mu.Lock()
+	fns := make([]func(), 0, len(d.pending))
+	for key, entry := range d.pending {
+		entry.timer.Stop()
+		delete(d.pending, key)
+		fns = append(fns, entry.fn)
+	}
+	d.mu.Unlock()
+
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+func (d *Debouncer) fire(key string, entry *debounced) {
+	d.mu.Lock()
+	if d.pending[key] != entry {
+		d.mu.Unlock()
+		return
+	}
+	delete(d.pending, key)
+	fn := entry.fn
+	d.mu.Unlock()
+
+	fn()
+}
@@ -0,0 +1,71 @@
+package helper
+
+import "strings"
+
+const (
+	DIFF_EQUAL  string = "equal"
+	DIFF_INSERT string = "insert"
+	DIFF_DELETE string = "delete"
+)
+
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// LineDiff returns the line-level edit script turning a into b, based on the
+// longest common subsequence of their lines.
+func LineDiff(a, b string) []DiffLine {
+	x := strings.Split(a, "\n")
+	y := strings.Split(b, "\n")
+
+	var prefix, suffix []DiffLine
+	for len(x) > 0 && len(y) > 0 && x[0] == y[0] {
+		prefix = append(prefix, DiffLine{Op: DIFF_EQUAL, Text: x[0]})
+		x, y = x[1:], y[1:]
+	}
+	for len(x) > 0 && len(y) > 0 && x[len(x)-1] == y[len(y)-1] {
+		suffix = append([]DiffLine{{Op: DIFF_EQUAL, Text: x[len(x)-1]}}, suffix...)
+		x, y = x[:len(x)-1], y[:len(y)-1]
+	}
+
+	// lcs[i][j] is the LCS length of x[i:] and y[j:].
+	lcs := make([][]int32, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := prefix
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			lines = append(lines, DiffLine{Op: DIFF_EQUAL, Text: x[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, DiffLine{Op: DIFF_DELETE, Text: x[i]})
+			i++
+		default:
+			lines = append(lines, DiffLine{Op: DIFF_INSERT, Text: y[j]})
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		lines = append(lines, DiffLine{Op: DIFF_DELETE, Text: x[i]})
+	}
+	for ; j < len(y); j++ {
+		lines = append(lines, DiffLine{Op: DIFF_INSERT, Text: y[j]})
+	}
+
+	return append(lines, suffix...)
+}
@@ -11,6 +11,7 @@ type AppError struct {
 	Code    string
 	Message string
 	Err     error
+	Details any
 }
 
 func (e *AppError) Error() string {
@@ -33,9 +34,28 @@ func (e *AppError) WriteError(c *gin.Context) {
 		status = http.StatusBadRequest
 	case EMAIL_EXIST:
 		status = http.StatusConflict
+	case UNAUTHORIZED:
+		status = http.StatusUnauthorized
+	case PRECONDITION_FAILED:
+		status = http.StatusPreconditionFailed
+	case PRECONDITION_REQUIRED:
+		status = http.StatusPreconditionRequired
+	case FILE_TOO_LARGE:
+		status = http.StatusRequestEntityTooLarge
+	case QUOTA_EXCEEDED:
+		status = http.StatusForbidden
+	case FORBIDDEN:
+		status = http.StatusForbidden
+	case CONFLICT:
+		status = http.StatusConflict
 	}
 
-	Fail(c, status, e.Message, e.Code, nil)
+	Fail(c, status, e.Message, e.Code, e.Details)
+}
+
+func (e *AppError) WithDetails(details any) *AppError {
+	e.Details = details
+	return e
 }
 
 func NewAppError(code, message string, err error) *AppError {
@@ -43,9 +63,16 @@ func NewAppError(code, message string, err error) *AppError {
 }
 
 const (
-	INTERNAL_ERROR   string = "INTERNAL_SERVER_ERROR"
-	VALIDATION_ERROR string = "VALIDATION_ERROR"
-	NOT_FOUND        string = "NOT_FOUND"
-	LOGIN_ERROR      string = "LOGIN_ERROR"
-	EMAIL_EXIST      string = "EMAIL_EXIST"
+	INTERNAL_ERROR        string = "INTERNAL_SERVER_ERROR"
+	VALIDATION_ERROR      string = "VALIDATION_ERROR"
+	NOT_FOUND             string = "NOT_FOUND"
+	LOGIN_ERROR           string = "LOGIN_ERROR"
+	EMAIL_EXIST           string = "EMAIL_EXIST"
+	UNAUTHORIZED          string = "UNAUTHORIZED"
+	PRECONDITION_FAILED   string = "PRECONDITION_FAILED"
+	PRECONDITION_REQUIRED string = "PRECONDITION_REQUIRED"
+	FILE_TOO_LARGE        string = "FILE_TOO_LARGE"
+	QUOTA_EXCEEDED        string = "QUOTA_EXCEEDED"
+	FORBIDDEN             string = "FORBIDDEN"
+	CONFLICT              string = "CONFLICT"
 )
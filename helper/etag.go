@@ -0,0 +1,32 @@
+package helper
+
+import (
+	"strconv"
+	"strings"
+)
+
+func ETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ParseIfMatch extracts the journal version from an If-Match header. A
+// wildcard yields version 0, which matches any current version.
+func ParseIfMatch(header string) (int64, bool) {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return 0, true
+	}
+
+	header = strings.TrimPrefix(header, "W/")
+	unquoted, err := strconv.Unquote(header)
+	if err != nil {
+		return 0, false
+	}
+
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+
+	return version, true
+}
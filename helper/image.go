@@ -0,0 +1,268 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"strings"
+	"time"
+)
+
+const EXIF_TIME_LAYOUT = "2006:01:02 15:04:05"
+
+// ImageMeta is what we keep from EXIF before it is stripped.
+type ImageMeta struct {
+	TakenAt     *time.Time
+	Orientation int
+}
+
+// ReadImageMeta pulls the capture time and orientation out of a JPEG's EXIF
+// segment. Anything it can't parse is left empty.
+func ReadImageMeta(data []byte) ImageMeta {
+	var meta ImageMeta
+
+	tiff := exifSegment(data)
+	if len(tiff) < 8 {
+		return meta
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return meta
+	}
+
+	ifd0 := readIFD(tiff, order, order.Uint32(tiff[4:]))
+	if v, ok := ifd0[0x0112]; ok {
+		meta.Orientation = int(order.Uint16(v.value))
+	}
+
+	if v, ok := ifd0[0x8769]; ok {
+		exif := readIFD(tiff, order, order.Uint32(v.value))
+		if v, ok := exif[0x9003]; ok {
+			if t, err := time.Parse(EXIF_TIME_LAYOUT, strings.TrimRight(v.ascii(tiff, order), "\x00 ")); err == nil {
+				meta.TakenAt = &t
+			}
+		}
+	}
+
+	return meta
+}
+
+// exifSegment returns the TIFF payload of the APP1 Exif segment of a JPEG.
+func exifSegment(data []byte) []byte {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return nil
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 {
+			return nil
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return nil
+		}
+		if marker == 0xE1 && bytes.HasPrefix(data[i+4:end], []byte("Exif\x00\x00")) {
+			return data[i+10 : end]
+		}
+		i = end
+	}
+
+	return nil
+}
+
+type ifdEntry struct {
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+// ascii reads an ASCII entry, whose value lives at an offset when it doesn't
+// fit in the four value bytes.
+func (e ifdEntry) ascii(tiff []byte, order binary.ByteOrder) string {
+	if e.typ != 2 {
+		return ""
+	}
+	if e.count <= 4 {
+		return string(e.value[:e.count])
+	}
+	offset := order.Uint32(e.value)
+	if uint64(offset)+uint64(e.count) > uint64(len(tiff)) {
+		return ""
+	}
+	return string(tiff[offset : offset+e.count])
+}
+
+func readIFD(tiff []byte, order binary.ByteOrder, offset uint32) map[uint16]ifdEntry {
+	entries := make(map[uint16]ifdEntry)
+	if uint64(offset)+2 > uint64(len(tiff)) {
+		return entries
+	}
+
+	n := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < n; i++ {
+		at := int(offset) + 2 + i*12
+		if at+12 > len(tiff) {
+			break
+		}
+		entries[order.Uint16(tiff[at:])] = ifdEntry{
+			typ:   order.Uint16(tiff[at+2:]),
+			count: order.Uint32(tiff[at+4:]),
+			value: tiff[at+8 : at+12],
+		}
+	}
+
+	return entries
+}
+
+// imageSignatures are the leading bytes of the formats DecodeImage reads.
+var imageSignatures = []struct {
+	magic       string
+	contentType string
+	extension   string
+}{
+	{magic: "\xff\xd8\xff", contentType: "image/jpeg", extension: ".jpg"},
+	{magic: "\x89PNG\r\n\x1a\n", contentType: "image/png", extension: ".png"},
+	{magic: "GIF87a", contentType: "image/gif", extension: ".gif"},
+	{magic: "GIF89a", contentType: "image/gif", extension: ".gif"},
+}
+
+// SniffImage reports the content type of an image from its magic bytes,
+// whatever the client claimed. ok is false for formats DecodeImage can't read.
+func SniffImage(header []byte) (contentType string, ok bool) {
+	for _, sig := range imageSignatures {
+		if bytes.HasPrefix(header, []byte(sig.magic)) {
+			return sig.contentType, true
+		}
+	}
+	return "", false
+}
+
+// ImageExtension is the file extension for a content type SniffImage
+// returns, or "" for anything else.
+func ImageExtension(contentType string) string {
+	for _, sig := range imageSignatures {
+		if sig.contentType == contentType {
+			return sig.extension
+		}
+	}
+	return ""
+}
+
+// DecodeImage decodes a JPEG, PNG or GIF into RGBA pixels, flattened onto
+// white since the JPEG variants have no alpha channel.
+func DecodeImage(r io.Reader) (*image.RGBA, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(rgba, rgba.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Over)
+	return rgba, nil
+}
+
+// Orient turns an image upright according to its EXIF orientation (1-8), so
+// it still displays correctly once the tag is gone.
+func Orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			copy(dst.Pix[dst.PixOffset(dx, dy):dst.PixOffset(dx, dy)+4], src.Pix[src.PixOffset(x, y):src.PixOffset(x, y)+4])
+		}
+	}
+
+	return dst
+}
+
+// Resize scales an image down so its longer side is at most maxSide,
+// averaging the source pixels each target pixel covers. Smaller images are
+// returned as they are.
+func Resize(src *image.RGBA, maxSide int) *image.RGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if w <= maxSide && h <= maxSide {
+		return src
+	}
+
+	dw, dh := maxSide, h*maxSide/w
+	if h > w {
+		dw, dh = w*maxSide/h, maxSide
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		y0, y1 := dy*h/dh, max((dy+1)*h/dh, dy*h/dh+1)
+		for dx := 0; dx < dw; dx++ {
+			x0, x1 := dx*w/dw, max((dx+1)*w/dw, dx*w/dw+1)
+
+			var sum [4]int
+			for y := y0; y < y1; y++ {
+				row := src.Pix[src.PixOffset(x0, y) : src.PixOffset(x1-1, y)+4]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += int(row[i])
+					sum[1] += int(row[i+1])
+					sum[2] += int(row[i+2])
+					sum[3] += int(row[i+3])
+				}
+			}
+
+			n := (x1 - x0) * (y1 - y0)
+			at := dst.PixOffset(dx, dy)
+			for c := 0; c < 4; c++ {
+				dst.Pix[at+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+
+	return dst
+}
+
+// EncodeJPEG writes img as a baseline JPEG. The encoder never writes EXIF,
+// so this is also what strips it.
+func EncodeJPEG(w io.Writer, img image.Image) error {
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: 82})
+}
@@ -0,0 +1,27 @@
+package helper
+
+import (
+	"context"
+	"log"
+)
+
+type Notification struct {
+	UserID int64
+	Email  string
+	Title  string
+	Body   string
+	Data   any
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, notification *Notification) error
+}
+
+// LogNotifier writes notifications to the server log. It stands in until a
+// push or email provider is wired up.
+type LogNotifier struct{}
+
+func (l LogNotifier) Notify(ctx context.Context, notification *Notification) error {
+	log.Printf("notify user %d <%s>: %s - %s", notification.UserID, notification.Email, notification.Title, notification.Body)
+	return nil
+}
@@ -0,0 +1,51 @@
+package helper
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Optional is a merge patch member that may be removed. Set tells a member
+// that is present from an absent one, and a null member leaves Value nil.
+// Binding rules apply to Value when there is one.
+type Optional[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	o.Value = &v
+	return nil
+}
+
+func (o Optional[T]) value() any {
+	if o.Value == nil {
+		return nil
+	}
+	return *o.Value
+}
+
+type removable interface {
+	value() any
+}
+
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterCustomTypeFunc(func(field reflect.Value) any {
+			return field.Interface().(removable).value()
+		}, Optional[string]{}, Optional[float64]{})
+	}
+}
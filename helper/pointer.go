@@ -1,5 +1,22 @@
 package helper
 
-func Ptr(s string) *string {
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// PtrOrNil is Ptr for optional text, where empty means absent.
+func PtrOrNil(s string) *string {
+	if s == "" {
+		return nil
+	}
 	return &s
 }
+
+// Deref returns the value p points at, or the zero value for nil.
+func Deref[T any](p *T) T {
+	var v T
+	if p != nil {
+		v = *p
+	}
+	return v
+}
@@ -22,6 +22,10 @@ func Ok[T any](c *gin.Context, data T) {
 	c.JSON(http.StatusOK, Response[T]{Status: "success", Data: data})
 }
 
+func Accepted[T any](c *gin.Context, data T) {
+	c.JSON(http.StatusAccepted, Response[T]{Status: "success", Data: data})
+}
+
 func Fail(c *gin.Context, httpCode int, msg, code string, details any) {
 	c.JSON(httpCode, Response[struct{}]{
 		Status:  "error",
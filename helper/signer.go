@@ -0,0 +1,74 @@
+package helper
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSignatureInvalid = errors.New("invalid url signature")
+	ErrSignatureExpired = errors.New("url signature expired")
+)
+
+// URLSigner hands out expiring links to stored media. A link carries the
+// storage key, the expiry as a unix timestamp and an HMAC over both, so the
+// download handler can check it without a database lookup.
+type URLSigner struct {
+	key     []byte
+	baseURL string
+	ttl     time.Duration
+}
+
+func NewURLSigner(key []byte, baseURL string, ttl time.Duration) *URLSigner {
+	return &URLSigner{key: key, baseURL: strings.TrimRight(baseURL, "/"), ttl: ttl}
+}
+
+// URL signs key with the default lifetime.
+func (s *URLSigner) URL(key string, now time.Time) string {
+	return s.URLFor(key, now, s.ttl)
+}
+
+// URLFor signs key so it stays valid for at least three quarters of ttl. The
+// expiry is rounded to a quarter of ttl so repeated requests hand out the
+// same link for a while and clients can cache the image by its URL.
+func (s *URLSigner) URLFor(key string, now time.Time, ttl time.Duration) string {
+	expires := now.Add(ttl).Truncate(ttl / 4).Unix()
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("sig", s.sign(key, expires))
+
+	return s.baseURL + "/" + (&url.URL{Path: key}).EscapedPath() + "?" + query.Encode()
+}
+
+// Verify checks a signature produced by URL and returns the expiry it
+// carries.
+func (s *URLSigner) Verify(key, expires, sig string, now time.Time) (time.Time, error) {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return time.Time{}, ErrSignatureInvalid
+	}
+
+	if !hmac.Equal([]byte(sig), []byte(s.sign(key, unix))) {
+		return time.Time{}, ErrSignatureInvalid
+	}
+
+	expiry := time.Unix(unix, 0)
+	if !now.Before(expiry) {
+		return time.Time{}, ErrSignatureExpired
+	}
+
+	return expiry, nil
+}
+
+func (s *URLSigner) sign(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte("media\n" + key + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
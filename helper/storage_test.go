@@ -0,0 +1,81 @@
+package helper
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStorage_InvalidKeys(t *testing.T) {
+	root := t.TempDir()
+	s := NewLocalStorage(filepath.Join(root, "media"), "http://api/media")
+	ctx := context.Background()
+
+	// A file next to the root that no key may reach.
+	secret := filepath.Join(root, "secret.txt")
+	os.WriteFile(secret, []byte("secret"), 0o644)
+
+	keys := []string{"", "../secret.txt", "users/../../secret.txt", "/etc/passwd", secret, "users/1/../../../secret.txt"}
+	for _, key := range keys {
+		t.Run(key, func(t *testing.T) {
+			err := s.Put(ctx, key, strings.NewReader("x"), 1, "text/plain")
+			assert.ErrorIs(t, err, ErrInvalidKey)
+
+			_, err = s.Get(ctx, key)
+			assert.ErrorIs(t, err, ErrInvalidKey)
+
+			_, err = s.Stat(ctx, key)
+			assert.ErrorIs(t, err, ErrInvalidKey)
+
+			err = s.Delete(ctx, key)
+			assert.ErrorIs(t, err, ErrInvalidKey)
+		})
+	}
+
+	content, _ := os.ReadFile(secret)
+	assert.Equal(t, "secret", string(content))
+}
+
+func TestLocalStorage_Objects(t *testing.T) {
+	s := NewLocalStorage(t.TempDir(), "http://api/media/")
+	ctx := context.Background()
+
+	err := s.Put(ctx, "users/1/photos/a.jpg", strings.NewReader("0123456789"), 10, "image/jpeg")
+	assert.NoError(t, err)
+
+	r, err := s.GetRange(ctx, "users/1/photos/a.jpg", 3, 4)
+	assert.NoError(t, err)
+	content, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "3456", string(content))
+
+	info, err := s.Stat(ctx, "users/1/photos/a.jpg")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), info.Size)
+	assert.Equal(t, "image/jpeg", info.ContentType)
+
+	var keys []string
+	err = s.List(ctx, "users/", func(obj *ObjectInfo) error {
+		keys = append(keys, obj.Key)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"users/1/photos/a.jpg"}, keys)
+
+	err = s.List(ctx, "users/2/", func(obj *ObjectInfo) error { return nil })
+	assert.NoError(t, err)
+
+	assert.Equal(t, "http://api/media/users/1/photos/a.jpg", s.URL("users/1/photos/a.jpg"))
+
+	err = s.Delete(ctx, "users/1/photos/a.jpg")
+	assert.NoError(t, err)
+	_, err = s.Get(ctx, "users/1/photos/a.jpg")
+	assert.ErrorIs(t, err, ErrObjectNotFound)
+	err = s.Delete(ctx, "users/1/photos/a.jpg")
+	assert.ErrorIs(t, err, ErrObjectNotFound)
+}
@@ -1,10 +1,23 @@
 package helper
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 )
 
+const MERGE_PATCH_CONTENT_TYPE = "application/merge-patch+json"
+
 type ValidatorError struct {
 	Field   string `json:"field"`
 	Message string `json:"message"`
@@ -12,20 +25,135 @@ type ValidatorError struct {
 
 func BindValidate[T any](c *gin.Context, req *T) ([]ValidatorError, error) {
 	if err := c.ShouldBindJSON(req); err != nil {
-		var errs []ValidatorError
 		if ve, ok := err.(validator.ValidationErrors); ok {
-			for _, e := range ve {
-				errs = append(errs, ValidatorError{
-					Field:   e.Field(),
-					Message: validationMessage(e),
-				})
-			}
-			return errs, err
+			return validationErrors(ve), err
 		}
 	}
 	return nil, nil
 }
 
+func BindQuery[T any](c *gin.Context, req *T) ([]ValidatorError, error) {
+	if err := c.ShouldBindQuery(req); err != nil {
+		if ve, ok := err.(validator.ValidationErrors); ok {
+			return validationErrors(ve), err
+		}
+		return []ValidatorError{{Field: "query", Message: err.Error()}}, err
+	}
+	return nil, nil
+}
+
+// BindForm binds and validates the fields of a form or multipart body.
+func BindForm[T any](c *gin.Context, req *T) ([]ValidatorError, error) {
+	if err := c.ShouldBindWith(req, binding.Form); err != nil {
+		if ve, ok := err.(validator.ValidationErrors); ok {
+			return validationErrors(ve), err
+		}
+		return []ValidatorError{{Field: "body", Message: err.Error()}}, err
+	}
+	return nil, nil
+}
+
+// multipartOverhead is the room a multipart body gets on top of its files
+// for the other fields, part headers and boundaries.
+const multipartOverhead = 1 << 20
+
+// LimitMultipart caps the body of a multipart request at limit bytes of files
+// and parses it, answering FILE_TOO_LARGE once the body runs past the cap
+// instead of spooling all of it to disk. Other parse errors are left to the
+// lookups of the form fields.
+func LimitMultipart(c *gin.Context, limit int64) bool {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+multipartOverhead)
+
+	var tooLarge *http.MaxBytesError
+	if _, err := c.MultipartForm(); errors.As(err, &tooLarge) {
+		NewAppError(FILE_TOO_LARGE, fmt.Sprintf("file must not be larger than %d MB", limit>>20), err).WriteError(c)
+		return false
+	}
+	return true
+}
+
+// BindMergePatch decodes an RFC 7396 merge patch into req, whose fields are
+// expected to be pointers so absent members stay nil and are not validated.
+// Fields that may be removed are Optional; a null member of any other field
+// is rejected.
+func BindMergePatch[T any](c *gin.Context, req *T) ([]ValidatorError, error) {
+	if ct := c.ContentType(); ct != MERGE_PATCH_CONTENT_TYPE && ct != gin.MIMEJSON {
+		err := errors.New("unsupported content type")
+		return []ValidatorError{{Field: "Content-Type", Message: "must be " + MERGE_PATCH_CONTENT_TYPE}}, err
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return []ValidatorError{{Field: "body", Message: "could not be read"}}, err
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil || members == nil {
+		return []ValidatorError{{Field: "body", Message: "must be a JSON object"}}, errors.New("merge patch is not an object")
+	}
+
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	removable := removableMembers(reflect.TypeFor[T]())
+	var errs []ValidatorError
+	for _, name := range names {
+		if string(bytes.TrimSpace(members[name])) == "null" && !removable[name] {
+			errs = append(errs, ValidatorError{Field: name, Message: "cannot be removed"})
+		}
+	}
+	if len(errs) > 0 {
+		return errs, errors.New("merge patch removes required fields")
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(req); err != nil {
+		return []ValidatorError{{Field: "body", Message: err.Error()}}, err
+	}
+
+	return Validate(req)
+}
+
+// removableMembers lists the JSON names of the Optional fields of t.
+func removableMembers(t reflect.Type) map[string]bool {
+	members := make(map[string]bool)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if field.Type.Implements(reflect.TypeFor[removable]()) {
+			members[strings.Split(field.Tag.Get("json"), ",")[0]] = true
+		}
+	}
+	return members
+}
+
+// Validate checks a request built by the server itself against the binding
+// rules a client's request would have to pass.
+func Validate[T any](req *T) ([]ValidatorError, error) {
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		if ve, ok := err.(validator.ValidationErrors); ok {
+			return validationErrors(ve), err
+		}
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func validationErrors(ve validator.ValidationErrors) []ValidatorError {
+	var errs []ValidatorError
+	for _, e := range ve {
+		errs = append(errs, ValidatorError{
+			Field:   e.Field(),
+			Message: validationMessage(e),
+		})
+	}
+	return errs
+}
+
 func validationMessage(e validator.FieldError) string {
 	switch e.Tag() {
 	case "required":
@@ -40,6 +168,20 @@ func validationMessage(e validator.FieldError) string {
 		return "must be greater than or equal to " + e.Param()
 	case "lte":
 		return "must be less than or equal to " + e.Param()
+	case "oneof":
+		return "must be one of " + e.Param()
+	case "datetime":
+		return "must match the format " + e.Param()
+	case "timezone":
+		return "must be a valid IANA timezone"
+	case "hexcolor":
+		return "must be a hex color"
+	case "len":
+		return "must be exactly " + e.Param() + " characters"
+	case "required_without":
+		return "is required when " + e.Param() + " is empty"
+	case "unique":
+		return "must not contain duplicates"
 	default:
 		return "is not valid"
 	}
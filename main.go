@@ -1,12 +1,20 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 	"timo/config"
 	"timo/database"
 	"timo/handler"
 	"timo/helper"
+	"timo/middleware"
 	"timo/repository"
 	"timo/routes"
 	"timo/service"
@@ -20,15 +28,72 @@ func main() {
 
 	//repo
 	authRepo := repository.NewAuth(pool)
+	userRepo := repository.NewUser(pool)
+	journalRepo := repository.NewJournal(pool)
+	syncRepo := repository.NewSync(pool)
+	statsRepo := repository.NewStats(pool)
+	moodRepo := repository.NewMood(pool)
+	checkinRepo := repository.NewCheckin(pool)
+	photoRepo := repository.NewPhoto(pool)
+	uploadRepo := repository.NewUpload(pool)
+	importRepo := repository.NewImport(pool)
 
 	//service
-	authSvc := service.NewAuth(authRepo, helper.BcryptHasher{}, helper.NewGoogleValidator(""), helper.NewJwtToken(conf.JwtKey))
+	jwtToken := helper.NewJwtToken(conf.JwtKey)
+	authSvc := service.NewAuth(authRepo, helper.BcryptHasher{}, helper.NewGoogleValidator(""), jwtToken)
+	signer := helper.NewURLSigner(conf.MediaKey, conf.Storage.BaseURL+"/media", time.Hour)
+	autosaver := helper.NewDebouncer(3*time.Second, 15*time.Second)
+	journalSvc := service.NewJournal(journalRepo, moodRepo, photoRepo, statsRepo, autosaver, signer)
+	syncSvc := service.NewSync(syncRepo, statsRepo)
+	userSvc := service.NewUser(userRepo)
+	statsSvc := service.NewStats(statsRepo)
+	moodSvc := service.NewMood(moodRepo)
+	checkinSvc := service.NewCheckin(checkinRepo, moodRepo)
+	storage := newStorage(conf.Storage)
+	photoProcessor := service.NewPhotoProcessor(photoRepo, storage)
+	photoGC := service.NewPhotoCollector(photoRepo, uploadRepo, storage)
+	photoSvc := service.NewPhoto(photoRepo, journalRepo, storage, photoProcessor, signer, service.DefaultPhotoLimits)
+	uploadSvc := service.NewUpload(uploadRepo, journalRepo, photoRepo, storage, photoSvc, service.DefaultPhotoLimits)
+	mediaSvc := service.NewMedia(storage, signer)
+	exportSvc := service.NewExport(journalRepo, photoRepo, storage)
+	importJob := service.NewImportJob(importRepo, moodRepo, journalSvc, photoSvc, storage, service.DefaultPhotoLimits)
+	importSvc := service.NewImport(importRepo, moodRepo, storage, importJob)
+	digest := service.NewMemoryDigest(userRepo, journalRepo, helper.LogNotifier{}, signer, 8)
+
+	//jobs
+	go digest.Run(context.Background(), 15*time.Minute)
+	go photoProcessor.Run(context.Background(), time.Minute)
+	go importJob.Run(context.Background(), time.Minute)
+	go photoGC.Run(context.Background(), time.Hour, service.PhotoGCOptions{DryRun: conf.PhotoGC.DryRun, OlderThan: conf.PhotoGC.OlderThan})
 
 	//handler
 	authH := handler.NewAuth(authSvc)
+	journalH := handler.NewJournal(journalSvc)
+	syncH := handler.NewSync(syncSvc)
+	userH := handler.NewUser(userSvc)
+	statsH := handler.NewStats(statsSvc)
+	moodH := handler.NewMood(moodSvc)
+	checkinH := handler.NewCheckin(checkinSvc)
+	photoH := handler.NewPhoto(photoSvc, service.DefaultPhotoLimits.MaxFileSize())
+	mediaH := handler.NewMedia(mediaSvc)
+	uploadH := handler.NewUpload(uploadSvc)
+	exportH := handler.NewExport(exportSvc)
+	importH := handler.NewImport(importSvc, service.ImportMaxSize)
 
 	handlers := &routes.Handlers{
-		AuthHandler: *authH,
+		AuthHandler:    *authH,
+		JournalHandler: *journalH,
+		SyncHandler:    *syncH,
+		UserHandler:    *userH,
+		StatsHandler:   *statsH,
+		MoodHandler:    *moodH,
+		CheckinHandler: *checkinH,
+		PhotoHandler:   *photoH,
+		MediaHandler:   *mediaH,
+		UploadHandler:  *uploadH,
+		ExportHandler:  *exportH,
+		ImportHandler:  *importH,
+		AuthMiddleware: middleware.Auth(jwtToken, userRepo),
 	}
 
 	r := gin.Default()
@@ -37,7 +102,39 @@ func main() {
 	r.SetTrustedProxies(nil)
 
 	addresss := fmt.Sprintf("%s:%s", conf.App.Host, conf.App.Port)
-	if err := r.Run(addresss); err != nil {
-		log.Fatal("Failed to run server:", err)
+	srv := &http.Server{Addr: addresss, Handler: r}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Failed to run server:", err)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Println("Failed to shut down server:", err)
+	}
+
+	// Autosaves still waiting to be coalesced would be lost on exit.
+	autosaver.FlushAll()
+}
+
+// newStorage picks the media backend. Anything but "s3" stores files on the
+// local disk. Either way files are only served through signed /media links.
+func newStorage(conf config.Storage) helper.Storage {
+	if conf.Driver == "s3" {
+		return helper.NewS3Storage(helper.S3Config{
+			Endpoint:  conf.Endpoint,
+			Region:    conf.Region,
+			Bucket:    conf.Bucket,
+			AccessKey: conf.AccessKey,
+			SecretKey: conf.SecretKey,
+		})
 	}
+
+	return helper.NewLocalStorage(conf.Dir, conf.BaseURL+"/media")
 }
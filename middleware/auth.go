@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+	"timo/domain"
+	"timo/helper"
+	"timo/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const UserKey = "user"
+
+func Auth(token helper.Token, users domain.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || tokenString == "" {
+			helper.Fail(c, http.StatusUnauthorized, "missing bearer token", helper.UNAUTHORIZED, nil)
+			c.Abort()
+			return
+		}
+
+		claims, err := token.Extract(tokenString)
+		if err != nil || claims.Exp < time.Now().Unix() {
+			helper.Fail(c, http.StatusUnauthorized, "invalid or expired token", helper.UNAUTHORIZED, nil)
+			c.Abort()
+			return
+		}
+
+		user, err := users.GetByUID(c.Request.Context(), claims.UserUID)
+		if err != nil {
+			helper.Fail(c, http.StatusUnauthorized, "user not found", helper.UNAUTHORIZED, nil)
+			c.Abort()
+			return
+		}
+
+		c.Set(UserKey, user)
+		c.Next()
+	}
+}
+
+func CurrentUser(c *gin.Context) *models.User {
+	user, _ := c.MustGet(UserKey).(*models.User)
+	return user
+}
@@ -0,0 +1 @@
+drop table journal_revisions;
\ No newline at end of file
@@ -0,0 +1,10 @@
+create table journal_revisions (
+	id bigserial primary key,
+	journal_id bigint not null references journals(id) on delete cascade,
+	revision int not null,
+	title text not null,
+	text text not null,
+	mood_id bigint not null references moods(id),
+	created_at timestamptz default now(),
+	unique (journal_id, revision)
+)
\ No newline at end of file
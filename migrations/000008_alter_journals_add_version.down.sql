@@ -0,0 +1,2 @@
+alter table journals
+drop column version;
\ No newline at end of file
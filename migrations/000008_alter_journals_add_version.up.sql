@@ -0,0 +1,2 @@
+alter table journals
+add column version bigint not null default 1;
\ No newline at end of file
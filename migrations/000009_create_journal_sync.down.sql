@@ -0,0 +1,9 @@
+drop table journal_tombstones;
+
+drop index journals_user_id_change_seq_idx;
+
+alter table journals
+drop column change_seq;
+
+alter table users
+drop column change_seq;
\ No newline at end of file
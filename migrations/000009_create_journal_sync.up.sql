@@ -0,0 +1,24 @@
+alter table users
+add column change_seq bigint not null default 0;
+
+alter table journals
+add column change_seq bigint not null default 0;
+
+update journals j
+set change_seq = s.seq
+from (select id, row_number() over (partition by user_id order by id) as seq from journals) s
+where s.id = j.id;
+
+update users u
+set change_seq = coalesce((select max(j.change_seq) from journals j where j.user_id = u.id), 0);
+
+create index journals_user_id_change_seq_idx on journals (user_id, change_seq);
+
+create table journal_tombstones (
+	uid uuid primary key,
+	user_id bigint not null references users(id) on delete cascade,
+	change_seq bigint not null,
+	deleted_at timestamptz default now()
+);
+
+create index journal_tombstones_user_id_change_seq_idx on journal_tombstones (user_id, change_seq);
\ No newline at end of file
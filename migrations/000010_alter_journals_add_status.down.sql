@@ -0,0 +1,5 @@
+alter table journals
+drop column autosaved_at;
+
+alter table journals
+drop column status;
\ No newline at end of file
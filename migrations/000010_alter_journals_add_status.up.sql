@@ -0,0 +1,5 @@
+alter table journals
+add column status text not null default 'published' check (status in ('draft', 'published'));
+
+alter table journals
+add column autosaved_at timestamptz;
\ No newline at end of file
@@ -0,0 +1,7 @@
+drop index journals_user_id_entry_date_idx;
+
+alter table journals
+drop column entry_date;
+
+alter table users
+drop column timezone;
\ No newline at end of file
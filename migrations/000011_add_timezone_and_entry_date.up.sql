@@ -0,0 +1,15 @@
+alter table users
+add column timezone text not null default 'UTC';
+
+alter table journals
+add column entry_date date;
+
+update journals j
+set entry_date = (j.created_at at time zone u.timezone)::date
+from users u
+where u.id = j.user_id;
+
+alter table journals
+alter column entry_date set not null;
+
+create index journals_user_id_entry_date_idx on journals (user_id, entry_date);
\ No newline at end of file
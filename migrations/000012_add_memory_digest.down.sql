@@ -0,0 +1,5 @@
+alter table users
+drop column memory_digest_sent_on;
+
+alter table users
+drop column memory_digest;
\ No newline at end of file
@@ -0,0 +1,5 @@
+alter table users
+add column memory_digest boolean not null default false;
+
+alter table users
+add column memory_digest_sent_on date;
\ No newline at end of file
@@ -0,0 +1,4 @@
+drop table user_streaks;
+
+alter table users
+drop column weekly_goal;
\ No newline at end of file
@@ -0,0 +1,10 @@
+alter table users
+add column weekly_goal int not null default 0 check (weekly_goal >= 0);
+
+create table user_streaks (
+	user_id bigint primary key references users(id) on delete cascade,
+	current_streak int not null default 0,
+	longest_streak int not null default 0,
+	last_entry_date date,
+	updated_at timestamptz default now()
+)
\ No newline at end of file
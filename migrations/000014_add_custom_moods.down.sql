@@ -0,0 +1,27 @@
+drop index moods_user_id_label_idx;
+
+update moods set label = 'exited' where label = 'excited' and user_id is null;
+
+update moods set label = 'anggry' where label = 'angry' and user_id is null;
+
+update journals
+set mood_id = (select min(id) from moods where user_id is null)
+where mood_id in (select id from moods where user_id is not null);
+
+update journal_revisions
+set mood_id = (select min(id) from moods where user_id is null)
+where mood_id in (select id from moods where user_id is not null);
+
+delete from moods where user_id is not null;
+
+alter table moods
+drop column created_at;
+
+alter table moods
+drop column archived_at;
+
+alter table moods
+drop column position;
+
+alter table moods
+drop column user_id;
\ No newline at end of file
@@ -0,0 +1,19 @@
+alter table moods
+add column user_id bigint references users(id) on delete cascade;
+
+alter table moods
+add column position int not null default 0;
+
+alter table moods
+add column archived_at timestamptz;
+
+alter table moods
+add column created_at timestamptz default now();
+
+update moods set label = 'excited' where label = 'exited' and user_id is null;
+
+update moods set label = 'angry' where label = 'anggry' and user_id is null;
+
+update moods set position = id where user_id is null;
+
+create unique index moods_user_id_label_idx on moods (user_id, lower(label));
\ No newline at end of file
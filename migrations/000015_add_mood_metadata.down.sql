@@ -0,0 +1,13 @@
+drop table mood_labels;
+
+alter table moods
+drop column energy;
+
+alter table moods
+drop column valence;
+
+alter table moods
+drop column color;
+
+alter table moods
+drop column emoji;
\ No newline at end of file
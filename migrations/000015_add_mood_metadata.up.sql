@@ -0,0 +1,43 @@
+alter table moods
+add column emoji text;
+
+alter table moods
+add column color text check (color ~ '^#[0-9A-Fa-f]{6}$');
+
+alter table moods
+add column valence double precision check (valence between -1 and 1);
+
+alter table moods
+add column energy double precision check (energy between -1 and 1);
+
+create table mood_labels (
+	mood_id bigint not null references moods(id) on delete cascade,
+	locale text not null,
+	label text not null,
+	primary key (mood_id, locale)
+);
+
+update moods m
+set emoji = d.emoji, color = d.color, valence = d.valence, energy = d.energy
+from (values
+	('happy', '😊', '#FFC93C', 0.8, 0.5),
+	('excited', '🤩', '#FF8C42', 0.7, 0.9),
+	('normal', '😐', '#A0AEC0', 0.0, 0.0),
+	('angry', '😠', '#E53E3E', -0.7, 0.8),
+	('sad', '😢', '#4A6FA5', -0.8, -0.5),
+	('tired', '😴', '#805AD5', -0.3, -0.8)
+) as d(label, emoji, color, valence, energy)
+where m.label = d.label and m.user_id is null;
+
+insert into mood_labels (mood_id, locale, label)
+select m.id, d.locale, d.translation
+from moods m
+join (values
+	('happy', 'id', 'senang'),
+	('excited', 'id', 'bersemangat'),
+	('normal', 'id', 'biasa'),
+	('angry', 'id', 'marah'),
+	('sad', 'id', 'sedih'),
+	('tired', 'id', 'lelah')
+) as d(label, locale, translation) on d.label = m.label
+where m.user_id is null;
\ No newline at end of file
@@ -0,0 +1 @@
+drop table mood_checkins
\ No newline at end of file
@@ -0,0 +1,14 @@
+create table mood_checkins (
+	id bigserial primary key,
+	uid uuid not null unique default gen_random_uuid(),
+	user_id bigint not null references users(id) on delete cascade,
+	mood_id bigint not null references moods(id),
+	intensity smallint check (intensity between 1 and 5),
+	note text,
+	checked_at timestamptz not null default now(),
+	entry_date date not null,
+	created_at timestamptz default now(),
+	updated_at timestamptz default now()
+);
+
+create index mood_checkins_user_id_entry_date_idx on mood_checkins (user_id, entry_date)
\ No newline at end of file
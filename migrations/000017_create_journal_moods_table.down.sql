@@ -0,0 +1 @@
+drop table journal_moods
\ No newline at end of file
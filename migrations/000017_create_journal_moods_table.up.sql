@@ -0,0 +1,11 @@
+create table journal_moods (
+	journal_id bigint not null references journals(id) on delete cascade,
+	mood_id bigint not null references moods(id),
+	intensity smallint not null default 3 check (intensity between 1 and 5),
+	primary key (journal_id, mood_id)
+);
+
+create index journal_moods_mood_id_idx on journal_moods (mood_id);
+
+insert into journal_moods (journal_id, mood_id)
+select id, mood_id from journals
\ No newline at end of file
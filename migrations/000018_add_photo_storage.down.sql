@@ -0,0 +1,11 @@
+alter table photos
+drop column checksum;
+
+alter table photos
+drop column content_type;
+
+alter table photos
+drop column size;
+
+alter table photos
+drop column storage_key
\ No newline at end of file
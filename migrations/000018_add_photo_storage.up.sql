@@ -0,0 +1,11 @@
+alter table photos
+add column storage_key text not null default '';
+
+alter table photos
+add column size bigint not null default 0;
+
+alter table photos
+add column content_type text not null default '';
+
+alter table photos
+add column checksum text not null default ''
\ No newline at end of file
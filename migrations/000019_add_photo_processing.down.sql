@@ -0,0 +1,21 @@
+drop table photo_variants;
+
+drop index photos_status_idx;
+
+alter table photos
+drop column orientation;
+
+alter table photos
+drop column taken_at;
+
+alter table photos
+drop column height;
+
+alter table photos
+drop column width;
+
+alter table photos
+drop column processing_at;
+
+alter table photos
+drop column status
\ No newline at end of file
@@ -0,0 +1,30 @@
+alter table photos
+add column status text not null default 'ready';
+
+alter table photos
+add column processing_at timestamptz;
+
+alter table photos
+add column width int;
+
+alter table photos
+add column height int;
+
+alter table photos
+add column taken_at timestamptz;
+
+alter table photos
+add column orientation smallint;
+
+create index photos_status_idx on photos (status) where status in ('pending', 'processing');
+
+create table photo_variants (
+	photo_id bigint not null references photos(id) on delete cascade,
+	name text not null,
+	storage_key text not null,
+	width int not null,
+	height int not null,
+	size bigint not null,
+	content_type text not null,
+	primary key (photo_id, name)
+)
\ No newline at end of file
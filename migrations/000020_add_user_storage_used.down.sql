@@ -0,0 +1,2 @@
+alter table users
+drop column storage_used
\ No newline at end of file
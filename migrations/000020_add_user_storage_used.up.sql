@@ -0,0 +1,12 @@
+alter table users
+add column storage_used bigint not null default 0;
+
+update users u
+set storage_used = s.total
+from (
+	select j.user_id, sum(p.size + coalesce((select sum(v.size) from photo_variants v where v.photo_id = p.id), 0)) as total
+	from photos p
+	join journals j on j.id = p.journal_id
+	group by j.user_id
+) s
+where s.user_id = u.id
\ No newline at end of file
@@ -0,0 +1,15 @@
+drop index photos_journal_id_cover_idx;
+
+drop index photos_journal_id_position_idx;
+
+alter table photos
+drop column cover;
+
+alter table photos
+drop column alt_text;
+
+alter table photos
+drop column caption;
+
+alter table photos
+drop column position
\ No newline at end of file
@@ -0,0 +1,23 @@
+alter table photos
+add column position int not null default 0;
+
+alter table photos
+add column caption text;
+
+alter table photos
+add column alt_text text;
+
+alter table photos
+add column cover boolean not null default false;
+
+update photos p
+set position = o.position
+from (
+	select id, row_number() over (partition by journal_id order by id) as position
+	from photos
+) o
+where o.id = p.id;
+
+create index photos_journal_id_position_idx on photos (journal_id, position);
+
+create unique index photos_journal_id_cover_idx on photos (journal_id) where cover
\ No newline at end of file
@@ -0,0 +1,63 @@
+alter table photos
+add column url text not null default '';
+
+alter table photos
+add column storage_key text not null default '';
+
+alter table photos
+add column size bigint not null default 0;
+
+alter table photos
+add column content_type text not null default '';
+
+alter table photos
+add column checksum text not null default '';
+
+alter table photos
+add column status text not null default 'ready';
+
+alter table photos
+add column processing_at timestamptz;
+
+alter table photos
+add column width int;
+
+alter table photos
+add column height int;
+
+alter table photos
+add column taken_at timestamptz;
+
+alter table photos
+add column orientation smallint;
+
+update photos p
+set url = b.url, storage_key = b.storage_key, size = b.size, content_type = b.content_type, checksum = b.checksum,
+	status = b.status, processing_at = b.processing_at, width = b.width, height = b.height,
+	taken_at = b.taken_at, orientation = b.orientation
+from photo_blobs b
+where b.id = p.blob_id;
+
+create index photos_status_idx on photos (status) where status in ('pending', 'processing');
+
+alter table photo_variants
+add column photo_id bigint references photos(id) on delete cascade;
+
+update photo_variants v
+set photo_id = (select min(p.id) from photos p where p.blob_id = v.blob_id);
+
+delete from photo_variants where photo_id is null;
+
+alter table photo_variants
+drop column blob_id;
+
+alter table photo_variants
+alter column photo_id set not null;
+
+alter table photo_variants
+add primary key (photo_id, name);
+
+alter table photos
+drop column blob_id;
+
+drop table photo_blobs
\ No newline at end of file
@@ -0,0 +1,111 @@
+create table photo_blobs (
+	id bigserial primary key,
+	user_id bigint not null references users(id) on delete cascade,
+	checksum text not null,
+	storage_key text not null default '',
+	url text not null default '',
+	size bigint not null default 0,
+	content_type text not null default '',
+	status text not null default 'ready',
+	processing_at timestamptz,
+	width int,
+	height int,
+	taken_at timestamptz,
+	orientation smallint,
+	ref_count int not null default 0,
+	created_at timestamptz default now(),
+	source_photo_id bigint
+);
+
+insert into photo_blobs (user_id, checksum, storage_key, url, size, content_type, status, processing_at, width, height, taken_at, orientation, created_at, source_photo_id)
+select distinct on (j.user_id, case when p.checksum = '' then p.id::text else p.checksum end)
+	j.user_id, p.checksum, p.storage_key, p.url, p.size, p.content_type, p.status, p.processing_at, p.width, p.height, p.taken_at, p.orientation, p.created_at, p.id
+from photos p
+join journals j on j.id = p.journal_id
+order by j.user_id, case when p.checksum = '' then p.id::text else p.checksum end, p.id;
+
+alter table photos
+add column blob_id bigint references photo_blobs(id);
+
+update photos p
+set blob_id = b.id
+from journals j, photo_blobs b
+where j.id = p.journal_id
+	and b.user_id = j.user_id
+	and (b.source_photo_id = p.id or (p.checksum <> '' and b.checksum = p.checksum));
+
+update photo_blobs b
+set ref_count = (select count(*) from photos p where p.blob_id = b.id);
+
+alter table photos
+alter column blob_id set not null;
+
+create index photos_blob_id_idx on photos (blob_id);
+
+alter table photo_variants
+add column blob_id bigint references photo_blobs(id) on delete cascade;
+
+update photo_variants v
+set blob_id = b.id
+from photo_blobs b
+where b.source_photo_id = v.photo_id;
+
+delete from photo_variants where blob_id is null;
+
+alter table photo_variants
+drop column photo_id;
+
+alter table photo_variants
+alter column blob_id set not null;
+
+alter table photo_variants
+add primary key (blob_id, name);
+
+update users u
+set storage_used = coalesce((
+	select sum(b.size + coalesce((select sum(v.size) from photo_variants v where v.blob_id = b.id), 0))
+	from photo_blobs b
+	where b.user_id = u.id
+), 0);
+
+alter table photo_blobs
+drop column source_photo_id;
+
+create unique index photo_blobs_user_id_checksum_idx on photo_blobs (user_id, checksum) where checksum <> '';
+
+create index photo_blobs_status_idx on photo_blobs (status) where status in ('pending', 'processing');
+
+drop index photos_status_idx;
+
+alter table photos
+drop column url;
+
+alter table photos
+drop column storage_key;
+
+alter table photos
+drop column size;
+
+alter table photos
+drop column content_type;
+
+alter table photos
+drop column checksum;
+
+alter table photos
+drop column status;
+
+alter table photos
+drop column processing_at;
+
+alter table photos
+drop column width;
+
+alter table photos
+drop column height;
+
+alter table photos
+drop column taken_at;
+
+alter table photos
+drop column orientation
\ No newline at end of file
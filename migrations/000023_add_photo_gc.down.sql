@@ -0,0 +1,3 @@
+drop index photo_variants_storage_key_idx;
+
+drop index photo_blobs_storage_key_idx
\ No newline at end of file
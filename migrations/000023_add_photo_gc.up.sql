@@ -0,0 +1,3 @@
+create index photo_blobs_storage_key_idx on photo_blobs (storage_key);
+
+create index photo_variants_storage_key_idx on photo_variants (storage_key)
\ No newline at end of file
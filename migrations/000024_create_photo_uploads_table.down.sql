@@ -0,0 +1,3 @@
+drop table photo_upload_parts;
+
+drop table photo_uploads
\ No newline at end of file
@@ -0,0 +1,29 @@
+create table photo_uploads (
+	id bigserial primary key,
+	uid uuid not null unique default gen_random_uuid(),
+	user_id bigint not null references users(id) on delete cascade,
+	journal_id bigint not null,
+	filename text not null default '',
+	size bigint not null,
+	received bigint not null default 0,
+	checksum text not null default '',
+	caption text,
+	alt_text text,
+	expires_at timestamptz not null,
+	finalizing_at timestamptz,
+	created_at timestamptz default now()
+);
+
+create index photo_uploads_expires_at_idx on photo_uploads (expires_at);
+
+create index photo_uploads_user_id_idx on photo_uploads (user_id);
+
+create table photo_upload_parts (
+	upload_id bigint not null references photo_uploads(id) on delete cascade,
+	start bigint not null,
+	storage_key text not null,
+	size bigint not null,
+	primary key (upload_id, start)
+);
+
+create index photo_upload_parts_storage_key_idx on photo_upload_parts (storage_key)
\ No newline at end of file
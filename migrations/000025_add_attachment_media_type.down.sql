@@ -0,0 +1,21 @@
+delete from photos p
+using photo_blobs b
+where b.id = p.blob_id and b.media_type <> 'image';
+
+delete from photo_blobs
+where media_type <> 'image';
+
+update users u
+set storage_used = coalesce((
+	select sum(b.size + coalesce((select sum(v.size) from photo_variants v where v.blob_id = b.id), 0))
+	from photo_blobs b
+	where b.user_id = u.id
+), 0);
+
+alter table photo_uploads
+drop column peaks;
+
+alter table photo_blobs
+drop column peaks,
+drop column duration_ms,
+drop column media_type;
\ No newline at end of file
@@ -0,0 +1,7 @@
+alter table photo_blobs
+add column media_type text not null default 'image',
+add column duration_ms int,
+add column peaks smallint[];
+
+alter table photo_uploads
+add column peaks smallint[];
\ No newline at end of file
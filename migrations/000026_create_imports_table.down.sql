@@ -0,0 +1,3 @@
+drop table import_entries;
+
+drop table imports
\ No newline at end of file
@@ -0,0 +1,35 @@
+create table imports (
+	id bigserial primary key,
+	uid uuid not null unique default gen_random_uuid(),
+	user_id bigint not null references users(id) on delete cascade,
+	format text not null,
+	filename text not null default '',
+	storage_key text,
+	size bigint not null,
+	default_mood_id bigint references moods(id) on delete set null,
+	status text not null default 'pending',
+	total int,
+	error text,
+	processing_at timestamptz,
+	finished_at timestamptz,
+	created_at timestamptz default now()
+);
+
+create index imports_user_id_idx on imports (user_id, created_at desc);
+
+create index imports_status_idx on imports (status) where status in ('pending', 'processing');
+
+create index imports_storage_key_idx on imports (storage_key);
+
+create table import_entries (
+	import_id bigint not null references imports(id) on delete cascade,
+	position int not null,
+	source_id text not null,
+	title text not null default '',
+	status text not null,
+	journal_uid uuid,
+	message text,
+	primary key (import_id, position)
+);
+
+create index import_entries_source_id_idx on import_entries (source_id) where journal_uid is not null
\ No newline at end of file
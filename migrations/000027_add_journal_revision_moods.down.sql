@@ -0,0 +1,4 @@
+drop table journal_revision_moods;
+
+alter table journal_revisions
+drop column entry_date
\ No newline at end of file
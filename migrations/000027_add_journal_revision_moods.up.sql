@@ -0,0 +1,11 @@
+alter table journal_revisions
+add column entry_date date;
+
+create table journal_revision_moods (
+	revision_id bigint not null references journal_revisions(id) on delete cascade,
+	mood_id bigint not null references moods(id),
+	intensity smallint not null check (intensity between 1 and 5),
+	primary key (revision_id, mood_id)
+);
+
+create index journal_revision_moods_mood_id_idx on journal_revision_moods (mood_id)
\ No newline at end of file
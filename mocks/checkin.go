@@ -0,0 +1,45 @@
+package mocks
+
+import (
+	"context"
+	"timo/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type CheckinRepositoryMock struct {
+	mock.Mock
+}
+
+func (c *CheckinRepositoryMock) GetListByUserID(ctx context.Context, userID int64, filter *models.CheckinFilter) ([]models.MoodCheckin, error) {
+	args := c.Called(ctx, userID, filter)
+	if checkins, ok := args.Get(0).([]models.MoodCheckin); ok {
+		return checkins, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (c *CheckinRepositoryMock) GetByID(ctx context.Context, uid string) (*models.MoodCheckin, error) {
+	args := c.Called(ctx, uid)
+	if checkin, ok := args.Get(0).(*models.MoodCheckin); ok {
+		return checkin, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (c *CheckinRepositoryMock) Create(ctx context.Context, checkin *models.MoodCheckin) error {
+	args := c.Called(ctx, checkin)
+	return args.Error(0)
+}
+
+func (c *CheckinRepositoryMock) Update(ctx context.Context, checkin *models.MoodCheckin) error {
+	args := c.Called(ctx, checkin)
+	return args.Error(0)
+}
+
+func (c *CheckinRepositoryMock) Delete(ctx context.Context, uid string) error {
+	args := c.Called(ctx, uid)
+	return args.Error(0)
+}
@@ -0,0 +1,21 @@
+package mocks
+
+import (
+	"context"
+	"timo/dto"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ExportServiceMock struct {
+	mock.Mock
+}
+
+func (e *ExportServiceMock) Export(ctx context.Context, userID int64, query *dto.ExportQuery) (*dto.Export, error) {
+	args := e.Called(ctx, userID, query)
+	if export, ok := args.Get(0).(*dto.Export); ok {
+		return export, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
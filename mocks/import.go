@@ -0,0 +1,101 @@
+package mocks
+
+import (
+	"context"
+	"time"
+	"timo/dto"
+	"timo/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ImportRepositoryMock struct {
+	mock.Mock
+}
+
+func (i *ImportRepositoryMock) Create(ctx context.Context, imp *models.Import) error {
+	args := i.Called(ctx, imp)
+	return args.Error(0)
+}
+
+func (i *ImportRepositoryMock) GetByUid(ctx context.Context, uid string) (*models.Import, error) {
+	args := i.Called(ctx, uid)
+	if imp, ok := args.Get(0).(*models.Import); ok {
+		return imp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (i *ImportRepositoryMock) GetListByUserID(ctx context.Context, userID int64, limit int) ([]models.Import, error) {
+	args := i.Called(ctx, userID, limit)
+	if imports, ok := args.Get(0).([]models.Import); ok {
+		return imports, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (i *ImportRepositoryMock) ClaimPending(ctx context.Context, limit int, staleAfter time.Duration) ([]models.Import, error) {
+	args := i.Called(ctx, limit, staleAfter)
+	if imports, ok := args.Get(0).([]models.Import); ok {
+		return imports, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (i *ImportRepositoryMock) SetTotal(ctx context.Context, id int64, total int) error {
+	args := i.Called(ctx, id, total)
+	return args.Error(0)
+}
+
+func (i *ImportRepositoryMock) AddEntry(ctx context.Context, entry *models.ImportEntry) error {
+	args := i.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (i *ImportRepositoryMock) FindImported(ctx context.Context, userID int64, sourceIDs []string) (map[string]string, error) {
+	args := i.Called(ctx, userID, sourceIDs)
+	if found, ok := args.Get(0).(map[string]string); ok {
+		return found, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (i *ImportRepositoryMock) Finish(ctx context.Context, id int64, status string, message *string) error {
+	args := i.Called(ctx, id, status, message)
+	return args.Error(0)
+}
+
+type ImportServiceMock struct {
+	mock.Mock
+}
+
+func (i *ImportServiceMock) Create(ctx context.Context, userID int64, upload *dto.ImportUpload) (*dto.ImportResponse, error) {
+	args := i.Called(ctx, userID, upload)
+	if resp, ok := args.Get(0).(*dto.ImportResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (i *ImportServiceMock) GetList(ctx context.Context, userID int64) ([]dto.ImportResponse, error) {
+	args := i.Called(ctx, userID)
+	if resp, ok := args.Get(0).([]dto.ImportResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (i *ImportServiceMock) GetByID(ctx context.Context, userID int64, uid string) (*dto.ImportResponse, error) {
+	args := i.Called(ctx, userID, uid)
+	if resp, ok := args.Get(0).(*dto.ImportResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
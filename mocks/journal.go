@@ -0,0 +1,220 @@
+package mocks
+
+import (
+	"context"
+	"time"
+	"timo/dto"
+	"timo/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type JournalRepositoryMock struct {
+	mock.Mock
+}
+
+func (j *JournalRepositoryMock) GetListByUserID(ctx context.Context, userID int64, filter *models.JournalFilter) ([]models.Journal, error) {
+	args := j.Called(ctx, userID, filter)
+	if journals, ok := args.Get(0).([]models.Journal); ok {
+		return journals, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (j *JournalRepositoryMock) GetByID(ctx context.Context, uid string) (*models.Journal, error) {
+	args := j.Called(ctx, uid)
+	if journal, ok := args.Get(0).(*models.Journal); ok {
+		return journal, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (j *JournalRepositoryMock) GetCalendar(ctx context.Context, userID int64, from, to time.Time) ([]models.CalendarDay, error) {
+	args := j.Called(ctx, userID, from, to)
+	if days, ok := args.Get(0).([]models.CalendarDay); ok {
+		return days, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (j *JournalRepositoryMock) GetOnThisDay(ctx context.Context, userID int64, date time.Time) ([]models.Memory, error) {
+	args := j.Called(ctx, userID, date)
+	if memories, ok := args.Get(0).([]models.Memory); ok {
+		return memories, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (j *JournalRepositoryMock) GetForExport(ctx context.Context, userID int64, from, to *time.Time) ([]models.Journal, error) {
+	args := j.Called(ctx, userID, from, to)
+	if journals, ok := args.Get(0).([]models.Journal); ok {
+		return journals, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (j *JournalRepositoryMock) Create(ctx context.Context, journal *models.Journal) error {
+	args := j.Called(ctx, journal)
+	return args.Error(0)
+}
+
+func (j *JournalRepositoryMock) Update(ctx context.Context, journal *models.Journal) error {
+	args := j.Called(ctx, journal)
+	return args.Error(0)
+}
+
+func (j *JournalRepositoryMock) Patch(ctx context.Context, uid string, version int64, patch *models.JournalPatch) error {
+	args := j.Called(ctx, uid, version, patch)
+	return args.Error(0)
+}
+
+func (j *JournalRepositoryMock) Autosave(ctx context.Context, uid string, patch *models.JournalPatch, savedAt time.Time) error {
+	args := j.Called(ctx, uid, patch, savedAt)
+	return args.Error(0)
+}
+
+func (j *JournalRepositoryMock) Publish(ctx context.Context, uid string) error {
+	args := j.Called(ctx, uid)
+	return args.Error(0)
+}
+
+func (j *JournalRepositoryMock) Delete(ctx context.Context, uid string, version int64) error {
+	args := j.Called(ctx, uid, version)
+	return args.Error(0)
+}
+
+func (j *JournalRepositoryMock) GetRevisions(ctx context.Context, journalID int64) ([]models.JournalRevision, error) {
+	args := j.Called(ctx, journalID)
+	if revisions, ok := args.Get(0).([]models.JournalRevision); ok {
+		return revisions, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (j *JournalRepositoryMock) GetRevision(ctx context.Context, journalID int64, revision int) (*models.JournalRevision, error) {
+	args := j.Called(ctx, journalID, revision)
+	if rev, ok := args.Get(0).(*models.JournalRevision); ok {
+		return rev, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+type JournalServiceMock struct {
+	mock.Mock
+}
+
+func (j *JournalServiceMock) GetList(ctx context.Context, userID int64, query *dto.JournalListQuery) ([]dto.JournalResponse, error) {
+	args := j.Called(ctx, userID, query)
+	if resp, ok := args.Get(0).([]dto.JournalResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (j *JournalServiceMock) GetByID(ctx context.Context, userID int64, uid string) (*dto.JournalResponse, error) {
+	args := j.Called(ctx, userID, uid)
+	if resp, ok := args.Get(0).(*dto.JournalResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (j *JournalServiceMock) GetCalendar(ctx context.Context, userID int64, loc *time.Location, query *dto.CalendarQuery) (*dto.CalendarResponse, error) {
+	args := j.Called(ctx, userID, loc, query)
+	if resp, ok := args.Get(0).(*dto.CalendarResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (j *JournalServiceMock) GetMemories(ctx context.Context, userID int64, loc *time.Location) ([]dto.MemoryResponse, error) {
+	args := j.Called(ctx, userID, loc)
+	if resp, ok := args.Get(0).([]dto.MemoryResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (j *JournalServiceMock) Create(ctx context.Context, userID int64, loc *time.Location, req *dto.JournalRequest) (*dto.JournalResponse, error) {
+	args := j.Called(ctx, userID, loc, req)
+	if resp, ok := args.Get(0).(*dto.JournalResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (j *JournalServiceMock) Update(ctx context.Context, userID int64, uid string, version int64, req *dto.JournalRequest) (*dto.JournalResponse, error) {
+	args := j.Called(ctx, userID, uid, version, req)
+	if resp, ok := args.Get(0).(*dto.JournalResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (j *JournalServiceMock) Patch(ctx context.Context, userID int64, uid string, version int64, req *dto.JournalPatchRequest) (*dto.JournalResponse, error) {
+	args := j.Called(ctx, userID, uid, version, req)
+	if resp, ok := args.Get(0).(*dto.JournalResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (j *JournalServiceMock) Delete(ctx context.Context, userID int64, uid string, version int64) error {
+	args := j.Called(ctx, userID, uid, version)
+	return args.Error(0)
+}
+
+func (j *JournalServiceMock) Autosave(ctx context.Context, userID int64, uid string, req *dto.JournalPatchRequest) error {
+	args := j.Called(ctx, userID, uid, req)
+	return args.Error(0)
+}
+
+func (j *JournalServiceMock) Publish(ctx context.Context, userID int64, uid string) (*dto.JournalResponse, error) {
+	args := j.Called(ctx, userID, uid)
+	if resp, ok := args.Get(0).(*dto.JournalResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (j *JournalServiceMock) GetRevisions(ctx context.Context, userID int64, uid string) ([]dto.JournalRevisionResponse, error) {
+	args := j.Called(ctx, userID, uid)
+	if resp, ok := args.Get(0).([]dto.JournalRevisionResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (j *JournalServiceMock) Diff(ctx context.Context, userID int64, uid string, from, to int) (*dto.JournalDiffResponse, error) {
+	args := j.Called(ctx, userID, uid, from, to)
+	if resp, ok := args.Get(0).(*dto.JournalDiffResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (j *JournalServiceMock) Revert(ctx context.Context, userID int64, uid string, revision int) (*dto.JournalResponse, error) {
+	args := j.Called(ctx, userID, uid, revision)
+	if resp, ok := args.Get(0).(*dto.JournalResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
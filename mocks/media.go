@@ -0,0 +1,21 @@
+package mocks
+
+import (
+	"context"
+	"timo/dto"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MediaServiceMock struct {
+	mock.Mock
+}
+
+func (m *MediaServiceMock) Open(ctx context.Context, key, expires, sig string) (*dto.MediaObject, error) {
+	args := m.Called(ctx, key, expires, sig)
+	if object, ok := args.Get(0).(*dto.MediaObject); ok {
+		return object, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
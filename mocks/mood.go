@@ -0,0 +1,101 @@
+package mocks
+
+import (
+	"context"
+	"timo/dto"
+	"timo/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MoodRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *MoodRepositoryMock) GetVisible(ctx context.Context, userID int64, includeArchived bool, locales []string) ([]models.Mood, error) {
+	args := m.Called(ctx, userID, includeArchived, locales)
+	if moods, ok := args.Get(0).([]models.Mood); ok {
+		return moods, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (m *MoodRepositoryMock) GetByID(ctx context.Context, id int64) (*models.Mood, error) {
+	args := m.Called(ctx, id)
+	if mood, ok := args.Get(0).(*models.Mood); ok {
+		return mood, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (m *MoodRepositoryMock) CountSelectable(ctx context.Context, userID int64, ids []int64) (int, error) {
+	args := m.Called(ctx, userID, ids)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MoodRepositoryMock) Create(ctx context.Context, mood *models.Mood) error {
+	args := m.Called(ctx, mood)
+	return args.Error(0)
+}
+
+func (m *MoodRepositoryMock) Update(ctx context.Context, mood *models.Mood) error {
+	args := m.Called(ctx, mood)
+	return args.Error(0)
+}
+
+func (m *MoodRepositoryMock) Reorder(ctx context.Context, userID int64, ids []int64) error {
+	args := m.Called(ctx, userID, ids)
+	return args.Error(0)
+}
+
+func (m *MoodRepositoryMock) Delete(ctx context.Context, id, reassignTo int64) error {
+	args := m.Called(ctx, id, reassignTo)
+	return args.Error(0)
+}
+
+type MoodServiceMock struct {
+	mock.Mock
+}
+
+func (m *MoodServiceMock) GetList(ctx context.Context, userID int64, locales []string, query *dto.MoodListQuery) ([]dto.MoodResponse, error) {
+	args := m.Called(ctx, userID, locales, query)
+	if resp, ok := args.Get(0).([]dto.MoodResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (m *MoodServiceMock) Create(ctx context.Context, userID int64, req *dto.MoodRequest) (*dto.MoodResponse, error) {
+	args := m.Called(ctx, userID, req)
+	if resp, ok := args.Get(0).(*dto.MoodResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (m *MoodServiceMock) Update(ctx context.Context, userID, id int64, req *dto.MoodUpdateRequest) (*dto.MoodResponse, error) {
+	args := m.Called(ctx, userID, id, req)
+	if resp, ok := args.Get(0).(*dto.MoodResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (m *MoodServiceMock) Reorder(ctx context.Context, userID int64, req *dto.MoodOrderRequest) ([]dto.MoodResponse, error) {
+	args := m.Called(ctx, userID, req)
+	if resp, ok := args.Get(0).([]dto.MoodResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (m *MoodServiceMock) Delete(ctx context.Context, userID, id int64, query *dto.MoodDeleteQuery) error {
+	args := m.Called(ctx, userID, id, query)
+	return args.Error(0)
+}
@@ -0,0 +1,17 @@
+package mocks
+
+import (
+	"context"
+	"timo/helper"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type NotifierMock struct {
+	mock.Mock
+}
+
+func (n *NotifierMock) Notify(ctx context.Context, notification *helper.Notification) error {
+	args := n.Called(ctx, notification)
+	return args.Error(0)
+}
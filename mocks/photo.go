@@ -0,0 +1,162 @@
+package mocks
+
+import (
+	"context"
+	"time"
+	"timo/dto"
+	"timo/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type PhotoRepositoryMock struct {
+	mock.Mock
+}
+
+func (p *PhotoRepositoryMock) GetByJournalID(ctx context.Context, journalID int64) ([]models.Photo, error) {
+	args := p.Called(ctx, journalID)
+	if photos, ok := args.Get(0).([]models.Photo); ok {
+		return photos, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (p *PhotoRepositoryMock) GetByJournalIDs(ctx context.Context, journalIDs []int64) ([]models.Photo, error) {
+	args := p.Called(ctx, journalIDs)
+	if photos, ok := args.Get(0).([]models.Photo); ok {
+		return photos, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (p *PhotoRepositoryMock) GetByID(ctx context.Context, id int64) (*models.Photo, error) {
+	args := p.Called(ctx, id)
+	if photo, ok := args.Get(0).(*models.Photo); ok {
+		return photo, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (p *PhotoRepositoryMock) GetBlob(ctx context.Context, userID int64, checksum string) (*models.PhotoBlob, error) {
+	args := p.Called(ctx, userID, checksum)
+	if blob, ok := args.Get(0).(*models.PhotoBlob); ok {
+		return blob, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (p *PhotoRepositoryMock) Create(ctx context.Context, photo *models.Photo, limit int, uploadID int64) (bool, error) {
+	args := p.Called(ctx, photo, limit, uploadID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (p *PhotoRepositoryMock) Delete(ctx context.Context, id int64) (*models.PhotoBlob, error) {
+	args := p.Called(ctx, id)
+	if blob, ok := args.Get(0).(*models.PhotoBlob); ok {
+		return blob, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (p *PhotoRepositoryMock) ClaimPending(ctx context.Context, limit int, staleAfter time.Duration) ([]models.PhotoBlob, error) {
+	args := p.Called(ctx, limit, staleAfter)
+	if blobs, ok := args.Get(0).([]models.PhotoBlob); ok {
+		return blobs, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (p *PhotoRepositoryMock) SaveProcessed(ctx context.Context, blob *models.PhotoBlob) error {
+	args := p.Called(ctx, blob)
+	return args.Error(0)
+}
+
+func (p *PhotoRepositoryMock) MarkFailed(ctx context.Context, blobID int64) error {
+	args := p.Called(ctx, blobID)
+	return args.Error(0)
+}
+
+func (p *PhotoRepositoryMock) CountByJournalID(ctx context.Context, journalID int64) (int, error) {
+	args := p.Called(ctx, journalID)
+	return args.Int(0), args.Error(1)
+}
+
+func (p *PhotoRepositoryMock) ReserveStorage(ctx context.Context, userID, size, quota int64) (int64, error) {
+	args := p.Called(ctx, userID, size, quota)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (p *PhotoRepositoryMock) ReleaseStorage(ctx context.Context, userID, size int64) error {
+	args := p.Called(ctx, userID, size)
+	return args.Error(0)
+}
+
+func (p *PhotoRepositoryMock) Update(ctx context.Context, id int64, patch *models.PhotoPatch) error {
+	args := p.Called(ctx, id, patch)
+	return args.Error(0)
+}
+
+func (p *PhotoRepositoryMock) Reorder(ctx context.Context, journalID int64, ids []int64) error {
+	args := p.Called(ctx, journalID, ids)
+	return args.Error(0)
+}
+
+func (p *PhotoRepositoryMock) UnreferencedKeys(ctx context.Context, keys []string) ([]string, error) {
+	args := p.Called(ctx, keys)
+	if unreferenced, ok := args.Get(0).([]string); ok {
+		return unreferenced, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+type PhotoServiceMock struct {
+	mock.Mock
+}
+
+func (p *PhotoServiceMock) GetList(ctx context.Context, userID int64, journalUid string) ([]dto.PhotoResponse, error) {
+	args := p.Called(ctx, userID, journalUid)
+	if resp, ok := args.Get(0).([]dto.PhotoResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (p *PhotoServiceMock) Upload(ctx context.Context, userID int64, journalUid string, upload *dto.PhotoUpload) (*dto.PhotoResponse, error) {
+	args := p.Called(ctx, userID, journalUid, upload)
+	if resp, ok := args.Get(0).(*dto.PhotoResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (p *PhotoServiceMock) Update(ctx context.Context, userID int64, journalUid string, id int64, req *dto.PhotoUpdateRequest) (*dto.PhotoResponse, error) {
+	args := p.Called(ctx, userID, journalUid, id, req)
+	if resp, ok := args.Get(0).(*dto.PhotoResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (p *PhotoServiceMock) Reorder(ctx context.Context, userID int64, journalUid string, req *dto.PhotoOrderRequest) ([]dto.PhotoResponse, error) {
+	args := p.Called(ctx, userID, journalUid, req)
+	if resp, ok := args.Get(0).([]dto.PhotoResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (p *PhotoServiceMock) Delete(ctx context.Context, userID int64, journalUid string, id int64) error {
+	args := p.Called(ctx, userID, journalUid, id)
+	return args.Error(0)
+}
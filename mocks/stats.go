@@ -0,0 +1,68 @@
+package mocks
+
+import (
+	"context"
+	"time"
+	"timo/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type StatsRepositoryMock struct {
+	mock.Mock
+}
+
+func (s *StatsRepositoryMock) GetStreak(ctx context.Context, userID int64) (*models.Streak, error) {
+	args := s.Called(ctx, userID)
+	if streak, ok := args.Get(0).(*models.Streak); ok {
+		return streak, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (s *StatsRepositoryMock) AdvanceStreak(ctx context.Context, userID int64, date time.Time) (bool, error) {
+	args := s.Called(ctx, userID, date)
+	return args.Bool(0), args.Error(1)
+}
+
+func (s *StatsRepositoryMock) RecomputeStreak(ctx context.Context, userID int64) (*models.Streak, error) {
+	args := s.Called(ctx, userID)
+	if streak, ok := args.Get(0).(*models.Streak); ok {
+		return streak, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (s *StatsRepositoryMock) CountEntries(ctx context.Context, userID int64, from, to time.Time) (int, error) {
+	args := s.Called(ctx, userID, from, to)
+	return args.Int(0), args.Error(1)
+}
+
+func (s *StatsRepositoryMock) GetMoodDistribution(ctx context.Context, userID int64, from, to time.Time) ([]models.MoodCount, error) {
+	args := s.Called(ctx, userID, from, to)
+	if counts, ok := args.Get(0).([]models.MoodCount); ok {
+		return counts, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (s *StatsRepositoryMock) GetMoodTrend(ctx context.Context, userID int64, from, to time.Time, interval string) ([]models.MoodTrendPoint, error) {
+	args := s.Called(ctx, userID, from, to, interval)
+	if points, ok := args.Get(0).([]models.MoodTrendPoint); ok {
+		return points, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (s *StatsRepositoryMock) GetMoodByWeekday(ctx context.Context, userID int64, from, to time.Time) ([]models.MoodWeekday, error) {
+	args := s.Called(ctx, userID, from, to)
+	if weekdays, ok := args.Get(0).([]models.MoodWeekday); ok {
+		return weekdays, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
@@ -0,0 +1,71 @@
+package mocks
+
+import (
+	"context"
+	"io"
+	"timo/helper"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type StorageMock struct {
+	mock.Mock
+}
+
+// Put drains r so callers hashing the stream see all of it.
+func (s *StorageMock) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	io.Copy(io.Discard, r)
+	args := s.Called(ctx, key, size, contentType)
+	return args.Error(0)
+}
+
+func (s *StorageMock) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	args := s.Called(ctx, key)
+	if body, ok := args.Get(0).(io.ReadCloser); ok {
+		return body, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (s *StorageMock) Delete(ctx context.Context, key string) error {
+	args := s.Called(ctx, key)
+	return args.Error(0)
+}
+
+// List hands the objects given to Return to fn.
+func (s *StorageMock) List(ctx context.Context, prefix string, fn func(*helper.ObjectInfo) error) error {
+	args := s.Called(ctx, prefix)
+	if objects, ok := args.Get(0).([]helper.ObjectInfo); ok {
+		for i := range objects {
+			if err := fn(&objects[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return args.Error(1)
+}
+
+func (s *StorageMock) URL(key string) string {
+	args := s.Called(key)
+	return args.String(0)
+}
+
+func (s *StorageMock) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	args := s.Called(ctx, key, offset, length)
+	if body, ok := args.Get(0).(io.ReadCloser); ok {
+		return body, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (s *StorageMock) Stat(ctx context.Context, key string) (*helper.ObjectInfo, error) {
+	args := s.Called(ctx, key)
+	if info, ok := args.Get(0).(*helper.ObjectInfo); ok {
+		return info, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
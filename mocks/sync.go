@@ -0,0 +1,21 @@
+package mocks
+
+import (
+	"context"
+	"timo/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type SyncRepositoryMock struct {
+	mock.Mock
+}
+
+func (s *SyncRepositoryMock) Sync(ctx context.Context, userID int64, since int64, changes []models.JournalChange) (*models.SyncResult, error) {
+	args := s.Called(ctx, userID, since, changes)
+	if result, ok := args.Get(0).(*models.SyncResult); ok {
+		return result, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
@@ -0,0 +1,100 @@
+package mocks
+
+import (
+	"context"
+	"io"
+	"time"
+	"timo/dto"
+	"timo/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type UploadRepositoryMock struct {
+	mock.Mock
+}
+
+func (u *UploadRepositoryMock) Create(ctx context.Context, upload *models.PhotoUpload, limit int) error {
+	args := u.Called(ctx, upload, limit)
+	return args.Error(0)
+}
+
+func (u *UploadRepositoryMock) GetByUid(ctx context.Context, uid string) (*models.PhotoUpload, error) {
+	args := u.Called(ctx, uid)
+	if upload, ok := args.Get(0).(*models.PhotoUpload); ok {
+		return upload, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (u *UploadRepositoryMock) AddPart(ctx context.Context, uploadID int64, part *models.PhotoUploadPart) (int64, error) {
+	args := u.Called(ctx, uploadID, part)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (u *UploadRepositoryMock) Claim(ctx context.Context, id int64, staleAfter time.Duration) error {
+	args := u.Called(ctx, id, staleAfter)
+	return args.Error(0)
+}
+
+func (u *UploadRepositoryMock) Unclaim(ctx context.Context, id int64) error {
+	args := u.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (u *UploadRepositoryMock) DeleteExpired(ctx context.Context, id int64, now time.Time, staleAfter time.Duration) error {
+	args := u.Called(ctx, id, now, staleAfter)
+	return args.Error(0)
+}
+
+func (u *UploadRepositoryMock) GetExpired(ctx context.Context, now time.Time) ([]models.PhotoUpload, error) {
+	args := u.Called(ctx, now)
+	if uploads, ok := args.Get(0).([]models.PhotoUpload); ok {
+		return uploads, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+type UploadServiceMock struct {
+	mock.Mock
+}
+
+func (u *UploadServiceMock) Create(ctx context.Context, userID int64, journalUid string, req *dto.UploadCreateRequest) (*dto.UploadResponse, error) {
+	args := u.Called(ctx, userID, journalUid, req)
+	if resp, ok := args.Get(0).(*dto.UploadResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (u *UploadServiceMock) Get(ctx context.Context, userID int64, journalUid, uid string) (*dto.UploadResponse, error) {
+	args := u.Called(ctx, userID, journalUid, uid)
+	if resp, ok := args.Get(0).(*dto.UploadResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+// Append drains the chunk so the test can check what the handler passed on.
+func (u *UploadServiceMock) Append(ctx context.Context, userID int64, journalUid, uid string, chunk *dto.UploadChunk) (*dto.UploadResponse, error) {
+	body, _ := io.ReadAll(chunk.Content)
+	args := u.Called(ctx, userID, journalUid, uid, chunk.Offset, string(body))
+	if resp, ok := args.Get(0).(*dto.UploadResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (u *UploadServiceMock) Finalize(ctx context.Context, userID int64, journalUid, uid string) (*dto.PhotoResponse, error) {
+	args := u.Called(ctx, userID, journalUid, uid)
+	if resp, ok := args.Get(0).(*dto.PhotoResponse); ok {
+		return resp, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
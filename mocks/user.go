@@ -0,0 +1,46 @@
+package mocks
+
+import (
+	"context"
+	"time"
+	"timo/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type UserRepositoryMock struct {
+	mock.Mock
+}
+
+func (u *UserRepositoryMock) GetByUID(ctx context.Context, uid string) (*models.User, error) {
+	args := u.Called(ctx, uid)
+	if user, ok := args.Get(0).(*models.User); ok {
+		return user, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (u *UserRepositoryMock) GetDigestSubscribers(ctx context.Context) ([]models.User, error) {
+	args := u.Called(ctx)
+	if users, ok := args.Get(0).([]models.User); ok {
+		return users, args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (u *UserRepositoryMock) ClaimDigest(ctx context.Context, userID int64, date time.Time) (bool, error) {
+	args := u.Called(ctx, userID, date)
+	return args.Bool(0), args.Error(1)
+}
+
+func (u *UserRepositoryMock) ReleaseDigest(ctx context.Context, userID int64, date time.Time) error {
+	args := u.Called(ctx, userID, date)
+	return args.Error(0)
+}
+
+func (u *UserRepositoryMock) UpdateSettings(ctx context.Context, uid string, settings *models.UserSettings) error {
+	args := u.Called(ctx, uid, settings)
+	return args.Error(0)
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+type MoodCheckin struct {
+	ID        int64     `db:"id"`
+	Uid       string    `db:"uid"`
+	UserID    int64     `db:"user_id"`
+	MoodID    int64     `db:"mood_id"`
+	MoodLabel string    `db:"mood_label"`
+	Intensity *int      `db:"intensity"`
+	Note      *string   `db:"note"`
+	CheckedAt time.Time `db:"checked_at"`
+	EntryDate time.Time `db:"entry_date"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type CheckinFilter struct {
+	From *time.Time
+	To   *time.Time
+}
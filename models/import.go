@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+const (
+	IMPORT_DAYONE   string = "dayone"
+	IMPORT_MARKDOWN string = "markdown"
+	IMPORT_CSV      string = "csv"
+)
+
+const (
+	IMPORT_PENDING    string = "pending"
+	IMPORT_PROCESSING string = "processing"
+	IMPORT_DONE       string = "done"
+	IMPORT_FAILED     string = "failed"
+)
+
+const (
+	IMPORT_ENTRY_PENDING  string = "pending"
+	IMPORT_ENTRY_IMPORTED string = "imported"
+	IMPORT_ENTRY_SKIPPED  string = "skipped"
+	IMPORT_ENTRY_FAILED   string = "failed"
+)
+
+// Import is an uploaded export of another journaling app, waiting for or
+// going through the import job. The uploaded file is kept under StorageKey
+// until the job has finished with it. Imported, Skipped and Failed count its
+// entries by status.
+type Import struct {
+	ID            int64         `db:"id"`
+	Uid           string        `db:"uid"`
+	UserID        int64         `db:"user_id"`
+	Format        string        `db:"format"`
+	Filename      string        `db:"filename"`
+	StorageKey    *string       `db:"storage_key"`
+	Size          int64         `db:"size"`
+	DefaultMoodID *int64        `db:"default_mood_id"`
+	Status        string        `db:"status"`
+	Total         *int          `db:"total"`
+	Error         *string       `db:"error"`
+	ProcessingAt  *time.Time    `db:"processing_at"`
+	FinishedAt    *time.Time    `db:"finished_at"`
+	CreatedAt     time.Time     `db:"created_at"`
+	Imported      int           `db:"-"`
+	Skipped       int           `db:"-"`
+	Failed        int           `db:"-"`
+	Entries       []ImportEntry `db:"-"`
+}
+
+// ImportEntry is the outcome for one entry of an import, at its Position in
+// the file. SourceID identifies the entry across imports, so importing the
+// same file again skips what is already there. JournalUid is the journal
+// created for it, or the one it duplicates, as long as it exists. A pending
+// entry is recorded with the uid of its journal before that is created.
+type ImportEntry struct {
+	ImportID   int64   `db:"import_id"`
+	Position   int     `db:"position"`
+	SourceID   string  `db:"source_id"`
+	Title      string  `db:"title"`
+	Status     string  `db:"status"`
+	JournalUid *string `db:"journal_uid"`
+	Message    *string `db:"message"`
+}
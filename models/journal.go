@@ -2,14 +2,91 @@ package models
 
 import "time"
 
+const (
+	JOURNAL_DRAFT     string = "draft"
+	JOURNAL_PUBLISHED string = "published"
+)
+
+// DEFAULT_MOOD_INTENSITY is the intensity of a mood set without one, such as
+// the primary mood written by clients that only know mood_id.
+const DEFAULT_MOOD_INTENSITY = 3
+
 type Journal struct {
-	ID        int64     `db:"id"`
+	ID        int64         `db:"id"`
+	Uid       string        `db:"uid"`
+	UserID    int64         `db:"user_id"`
+	Title     string        `db:"title"`
+	Text      string        `db:"text"`
+	MoodID    int64         `db:"mood_id"`
+	MoodLabel string        `db:"mood_label"`
+	Version   int64         `db:"version"`
+	Status    string        `db:"status"`
+	EntryDate time.Time     `db:"entry_date"`
+	CreatedAt time.Time     `db:"created_at"`
+	UpdatedAt time.Time     `db:"updated_at"`
+	Moods     []JournalMood `db:"-"`
+	Cover     *JournalCover `db:"-"`
+}
+
+// JournalCover is the photo shown for a journal in lists: the one marked as
+// cover, or else the first one.
+type JournalCover struct {
+	PhotoID    int64
+	Url        string
+	StorageKey *string
+	AltText    *string
+}
+
+// JournalMood is one of the moods of a journal. The journal's MoodID is its
+// primary mood and is always one of them.
+type JournalMood struct {
+	MoodID    int64  `db:"mood_id"`
+	MoodLabel string `db:"mood_label"`
+	Intensity int    `db:"intensity"`
+}
+
+type JournalPatch struct {
+	Title     *string
+	Text      *string
+	MoodID    *int64
+	Moods     []JournalMood
+	EntryDate *time.Time
+}
+
+type JournalFilter struct {
+	Status       string
+	From         *time.Time
+	To           *time.Time
+	MoodID       *int64
+	MinIntensity int
+}
+
+type CalendarDay struct {
+	Date         time.Time `db:"entry_date"`
+	Count        int       `db:"count"`
+	DominantMood string    `db:"dominant_mood"`
+	JournalUids  []string  `db:"journal_uids"`
+	CheckinCount int       `db:"checkin_count"`
+}
+
+type Memory struct {
 	Uid       string    `db:"uid"`
-	UserID    int64     `db:"user_id"`
 	Title     string    `db:"title"`
-	Text      string    `db:"text"`
-	MoodID    int64     `db:"mood_id"`
 	MoodLabel string    `db:"mood_label"`
-	CreatedAt time.Time `db:"created_at"`
-	UpdatedAt time.Time `db:"updated_at"`
+	EntryDate time.Time `db:"entry_date"`
+	PhotoUrl  *string   `db:"photo_url"`
+	PhotoKey  *string   `db:"photo_key"`
+}
+
+type JournalRevision struct {
+	ID        int64         `db:"id"`
+	JournalID int64         `db:"journal_id"`
+	Revision  int           `db:"revision"`
+	Title     string        `db:"title"`
+	Text      string        `db:"text"`
+	MoodID    int64         `db:"mood_id"`
+	MoodLabel string        `db:"mood_label"`
+	EntryDate *time.Time    `db:"entry_date"`
+	Moods     []JournalMood `db:"-"`
+	CreatedAt time.Time     `db:"created_at"`
 }
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+type Mood struct {
+	ID         int64      `db:"id"`
+	UserID     *int64     `db:"user_id"`
+	Label      string     `db:"label"`
+	Emoji      *string    `db:"emoji"`
+	Color      *string    `db:"color"`
+	Valence    *float64   `db:"valence"`
+	Energy     *float64   `db:"energy"`
+	Position   int        `db:"position"`
+	ArchivedAt *time.Time `db:"archived_at"`
+	CreatedAt  time.Time  `db:"created_at"`
+}
@@ -2,9 +2,87 @@ package models
 
 import "time"
 
+const (
+	PHOTO_PENDING    string = "pending"
+	PHOTO_PROCESSING string = "processing"
+	PHOTO_READY      string = "ready"
+	PHOTO_FAILED     string = "failed"
+)
+
+const (
+	MEDIA_IMAGE string = "image"
+	MEDIA_AUDIO string = "audio"
+)
+
+// Photo is an attachment of a journal: an image, or an audio recording as
+// told by MediaType. The stored file and what was learned from it belong to
+// its blob and are copied in when loading. Recordings kept the photo name in
+// the tables, the /photos routes, the "photo" form field and its errors on
+// purpose, so clients and stored data from before audio keep working.
 type Photo struct {
-	ID        int64     `db:"id"`
-	JournalID int64     `db:"journal_id"`
-	Url       string    `db:"url"`
-	CreatedAt time.Time `db:"created_at"`
+	ID          int64          `db:"id"`
+	JournalID   int64          `db:"journal_id"`
+	BlobID      int64          `db:"blob_id"`
+	Url         string         `db:"url"`
+	StorageKey  string         `db:"storage_key"`
+	Size        int64          `db:"size"`
+	ContentType string         `db:"content_type"`
+	Checksum    string         `db:"checksum"`
+	Status      string         `db:"status"`
+	Width       *int           `db:"width"`
+	Height      *int           `db:"height"`
+	TakenAt     *time.Time     `db:"taken_at"`
+	Orientation *int           `db:"orientation"`
+	MediaType   string         `db:"media_type"`
+	DurationMs  *int           `db:"duration_ms"`
+	Peaks       []int          `db:"peaks"`
+	Position    int            `db:"position"`
+	Caption     *string        `db:"caption"`
+	AltText     *string        `db:"alt_text"`
+	Cover       bool           `db:"cover"`
+	CreatedAt   time.Time      `db:"created_at"`
+	Variants    []PhotoVariant `db:"-"`
+}
+
+// PhotoBlob is one stored image or recording, keyed by the SHA-256 of the uploaded file
+// and shared by all of a user's photos with that content. RefCount counts
+// those photos; the blob and its objects go away with the last one.
+type PhotoBlob struct {
+	ID          int64          `db:"id"`
+	UserID      int64          `db:"user_id"`
+	Checksum    string         `db:"checksum"`
+	StorageKey  string         `db:"storage_key"`
+	Url         string         `db:"url"`
+	Size        int64          `db:"size"`
+	ContentType string         `db:"content_type"`
+	Status      string         `db:"status"`
+	Width       *int           `db:"width"`
+	Height      *int           `db:"height"`
+	TakenAt     *time.Time     `db:"taken_at"`
+	Orientation *int           `db:"orientation"`
+	MediaType   string         `db:"media_type"`
+	DurationMs  *int           `db:"duration_ms"`
+	Peaks       []int          `db:"peaks"`
+	RefCount    int            `db:"ref_count"`
+	CreatedAt   time.Time      `db:"created_at"`
+	Variants    []PhotoVariant `db:"-"`
+}
+
+// PhotoVariant is a resized copy of a processed blob, e.g. a thumbnail.
+type PhotoVariant struct {
+	BlobID      int64  `db:"blob_id"`
+	Name        string `db:"name"`
+	StorageKey  string `db:"storage_key"`
+	Width       int    `db:"width"`
+	Height      int    `db:"height"`
+	Size        int64  `db:"size"`
+	ContentType string `db:"content_type"`
+}
+
+// PhotoPatch holds the photo fields a client may change. An empty caption or
+// alt text clears it.
+type PhotoPatch struct {
+	Caption *string
+	AltText *string
+	Cover   *bool
 }
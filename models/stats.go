@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+type Streak struct {
+	UserID        int64      `db:"user_id"`
+	CurrentStreak int        `db:"current_streak"`
+	LongestStreak int        `db:"longest_streak"`
+	LastEntryDate *time.Time `db:"last_entry_date"`
+}
+
+type MoodCount struct {
+	MoodID    int64    `db:"mood_id"`
+	MoodLabel string   `db:"mood_label"`
+	Valence   *float64 `db:"valence"`
+	Count     int      `db:"count"`
+	Weight    float64  `db:"weight"`
+}
+
+type MoodTrendPoint struct {
+	PeriodStart time.Time `db:"period_start"`
+	MoodCount
+}
+
+type MoodWeekday struct {
+	// Weekday follows ISO 8601: 1 is Monday and 7 is Sunday.
+	Weekday int `db:"weekday"`
+	MoodCount
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+const (
+	CHANGE_CREATE string = "create"
+	CHANGE_UPDATE string = "update"
+	CHANGE_DELETE string = "delete"
+
+	CONFLICT_VERSION_MISMATCH string = "version_mismatch"
+	CONFLICT_ALREADY_EXISTS   string = "already_exists"
+	CONFLICT_DELETED          string = "deleted"
+	CONFLICT_NOT_FOUND        string = "not_found"
+	CONFLICT_INVALID          string = "invalid"
+)
+
+type JournalChange struct {
+	Op          string
+	Uid         string
+	BaseVersion int64
+	Title       string
+	Text        string
+	MoodID      int64
+	EntryDate   *time.Time
+}
+
+type JournalChangeResult struct {
+	Uid      string
+	Op       string
+	Version  int64
+	Conflict string
+	Server   *Journal
+}
+
+type JournalTombstone struct {
+	Uid       string    `db:"uid"`
+	UserID    int64     `db:"user_id"`
+	ChangeSeq int64     `db:"change_seq"`
+	DeletedAt time.Time `db:"deleted_at"`
+}
+
+type SyncResult struct {
+	Results    []JournalChangeResult
+	Journals   []Journal
+	Tombstones []JournalTombstone
+	ChangeSeq  int64
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// PhotoUpload is a resumable upload in progress. Each chunk is stored as its
+// own part until the upload is finalized into a photo on the journal.
+type PhotoUpload struct {
+	ID        int64             `db:"id"`
+	Uid       string            `db:"uid"`
+	UserID    int64             `db:"user_id"`
+	JournalID int64             `db:"journal_id"`
+	Filename  string            `db:"filename"`
+	Size      int64             `db:"size"`
+	Received  int64             `db:"received"`
+	Checksum  string            `db:"checksum"`
+	Caption   *string           `db:"caption"`
+	AltText   *string           `db:"alt_text"`
+	Peaks     []int             `db:"peaks"`
+	ExpiresAt time.Time         `db:"expires_at"`
+	CreatedAt time.Time         `db:"created_at"`
+	Parts     []PhotoUploadPart `db:"-"`
+}
+
+// PhotoUploadPart is one stored chunk, covering Size bytes from Start.
+type PhotoUploadPart struct {
+	UploadID   int64  `db:"upload_id"`
+	Start      int64  `db:"start"`
+	StorageKey string `db:"storage_key"`
+	Size       int64  `db:"size"`
+}
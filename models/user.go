@@ -3,12 +3,21 @@ package models
 import "time"
 
 type User struct {
-	ID        int64     `db:"id"`
-	Uid       string    `db:"uid"`
-	GoogleID  *string   `db:"google_id"`
-	Name      string    `db:"name"`
-	Email     string    `db:"email"`
-	Password  *string   `db:"password_hash"`
-	CreatedAt time.Time `db:"created_at"`
-	UpdatedAt time.Time `db:"updated_at"`
+	ID           int64     `db:"id"`
+	Uid          string    `db:"uid"`
+	GoogleID     *string   `db:"google_id"`
+	Name         string    `db:"name"`
+	Email        string    `db:"email"`
+	Password     *string   `db:"password_hash"`
+	Timezone     string    `db:"timezone"`
+	MemoryDigest bool      `db:"memory_digest"`
+	WeeklyGoal   int       `db:"weekly_goal"`
+	CreatedAt    time.Time `db:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at"`
+}
+
+type UserSettings struct {
+	Timezone     *string
+	MemoryDigest *bool
+	WeeklyGoal   *int
 }
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"timo/domain"
+	"timo/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type checkin struct {
+	pool *pgxpool.Pool
+}
+
+func NewCheckin(pool *pgxpool.Pool) domain.CheckinRepository {
+	return &checkin{pool: pool}
+}
+
+func (c *checkin) GetListByUserID(ctx context.Context, userID int64, filter *models.CheckinFilter) ([]models.MoodCheckin, error) {
+	var checkins []models.MoodCheckin
+
+	query := `
+		SELECT c.id, c.uid, c.user_id, c.mood_id, m.label AS mood_label, c.intensity, c.note, c.checked_at, c.entry_date, c.created_at, c.updated_at
+		FROM mood_checkins c
+		JOIN moods m ON m.id = c.mood_id
+		WHERE c.user_id = $1
+			AND ($2::date IS NULL OR c.entry_date >= $2)
+			AND ($3::date IS NULL OR c.entry_date <= $3)
+		ORDER BY c.checked_at DESC, c.id DESC
+	`
+
+	rows, err := c.pool.Query(ctx, query, userID, filter.From, filter.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ch models.MoodCheckin
+		err := rows.Scan(&ch.ID, &ch.Uid, &ch.UserID, &ch.MoodID, &ch.MoodLabel, &ch.Intensity, &ch.Note, &ch.CheckedAt, &ch.EntryDate, &ch.CreatedAt, &ch.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		checkins = append(checkins, ch)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return checkins, nil
+}
+
+func (c *checkin) GetByID(ctx context.Context, uid string) (*models.MoodCheckin, error) {
+	var ch models.MoodCheckin
+
+	query := `
+		SELECT c.id, c.uid, c.user_id, c.mood_id, m.label AS mood_label, c.intensity, c.note, c.checked_at, c.entry_date, c.created_at, c.updated_at
+		FROM mood_checkins c
+		JOIN moods m ON m.id = c.mood_id
+		WHERE c.uid = $1
+	`
+
+	err := c.pool.QueryRow(ctx, query, uid).
+		Scan(&ch.ID, &ch.Uid, &ch.UserID, &ch.MoodID, &ch.MoodLabel, &ch.Intensity, &ch.Note, &ch.CheckedAt, &ch.EntryDate, &ch.CreatedAt, &ch.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+
+	return &ch, nil
+}
+
+func (c *checkin) Create(ctx context.Context, checkin *models.MoodCheckin) error {
+	query := `
+		INSERT INTO mood_checkins (user_id, mood_id, intensity, note, checked_at, entry_date)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, uid, created_at, updated_at
+	`
+
+	return c.pool.QueryRow(ctx, query, checkin.UserID, checkin.MoodID, checkin.Intensity, checkin.Note, checkin.CheckedAt, checkin.EntryDate).
+		Scan(&checkin.ID, &checkin.Uid, &checkin.CreatedAt, &checkin.UpdatedAt)
+}
+
+func (c *checkin) Update(ctx context.Context, checkin *models.MoodCheckin) error {
+	query := `
+		UPDATE mood_checkins
+		SET mood_id = $1,
+			intensity = $2,
+			note = $3,
+			checked_at = $4,
+			entry_date = $5,
+			updated_at = $6
+		WHERE uid = $7
+	`
+
+	result, err := c.pool.Exec(ctx, query, checkin.MoodID, checkin.Intensity, checkin.Note, checkin.CheckedAt, checkin.EntryDate, time.Now().UTC(), checkin.Uid)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (c *checkin) Delete(ctx context.Context, uid string) error {
+	query := `
+		DELETE FROM mood_checkins
+		WHERE uid = $1
+	`
+
+	result, err := c.pool.Exec(ctx, query, uid)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"timo/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckinRepository_CRUD(t *testing.T) {
+	ctx := context.Background()
+	repo := NewCheckin(testDB)
+
+	intensity := 3
+	checkin := &models.MoodCheckin{
+		UserID:    14,
+		MoodID:    1,
+		Intensity: &intensity,
+		CheckedAt: time.Date(2024, 3, 9, 15, 0, 0, 0, time.UTC),
+		EntryDate: time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC),
+	}
+	err := repo.Create(ctx, checkin)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, checkin.Uid)
+
+	day := checkin.EntryDate
+	list, err := repo.GetListByUserID(ctx, 14, &models.CheckinFilter{From: &day, To: &day})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, list)
+
+	checkin.MoodID = 2
+	checkin.Intensity = nil
+	err = repo.Update(ctx, checkin)
+	assert.NoError(t, err)
+
+	got, err := repo.GetByID(ctx, checkin.Uid)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), got.MoodID)
+	assert.Nil(t, got.Intensity)
+
+	err = repo.Delete(ctx, checkin.Uid)
+	assert.NoError(t, err)
+}
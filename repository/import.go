@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"timo/domain"
+	"timo/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type journalImport struct {
+	pool *pgxpool.Pool
+}
+
+func NewImport(pool *pgxpool.Pool) domain.ImportRepository {
+	return &journalImport{pool: pool}
+}
+
+const importColumns = `id, uid, user_id, format, filename, storage_key, size, default_mood_id, status, total, error,
+	processing_at, finished_at, created_at`
+
+// importCounts joins the entry counts by status of the import aliased i, as
+// c.imported, c.skipped and c.failed.
+const importCounts = `
+	CROSS JOIN LATERAL (
+		SELECT COUNT(*) FILTER (WHERE e.status = 'imported') AS imported,
+			COUNT(*) FILTER (WHERE e.status = 'skipped') AS skipped,
+			COUNT(*) FILTER (WHERE e.status = 'failed') AS failed
+		FROM import_entries e
+		WHERE e.import_id = i.id
+	) c`
+
+func scanImport(row pgx.Row, imp *models.Import, counts ...any) error {
+	dest := []any{&imp.ID, &imp.Uid, &imp.UserID, &imp.Format, &imp.Filename, &imp.StorageKey, &imp.Size, &imp.DefaultMoodID,
+		&imp.Status, &imp.Total, &imp.Error, &imp.ProcessingAt, &imp.FinishedAt, &imp.CreatedAt}
+	return row.Scan(append(dest, counts...)...)
+}
+
+func (i *journalImport) Create(ctx context.Context, imp *models.Import) error {
+	query := `
+		INSERT INTO imports (user_id, format, filename, storage_key, size, default_mood_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, uid, status, created_at
+	`
+
+	return i.pool.QueryRow(ctx, query, imp.UserID, imp.Format, imp.Filename, imp.StorageKey, imp.Size, imp.DefaultMoodID).
+		Scan(&imp.ID, &imp.Uid, &imp.Status, &imp.CreatedAt)
+}
+
+// GetByUid loads an import with its counts and the entries handled so far,
+// in file order.
+func (i *journalImport) GetByUid(ctx context.Context, uid string) (*models.Import, error) {
+	var imp models.Import
+
+	query := `
+		SELECT ` + importColumns + `, c.imported, c.skipped, c.failed
+		FROM imports i` + importCounts + `
+		WHERE i.uid = $1
+	`
+
+	err := scanImport(i.pool.QueryRow(ctx, query, uid), &imp, &imp.Imported, &imp.Skipped, &imp.Failed)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+
+	query = `
+		SELECT e.import_id, e.position, e.source_id, e.title, e.status, j.uid, e.message
+		FROM import_entries e
+		LEFT JOIN journals j ON j.uid = e.journal_uid
+		WHERE e.import_id = $1
+		ORDER BY e.position
+	`
+
+	rows, err := i.pool.Query(ctx, query, imp.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.ImportEntry
+		err := rows.Scan(&entry.ImportID, &entry.Position, &entry.SourceID, &entry.Title, &entry.Status, &entry.JournalUid, &entry.Message)
+		if err != nil {
+			return nil, err
+		}
+		imp.Entries = append(imp.Entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &imp, nil
+}
+
+// GetListByUserID returns the user's latest imports with their counts but
+// without entries.
+func (i *journalImport) GetListByUserID(ctx context.Context, userID int64, limit int) ([]models.Import, error) {
+	var imports []models.Import
+	query := `
+		SELECT ` + importColumns + `, c.imported, c.skipped, c.failed
+		FROM imports i` + importCounts + `
+		WHERE i.user_id = $1
+		ORDER BY i.created_at DESC, i.id DESC
+		LIMIT $2
+	`
+
+	rows, err := i.pool.Query(ctx, query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var imp models.Import
+		if err := scanImport(rows, &imp, &imp.Imported, &imp.Skipped, &imp.Failed); err != nil {
+			return nil, err
+		}
+		imports = append(imports, imp)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return imports, nil
+}
+
+// ClaimPending moves up to limit pending imports to processing and returns
+// them. Imports that haven't recorded an entry for longer than staleAfter,
+// e.g. after a crash, are claimed again and pick up where they stopped.
+func (i *journalImport) ClaimPending(ctx context.Context, limit int, staleAfter time.Duration) ([]models.Import, error) {
+	var imports []models.Import
+	query := `
+		UPDATE imports
+		SET status = 'processing', processing_at = now()
+		WHERE id IN (
+			SELECT id FROM imports
+			WHERE status = 'pending'
+				OR (status = 'processing' AND processing_at < now() - make_interval(secs => $2))
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + importColumns
+
+	rows, err := i.pool.Query(ctx, query, limit, staleAfter.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var imp models.Import
+		if err := scanImport(rows, &imp); err != nil {
+			return nil, err
+		}
+		imports = append(imports, imp)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return imports, nil
+}
+
+func (i *journalImport) SetTotal(ctx context.Context, id int64, total int) error {
+	result, err := i.pool.Exec(ctx, `UPDATE imports SET total = $2 WHERE id = $1`, id, total)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// AddEntry records the outcome of one entry and keeps the import's claim
+// fresh. Only a pending entry can be recorded again; any other outcome
+// recorded before is left as it was.
+func (i *journalImport) AddEntry(ctx context.Context, entry *models.ImportEntry) error {
+	tx, err := i.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO import_entries (import_id, position, source_id, title, status, journal_uid, message)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (import_id, position) DO UPDATE
+		SET source_id = excluded.source_id,
+			title = excluded.title,
+			status = excluded.status,
+			journal_uid = excluded.journal_uid,
+			message = excluded.message
+		WHERE import_entries.status = 'pending'
+	`
+
+	_, err = tx.Exec(ctx, query, entry.ImportID, entry.Position, entry.SourceID, entry.Title, entry.Status,
+		entry.JournalUid, entry.Message)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE imports SET processing_at = now() WHERE id = $1`, entry.ImportID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// FindImported maps those of sourceIDs that an earlier import of the user
+// turned into a journal, which still exists, to that journal's uid.
+func (i *journalImport) FindImported(ctx context.Context, userID int64, sourceIDs []string) (map[string]string, error) {
+	found := make(map[string]string)
+	query := `
+		SELECT DISTINCT ON (e.source_id) e.source_id, j.uid
+		FROM import_entries e
+		JOIN imports i ON i.id = e.import_id
+		JOIN journals j ON j.uid = e.journal_uid
+		WHERE i.user_id = $1 AND e.source_id = ANY($2)
+		ORDER BY e.source_id, e.import_id
+	`
+
+	rows, err := i.pool.Query(ctx, query, userID, sourceIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sourceID, uid string
+		if err := rows.Scan(&sourceID, &uid); err != nil {
+			return nil, err
+		}
+		found[sourceID] = uid
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// Finish sets the final status of an import and lets go of its file, which
+// the caller removes from storage.
+func (i *journalImport) Finish(ctx context.Context, id int64, status string, message *string) error {
+	query := `
+		UPDATE imports
+		SET status = $2, error = $3, storage_key = NULL, finished_at = now()
+		WHERE id = $1
+	`
+
+	result, err := i.pool.Exec(ctx, query, id, status, message)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
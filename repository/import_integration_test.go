@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"timo/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportRepository_Entries(t *testing.T) {
+	ctx := context.Background()
+	repo := NewImport(testDB)
+
+	var userID int64
+	var journalUID string
+	_ = testDB.QueryRow(ctx, `SELECT user_id, uid FROM journals WHERE id = 17`).Scan(&userID, &journalUID)
+
+	key := "users/imports/test.zip"
+	imp := &models.Import{UserID: userID, Format: models.IMPORT_CSV, Filename: "test.zip", StorageKey: &key, Size: 10}
+	err := repo.Create(ctx, imp)
+	assert.NoError(t, err)
+	assert.Equal(t, models.IMPORT_PENDING, imp.Status)
+	defer testDB.Exec(ctx, `DELETE FROM imports WHERE id = $1`, imp.ID)
+
+	claimed, err := repo.ClaimPending(ctx, 10, time.Minute)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, claimed)
+
+	err = repo.SetTotal(ctx, imp.ID, 2)
+	assert.NoError(t, err)
+
+	err = repo.AddEntry(ctx, &models.ImportEntry{ImportID: imp.ID, Position: 0, SourceID: "csv:test-1", Title: "Hike",
+		Status: models.IMPORT_ENTRY_PENDING, JournalUid: &journalUID})
+	assert.NoError(t, err)
+	err = repo.AddEntry(ctx, &models.ImportEntry{ImportID: imp.ID, Position: 0, SourceID: "csv:test-1", Title: "Hike",
+		Status: models.IMPORT_ENTRY_IMPORTED, JournalUid: &journalUID})
+	assert.NoError(t, err)
+	// A recorded outcome is final.
+	err = repo.AddEntry(ctx, &models.ImportEntry{ImportID: imp.ID, Position: 0, SourceID: "csv:test-1", Title: "Hike",
+		Status: models.IMPORT_ENTRY_PENDING})
+	assert.NoError(t, err)
+	message := "entry has no date"
+	err = repo.AddEntry(ctx, &models.ImportEntry{ImportID: imp.ID, Position: 1, SourceID: "csv:test-2",
+		Status: models.IMPORT_ENTRY_FAILED, Message: &message})
+	assert.NoError(t, err)
+
+	found, err := repo.FindImported(ctx, userID, []string{"csv:test-1", "csv:test-2"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"csv:test-1": journalUID}, found)
+
+	err = repo.Finish(ctx, imp.ID, models.IMPORT_DONE, nil)
+	assert.NoError(t, err)
+
+	got, err := repo.GetByUid(ctx, imp.Uid)
+	assert.NoError(t, err)
+	assert.Equal(t, models.IMPORT_DONE, got.Status)
+	assert.Nil(t, got.StorageKey)
+	assert.Equal(t, 1, got.Imported)
+	assert.Equal(t, 1, got.Failed)
+	assert.Len(t, got.Entries, 2)
+	assert.Equal(t, models.IMPORT_ENTRY_IMPORTED, got.Entries[0].Status)
+	assert.Equal(t, journalUID, *got.Entries[0].JournalUid)
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 	"timo/domain"
+	"timo/helper"
 	"timo/models"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -20,45 +24,75 @@ func NewJournal(pool *pgxpool.Pool) domain.JournalRepository {
 }
 
 func (j *journal) Create(ctx context.Context, journal *models.Journal) error {
+	tx, err := j.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
-		INSERT INTO journals (user_id, title, text, mood_id)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, uid, created_at, updated_at
+		WITH seq AS (
+			UPDATE users SET change_seq = change_seq + 1 WHERE id = $1 RETURNING change_seq
+		)
+		INSERT INTO journals (uid, user_id, title, text, mood_id, status, entry_date, change_seq)
+		SELECT COALESCE($7::uuid, gen_random_uuid()), $1, $2, $3, $4, COALESCE(NULLIF($5, ''), 'published'), $6, seq.change_seq FROM seq
+		RETURNING id, uid, version, status, entry_date, created_at, updated_at
 	`
 
-	return j.pool.QueryRow(ctx, query, journal.UserID, journal.Title, journal.Text, journal.MoodID).
-		Scan(&journal.ID, &journal.Uid, &journal.CreatedAt, &journal.UpdatedAt)
+	err = tx.QueryRow(ctx, query, journal.UserID, journal.Title, journal.Text, journal.MoodID, journal.Status, journal.EntryDate,
+		helper.PtrOrNil(journal.Uid)).
+		Scan(&journal.ID, &journal.Uid, &journal.Version, &journal.Status, &journal.EntryDate, &journal.CreatedAt, &journal.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	moods := journal.Moods
+	if moods == nil {
+		moods = []models.JournalMood{{MoodID: journal.MoodID, Intensity: models.DEFAULT_MOOD_INTENSITY}}
+	}
+	if err := setMoods(ctx, tx, journal.ID, journal.MoodID, moods); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
-func (j *journal) Delete(ctx context.Context, uid string) error {
-	query := `
-		DELETE FROM journals WHERE uid = $1
-	`
+func (j *journal) Delete(ctx context.Context, uid string, version int64) error {
+	tx, err := j.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
 
-	result, err := j.pool.Exec(ctx, query, uid)
+	seq, err := nextChangeSeq(ctx, tx, uid)
 	if err != nil {
 		return err
 	}
 
-	if result.RowsAffected() == 0 {
-		return sql.ErrNoRows
+	journalID, err := lockVersion(ctx, tx, uid, version)
+	if err != nil {
+		return err
+	}
+
+	if err := deleteWithTombstone(ctx, tx, journalID, seq); err != nil {
+		return err
 	}
 
-	return nil
+	return tx.Commit(ctx)
 }
 
 func (j *journal) GetByID(ctx context.Context, uid string) (*models.Journal, error) {
 	var journal models.Journal
 
 	query := `
-		SELECT j.id, j.uid, j.user_id, j.title, j.text, j.mood_id, m.label AS mood_label,  j.created_at, j.updated_at
+		SELECT j.id, j.uid, j.user_id, j.title, j.text, j.mood_id, m.label AS mood_label, j.version, j.status, j.entry_date, j.created_at, j.updated_at
 		FROM journals j
 		JOIN moods m ON m.id = j.mood_id
 		WHERE uid = $1
 	`
 
 	err := j.pool.QueryRow(ctx, query, uid).
-		Scan(&journal.ID, &journal.Uid, &journal.UserID, &journal.Title, &journal.Text, &journal.MoodID, &journal.MoodLabel, &journal.CreatedAt, &journal.UpdatedAt)
+		Scan(&journal.ID, &journal.Uid, &journal.UserID, &journal.Title, &journal.Text, &journal.MoodID, &journal.MoodLabel, &journal.Version, &journal.Status, &journal.EntryDate, &journal.CreatedAt, &journal.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, sql.ErrNoRows
@@ -66,19 +100,76 @@ func (j *journal) GetByID(ctx context.Context, uid string) (*models.Journal, err
 		return nil, err
 	}
 
+	journal.Moods, err = j.getMoods(ctx, journal.ID, journal.MoodID)
+	if err != nil {
+		return nil, err
+	}
+
 	return &journal, nil
 }
 
-func (j *journal) GetListByUserID(ctx context.Context, userID int64) ([]models.Journal, error) {
+// getMoods lists the moods of a journal, primary mood first and the rest by
+// falling intensity.
+func (j *journal) getMoods(ctx context.Context, journalID, primary int64) ([]models.JournalMood, error) {
+	var moods []models.JournalMood
+
+	query := `
+		SELECT jm.mood_id, m.label, jm.intensity
+		FROM journal_moods jm
+		JOIN moods m ON m.id = jm.mood_id
+		WHERE jm.journal_id = $1
+		ORDER BY jm.mood_id = $2 DESC, jm.intensity DESC, jm.mood_id
+	`
+
+	rows, err := j.pool.Query(ctx, query, journalID, primary)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m models.JournalMood
+		if err := rows.Scan(&m.MoodID, &m.MoodLabel, &m.Intensity); err != nil {
+			return nil, err
+		}
+		moods = append(moods, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return moods, nil
+}
+
+func (j *journal) GetListByUserID(ctx context.Context, userID int64, filter *models.JournalFilter) ([]models.Journal, error) {
 	var journals []models.Journal
 
 	query := `
-		SELECT j.id, j.uid, j.title, j.text, j.created_at, j.updated_at
+		SELECT j.id, j.uid, j.title, j.text, j.version, j.status, j.entry_date, j.created_at, j.updated_at,
+			c.id, c.url, c.storage_key, c.alt_text
 		FROM journals j
-		WHERE user_id = $1
+		LEFT JOIN LATERAL (
+			SELECT p.id, b.url, COALESCE(v.storage_key, NULLIF(b.storage_key, '')) AS storage_key, p.alt_text
+			FROM photos p
+			JOIN photo_blobs b ON b.id = p.blob_id
+			LEFT JOIN photo_variants v ON v.blob_id = b.id AND v.name = 'medium'
+			WHERE p.journal_id = j.id AND b.media_type = 'image'
+			ORDER BY p.cover DESC, p.position, p.id
+			LIMIT 1
+		) c ON true
+		WHERE j.user_id = $1
+			AND j.status = $2
+			AND ($3::date IS NULL OR j.entry_date >= $3)
+			AND ($4::date IS NULL OR j.entry_date <= $4)
+			AND ($5::bigint IS NULL OR EXISTS (
+				SELECT 1 FROM journal_moods jm
+				WHERE jm.journal_id = j.id AND jm.mood_id = $5 AND jm.intensity >= $6
+			))
+		ORDER BY j.entry_date, j.id
 	`
 
-	rows, err := j.pool.Query(ctx, query, userID)
+	rows, err := j.pool.Query(ctx, query, userID, filter.Status, filter.From, filter.To, filter.MoodID, filter.MinIntensity)
 	if err != nil {
 		return nil, err
 	}
@@ -86,10 +177,18 @@ func (j *journal) GetListByUserID(ctx context.Context, userID int64) ([]models.J
 
 	for rows.Next() {
 		var j models.Journal
-		err := rows.Scan(&j.ID, &j.Uid, &j.Title, &j.Text, &j.CreatedAt, &j.UpdatedAt)
+		var coverID *int64
+		var coverUrl *string
+		var cover models.JournalCover
+		err := rows.Scan(&j.ID, &j.Uid, &j.Title, &j.Text, &j.Version, &j.Status, &j.EntryDate, &j.CreatedAt, &j.UpdatedAt,
+			&coverID, &coverUrl, &cover.StorageKey, &cover.AltText)
 		if err != nil {
 			return nil, err
 		}
+		if coverID != nil {
+			cover.PhotoID, cover.Url = *coverID, *coverUrl
+			j.Cover = &cover
+		}
 		journals = append(journals, j)
 	}
 
@@ -100,17 +199,407 @@ func (j *journal) GetListByUserID(ctx context.Context, userID int64) ([]models.J
 	return journals, nil
 }
 
+// GetForExport loads the user's published journals with entry dates in
+// [from, to], either bound being optional, oldest first with their moods.
+func (j *journal) GetForExport(ctx context.Context, userID int64, from, to *time.Time) ([]models.Journal, error) {
+	var journals []models.Journal
+
+	query := `
+		SELECT j.id, j.uid, j.user_id, j.title, j.text, j.mood_id, m.label, j.version, j.status, j.entry_date, j.created_at, j.updated_at
+		FROM journals j
+		JOIN moods m ON m.id = j.mood_id
+		WHERE j.user_id = $1
+			AND j.status = 'published'
+			AND ($2::date IS NULL OR j.entry_date >= $2)
+			AND ($3::date IS NULL OR j.entry_date <= $3)
+		ORDER BY j.entry_date, j.id
+	`
+
+	rows, err := j.pool.Query(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	index := make(map[int64]int)
+	var ids []int64
+	for rows.Next() {
+		var journal models.Journal
+		err := rows.Scan(&journal.ID, &journal.Uid, &journal.UserID, &journal.Title, &journal.Text, &journal.MoodID, &journal.MoodLabel,
+			&journal.Version, &journal.Status, &journal.EntryDate, &journal.CreatedAt, &journal.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		index[journal.ID] = len(journals)
+		ids = append(ids, journal.ID)
+		journals = append(journals, journal)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return journals, nil
+	}
+
+	query = `
+		SELECT jm.journal_id, jm.mood_id, m.label, jm.intensity
+		FROM journal_moods jm
+		JOIN journals j ON j.id = jm.journal_id
+		JOIN moods m ON m.id = jm.mood_id
+		WHERE jm.journal_id = ANY($1)
+		ORDER BY jm.journal_id, jm.mood_id = j.mood_id DESC, jm.intensity DESC, jm.mood_id
+	`
+
+	rows, err = j.pool.Query(ctx, query, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var journalID int64
+		var m models.JournalMood
+		if err := rows.Scan(&journalID, &m.MoodID, &m.MoodLabel, &m.Intensity); err != nil {
+			return nil, err
+		}
+		journal := &journals[index[journalID]]
+		journal.Moods = append(journal.Moods, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return journals, nil
+}
+
+// GetCalendar summarises published journals and mood check-ins per entry
+// date in [from, to). Count only covers journals. Every journal and check-in
+// weighs one, split across a journal's moods by intensity, and the dominant
+// mood is the label with the most weight that day, ties broken by label.
+func (j *journal) GetCalendar(ctx context.Context, userID int64, from, to time.Time) ([]models.CalendarDay, error) {
+	var days []models.CalendarDay
+
+	query := `
+		WITH entries AS (
+			SELECT id, uid, entry_date
+			FROM journals
+			WHERE user_id = $1
+				AND status = 'published'
+				AND entry_date >= $2
+				AND entry_date < $3
+		),
+		checkins AS (
+			SELECT mood_id, entry_date
+			FROM mood_checkins
+			WHERE user_id = $1
+				AND entry_date >= $2
+				AND entry_date < $3
+		),
+		weights AS (
+			SELECT e.entry_date, jm.mood_id, jm.intensity::float8 / SUM(jm.intensity) OVER (PARTITION BY e.id) AS weight
+			FROM entries e
+			JOIN journal_moods jm ON jm.journal_id = e.id
+			UNION ALL
+			SELECT entry_date, mood_id, 1
+			FROM checkins
+		),
+		dominant AS (
+			SELECT DISTINCT ON (w.entry_date) w.entry_date, m.label
+			FROM weights w
+			JOIN moods m ON m.id = w.mood_id
+			GROUP BY w.entry_date, m.label
+			ORDER BY w.entry_date, SUM(w.weight) DESC, m.label
+		)
+		SELECT d.entry_date,
+			(SELECT COUNT(*) FROM entries e WHERE e.entry_date = d.entry_date),
+			d.label,
+			ARRAY(SELECT e.uid::text FROM entries e WHERE e.entry_date = d.entry_date ORDER BY e.id),
+			(SELECT COUNT(*) FROM checkins c WHERE c.entry_date = d.entry_date)
+		FROM dominant d
+		ORDER BY d.entry_date
+	`
+
+	rows, err := j.pool.Query(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d models.CalendarDay
+		if err := rows.Scan(&d.Date, &d.Count, &d.DominantMood, &d.JournalUids, &d.CheckinCount); err != nil {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return days, nil
+}
+
+// GetOnThisDay returns published journals written on the same month and day
+// as date in earlier years. On Feb 28 of a non-leap year, entries from Feb 29
+// are included as well so leap-day memories still resurface.
+func (j *journal) GetOnThisDay(ctx context.Context, userID int64, date time.Time) ([]models.Memory, error) {
+	var memories []models.Memory
+
+	year, month, day := date.Date()
+	leapDay := month == time.February && day == 28 && time.Date(year, time.February, 29, 0, 0, 0, 0, time.UTC).Day() != 29
+
+	query := `
+		SELECT j.uid, j.title, m.label, j.entry_date, p.url, p.storage_key
+		FROM journals j
+		JOIN moods m ON m.id = j.mood_id
+		LEFT JOIN LATERAL (
+			SELECT b.url, COALESCE(v.storage_key, NULLIF(b.storage_key, '')) AS storage_key
+			FROM photos p
+			JOIN photo_blobs b ON b.id = p.blob_id
+			LEFT JOIN photo_variants v ON v.blob_id = b.id AND v.name = 'small'
+			WHERE p.journal_id = j.id AND b.media_type = 'image'
+			ORDER BY p.cover DESC, p.position, p.id
+			LIMIT 1
+		) p ON true
+		WHERE j.user_id = $1
+			AND j.status = 'published'
+			AND EXTRACT(YEAR FROM j.entry_date) < $2
+			AND (
+				(EXTRACT(MONTH FROM j.entry_date) = $3 AND EXTRACT(DAY FROM j.entry_date) = $4)
+				OR ($5 AND EXTRACT(MONTH FROM j.entry_date) = 2 AND EXTRACT(DAY FROM j.entry_date) = 29)
+			)
+		ORDER BY j.entry_date DESC, j.id
+	`
+
+	rows, err := j.pool.Query(ctx, query, userID, year, int(month), day, leapDay)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m models.Memory
+		if err := rows.Scan(&m.Uid, &m.Title, &m.MoodLabel, &m.EntryDate, &m.PhotoUrl, &m.PhotoKey); err != nil {
+			return nil, err
+		}
+		memories = append(memories, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return memories, nil
+}
+
 func (j *journal) Update(ctx context.Context, journal *models.Journal) error {
+	tx, err := j.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	seq, err := nextChangeSeq(ctx, tx, journal.Uid)
+	if err != nil {
+		return err
+	}
+
+	journalID, err := lockVersion(ctx, tx, journal.Uid, journal.Version)
+	if err != nil {
+		return err
+	}
+
+	if err := saveRevision(ctx, tx, journalID); err != nil {
+		return err
+	}
+
+	if err := setMoods(ctx, tx, journalID, journal.MoodID, journal.Moods); err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE journals
 		SET title = $1,
 			text = $2,
 			mood_id = $3,
-			updated_at = $4
-		WHERE uid = $5
+			entry_date = $4,
+			updated_at = $5,
+			version = version + 1,
+			change_seq = $6
+		WHERE id = $7
+		RETURNING version, updated_at
 	`
 
-	result, err := j.pool.Exec(ctx, query, journal.Title, journal.Text, journal.MoodID, time.Now().UTC(), journal.Uid)
+	err = tx.QueryRow(ctx, query, journal.Title, journal.Text, journal.MoodID, journal.EntryDate, time.Now().UTC(), seq, journalID).
+		Scan(&journal.Version, &journal.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (j *journal) Patch(ctx context.Context, uid string, version int64, patch *models.JournalPatch) error {
+	var sets []string
+	var args []any
+	set := func(column string, value any) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if patch.Title != nil {
+		set("title", *patch.Title)
+	}
+	if patch.Text != nil {
+		set("text", *patch.Text)
+	}
+	if patch.MoodID != nil {
+		set("mood_id", *patch.MoodID)
+	}
+	if patch.EntryDate != nil {
+		set("entry_date", *patch.EntryDate)
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+	set("updated_at", time.Now().UTC())
+
+	tx, err := j.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	seq, err := nextChangeSeq(ctx, tx, uid)
+	if err != nil {
+		return err
+	}
+	set("change_seq", seq)
+
+	journalID, err := lockVersion(ctx, tx, uid, version)
+	if err != nil {
+		return err
+	}
+
+	if err := saveRevision(ctx, tx, journalID); err != nil {
+		return err
+	}
+
+	if patch.MoodID != nil {
+		if err := setMoods(ctx, tx, journalID, *patch.MoodID, patch.Moods); err != nil {
+			return err
+		}
+	}
+
+	args = append(args, journalID)
+	query := fmt.Sprintf(`
+		UPDATE journals
+		SET %s,
+			version = version + 1
+		WHERE id = $%d
+	`, strings.Join(sets, ", "), len(args))
+
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Autosave writes editor content straight into a draft. It skips revisions
+// but bumps the version like any other write, so a client still holding the
+// old ETag can't overwrite the autosaved text; savedAt orders concurrent
+// autosaves so a late write can't replace a newer one.
+func (j *journal) Autosave(ctx context.Context, uid string, patch *models.JournalPatch, savedAt time.Time) error {
+	tx, err := j.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// The owner is locked first to keep the lock order of nextChangeSeq, but
+	// the sequence is only taken once the draft is known to need the write.
+	if err := lockOwner(ctx, tx, uid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	var journalID int64
+	query := `
+		SELECT id
+		FROM journals
+		WHERE uid = $1
+			AND status = 'draft'
+			AND (autosaved_at IS NULL OR autosaved_at < $2)
+		FOR UPDATE
+	`
+	if err := tx.QueryRow(ctx, query, uid, savedAt.UTC()).Scan(&journalID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	seq, err := nextChangeSeq(ctx, tx, uid)
+	if err != nil {
+		return err
+	}
+
+	if patch.MoodID != nil {
+		if err := setMoods(ctx, tx, journalID, *patch.MoodID, patch.Moods); err != nil {
+			return err
+		}
+	}
+
+	query = `
+		UPDATE journals
+		SET title = COALESCE($1, title),
+			text = COALESCE($2, text),
+			mood_id = COALESCE($3, mood_id),
+			entry_date = COALESCE($4, entry_date),
+			autosaved_at = $5,
+			updated_at = $5,
+			version = version + 1,
+			change_seq = $6
+		WHERE id = $7
+	`
+
+	_, err = tx.Exec(ctx, query, patch.Title, patch.Text, patch.MoodID, patch.EntryDate, savedAt.UTC(), seq, journalID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (j *journal) Publish(ctx context.Context, uid string) error {
+	tx, err := j.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	seq, err := nextChangeSeq(ctx, tx, uid)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE journals
+		SET status = 'published',
+			updated_at = $1,
+			version = version + 1,
+			change_seq = $2
+		WHERE uid = $3 AND status = 'draft'
+	`
+
+	result, err := tx.Exec(ctx, query, time.Now().UTC(), seq, uid)
 	if err != nil {
 		return err
 	}
@@ -119,5 +608,315 @@ func (j *journal) Update(ctx context.Context, journal *models.Journal) error {
 		return sql.ErrNoRows
 	}
 
-	return nil
+	return tx.Commit(ctx)
+}
+
+func (j *journal) GetRevisions(ctx context.Context, journalID int64) ([]models.JournalRevision, error) {
+	var revisions []models.JournalRevision
+
+	query := `
+		SELECT r.id, r.journal_id, r.revision, r.title, r.text, r.mood_id, m.label AS mood_label, r.entry_date, r.created_at
+		FROM journal_revisions r
+		JOIN moods m ON m.id = r.mood_id
+		WHERE r.journal_id = $1
+		ORDER BY r.revision DESC
+	`
+
+	rows, err := j.pool.Query(ctx, query, journalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r models.JournalRevision
+		err := rows.Scan(&r.ID, &r.JournalID, &r.Revision, &r.Title, &r.Text, &r.MoodID, &r.MoodLabel, &r.EntryDate, &r.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+func (j *journal) GetRevision(ctx context.Context, journalID int64, revision int) (*models.JournalRevision, error) {
+	var r models.JournalRevision
+
+	query := `
+		SELECT r.id, r.journal_id, r.revision, r.title, r.text, r.mood_id, m.label AS mood_label, r.entry_date, r.created_at
+		FROM journal_revisions r
+		JOIN moods m ON m.id = r.mood_id
+		WHERE r.journal_id = $1 AND r.revision = $2
+	`
+
+	err := j.pool.QueryRow(ctx, query, journalID, revision).
+		Scan(&r.ID, &r.JournalID, &r.Revision, &r.Title, &r.Text, &r.MoodID, &r.MoodLabel, &r.EntryDate, &r.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+
+	r.Moods, err = j.getRevisionMoods(ctx, r.ID, r.MoodID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// getRevisionMoods lists the moods a revision recorded in the order of
+// getMoods. Revisions saved before moods were recorded have none.
+func (j *journal) getRevisionMoods(ctx context.Context, revisionID, primary int64) ([]models.JournalMood, error) {
+	var moods []models.JournalMood
+
+	query := `
+		SELECT rm.mood_id, m.label, rm.intensity
+		FROM journal_revision_moods rm
+		JOIN moods m ON m.id = rm.mood_id
+		WHERE rm.revision_id = $1
+		ORDER BY rm.mood_id = $2 DESC, rm.intensity DESC, rm.mood_id
+	`
+
+	rows, err := j.pool.Query(ctx, query, revisionID, primary)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m models.JournalMood
+		if err := rows.Scan(&m.MoodID, &m.MoodLabel, &m.Intensity); err != nil {
+			return nil, err
+		}
+		moods = append(moods, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return moods, nil
+}
+
+// nextChangeSeq bumps the sync counter of the journal owner. The users row
+// stays locked until commit, so change sequences are committed in order and
+// it must be taken before any journal row lock.
+func nextChangeSeq(ctx context.Context, tx pgx.Tx, uid string) (int64, error) {
+	query := `
+		UPDATE users
+		SET change_seq = change_seq + 1
+		WHERE id = (SELECT user_id FROM journals WHERE uid = $1)
+		RETURNING change_seq
+	`
+
+	var seq int64
+	err := tx.QueryRow(ctx, query, uid).Scan(&seq)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, sql.ErrNoRows
+		}
+		return 0, err
+	}
+
+	return seq, nil
+}
+
+// lockOwner locks the users row of the journal owner without bumping its
+// change sequence, for writes that only take one once they know they apply.
+func lockOwner(ctx context.Context, tx pgx.Tx, uid string) error {
+	query := `
+		SELECT id
+		FROM users
+		WHERE id = (SELECT user_id FROM journals WHERE uid = $1)
+		FOR UPDATE
+	`
+
+	var userID int64
+	return tx.QueryRow(ctx, query, uid).Scan(&userID)
+}
+
+// lockVersion locks the journal row for the rest of the transaction and
+// checks it is still at the expected version. A zero version skips the check.
+func lockVersion(ctx context.Context, tx pgx.Tx, uid string, version int64) (int64, error) {
+	var journalID, current int64
+	err := tx.QueryRow(ctx, `SELECT id, version FROM journals WHERE uid = $1 FOR UPDATE`, uid).Scan(&journalID, &current)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, sql.ErrNoRows
+		}
+		return 0, err
+	}
+
+	if version != 0 && version != current {
+		return 0, domain.ErrVersionMismatch
+	}
+
+	return journalID, nil
+}
+
+// saveRevision copies the current content of a locked journal, its entry
+// date and moods included, into journal_revisions so the caller can overwrite
+// it in the same transaction.
+func saveRevision(ctx context.Context, tx pgx.Tx, journalID int64) error {
+	query := `
+		INSERT INTO journal_revisions (journal_id, revision, title, text, mood_id, entry_date)
+		SELECT j.id,
+			COALESCE((SELECT MAX(r.revision) FROM journal_revisions r WHERE r.journal_id = j.id), 0) + 1,
+			j.title, j.text, j.mood_id, j.entry_date
+		FROM journals j
+		WHERE j.id = $1
+		RETURNING id
+	`
+
+	var revisionID int64
+	if err := tx.QueryRow(ctx, query, journalID).Scan(&revisionID); err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO journal_revision_moods (revision_id, mood_id, intensity)
+		SELECT $1, mood_id, intensity
+		FROM journal_moods
+		WHERE journal_id = $2
+	`
+
+	_, err := tx.Exec(ctx, query, revisionID, journalID)
+	return err
+}
+
+// setMoods writes the moods of a locked journal whose primary mood is about
+// to become primary, so it must run before journals.mood_id is updated. A nil
+// list keeps the other moods and only swaps the old primary mood for the new
+// one, which is what clients that only send mood_id expect.
+func setMoods(ctx context.Context, tx pgx.Tx, journalID, primary int64, moods []models.JournalMood) error {
+	if moods == nil {
+		query := `
+			DELETE FROM journal_moods jm
+			USING journals j
+			WHERE j.id = $1
+				AND jm.journal_id = j.id
+				AND jm.mood_id = j.mood_id
+				AND j.mood_id <> $2
+				AND EXISTS (SELECT 1 FROM journal_moods x WHERE x.journal_id = j.id AND x.mood_id = $2)
+		`
+		if _, err := tx.Exec(ctx, query, journalID, primary); err != nil {
+			return err
+		}
+
+		query = `
+			UPDATE journal_moods jm
+			SET mood_id = $2
+			FROM journals j
+			WHERE j.id = $1
+				AND jm.journal_id = j.id
+				AND jm.mood_id = j.mood_id
+		`
+		_, err := tx.Exec(ctx, query, journalID, primary)
+		return err
+	}
+
+	ids := make([]int64, 0, len(moods))
+	intensities := make([]int32, 0, len(moods))
+	for _, m := range moods {
+		ids = append(ids, m.MoodID)
+		intensities = append(intensities, int32(m.Intensity))
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM journal_moods WHERE journal_id = $1`, journalID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO journal_moods (journal_id, mood_id, intensity)
+		SELECT $1, m.mood_id, m.intensity
+		FROM unnest($2::bigint[], $3::int[]) AS m(mood_id, intensity)
+	`
+	_, err := tx.Exec(ctx, query, journalID, ids, intensities)
+	return err
+}
+
+// deleteWithTombstone removes a locked journal and leaves a tombstone so
+// syncing clients learn about the deletion. The journal's photos go with it:
+// blobs no other photo shares are deleted and their space is given back to
+// the owner's quota, while their stored objects are left to the photo GC.
+func deleteWithTombstone(ctx context.Context, tx pgx.Tx, journalID, seq int64) error {
+	query := `
+		INSERT INTO journal_tombstones (uid, user_id, change_seq)
+		SELECT uid, user_id, $2 FROM journals WHERE id = $1
+		RETURNING user_id
+	`
+
+	var userID int64
+	if err := tx.QueryRow(ctx, query, journalID, seq).Scan(&userID); err != nil {
+		return err
+	}
+
+	if err := deletePhotos(ctx, tx, userID, journalID); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(ctx, `DELETE FROM journals WHERE id = $1`, journalID)
+	return err
+}
+
+// deletePhotos removes the photos of a journal being deleted and drops their
+// blob references.
+func deletePhotos(ctx context.Context, tx pgx.Tx, userID, journalID int64) error {
+	query := `
+		WITH deleted AS (
+			DELETE FROM photos WHERE journal_id = $1 RETURNING blob_id
+		)
+		UPDATE photo_blobs b
+		SET ref_count = b.ref_count - d.count
+		FROM (SELECT blob_id, COUNT(*) AS count FROM deleted GROUP BY blob_id) d
+		WHERE b.id = d.blob_id
+		RETURNING b.id, b.ref_count
+	`
+
+	rows, err := tx.Query(ctx, query, journalID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var released []int64
+	for rows.Next() {
+		var blobID int64
+		var refCount int
+		if err := rows.Scan(&blobID, &refCount); err != nil {
+			return err
+		}
+		if refCount <= 0 {
+			released = append(released, blobID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(released) == 0 {
+		return nil
+	}
+
+	var size int64
+	query = `
+		SELECT COALESCE(SUM(b.size + COALESCE((SELECT SUM(v.size) FROM photo_variants v WHERE v.blob_id = b.id), 0)), 0)
+		FROM photo_blobs b
+		WHERE b.id = ANY($1)
+	`
+	if err := tx.QueryRow(ctx, query, released).Scan(&size); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM photo_blobs WHERE id = ANY($1)`, released); err != nil {
+		return err
+	}
+
+	return addStorageUsed(ctx, tx, userID, -size)
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"testing"
+	"time"
+	"timo/domain"
 	"timo/models"
 
 	"github.com/stretchr/testify/assert"
@@ -109,7 +111,7 @@ func TestJournalRepository_Delete(t *testing.T) {
 			repo := NewJournal(testDB)
 
 			if tt.err {
-				err := repo.Delete(ctx, "550e8400-e29b-41d4-a716-446655440000")
+				err := repo.Delete(ctx, "550e8400-e29b-41d4-a716-446655440000", 0)
 
 				assert.Error(t, err)
 				assert.Equal(t, sql.ErrNoRows, err)
@@ -117,7 +119,7 @@ func TestJournalRepository_Delete(t *testing.T) {
 				err := repo.Create(ctx, tt.journal)
 				assert.NoError(t, err)
 
-				err = repo.Delete(ctx, tt.journal.Uid)
+				err = repo.Delete(ctx, tt.journal.Uid, tt.journal.Version)
 				assert.NoError(t, err)
 
 				journal, err := repo.GetByID(ctx, tt.journal.Uid)
@@ -129,6 +131,45 @@ func TestJournalRepository_Delete(t *testing.T) {
 	}
 }
 
+func TestJournalRepository_DeleteWithPhotos(t *testing.T) {
+	ctx := context.Background()
+	repo := NewJournal(testDB)
+	photos := NewPhoto(testDB)
+
+	journal := &models.Journal{UserID: 14, Title: "title test", Text: "text test", MoodID: 1}
+	err := repo.Create(ctx, journal)
+	assert.NoError(t, err)
+
+	// One blob only this journal uses and one it shares with another journal.
+	own := &models.Photo{JournalID: journal.ID, StorageKey: "users/14/photos/own.jpg", Checksum: "own", Size: 100}
+	_, err = photos.Create(ctx, own, 10, 0)
+	assert.NoError(t, err)
+	shared := &models.Photo{JournalID: journal.ID, StorageKey: "users/14/photos/shared.jpg", Checksum: "shared", Size: 50}
+	_, err = photos.Create(ctx, shared, 10, 0)
+	assert.NoError(t, err)
+	kept := &models.Photo{JournalID: 17, BlobID: shared.BlobID}
+	_, err = photos.Create(ctx, kept, 100, 0)
+	assert.NoError(t, err)
+	defer testDB.Exec(ctx, `DELETE FROM photo_blobs WHERE id = $1`, shared.BlobID)
+	defer testDB.Exec(ctx, `DELETE FROM photos WHERE id = $1`, kept.ID)
+
+	_, _ = testDB.Exec(ctx, `UPDATE users SET storage_used = 1000 WHERE id = 14`)
+
+	err = repo.Delete(ctx, journal.Uid, journal.Version)
+	assert.NoError(t, err)
+
+	var left, refCount int
+	var used int64
+	_ = testDB.QueryRow(ctx, `SELECT count(*) FROM photos WHERE journal_id = $1`, journal.ID).Scan(&left)
+	assert.Equal(t, 0, left)
+	_ = testDB.QueryRow(ctx, `SELECT count(*) FROM photo_blobs WHERE id = $1`, own.BlobID).Scan(&left)
+	assert.Equal(t, 0, left)
+	_ = testDB.QueryRow(ctx, `SELECT ref_count FROM photo_blobs WHERE id = $1`, shared.BlobID).Scan(&refCount)
+	assert.Equal(t, 1, refCount)
+	_ = testDB.QueryRow(ctx, `SELECT storage_used FROM users WHERE id = 14`).Scan(&used)
+	assert.Equal(t, int64(900), used)
+}
+
 func TestJournalRepository_GetListByUserID(t *testing.T) {
 	ctx := context.Background()
 	repo := NewJournal(testDB)
@@ -146,10 +187,250 @@ func TestJournalRepository_GetListByUserID(t *testing.T) {
 		assert.NoError(t, err)
 	}
 
-	journals, err := repo.GetListByUserID(ctx, 14)
+	journals, err := repo.GetListByUserID(ctx, 14, &models.JournalFilter{Status: models.JOURNAL_PUBLISHED})
 
 	assert.NoError(t, err)
 	for i, j := range journals {
 		assert.Equal(t, listText[i], j.Text)
 	}
 }
+
+func TestJournalRepository_GetRevisions(t *testing.T) {
+	ctx := context.Background()
+	repo := NewJournal(testDB)
+
+	journal := &models.Journal{UserID: 14, Title: "title v1", Text: "text v1", MoodID: 1}
+	err := repo.Create(ctx, journal)
+	assert.NoError(t, err)
+
+	for _, title := range []string{"title v2", "title v3"} {
+		journal.Title = title
+		err = repo.Update(ctx, journal)
+		assert.NoError(t, err)
+	}
+
+	revisions, err := repo.GetRevisions(ctx, journal.ID)
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 2)
+	assert.Equal(t, 2, revisions[0].Revision)
+	assert.Equal(t, "title v2", revisions[0].Title)
+	assert.Equal(t, "title v1", revisions[1].Title)
+
+	revision, err := repo.GetRevision(ctx, journal.ID, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "title v1", revision.Title)
+
+	_, _ = testDB.Exec(ctx, `DELETE FROM journals WHERE id = $1`, journal.ID)
+}
+
+func TestJournalRepository_RevisionMoods(t *testing.T) {
+	ctx := context.Background()
+	repo := NewJournal(testDB)
+
+	entryDate := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	journal := &models.Journal{
+		UserID:    14,
+		Title:     "title v1",
+		Text:      "text v1",
+		MoodID:    1,
+		Moods:     []models.JournalMood{{MoodID: 1, Intensity: 2}, {MoodID: 5, Intensity: 4}},
+		EntryDate: entryDate,
+	}
+	err := repo.Create(ctx, journal)
+	assert.NoError(t, err)
+
+	journal.MoodID = 2
+	journal.Moods = []models.JournalMood{{MoodID: 2, Intensity: 3}}
+	journal.EntryDate = entryDate.AddDate(0, 0, 1)
+	err = repo.Update(ctx, journal)
+	assert.NoError(t, err)
+
+	revision, err := repo.GetRevision(ctx, journal.ID, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), revision.MoodID)
+	if assert.NotNil(t, revision.EntryDate) {
+		assert.True(t, entryDate.Equal(*revision.EntryDate))
+	}
+	if assert.Len(t, revision.Moods, 2) {
+		assert.Equal(t, int64(1), revision.Moods[0].MoodID)
+		assert.Equal(t, 2, revision.Moods[0].Intensity)
+		assert.Equal(t, int64(5), revision.Moods[1].MoodID)
+		assert.Equal(t, 4, revision.Moods[1].Intensity)
+	}
+
+	_, _ = testDB.Exec(ctx, `DELETE FROM journals WHERE id = $1`, journal.ID)
+}
+
+func TestJournalRepository_UpdateVersionMismatch(t *testing.T) {
+	ctx := context.Background()
+	repo := NewJournal(testDB)
+
+	journal := &models.Journal{UserID: 14, Title: "title test", Text: "text test", MoodID: 1}
+	err := repo.Create(ctx, journal)
+	assert.NoError(t, err)
+
+	stale := *journal
+	journal.Title = "title update"
+	err = repo.Update(ctx, journal)
+	assert.NoError(t, err)
+	assert.Equal(t, stale.Version+1, journal.Version)
+
+	stale.Title = "stale update"
+	err = repo.Update(ctx, &stale)
+	assert.ErrorIs(t, err, domain.ErrVersionMismatch)
+
+	err = repo.Delete(ctx, journal.Uid, stale.Version)
+	assert.ErrorIs(t, err, domain.ErrVersionMismatch)
+
+	_, _ = testDB.Exec(ctx, `DELETE FROM journals WHERE id = $1`, journal.ID)
+}
+
+func TestJournalRepository_Patch(t *testing.T) {
+	ctx := context.Background()
+	repo := NewJournal(testDB)
+
+	journal := &models.Journal{UserID: 14, Title: "title test", Text: "text test", MoodID: 1}
+	err := repo.Create(ctx, journal)
+	assert.NoError(t, err)
+
+	moodID := int64(2)
+	err = repo.Patch(ctx, journal.Uid, journal.Version, &models.JournalPatch{MoodID: &moodID})
+	assert.NoError(t, err)
+
+	patched, err := repo.GetByID(ctx, journal.Uid)
+	assert.NoError(t, err)
+	assert.Equal(t, moodID, patched.MoodID)
+	assert.Equal(t, journal.Title, patched.Title)
+	assert.Equal(t, journal.Text, patched.Text)
+	assert.Equal(t, journal.Version+1, patched.Version)
+
+	_, _ = testDB.Exec(ctx, `DELETE FROM journals WHERE id = $1`, journal.ID)
+}
+
+func TestJournalRepository_AutosaveAndPublish(t *testing.T) {
+	ctx := context.Background()
+	repo := NewJournal(testDB)
+
+	draft := &models.Journal{UserID: 14, Title: "draft title", Text: "draft text", MoodID: 1, Status: models.JOURNAL_DRAFT}
+	err := repo.Create(ctx, draft)
+	assert.NoError(t, err)
+	assert.Equal(t, models.JOURNAL_DRAFT, draft.Status)
+
+	newer := time.Now()
+	text := "autosaved text"
+	err = repo.Autosave(ctx, draft.Uid, &models.JournalPatch{Text: &text}, newer)
+	assert.NoError(t, err)
+
+	stale := "stale text"
+	err = repo.Autosave(ctx, draft.Uid, &models.JournalPatch{Text: &stale}, newer.Add(-time.Second))
+	assert.NoError(t, err)
+
+	journal, err := repo.GetByID(ctx, draft.Uid)
+	assert.NoError(t, err)
+	assert.Equal(t, text, journal.Text)
+	assert.Equal(t, draft.Version+1, journal.Version)
+
+	revisions, err := repo.GetRevisions(ctx, draft.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, revisions)
+
+	err = repo.Publish(ctx, draft.Uid)
+	assert.NoError(t, err)
+
+	err = repo.Publish(ctx, draft.Uid)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	_, _ = testDB.Exec(ctx, `DELETE FROM journals WHERE id = $1`, draft.ID)
+}
+
+func TestJournalRepository_GetCalendar(t *testing.T) {
+	ctx := context.Background()
+	repo := NewJournal(testDB)
+
+	day := time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)
+	var ids []int64
+	for _, moodID := range []int64{1, 1, 5} {
+		journal := &models.Journal{UserID: 14, Title: "title test", Text: "text test", MoodID: moodID, EntryDate: day}
+		err := repo.Create(ctx, journal)
+		assert.NoError(t, err)
+		ids = append(ids, journal.ID)
+	}
+
+	from := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+	days, err := repo.GetCalendar(ctx, 14, from, from.AddDate(0, 1, 0))
+
+	assert.NoError(t, err)
+	assert.Len(t, days, 1)
+	assert.Equal(t, day, days[0].Date)
+	assert.Equal(t, 3, days[0].Count)
+	assert.Equal(t, "happy", days[0].DominantMood)
+	assert.Len(t, days[0].JournalUids, 3)
+
+	_, _ = testDB.Exec(ctx, `DELETE FROM journals WHERE id = ANY($1)`, ids)
+}
+
+func TestJournalRepository_GetOnThisDay(t *testing.T) {
+	ctx := context.Background()
+	repo := NewJournal(testDB)
+
+	leapDay := &models.Journal{UserID: 14, Title: "leap day", Text: "text test", MoodID: 1, EntryDate: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)}
+	err := repo.Create(ctx, leapDay)
+	assert.NoError(t, err)
+
+	memories, err := repo.GetOnThisDay(ctx, 14, time.Date(2025, time.February, 28, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Contains(t, memories, models.Memory{Uid: leapDay.Uid, Title: "leap day", MoodLabel: "happy", EntryDate: leapDay.EntryDate})
+
+	memories, err = repo.GetOnThisDay(ctx, 14, time.Date(2028, time.February, 28, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.NotContains(t, memories, models.Memory{Uid: leapDay.Uid, Title: "leap day", MoodLabel: "happy", EntryDate: leapDay.EntryDate})
+
+	_, _ = testDB.Exec(ctx, `DELETE FROM journals WHERE id = $1`, leapDay.ID)
+}
+
+func TestJournalRepository_Moods(t *testing.T) {
+	ctx := context.Background()
+	repo := NewJournal(testDB)
+
+	journal := &models.Journal{
+		UserID: 14,
+		Title:  "title test",
+		Text:   "text test",
+		MoodID: 1,
+		Moods:  []models.JournalMood{{MoodID: 1, Intensity: 2}, {MoodID: 5, Intensity: 4}},
+	}
+	err := repo.Create(ctx, journal)
+	assert.NoError(t, err)
+
+	got, err := repo.GetByID(ctx, journal.Uid)
+	assert.NoError(t, err)
+	assert.Len(t, got.Moods, 2)
+	assert.Equal(t, int64(1), got.Moods[0].MoodID)
+
+	moodID := int64(5)
+	journals, err := repo.GetListByUserID(ctx, 14, &models.JournalFilter{Status: models.JOURNAL_PUBLISHED, MoodID: &moodID, MinIntensity: 4})
+	assert.NoError(t, err)
+	assert.Contains(t, uids(journals), journal.Uid)
+
+	// Swapping only the primary mood keeps the secondary one.
+	primary := int64(2)
+	err = repo.Patch(ctx, journal.Uid, got.Version, &models.JournalPatch{MoodID: &primary})
+	assert.NoError(t, err)
+
+	got, err = repo.GetByID(ctx, journal.Uid)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.JournalMood{
+		{MoodID: 2, MoodLabel: got.Moods[0].MoodLabel, Intensity: 2},
+		{MoodID: 5, MoodLabel: got.Moods[1].MoodLabel, Intensity: 4},
+	}, got.Moods)
+
+	_, _ = testDB.Exec(ctx, `DELETE FROM journals WHERE id = $1`, journal.ID)
+}
+
+func uids(journals []models.Journal) []string {
+	var uids []string
+	for _, j := range journals {
+		uids = append(uids, j.Uid)
+	}
+	return uids
+}
@@ -0,0 +1,318 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"timo/domain"
+	"timo/models"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type mood struct {
+	pool *pgxpool.Pool
+}
+
+func NewMood(pool *pgxpool.Pool) domain.MoodRepository {
+	return &mood{pool: pool}
+}
+
+// GetVisible lists the global moods followed by the user's own moods, each in
+// their position order. Labels are translated to the first of locales that
+// has a translation and fall back to the stored label.
+func (m *mood) GetVisible(ctx context.Context, userID int64, includeArchived bool, locales []string) ([]models.Mood, error) {
+	var moods []models.Mood
+
+	query := `
+		SELECT m.id, m.user_id,
+			COALESCE((
+				SELECT l.label
+				FROM mood_labels l
+				WHERE l.mood_id = m.id AND l.locale = ANY($3)
+				ORDER BY array_position($3, l.locale)
+				LIMIT 1
+			), m.label),
+			m.emoji, m.color, m.valence, m.energy, m.position, m.archived_at, m.created_at
+		FROM moods m
+		WHERE (m.user_id IS NULL OR m.user_id = $1)
+			AND ($2 OR m.archived_at IS NULL)
+		ORDER BY m.user_id NULLS FIRST, m.position, m.id
+	`
+
+	rows, err := m.pool.Query(ctx, query, userID, includeArchived, locales)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var mood models.Mood
+		err := rows.Scan(&mood.ID, &mood.UserID, &mood.Label, &mood.Emoji, &mood.Color, &mood.Valence, &mood.Energy,
+			&mood.Position, &mood.ArchivedAt, &mood.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		moods = append(moods, mood)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return moods, nil
+}
+
+func (m *mood) GetByID(ctx context.Context, id int64) (*models.Mood, error) {
+	var mood models.Mood
+
+	query := `
+		SELECT id, user_id, label, emoji, color, valence, energy, position, archived_at, created_at
+		FROM moods
+		WHERE id = $1
+	`
+
+	err := m.pool.QueryRow(ctx, query, id).
+		Scan(&mood.ID, &mood.UserID, &mood.Label, &mood.Emoji, &mood.Color, &mood.Valence, &mood.Energy,
+			&mood.Position, &mood.ArchivedAt, &mood.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+
+	return &mood, nil
+}
+
+// CountSelectable counts how many of ids the user may pick for an entry:
+// global or own moods that aren't archived.
+func (m *mood) CountSelectable(ctx context.Context, userID int64, ids []int64) (int, error) {
+	var count int
+
+	query := `
+		SELECT COUNT(*)
+		FROM moods
+		WHERE id = ANY($2)
+			AND (user_id IS NULL OR user_id = $1)
+			AND archived_at IS NULL
+	`
+
+	err := m.pool.QueryRow(ctx, query, userID, ids).Scan(&count)
+	return count, err
+}
+
+// Create adds a custom mood after the user's last one. Its label may match
+// neither another of the user's moods nor a default mood.
+func (m *mood) Create(ctx context.Context, mood *models.Mood) error {
+	taken, err := m.defaultLabel(ctx, 0, mood.Label)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return domain.ErrMoodDuplicate
+	}
+
+	query := `
+		INSERT INTO moods (user_id, label, emoji, color, valence, energy, position)
+		SELECT $1, $2, $3, $4, $5, $6, COALESCE(MAX(position), 0) + 1
+		FROM moods
+		WHERE user_id = $1
+		RETURNING id, position, created_at
+	`
+
+	err = m.pool.QueryRow(ctx, query, mood.UserID, mood.Label, mood.Emoji, mood.Color, mood.Valence, mood.Energy).
+		Scan(&mood.ID, &mood.Position, &mood.CreatedAt)
+	if isUniqueViolation(err) {
+		return domain.ErrMoodDuplicate
+	}
+
+	return err
+}
+
+// Update saves a custom mood. A new label is checked like in Create.
+func (m *mood) Update(ctx context.Context, mood *models.Mood) error {
+	taken, err := m.defaultLabel(ctx, mood.ID, mood.Label)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return domain.ErrMoodDuplicate
+	}
+
+	query := `
+		UPDATE moods
+		SET label = $2, emoji = $3, color = $4, valence = $5, energy = $6, archived_at = $7
+		WHERE id = $1
+	`
+
+	result, err := m.pool.Exec(ctx, query, mood.ID, mood.Label, mood.Emoji, mood.Color, mood.Valence, mood.Energy, mood.ArchivedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrMoodDuplicate
+		}
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Reorder sets the position of each of the user's moods to its index in ids.
+func (m *mood) Reorder(ctx context.Context, userID int64, ids []int64) error {
+	query := `
+		UPDATE moods m
+		SET position = o.position
+		FROM unnest($2::bigint[]) WITH ORDINALITY AS o(id, position)
+		WHERE m.id = o.id AND m.user_id = $1
+	`
+
+	_, err := m.pool.Exec(ctx, query, userID, ids)
+	return err
+}
+
+// Delete removes a mood, first moving every journal and revision that uses it
+// to reassignTo. Journals that move get a new version and change sequence so
+// syncing clients pick the change up. A zero reassignTo only succeeds when
+// the mood is unused.
+func (m *mood) Delete(ctx context.Context, id, reassignTo int64) error {
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the owner's row before the mood, the same order journal writes
+	// take through the mood foreign key.
+	query := `
+		UPDATE users
+		SET change_seq = change_seq + 1
+		WHERE id = (SELECT user_id FROM moods WHERE id = $1)
+		RETURNING change_seq
+	`
+
+	var seq int64
+	if err := tx.QueryRow(ctx, query, id).Scan(&seq); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.ErrNoRows
+		}
+		return err
+	}
+
+	var inJournals, inRevisions, inCheckins bool
+	query = `
+		SELECT EXISTS (SELECT 1 FROM journal_moods WHERE mood_id = m.id),
+			EXISTS (SELECT 1 FROM journal_revisions WHERE mood_id = m.id)
+				OR EXISTS (SELECT 1 FROM journal_revision_moods WHERE mood_id = m.id),
+			EXISTS (SELECT 1 FROM mood_checkins WHERE mood_id = m.id)
+		FROM moods m
+		WHERE m.id = $1
+		FOR UPDATE
+	`
+	if err := tx.QueryRow(ctx, query, id).Scan(&inJournals, &inRevisions, &inCheckins); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.ErrNoRows
+		}
+		return err
+	}
+
+	if (inJournals || inRevisions || inCheckins) && reassignTo == 0 {
+		return domain.ErrMoodInUse
+	}
+
+	if inJournals {
+		query = `
+			UPDATE journals
+			SET mood_id = CASE WHEN mood_id = $1 THEN $2 ELSE mood_id END,
+				version = version + 1,
+				change_seq = $3,
+				updated_at = now()
+			WHERE id IN (SELECT journal_id FROM journal_moods WHERE mood_id = $1)
+		`
+		if _, err := tx.Exec(ctx, query, id, reassignTo, seq); err != nil {
+			return err
+		}
+
+		// A journal that already has the replacement keeps its own intensity.
+		query = `
+			DELETE FROM journal_moods jm
+			WHERE jm.mood_id = $1
+				AND EXISTS (SELECT 1 FROM journal_moods x WHERE x.journal_id = jm.journal_id AND x.mood_id = $2)
+		`
+		if _, err := tx.Exec(ctx, query, id, reassignTo); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, `UPDATE journal_moods SET mood_id = $2 WHERE mood_id = $1`, id, reassignTo); err != nil {
+			return err
+		}
+	}
+
+	if inRevisions {
+		if _, err := tx.Exec(ctx, `UPDATE journal_revisions SET mood_id = $2 WHERE mood_id = $1`, id, reassignTo); err != nil {
+			return err
+		}
+
+		query = `
+			DELETE FROM journal_revision_moods rm
+			WHERE rm.mood_id = $1
+				AND EXISTS (SELECT 1 FROM journal_revision_moods x WHERE x.revision_id = rm.revision_id AND x.mood_id = $2)
+		`
+		if _, err := tx.Exec(ctx, query, id, reassignTo); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, `UPDATE journal_revision_moods SET mood_id = $2 WHERE mood_id = $1`, id, reassignTo); err != nil {
+			return err
+		}
+	}
+
+	if inCheckins {
+		query = `
+			UPDATE mood_checkins
+			SET mood_id = $2, updated_at = now()
+			WHERE mood_id = $1
+		`
+		if _, err := tx.Exec(ctx, query, id, reassignTo); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM moods WHERE id = $1`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// defaultLabel reports whether label is the label of a default mood in any of
+// its translations, unless the mood with id already has it. The unique index
+// only covers labels of one owner; default moods only change in migrations,
+// so checking ahead of the write is enough.
+func (m *mood) defaultLabel(ctx context.Context, id int64, label string) (bool, error) {
+	var taken bool
+
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM moods d
+			WHERE d.user_id IS NULL
+				AND (lower(d.label) = lower($2)
+					OR EXISTS (SELECT 1 FROM mood_labels l WHERE l.mood_id = d.id AND lower(l.label) = lower($2)))
+		) AND NOT EXISTS (
+			SELECT 1 FROM moods WHERE id = $1 AND lower(label) = lower($2)
+		)
+	`
+
+	err := m.pool.QueryRow(ctx, query, id, label).Scan(&taken)
+	return taken, err
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
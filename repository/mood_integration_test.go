@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"timo/domain"
+	"timo/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoodRepository_DeleteWithReassign(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMood(testDB)
+	journals := NewJournal(testDB)
+
+	var userID int64 = 14
+	mood := &models.Mood{UserID: &userID, Label: "integration mood"}
+	err := repo.Create(ctx, mood)
+	assert.NoError(t, err)
+
+	err = repo.Create(ctx, &models.Mood{UserID: &userID, Label: "Integration Mood"})
+	assert.ErrorIs(t, err, domain.ErrMoodDuplicate)
+
+	// Default moods are taken under their label and its translations.
+	err = repo.Create(ctx, &models.Mood{UserID: &userID, Label: "Happy"})
+	assert.ErrorIs(t, err, domain.ErrMoodDuplicate)
+	err = repo.Create(ctx, &models.Mood{UserID: &userID, Label: "senang"})
+	assert.ErrorIs(t, err, domain.ErrMoodDuplicate)
+
+	renamed := *mood
+	renamed.Label = "sad"
+	err = repo.Update(ctx, &renamed)
+	assert.ErrorIs(t, err, domain.ErrMoodDuplicate)
+	err = repo.Update(ctx, mood)
+	assert.NoError(t, err)
+
+	count, err := repo.CountSelectable(ctx, userID, []int64{1, mood.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	count, err = repo.CountSelectable(ctx, 15, []int64{mood.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	journal := &models.Journal{UserID: userID, Title: "title test", Text: "text test", MoodID: mood.ID}
+	err = journals.Create(ctx, journal)
+	assert.NoError(t, err)
+
+	err = repo.Delete(ctx, mood.ID, 0)
+	assert.ErrorIs(t, err, domain.ErrMoodInUse)
+
+	err = repo.Delete(ctx, mood.ID, 1)
+	assert.NoError(t, err)
+
+	moved, err := journals.GetByID(ctx, journal.Uid)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), moved.MoodID)
+	assert.Equal(t, journal.Version+1, moved.Version)
+
+	_, _ = testDB.Exec(ctx, `DELETE FROM journals WHERE id = $1`, journal.ID)
+}
+
+func TestMoodRepository_GetVisibleLocalized(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMood(testDB)
+
+	moods, err := repo.GetVisible(ctx, 14, false, []string{"id-id", "id"})
+	assert.NoError(t, err)
+	assert.Equal(t, "senang", moods[0].Label)
+	assert.NotNil(t, moods[0].Emoji)
+
+	moods, err = repo.GetVisible(ctx, 14, false, []string{"fr"})
+	assert.NoError(t, err)
+	assert.Equal(t, "happy", moods[0].Label)
+}
@@ -3,9 +3,14 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 	"timo/domain"
 	"timo/models"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -17,24 +22,319 @@ func NewPhoto(pool *pgxpool.Pool) domain.PhotoRepository {
 	return &photo{pool: pool}
 }
 
-func (p *photo) Create(ctx context.Context, photo *models.Photo) error {
+const photoColumns = `p.id, p.journal_id, p.blob_id, b.url, b.storage_key, b.size, b.content_type, b.checksum, b.status,
+	b.width, b.height, b.taken_at, b.orientation, b.media_type, b.duration_ms, b.peaks, p.position, p.caption, p.alt_text, p.cover, p.created_at`
+
+func scanPhoto(row pgx.Row, photo *models.Photo) error {
+	return row.Scan(&photo.ID, &photo.JournalID, &photo.BlobID, &photo.Url, &photo.StorageKey, &photo.Size, &photo.ContentType, &photo.Checksum,
+		&photo.Status, &photo.Width, &photo.Height, &photo.TakenAt, &photo.Orientation, &photo.MediaType, &photo.DurationMs, &photo.Peaks,
+		&photo.Position, &photo.Caption, &photo.AltText, &photo.Cover, &photo.CreatedAt)
+}
+
+const blobColumns = `id, user_id, checksum, storage_key, url, size, content_type, status, width, height, taken_at, orientation,
+	media_type, duration_ms, peaks, ref_count, created_at`
+
+func scanBlob(row pgx.Row, blob *models.PhotoBlob) error {
+	return row.Scan(&blob.ID, &blob.UserID, &blob.Checksum, &blob.StorageKey, &blob.Url, &blob.Size, &blob.ContentType,
+		&blob.Status, &blob.Width, &blob.Height, &blob.TakenAt, &blob.Orientation, &blob.MediaType, &blob.DurationMs, &blob.Peaks,
+		&blob.RefCount, &blob.CreatedAt)
+}
+
+// GetBlob finds the user's blob with the given SHA-256.
+func (p *photo) GetBlob(ctx context.Context, userID int64, checksum string) (*models.PhotoBlob, error) {
+	var blob models.PhotoBlob
+
+	query := `
+		SELECT ` + blobColumns + `
+		FROM photo_blobs
+		WHERE user_id = $1 AND checksum = $2 AND checksum <> ''
+	`
+
+	err := scanBlob(p.pool.QueryRow(ctx, query, userID, checksum), &blob)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+
+	return &blob, nil
+}
+
+// Create adds the photo after the journal's last one and takes a reference on
+// its blob. With BlobID set the photo shares that blob. Otherwise the blob is
+// stored from the photo's fields, unless the owner already has one with the
+// same checksum, in which case that one is shared instead. created reports
+// whether a new blob was stored; the photo's blob fields are filled either
+// way. The journal row is locked while its photos are counted, so it never
+// ends up with more than limit of them; domain.ErrPhotoLimit is returned at
+// the limit. A non-zero uploadID names the resumable upload the file came
+// from. It is deleted in the same transaction, so its reservation passes to
+// the photo and is never released as an expired upload's as well;
+// domain.ErrUploadGone is returned when the upload no longer exists.
+func (p *photo) Create(ctx context.Context, photo *models.Photo, limit int, uploadID int64) (bool, error) {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var count int
+	err = tx.QueryRow(ctx, `SELECT id FROM journals WHERE id = $1 FOR UPDATE`, photo.JournalID).Scan(new(int64))
+	if err == nil {
+		err = tx.QueryRow(ctx, `SELECT COUNT(*) FROM photos WHERE journal_id = $1`, photo.JournalID).Scan(&count)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, sql.ErrNoRows
+		}
+		return false, err
+	}
+	if count >= limit {
+		return false, domain.ErrPhotoLimit
+	}
+
+	var created bool
+	if photo.BlobID != 0 {
+		query := `
+			UPDATE photo_blobs
+			SET ref_count = ref_count + 1
+			WHERE id = $1
+			RETURNING checksum, storage_key, url, size, content_type, status, width, height, taken_at, orientation,
+				media_type, duration_ms, peaks
+		`
+
+		err = tx.QueryRow(ctx, query, photo.BlobID).
+			Scan(&photo.Checksum, &photo.StorageKey, &photo.Url, &photo.Size, &photo.ContentType, &photo.Status,
+				&photo.Width, &photo.Height, &photo.TakenAt, &photo.Orientation, &photo.MediaType, &photo.DurationMs, &photo.Peaks)
+	} else {
+		// xmax is only zero on a freshly inserted row, which tells an insert
+		// from a conflict update.
+		query := `
+			INSERT INTO photo_blobs (user_id, checksum, storage_key, url, size, content_type, status, media_type, duration_ms, peaks, ref_count)
+			SELECT j.user_id, $2, $3, $4, $5, $6, COALESCE(NULLIF($7, ''), 'ready'), COALESCE(NULLIF($8, ''), 'image'), $9, $10, 1
+			FROM journals j
+			WHERE j.id = $1
+			ON CONFLICT (user_id, checksum) WHERE checksum <> '' DO UPDATE
+			SET ref_count = photo_blobs.ref_count + 1
+			RETURNING id, checksum, storage_key, url, size, content_type, status, width, height, taken_at, orientation,
+				media_type, duration_ms, peaks, xmax = 0
+		`
+
+		err = tx.QueryRow(ctx, query, photo.JournalID, photo.Checksum, photo.StorageKey, photo.Url, photo.Size, photo.ContentType, photo.Status,
+			photo.MediaType, photo.DurationMs, photo.Peaks).
+			Scan(&photo.BlobID, &photo.Checksum, &photo.StorageKey, &photo.Url, &photo.Size, &photo.ContentType, &photo.Status,
+				&photo.Width, &photo.Height, &photo.TakenAt, &photo.Orientation, &photo.MediaType, &photo.DurationMs, &photo.Peaks, &created)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, sql.ErrNoRows
+		}
+		return false, err
+	}
+
+	query := `
+		INSERT INTO photos (journal_id, blob_id, caption, alt_text, position)
+		SELECT $1, $2, NULLIF($3, ''), NULLIF($4, ''), COALESCE(MAX(position), 0) + 1
+		FROM photos
+		WHERE journal_id = $1
+		RETURNING id, position, created_at
+	`
+
+	err = tx.QueryRow(ctx, query, photo.JournalID, photo.BlobID, photo.Caption, photo.AltText).
+		Scan(&photo.ID, &photo.Position, &photo.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+
+	if uploadID != 0 {
+		result, err := tx.Exec(ctx, `DELETE FROM photo_uploads WHERE id = $1`, uploadID)
+		if err != nil {
+			return false, err
+		}
+		if result.RowsAffected() == 0 {
+			return false, domain.ErrUploadGone
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	photos := []models.Photo{*photo}
+	if err := p.loadVariants(ctx, photos); err != nil {
+		return false, err
+	}
+	*photo = photos[0]
+
+	return created, nil
+}
+
+func (p *photo) GetByID(ctx context.Context, id int64) (*models.Photo, error) {
+	var photo models.Photo
+
 	query := `
-		INSERT INTO photos (journal_id, url)
-		VALUES ($1, $2)
-		RETURNING id, created_at
+		SELECT ` + photoColumns + `
+		FROM photos p
+		JOIN photo_blobs b ON b.id = p.blob_id
+		WHERE p.id = $1
 	`
 
-	return p.pool.QueryRow(ctx, query, photo.JournalID, photo.Url).
-		Scan(&photo.ID, &photo.CreatedAt)
+	err := scanPhoto(p.pool.QueryRow(ctx, query, id), &photo)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+
+	photos := []models.Photo{photo}
+	if err := p.loadVariants(ctx, photos); err != nil {
+		return nil, err
+	}
+
+	return &photos[0], nil
 }
 
-func (p *photo) Delete(ctx context.Context, id int64) error {
+// Delete removes the photo and drops its reference on the blob. When that
+// was the last reference the blob and its variants go too, their space is
+// given back to the owner's storage usage, and the blob is returned so the
+// caller can remove its objects. Otherwise the returned blob is nil.
+func (p *photo) Delete(ctx context.Context, id int64) (*models.PhotoBlob, error) {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// The owner is locked before the blob, the order journal deletes take
+	// when they drop the references of their photos.
 	query := `
-		DELETE FROM photos
+		SELECT u.id
+		FROM users u
+		JOIN journals j ON j.user_id = u.id
+		JOIN photos p ON p.journal_id = j.id
+		WHERE p.id = $1
+		FOR UPDATE OF u
+	`
+	if err := tx.QueryRow(ctx, query, id).Scan(new(int64)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+
+	var blobID int64
+	if err := tx.QueryRow(ctx, `DELETE FROM photos WHERE id = $1 RETURNING blob_id`, id).Scan(&blobID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+
+	query = `
+		UPDATE photo_blobs
+		SET ref_count = ref_count - 1
 		WHERE id = $1
+		RETURNING ref_count
 	`
 
-	result, err := p.pool.Exec(ctx, query, id)
+	var refCount int
+	if err := tx.QueryRow(ctx, query, blobID).Scan(&refCount); err != nil {
+		return nil, err
+	}
+	if refCount > 0 {
+		return nil, tx.Commit(ctx)
+	}
+
+	variants, err := blobVariants(ctx, tx, blobID)
+	if err != nil {
+		return nil, err
+	}
+
+	var blob models.PhotoBlob
+	err = scanBlob(tx.QueryRow(ctx, `DELETE FROM photo_blobs WHERE id = $1 RETURNING `+blobColumns, blobID), &blob)
+	if err != nil {
+		return nil, err
+	}
+	blob.Variants = variants
+
+	size := blob.Size
+	for _, v := range variants {
+		size += v.Size
+	}
+	if err := addStorageUsed(ctx, tx, blob.UserID, -size); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return &blob, nil
+}
+
+// Update applies a photo patch. Marking a photo as cover takes the flag away
+// from the journal's previous cover in the same transaction.
+func (p *photo) Update(ctx context.Context, id int64, patch *models.PhotoPatch) error {
+	var sets []string
+	var args []any
+	set := func(column string, value any) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = NULLIF($%d, '')", column, len(args)))
+	}
+
+	if patch.Caption != nil {
+		set("caption", *patch.Caption)
+	}
+	if patch.AltText != nil {
+		set("alt_text", *patch.AltText)
+	}
+	if patch.Cover != nil {
+		args = append(args, *patch.Cover)
+		sets = append(sets, fmt.Sprintf("cover = $%d", len(args)))
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if patch.Cover != nil && *patch.Cover {
+		// Covers of one journal change one at a time, so a concurrent update
+		// sees the cover this one sets and unsets it instead of violating
+		// photos_journal_id_cover_idx.
+		query := `
+			SELECT id FROM journals
+			WHERE id = (SELECT journal_id FROM photos WHERE id = $1)
+			FOR UPDATE
+		`
+		if _, err := tx.Exec(ctx, query, id); err != nil {
+			return err
+		}
+
+		query = `
+			UPDATE photos
+			SET cover = false
+			WHERE journal_id = (SELECT journal_id FROM photos WHERE id = $1) AND cover AND id <> $1
+		`
+		if _, err := tx.Exec(ctx, query, id); err != nil {
+			return err
+		}
+	}
+
+	args = append(args, id)
+	query := fmt.Sprintf(`
+		UPDATE photos
+		SET %s
+		WHERE id = $%d
+	`, strings.Join(sets, ", "), len(args))
+
+	result, err := tx.Exec(ctx, query, args...)
 	if err != nil {
 		return err
 	}
@@ -43,15 +343,77 @@ func (p *photo) Delete(ctx context.Context, id int64) error {
 		return sql.ErrNoRows
 	}
 
-	return nil
+	return tx.Commit(ctx)
+}
+
+// Reorder sets the position of each of the journal's photos to its index in
+// ids.
+func (p *photo) Reorder(ctx context.Context, journalID int64, ids []int64) error {
+	query := `
+		UPDATE photos p
+		SET position = o.position
+		FROM unnest($2::bigint[]) WITH ORDINALITY AS o(id, position)
+		WHERE p.id = o.id AND p.journal_id = $1
+	`
+
+	_, err := p.pool.Exec(ctx, query, journalID, ids)
+	return err
+}
+
+func (p *photo) CountByJournalID(ctx context.Context, journalID int64) (int, error) {
+	var count int
+	err := p.pool.QueryRow(ctx, `SELECT COUNT(*) FROM photos WHERE journal_id = $1`, journalID).Scan(&count)
+	return count, err
+}
+
+// ReserveStorage adds size to the user's storage usage if that stays within
+// quota and returns the new usage. Otherwise it returns the current usage
+// with domain.ErrQuotaExceeded. The check and the increment are one
+// statement, so concurrent uploads can't overshoot the quota together.
+func (p *photo) ReserveStorage(ctx context.Context, userID, size, quota int64) (int64, error) {
+	var used int64
+
+	query := `
+		UPDATE users
+		SET storage_used = storage_used + $2
+		WHERE id = $1 AND storage_used + $2 <= $3
+		RETURNING storage_used
+	`
+
+	err := p.pool.QueryRow(ctx, query, userID, size, quota).Scan(&used)
+	if err == nil {
+		return used, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+
+	if err := p.pool.QueryRow(ctx, `SELECT storage_used FROM users WHERE id = $1`, userID).Scan(&used); err != nil {
+		return 0, err
+	}
+
+	return used, domain.ErrQuotaExceeded
+}
+
+func (p *photo) ReleaseStorage(ctx context.Context, userID, size int64) error {
+	query := `
+		UPDATE users
+		SET storage_used = GREATEST(storage_used - $2, 0)
+		WHERE id = $1
+	`
+
+	_, err := p.pool.Exec(ctx, query, userID, size)
+	return err
 }
 
 func (p *photo) GetByJournalID(ctx context.Context, journalID int64) ([]models.Photo, error) {
 	var photos []models.Photo
 	query := `
-		SELECT id, journal_id, url, created_at
-		FROM photos
-		WHERE journal_id = $1
+		SELECT ` + photoColumns + `
+		FROM photos p
+		JOIN photo_blobs b ON b.id = p.blob_id
+		WHERE p.journal_id = $1
+		ORDER BY p.position, p.id
 	`
 
 	rows, err := p.pool.Query(ctx, query, journalID)
@@ -62,8 +424,44 @@ func (p *photo) GetByJournalID(ctx context.Context, journalID int64) ([]models.P
 
 	for rows.Next() {
 		var p models.Photo
-		err := rows.Scan(&p.ID, &p.JournalID, &p.Url, &p.CreatedAt)
-		if err != nil {
+		if err := scanPhoto(rows, &p); err != nil {
+			return nil, err
+		}
+		photos = append(photos, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := p.loadVariants(ctx, photos); err != nil {
+		return nil, err
+	}
+
+	return photos, nil
+}
+
+// GetByJournalIDs loads the photos of several journals at once, grouped by
+// journal in their order.
+func (p *photo) GetByJournalIDs(ctx context.Context, journalIDs []int64) ([]models.Photo, error) {
+	var photos []models.Photo
+	query := `
+		SELECT ` + photoColumns + `
+		FROM photos p
+		JOIN photo_blobs b ON b.id = p.blob_id
+		WHERE p.journal_id = ANY($1)
+		ORDER BY p.journal_id, p.position, p.id
+	`
+
+	rows, err := p.pool.Query(ctx, query, journalIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p models.Photo
+		if err := scanPhoto(rows, &p); err != nil {
 			return nil, err
 		}
 		photos = append(photos, p)
@@ -73,5 +471,241 @@ func (p *photo) GetByJournalID(ctx context.Context, journalID int64) ([]models.P
 		return nil, err
 	}
 
+	if err := p.loadVariants(ctx, photos); err != nil {
+		return nil, err
+	}
+
 	return photos, nil
 }
+
+// UnreferencedKeys returns the storage keys no blob, variant, upload part or
+// unfinished import points at.
+func (p *photo) UnreferencedKeys(ctx context.Context, keys []string) ([]string, error) {
+	var unreferenced []string
+	query := `
+		SELECT k
+		FROM unnest($1::text[]) AS k
+		WHERE NOT EXISTS (SELECT 1 FROM photo_blobs b WHERE b.storage_key = k)
+			AND NOT EXISTS (SELECT 1 FROM photo_variants v WHERE v.storage_key = k)
+			AND NOT EXISTS (SELECT 1 FROM photo_upload_parts u WHERE u.storage_key = k)
+			AND NOT EXISTS (SELECT 1 FROM imports i WHERE i.storage_key = k)
+	`
+
+	rows, err := p.pool.Query(ctx, query, keys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		unreferenced = append(unreferenced, key)
+	}
+
+	return unreferenced, rows.Err()
+}
+
+// ClaimPending moves up to limit pending blobs to processing and returns
+// them. Blobs stuck in processing for longer than staleAfter, e.g. after a
+// crash, are claimed again. SKIP LOCKED keeps concurrent workers apart.
+func (p *photo) ClaimPending(ctx context.Context, limit int, staleAfter time.Duration) ([]models.PhotoBlob, error) {
+	var blobs []models.PhotoBlob
+	query := `
+		UPDATE photo_blobs
+		SET status = 'processing', processing_at = now()
+		WHERE id IN (
+			SELECT id FROM photo_blobs
+			WHERE status = 'pending'
+				OR (status = 'processing' AND processing_at < now() - make_interval(secs => $2))
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + blobColumns
+
+	rows, err := p.pool.Query(ctx, query, limit, staleAfter.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var b models.PhotoBlob
+		if err := scanBlob(rows, &b); err != nil {
+			return nil, err
+		}
+		blobs = append(blobs, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return blobs, nil
+}
+
+// SaveProcessed points the blob at its re-encoded object, records the
+// extracted metadata and replaces its variants. The owner's storage usage
+// moves by the difference to what the blob took before.
+func (p *photo) SaveProcessed(ctx context.Context, blob *models.PhotoBlob) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var userID, oldSize int64
+	query := `
+		SELECT b.user_id, b.size + COALESCE((SELECT SUM(v.size) FROM photo_variants v WHERE v.blob_id = b.id), 0)
+		FROM photo_blobs b
+		WHERE b.id = $1
+		FOR UPDATE
+	`
+
+	if err := tx.QueryRow(ctx, query, blob.ID).Scan(&userID, &oldSize); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.ErrNoRows
+		}
+		return err
+	}
+
+	query = `
+		UPDATE photo_blobs
+		SET url = $2, storage_key = $3, size = $4, content_type = $5, width = $6, height = $7,
+			taken_at = $8, orientation = $9, status = 'ready', processing_at = NULL
+		WHERE id = $1
+		RETURNING status
+	`
+
+	err = tx.QueryRow(ctx, query, blob.ID, blob.Url, blob.StorageKey, blob.Size, blob.ContentType, blob.Width, blob.Height,
+		blob.TakenAt, blob.Orientation).Scan(&blob.Status)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM photo_variants WHERE blob_id = $1`, blob.ID); err != nil {
+		return err
+	}
+
+	for _, v := range blob.Variants {
+		query := `
+			INSERT INTO photo_variants (blob_id, name, storage_key, width, height, size, content_type)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`
+		if _, err := tx.Exec(ctx, query, blob.ID, v.Name, v.StorageKey, v.Width, v.Height, v.Size, v.ContentType); err != nil {
+			return err
+		}
+	}
+
+	newSize := blob.Size
+	for _, v := range blob.Variants {
+		newSize += v.Size
+	}
+	if err := addStorageUsed(ctx, tx, userID, newSize-oldSize); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (p *photo) MarkFailed(ctx context.Context, blobID int64) error {
+	query := `
+		UPDATE photo_blobs
+		SET status = 'failed', processing_at = NULL
+		WHERE id = $1
+	`
+
+	result, err := p.pool.Exec(ctx, query, blobID)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// loadVariants attaches the variants of each photo's blob. Photos sharing a
+// blob get the same variants.
+func (p *photo) loadVariants(ctx context.Context, photos []models.Photo) error {
+	if len(photos) == 0 {
+		return nil
+	}
+
+	index := make(map[int64][]int, len(photos))
+	ids := make([]int64, 0, len(photos))
+	for i, photo := range photos {
+		if _, ok := index[photo.BlobID]; !ok {
+			ids = append(ids, photo.BlobID)
+		}
+		index[photo.BlobID] = append(index[photo.BlobID], i)
+	}
+
+	query := `
+		SELECT blob_id, name, storage_key, width, height, size, content_type
+		FROM photo_variants
+		WHERE blob_id = ANY($1)
+		ORDER BY blob_id, width DESC
+	`
+
+	rows, err := p.pool.Query(ctx, query, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v models.PhotoVariant
+		if err := rows.Scan(&v.BlobID, &v.Name, &v.StorageKey, &v.Width, &v.Height, &v.Size, &v.ContentType); err != nil {
+			return err
+		}
+		for _, i := range index[v.BlobID] {
+			photos[i].Variants = append(photos[i].Variants, v)
+		}
+	}
+
+	return rows.Err()
+}
+
+func blobVariants(ctx context.Context, tx pgx.Tx, blobID int64) ([]models.PhotoVariant, error) {
+	var variants []models.PhotoVariant
+	query := `
+		SELECT blob_id, name, storage_key, width, height, size, content_type
+		FROM photo_variants
+		WHERE blob_id = $1
+	`
+
+	rows, err := tx.Query(ctx, query, blobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v models.PhotoVariant
+		if err := rows.Scan(&v.BlobID, &v.Name, &v.StorageKey, &v.Width, &v.Height, &v.Size, &v.ContentType); err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
+	}
+
+	return variants, rows.Err()
+}
+
+// addStorageUsed moves the user's storage usage by delta. Processing usually
+// shrinks a blob, so no quota is checked here.
+func addStorageUsed(ctx context.Context, tx pgx.Tx, userID, delta int64) error {
+	query := `
+		UPDATE users
+		SET storage_used = GREATEST(storage_used + $2, 0)
+		WHERE id = $1
+	`
+
+	_, err := tx.Exec(ctx, query, userID, delta)
+	return err
+}
@@ -3,12 +3,19 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"sync"
 	"testing"
+	"time"
+	"timo/domain"
+	"timo/helper"
 	"timo/models"
 
 	"github.com/stretchr/testify/assert"
 )
 
+// photoLimit is well above the photos any of these tests adds to a journal.
+const photoLimit = 100
+
 func TestPhotoRepository_Create(t *testing.T) {
 	ctx := context.Background()
 	repo := NewPhoto(testDB)
@@ -18,12 +25,75 @@ func TestPhotoRepository_Create(t *testing.T) {
 		Url:       "url_test",
 	}
 
-	err := repo.Create(ctx, photo)
+	created, err := repo.Create(ctx, photo, photoLimit, 0)
 
 	assert.NoError(t, err)
+	assert.True(t, created)
 	assert.NotZero(t, photo.ID)
+	assert.NotZero(t, photo.BlobID)
+
+	_, _ = testDB.Exec(ctx, `DELETE FROM photos WHERE id = $1`, photo.ID)
+	_, _ = testDB.Exec(ctx, `DELETE FROM photo_blobs WHERE id = $1`, photo.BlobID)
+}
+
+func TestPhotoRepository_CreateFromUpload(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPhoto(testDB)
+	uploads := NewUpload(testDB)
+
+	upload := &models.PhotoUpload{UserID: 14, JournalID: 17, Size: 10, ExpiresAt: time.Now().Add(time.Hour)}
+	err := uploads.Create(ctx, upload, 5)
+	assert.NoError(t, err)
+	defer testDB.Exec(ctx, `DELETE FROM photo_uploads WHERE id = $1`, upload.ID)
+
+	photo := &models.Photo{JournalID: 17, Url: "url_upload", Size: 10}
+	_, err = repo.Create(ctx, photo, photoLimit, upload.ID)
+	assert.NoError(t, err)
+
+	// The upload goes with its reservation, so the GC can't release it again.
+	_, err = uploads.GetByUid(ctx, upload.Uid)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	again := &models.Photo{JournalID: 17, Url: "url_upload_again", Size: 10}
+	_, err = repo.Create(ctx, again, photoLimit, upload.ID)
+	assert.ErrorIs(t, err, domain.ErrUploadGone)
 
 	_, _ = testDB.Exec(ctx, `DELETE FROM photos WHERE id = $1`, photo.ID)
+	_, _ = testDB.Exec(ctx, `DELETE FROM photo_blobs WHERE id = $1`, photo.BlobID)
+}
+
+func TestPhotoRepository_CreateLimit(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPhoto(testDB)
+
+	var count int
+	_ = testDB.QueryRow(ctx, `SELECT count(*) FROM photos WHERE journal_id = 17`).Scan(&count)
+
+	// Concurrent uploads may only fill the journal up to the limit.
+	photos := make([]*models.Photo, 4)
+	errs := make([]error, len(photos))
+	var wg sync.WaitGroup
+	for i := range photos {
+		photos[i] = &models.Photo{JournalID: 17, Url: "url_test"}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = repo.Create(ctx, photos[i], count+2, 0)
+		}()
+	}
+	wg.Wait()
+
+	var created int
+	for i, err := range errs {
+		if err == nil {
+			created++
+			defer testDB.Exec(ctx, `DELETE FROM photo_blobs WHERE id = $1`, photos[i].BlobID)
+			defer testDB.Exec(ctx, `DELETE FROM photos WHERE id = $1`, photos[i].ID)
+			continue
+		}
+		assert.ErrorIs(t, err, domain.ErrPhotoLimit)
+	}
+	assert.Equal(t, 2, created)
 }
 
 func TestPhotoRepository_GetByJounalID(t *testing.T) {
@@ -34,7 +104,7 @@ func TestPhotoRepository_GetByJounalID(t *testing.T) {
 
 	for _, url := range listUrl {
 		p := &models.Photo{JournalID: 17, Url: url}
-		err := repo.Create(ctx, p)
+		_, err := repo.Create(ctx, p, photoLimit, 0)
 		assert.NoError(t, err)
 	}
 
@@ -46,6 +116,7 @@ func TestPhotoRepository_GetByJounalID(t *testing.T) {
 		assert.Equal(t, listUrl[i], p.Url)
 	}
 
+	defer testDB.Exec(ctx, `DELETE FROM photo_blobs`)
 	defer testDB.Exec(ctx, `DELETE FROM photos`)
 }
 
@@ -72,18 +143,214 @@ func TestPhotoRepository_Delete(t *testing.T) {
 			repo := NewPhoto(testDB)
 
 			if tt.isErr {
-				err := repo.Delete(ctx, 1)
+				_, err := repo.Delete(ctx, 1)
 
 				assert.Error(t, err)
 				assert.Equal(t, sql.ErrNoRows, err)
 			} else {
-				err := repo.Create(ctx, tt.photo)
+				_, err := repo.Create(ctx, tt.photo, photoLimit, 0)
 				assert.NoError(t, err)
 
-				err = repo.Delete(ctx, tt.photo.ID)
+				blob, err := repo.Delete(ctx, tt.photo.ID)
 				assert.NoError(t, err)
-
+				assert.Equal(t, tt.photo.BlobID, blob.ID)
 			}
 		})
 	}
 }
+
+func TestPhotoRepository_Process(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPhoto(testDB)
+
+	photo := &models.Photo{JournalID: 17, Url: "url_test", StorageKey: "a.png", Status: models.PHOTO_PENDING}
+	_, err := repo.Create(ctx, photo, photoLimit, 0)
+	assert.NoError(t, err)
+	defer testDB.Exec(ctx, `DELETE FROM photo_blobs WHERE id = $1`, photo.BlobID)
+	defer testDB.Exec(ctx, `DELETE FROM photos WHERE id = $1`, photo.ID)
+
+	claimed, err := repo.ClaimPending(ctx, 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+	assert.Equal(t, models.PHOTO_PROCESSING, claimed[0].Status)
+
+	again, err := repo.ClaimPending(ctx, 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Empty(t, again)
+
+	width, height := 2048, 1536
+	claimed[0].StorageKey = "a.jpg"
+	claimed[0].Width, claimed[0].Height = &width, &height
+	claimed[0].Variants = []models.PhotoVariant{
+		{Name: "small", StorageKey: "a_small.jpg", Width: 320, Height: 240, Size: 10, ContentType: "image/jpeg"},
+		{Name: "medium", StorageKey: "a_medium.jpg", Width: 1024, Height: 768, Size: 100, ContentType: "image/jpeg"},
+	}
+	err = repo.SaveProcessed(ctx, &claimed[0])
+	assert.NoError(t, err)
+
+	got, err := repo.GetByID(ctx, photo.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.PHOTO_READY, got.Status)
+	assert.Equal(t, "a.jpg", got.StorageKey)
+	assert.Equal(t, 2048, *got.Width)
+	assert.Len(t, got.Variants, 2)
+	assert.Equal(t, "medium", got.Variants[0].Name)
+}
+
+func TestPhotoRepository_ReserveStorage(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPhoto(testDB)
+
+	_, _ = testDB.Exec(ctx, `UPDATE users SET storage_used = 0 WHERE id = 14`)
+	defer testDB.Exec(ctx, `UPDATE users SET storage_used = 0 WHERE id = 14`)
+
+	used, err := repo.ReserveStorage(ctx, 14, 600, 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(600), used)
+
+	used, err = repo.ReserveStorage(ctx, 14, 600, 1000)
+	assert.ErrorIs(t, err, domain.ErrQuotaExceeded)
+	assert.Equal(t, int64(600), used)
+
+	err = repo.ReleaseStorage(ctx, 14, 1000)
+	assert.NoError(t, err)
+
+	used, err = repo.ReserveStorage(ctx, 14, 600, 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(600), used)
+}
+
+func TestPhotoRepository_OrderAndCover(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPhoto(testDB)
+
+	var ids []int64
+	for _, url := range []string{"url_1", "url_2", "url_3"} {
+		p := &models.Photo{JournalID: 17, Url: url}
+		_, err := repo.Create(ctx, p, photoLimit, 0)
+		assert.NoError(t, err)
+		ids = append(ids, p.ID)
+	}
+	defer testDB.Exec(ctx, `DELETE FROM photo_blobs WHERE ref_count > 0 AND NOT EXISTS (SELECT 1 FROM photos p WHERE p.blob_id = photo_blobs.id)`)
+	defer testDB.Exec(ctx, `DELETE FROM photos WHERE journal_id = 17`)
+
+	err := repo.Reorder(ctx, 17, []int64{ids[2], ids[0], ids[1]})
+	assert.NoError(t, err)
+
+	err = repo.Update(ctx, ids[0], &models.PhotoPatch{Caption: helper.Ptr("first"), Cover: helper.Ptr(true)})
+	assert.NoError(t, err)
+	err = repo.Update(ctx, ids[1], &models.PhotoPatch{Cover: helper.Ptr(true)})
+	assert.NoError(t, err)
+
+	photos, err := repo.GetByJournalID(ctx, 17)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"url_3", "url_1", "url_2"}, []string{photos[0].Url, photos[1].Url, photos[2].Url})
+	assert.Equal(t, "first", *photos[1].Caption)
+	assert.False(t, photos[1].Cover)
+	assert.True(t, photos[2].Cover)
+
+	// Concurrent covers take turns, so the last one wins without a conflict.
+	var wg sync.WaitGroup
+	errs := make([]error, len(ids))
+	for i, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = repo.Update(ctx, id, &models.PhotoPatch{Cover: helper.Ptr(true)})
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, make([]error, len(ids)), errs)
+
+	var covers int
+	_ = testDB.QueryRow(ctx, `SELECT count(*) FROM photos WHERE journal_id = 17 AND cover`).Scan(&covers)
+	assert.Equal(t, 1, covers)
+}
+
+func TestPhotoRepository_Dedup(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPhoto(testDB)
+
+	first := &models.Photo{JournalID: 17, Url: "url_test", StorageKey: "a.png", Size: 100, Checksum: "abc", Status: models.PHOTO_PENDING}
+	created, err := repo.Create(ctx, first, photoLimit, 0)
+	assert.NoError(t, err)
+	assert.True(t, created)
+
+	second := &models.Photo{JournalID: 17, StorageKey: "b.png", Checksum: "abc"}
+	created, err = repo.Create(ctx, second, photoLimit, 0)
+	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, first.BlobID, second.BlobID)
+	assert.Equal(t, "a.png", second.StorageKey)
+
+	third := &models.Photo{JournalID: 17, BlobID: first.BlobID}
+	_, err = repo.Create(ctx, third, photoLimit, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), third.Size)
+
+	var userID int64
+	_ = testDB.QueryRow(ctx, `SELECT user_id FROM journals WHERE id = 17`).Scan(&userID)
+
+	blob, err := repo.GetBlob(ctx, userID, "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, blob.RefCount)
+
+	for _, photo := range []*models.Photo{first, second} {
+		blob, err = repo.Delete(ctx, photo.ID)
+		assert.NoError(t, err)
+		assert.Nil(t, blob)
+	}
+
+	blob, err = repo.Delete(ctx, third.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, first.BlobID, blob.ID)
+
+	_, err = repo.GetBlob(ctx, userID, "abc")
+	assert.Equal(t, sql.ErrNoRows, err)
+}
+
+func TestPhotoRepository_Audio(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPhoto(testDB)
+
+	recording := &models.Photo{JournalID: 17, StorageKey: "a.m4a", Size: 100, Checksum: "def", Status: models.PHOTO_READY,
+		MediaType: models.MEDIA_AUDIO, DurationMs: helper.Ptr(61500), Peaks: []int{0, 128, 255}}
+	_, err := repo.Create(ctx, recording, photoLimit, 0)
+	assert.NoError(t, err)
+
+	shared := &models.Photo{JournalID: 17, BlobID: recording.BlobID}
+	_, err = repo.Create(ctx, shared, photoLimit, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, models.MEDIA_AUDIO, shared.MediaType)
+	assert.Equal(t, []int{0, 128, 255}, shared.Peaks)
+
+	loaded, err := repo.GetByID(ctx, recording.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 61500, *loaded.DurationMs)
+
+	image := &models.Photo{JournalID: 17, StorageKey: "b.png", Checksum: "ghi"}
+	_, err = repo.Create(ctx, image, photoLimit, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, models.MEDIA_IMAGE, image.MediaType)
+	assert.Nil(t, image.Peaks)
+
+	for _, photo := range []*models.Photo{recording, shared, image} {
+		_, err = repo.Delete(ctx, photo.ID)
+		assert.NoError(t, err)
+	}
+}
+
+func TestPhotoRepository_UnreferencedKeys(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPhoto(testDB)
+
+	photo := &models.Photo{JournalID: 17, StorageKey: "users/1/photos/kept.jpg", Checksum: "kept"}
+	_, err := repo.Create(ctx, photo, photoLimit, 0)
+	assert.NoError(t, err)
+	defer testDB.Exec(ctx, `DELETE FROM photo_blobs WHERE id = $1`, photo.BlobID)
+	defer testDB.Exec(ctx, `DELETE FROM photos WHERE id = $1`, photo.ID)
+
+	keys, err := repo.UnreferencedKeys(ctx, []string{"users/1/photos/kept.jpg", "users/1/photos/lost.jpg"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"users/1/photos/lost.jpg"}, keys)
+}
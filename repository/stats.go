@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"timo/domain"
+	"timo/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type stats struct {
+	pool *pgxpool.Pool
+}
+
+func NewStats(pool *pgxpool.Pool) domain.StatsRepository {
+	return &stats{pool: pool}
+}
+
+func (s *stats) GetStreak(ctx context.Context, userID int64) (*models.Streak, error) {
+	streak := models.Streak{UserID: userID}
+
+	query := `
+		SELECT current_streak, longest_streak, last_entry_date
+		FROM user_streaks
+		WHERE user_id = $1
+	`
+
+	err := s.pool.QueryRow(ctx, query, userID).
+		Scan(&streak.CurrentStreak, &streak.LongestStreak, &streak.LastEntryDate)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+
+	return &streak, nil
+}
+
+// AdvanceStreak folds an entry dated on or after the cached last entry date
+// into the streak. It reports false when there is no cached streak or the
+// entry is backdated, in which case the streak has to be recomputed.
+func (s *stats) AdvanceStreak(ctx context.Context, userID int64, date time.Time) (bool, error) {
+	query := `
+		UPDATE user_streaks
+		SET current_streak = CASE
+				WHEN last_entry_date = $2 THEN current_streak
+				WHEN last_entry_date = $2::date - 1 THEN current_streak + 1
+				ELSE 1
+			END,
+			longest_streak = GREATEST(longest_streak, CASE
+				WHEN last_entry_date = $2 THEN current_streak
+				WHEN last_entry_date = $2::date - 1 THEN current_streak + 1
+				ELSE 1
+			END),
+			last_entry_date = $2,
+			updated_at = now()
+		WHERE user_id = $1 AND last_entry_date <= $2
+	`
+
+	result, err := s.pool.Exec(ctx, query, userID, date)
+	if err != nil {
+		return false, err
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+// RecomputeStreak rebuilds the cached streak from the user's published entry
+// dates. Consecutive dates share the same date - row_number() value, so each
+// group is one run of days.
+func (s *stats) RecomputeStreak(ctx context.Context, userID int64) (*models.Streak, error) {
+	streak := models.Streak{UserID: userID}
+
+	query := `
+		WITH days AS (
+			SELECT DISTINCT entry_date
+			FROM journals
+			WHERE user_id = $1 AND status = 'published'
+		), runs AS (
+			SELECT COUNT(*) AS length, MAX(entry_date) AS last_date
+			FROM (
+				SELECT entry_date, entry_date - (ROW_NUMBER() OVER (ORDER BY entry_date))::int AS run
+				FROM days
+			) d
+			GROUP BY run
+		)
+		INSERT INTO user_streaks (user_id, current_streak, longest_streak, last_entry_date, updated_at)
+		SELECT $1,
+			COALESCE((SELECT length FROM runs ORDER BY last_date DESC LIMIT 1), 0),
+			COALESCE((SELECT MAX(length) FROM runs), 0),
+			(SELECT MAX(last_date) FROM runs),
+			now()
+		ON CONFLICT (user_id) DO UPDATE
+		SET current_streak = EXCLUDED.current_streak,
+			longest_streak = EXCLUDED.longest_streak,
+			last_entry_date = EXCLUDED.last_entry_date,
+			updated_at = EXCLUDED.updated_at
+		RETURNING current_streak, longest_streak, last_entry_date
+	`
+
+	err := s.pool.QueryRow(ctx, query, userID).
+		Scan(&streak.CurrentStreak, &streak.LongestStreak, &streak.LastEntryDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &streak, nil
+}
+
+func (s *stats) CountEntries(ctx context.Context, userID int64, from, to time.Time) (int, error) {
+	var count int
+
+	query := `
+		SELECT COUNT(*)
+		FROM journals
+		WHERE user_id = $1
+			AND status = 'published'
+			AND entry_date >= $2
+			AND entry_date < $3
+	`
+
+	err := s.pool.QueryRow(ctx, query, userID, from, to).Scan(&count)
+	return count, err
+}
+
+// moodEvents collects every mood the user logged between $2 and $3, from
+// published journals and quick check-ins alike. Each journal or check-in
+// weighs one in total, split across a journal's moods by their intensity.
+const moodEvents = `
+	WITH mood_events AS (
+		SELECT jm.mood_id, j.entry_date, jm.intensity::float8 / SUM(jm.intensity) OVER (PARTITION BY j.id) AS weight
+		FROM journals j
+		JOIN journal_moods jm ON jm.journal_id = j.id
+		WHERE j.user_id = $1
+			AND j.status = 'published'
+			AND j.entry_date BETWEEN $2 AND $3
+		UNION ALL
+		SELECT mood_id, entry_date, 1
+		FROM mood_checkins
+		WHERE user_id = $1
+			AND entry_date BETWEEN $2 AND $3
+	)
+`
+
+func (s *stats) GetMoodDistribution(ctx context.Context, userID int64, from, to time.Time) ([]models.MoodCount, error) {
+	var counts []models.MoodCount
+
+	query := moodEvents + `
+		SELECT m.id, m.label, m.valence, COUNT(*), SUM(e.weight)
+		FROM mood_events e
+		JOIN moods m ON m.id = e.mood_id
+		GROUP BY m.id, m.label, m.valence
+		ORDER BY SUM(e.weight) DESC, m.id
+	`
+
+	rows, err := s.pool.Query(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c models.MoodCount
+		if err := rows.Scan(&c.MoodID, &c.MoodLabel, &c.Valence, &c.Count, &c.Weight); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// GetMoodTrend buckets moods by the week (starting Monday) or month of their
+// entry date.
+func (s *stats) GetMoodTrend(ctx context.Context, userID int64, from, to time.Time, interval string) ([]models.MoodTrendPoint, error) {
+	var points []models.MoodTrendPoint
+
+	query := moodEvents + `
+		SELECT date_trunc($4, e.entry_date::timestamp)::date AS period_start, m.id, m.label, m.valence, COUNT(*), SUM(e.weight)
+		FROM mood_events e
+		JOIN moods m ON m.id = e.mood_id
+		GROUP BY period_start, m.id, m.label, m.valence
+		ORDER BY period_start, SUM(e.weight) DESC, m.id
+	`
+
+	rows, err := s.pool.Query(ctx, query, userID, from, to, interval)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p models.MoodTrendPoint
+		if err := rows.Scan(&p.PeriodStart, &p.MoodID, &p.MoodLabel, &p.Valence, &p.Count, &p.Weight); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return points, nil
+}
+
+func (s *stats) GetMoodByWeekday(ctx context.Context, userID int64, from, to time.Time) ([]models.MoodWeekday, error) {
+	var weekdays []models.MoodWeekday
+
+	query := moodEvents + `
+		SELECT EXTRACT(ISODOW FROM e.entry_date)::int AS weekday, m.id, m.label, m.valence, COUNT(*), SUM(e.weight)
+		FROM mood_events e
+		JOIN moods m ON m.id = e.mood_id
+		GROUP BY weekday, m.id, m.label, m.valence
+		ORDER BY weekday, SUM(e.weight) DESC, m.id
+	`
+
+	rows, err := s.pool.Query(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var w models.MoodWeekday
+		if err := rows.Scan(&w.Weekday, &w.MoodID, &w.MoodLabel, &w.Valence, &w.Count, &w.Weight); err != nil {
+			return nil, err
+		}
+		weekdays = append(weekdays, w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return weekdays, nil
+}
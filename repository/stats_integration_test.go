@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"timo/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsRepository_Streak(t *testing.T) {
+	ctx := context.Background()
+	journals := NewJournal(testDB)
+	repo := NewStats(testDB)
+
+	day := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	var ids []int64
+	for _, offset := range []int{0, 1, 2, 5} {
+		journal := &models.Journal{UserID: 14, Title: "title test", Text: "text test", MoodID: 1, EntryDate: day.AddDate(0, 0, offset)}
+		err := journals.Create(ctx, journal)
+		assert.NoError(t, err)
+		ids = append(ids, journal.ID)
+	}
+
+	streak, err := repo.RecomputeStreak(ctx, 14)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, streak.CurrentStreak)
+	assert.GreaterOrEqual(t, streak.LongestStreak, 3)
+
+	advanced, err := repo.AdvanceStreak(ctx, 14, day.AddDate(0, 0, 6))
+	assert.NoError(t, err)
+	assert.True(t, advanced)
+
+	streak, err = repo.GetStreak(ctx, 14)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, streak.CurrentStreak)
+
+	advanced, err = repo.AdvanceStreak(ctx, 14, day.AddDate(0, 0, 3))
+	assert.NoError(t, err)
+	assert.False(t, advanced)
+
+	_, _ = testDB.Exec(ctx, `DELETE FROM journals WHERE id = ANY($1)`, ids)
+	_, _ = testDB.Exec(ctx, `DELETE FROM user_streaks WHERE user_id = 14`)
+}
+
+func TestStatsRepository_MoodAnalytics(t *testing.T) {
+	ctx := context.Background()
+	journals := NewJournal(testDB)
+	repo := NewStats(testDB)
+
+	// 2024-03-04 is a Monday.
+	monday := time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC)
+	var ids []int64
+	for _, j := range []struct {
+		offset int
+		moodID int64
+	}{{0, 1}, {0, 1}, {1, 5}, {7, 1}} {
+		journal := &models.Journal{UserID: 14, Title: "title test", Text: "text test", MoodID: j.moodID, EntryDate: monday.AddDate(0, 0, j.offset)}
+		err := journals.Create(ctx, journal)
+		assert.NoError(t, err)
+		ids = append(ids, journal.ID)
+	}
+
+	to := monday.AddDate(0, 0, 13)
+
+	counts, err := repo.GetMoodDistribution(ctx, 14, monday, to)
+	assert.NoError(t, err)
+	assert.Equal(t, models.MoodCount{MoodID: 1, MoodLabel: "happy", Count: 3}, counts[0])
+
+	points, err := repo.GetMoodTrend(ctx, 14, monday, to, "week")
+	assert.NoError(t, err)
+	assert.Len(t, points, 3)
+	assert.Equal(t, monday, points[0].PeriodStart)
+	assert.Equal(t, monday.AddDate(0, 0, 7), points[2].PeriodStart)
+
+	weekdays, err := repo.GetMoodByWeekday(ctx, 14, monday, to)
+	assert.NoError(t, err)
+	assert.Equal(t, models.MoodWeekday{Weekday: 1, MoodCount: models.MoodCount{MoodID: 1, MoodLabel: "happy", Count: 3}}, weekdays[0])
+
+	_, _ = testDB.Exec(ctx, `DELETE FROM journals WHERE id = ANY($1)`, ids)
+}
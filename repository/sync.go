@@ -0,0 +1,334 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+	"timo/domain"
+	"timo/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type journalSync struct {
+	pool *pgxpool.Pool
+}
+
+func NewSync(pool *pgxpool.Pool) domain.SyncRepository {
+	return &journalSync{pool: pool}
+}
+
+// Sync applies a client change set and collects every change the user made
+// after since, all in one transaction. All changes of a batch share one
+// change sequence, and locking the user row first keeps batches ordered with
+// the regular journal writes.
+func (s *journalSync) Sync(ctx context.Context, userID int64, since int64, changes []models.JournalChange) (*models.SyncResult, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `SELECT change_seq FROM users WHERE id = $1`
+	if len(changes) > 0 {
+		query = `UPDATE users SET change_seq = change_seq + 1 WHERE id = $1 RETURNING change_seq`
+	}
+
+	result := &models.SyncResult{}
+	if err := tx.QueryRow(ctx, query, userID).Scan(&result.ChangeSeq); err != nil {
+		return nil, err
+	}
+
+	for _, change := range changes {
+		res, err := applyChange(ctx, tx, userID, result.ChangeSeq, change)
+		if err != nil {
+			return nil, err
+		}
+		result.Results = append(result.Results, *res)
+	}
+
+	result.Journals, err = changedJournals(ctx, tx, userID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Tombstones, err = changedTombstones(ctx, tx, userID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// applyChange runs a single change inside a savepoint so a change rejected by
+// a constraint is reported as a conflict without aborting the whole batch.
+func applyChange(ctx context.Context, tx pgx.Tx, userID, seq int64, change models.JournalChange) (*models.JournalChangeResult, error) {
+	res := &models.JournalChangeResult{Uid: change.Uid, Op: change.Op}
+
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer sp.Rollback(ctx)
+
+	switch change.Op {
+	case models.CHANGE_CREATE:
+		err = applyCreate(ctx, sp, userID, seq, change, res)
+	case models.CHANGE_UPDATE:
+		err = applyUpdate(ctx, sp, userID, seq, change, res)
+	case models.CHANGE_DELETE:
+		err = applyDelete(ctx, sp, userID, seq, change, res)
+	default:
+		res.Conflict = models.CONFLICT_INVALID
+	}
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && strings.HasPrefix(pgErr.Code, "23") {
+			res.Conflict = models.CONFLICT_INVALID
+			return res, nil
+		}
+		return nil, err
+	}
+
+	if res.Conflict != "" {
+		return res, nil
+	}
+
+	return res, sp.Commit(ctx)
+}
+
+func applyCreate(ctx context.Context, tx pgx.Tx, userID, seq int64, change models.JournalChange, res *models.JournalChangeResult) error {
+	deleted, err := isTombstoned(ctx, tx, userID, change.Uid)
+	if err != nil {
+		return err
+	}
+	if deleted {
+		res.Conflict = models.CONFLICT_DELETED
+		return nil
+	}
+
+	visible, err := moodVisible(ctx, tx, userID, change.MoodID)
+	if err != nil {
+		return err
+	}
+	if !visible {
+		res.Conflict = models.CONFLICT_INVALID
+		return nil
+	}
+
+	query := `
+		INSERT INTO journals (uid, user_id, title, text, mood_id, entry_date, change_seq)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (uid) DO NOTHING
+		RETURNING id, version
+	`
+
+	var journalID int64
+	err = tx.QueryRow(ctx, query, change.Uid, userID, change.Title, change.Text, change.MoodID, change.EntryDate, seq).Scan(&journalID, &res.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		res.Conflict = models.CONFLICT_ALREADY_EXISTS
+		res.Server, err = ownedJournal(ctx, tx, userID, change.Uid)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	moods := []models.JournalMood{{MoodID: change.MoodID, Intensity: models.DEFAULT_MOOD_INTENSITY}}
+	return setMoods(ctx, tx, journalID, change.MoodID, moods)
+}
+
+func applyUpdate(ctx context.Context, tx pgx.Tx, userID, seq int64, change models.JournalChange, res *models.JournalChangeResult) error {
+	journalID, ok, err := lockForChange(ctx, tx, userID, change, res)
+	if err != nil || !ok {
+		return err
+	}
+
+	visible, err := moodVisible(ctx, tx, userID, change.MoodID)
+	if err != nil {
+		return err
+	}
+	if !visible {
+		res.Conflict = models.CONFLICT_INVALID
+		return nil
+	}
+
+	if err := saveRevision(ctx, tx, journalID); err != nil {
+		return err
+	}
+
+	if err := setMoods(ctx, tx, journalID, change.MoodID, nil); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE journals
+		SET title = $1,
+			text = $2,
+			mood_id = $3,
+			entry_date = COALESCE($4, entry_date),
+			updated_at = $5,
+			version = version + 1,
+			change_seq = $6
+		WHERE id = $7
+		RETURNING version
+	`
+
+	return tx.QueryRow(ctx, query, change.Title, change.Text, change.MoodID, change.EntryDate, time.Now().UTC(), seq, journalID).Scan(&res.Version)
+}
+
+func applyDelete(ctx context.Context, tx pgx.Tx, userID, seq int64, change models.JournalChange, res *models.JournalChangeResult) error {
+	journalID, ok, err := lockForChange(ctx, tx, userID, change, res)
+	if err != nil || !ok {
+		if res.Conflict == models.CONFLICT_DELETED {
+			// Deleting an entry that is already gone is not a conflict.
+			res.Conflict = ""
+		}
+		return err
+	}
+
+	return deleteWithTombstone(ctx, tx, journalID, seq)
+}
+
+// lockForChange locks the journal targeted by an update or delete and checks
+// it against the client's base version, recording a conflict when it can't
+// be applied.
+func lockForChange(ctx context.Context, tx pgx.Tx, userID int64, change models.JournalChange, res *models.JournalChangeResult) (int64, bool, error) {
+	var journalID, ownerID, version int64
+	err := tx.QueryRow(ctx, `SELECT id, user_id, version FROM journals WHERE uid = $1 FOR UPDATE`, change.Uid).
+		Scan(&journalID, &ownerID, &version)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, false, err
+	}
+
+	if errors.Is(err, sql.ErrNoRows) || ownerID != userID {
+		deleted, err := isTombstoned(ctx, tx, userID, change.Uid)
+		if err != nil {
+			return 0, false, err
+		}
+
+		res.Conflict = models.CONFLICT_NOT_FOUND
+		if deleted {
+			res.Conflict = models.CONFLICT_DELETED
+		}
+		return 0, false, nil
+	}
+
+	if version != change.BaseVersion {
+		res.Conflict = models.CONFLICT_VERSION_MISMATCH
+		res.Server, err = ownedJournal(ctx, tx, userID, change.Uid)
+		return 0, false, err
+	}
+
+	return journalID, true, nil
+}
+
+func isTombstoned(ctx context.Context, tx pgx.Tx, userID int64, uid string) (bool, error) {
+	var deleted bool
+	query := `SELECT EXISTS (SELECT 1 FROM journal_tombstones WHERE uid = $1 AND user_id = $2)`
+	err := tx.QueryRow(ctx, query, uid, userID).Scan(&deleted)
+	return deleted, err
+}
+
+func ownedJournal(ctx context.Context, tx pgx.Tx, userID int64, uid string) (*models.Journal, error) {
+	var journal models.Journal
+
+	query := `
+		SELECT j.id, j.uid, j.user_id, j.title, j.text, j.mood_id, m.label AS mood_label, j.version, j.status, j.entry_date, j.created_at, j.updated_at
+		FROM journals j
+		JOIN moods m ON m.id = j.mood_id
+		WHERE j.uid = $1 AND j.user_id = $2
+	`
+
+	err := tx.QueryRow(ctx, query, uid, userID).
+		Scan(&journal.ID, &journal.Uid, &journal.UserID, &journal.Title, &journal.Text, &journal.MoodID, &journal.MoodLabel, &journal.Version, &journal.Status, &journal.EntryDate, &journal.CreatedAt, &journal.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &journal, nil
+}
+
+func changedJournals(ctx context.Context, tx pgx.Tx, userID, since int64) ([]models.Journal, error) {
+	var journals []models.Journal
+
+	query := `
+		SELECT j.id, j.uid, j.user_id, j.title, j.text, j.mood_id, m.label AS mood_label, j.version, j.status, j.entry_date, j.created_at, j.updated_at
+		FROM journals j
+		JOIN moods m ON m.id = j.mood_id
+		WHERE j.user_id = $1 AND j.change_seq > $2
+		ORDER BY j.change_seq, j.id
+	`
+
+	rows, err := tx.Query(ctx, query, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var j models.Journal
+		err := rows.Scan(&j.ID, &j.Uid, &j.UserID, &j.Title, &j.Text, &j.MoodID, &j.MoodLabel, &j.Version, &j.Status, &j.EntryDate, &j.CreatedAt, &j.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		journals = append(journals, j)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return journals, nil
+}
+
+func changedTombstones(ctx context.Context, tx pgx.Tx, userID, since int64) ([]models.JournalTombstone, error) {
+	var tombstones []models.JournalTombstone
+
+	query := `
+		SELECT uid, user_id, change_seq, deleted_at
+		FROM journal_tombstones
+		WHERE user_id = $1 AND change_seq > $2
+		ORDER BY change_seq
+	`
+
+	rows, err := tx.Query(ctx, query, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t models.JournalTombstone
+		if err := rows.Scan(&t.Uid, &t.UserID, &t.ChangeSeq, &t.DeletedAt); err != nil {
+			return nil, err
+		}
+		tombstones = append(tombstones, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tombstones, nil
+}
+
+// moodVisible reports whether the mood is a global one or belongs to the user.
+// Archived moods still count so offline edits made before archiving apply.
+func moodVisible(ctx context.Context, tx pgx.Tx, userID, moodID int64) (bool, error) {
+	var ok bool
+	err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM moods WHERE id = $1 AND (user_id IS NULL OR user_id = $2))`, moodID, userID).Scan(&ok)
+	return ok, err
+}
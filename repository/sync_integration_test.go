@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"timo/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncRepository_Sync(t *testing.T) {
+	ctx := context.Background()
+	repo := NewSync(testDB)
+	journalRepo := NewJournal(testDB)
+
+	initial, err := repo.Sync(ctx, 14, 0, nil)
+	assert.NoError(t, err)
+
+	created := "3f1c2a9e-8d4b-4c1e-9a7f-2b6d5e4c3a21"
+	result, err := repo.Sync(ctx, 14, initial.ChangeSeq, []models.JournalChange{
+		{Op: models.CHANGE_CREATE, Uid: created, Title: "title test", Text: "text test", MoodID: 1},
+		{Op: models.CHANGE_UPDATE, Uid: "550e8400-e29b-41d4-a716-446655440000", BaseVersion: 1, Title: "title", Text: "text", MoodID: 1},
+	})
+	assert.NoError(t, err)
+	assert.Greater(t, result.ChangeSeq, initial.ChangeSeq)
+	assert.Equal(t, "", result.Results[0].Conflict)
+	assert.Equal(t, models.CONFLICT_NOT_FOUND, result.Results[1].Conflict)
+	assert.Len(t, result.Journals, 1)
+
+	journal, err := journalRepo.GetByID(ctx, created)
+	assert.NoError(t, err)
+
+	result, err = repo.Sync(ctx, 14, result.ChangeSeq, []models.JournalChange{
+		{Op: models.CHANGE_DELETE, Uid: created, BaseVersion: journal.Version},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "", result.Results[0].Conflict)
+	assert.Len(t, result.Tombstones, 1)
+	assert.Equal(t, created, result.Tombstones[0].Uid)
+
+	_, _ = testDB.Exec(ctx, `DELETE FROM journal_tombstones WHERE uid = $1`, created)
+}
@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"timo/domain"
+	"timo/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type upload struct {
+	pool *pgxpool.Pool
+}
+
+func NewUpload(pool *pgxpool.Pool) domain.UploadRepository {
+	return &upload{pool: pool}
+}
+
+const uploadColumns = `id, uid, user_id, journal_id, filename, size, received, checksum, caption, alt_text, peaks, expires_at, created_at`
+
+func scanUpload(row pgx.Row, upload *models.PhotoUpload) error {
+	return row.Scan(&upload.ID, &upload.Uid, &upload.UserID, &upload.JournalID, &upload.Filename, &upload.Size, &upload.Received,
+		&upload.Checksum, &upload.Caption, &upload.AltText, &upload.Peaks, &upload.ExpiresAt, &upload.CreatedAt)
+}
+
+// Create adds an upload unless the user already has limit unexpired ones.
+// The user's row is locked for the count, so concurrent creates can't pass
+// the limit together.
+func (u *upload) Create(ctx context.Context, upload *models.PhotoUpload, limit int) error {
+	tx, err := u.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, upload.UserID); err != nil {
+		return err
+	}
+
+	var count int
+	query := `
+		SELECT count(*)
+		FROM photo_uploads
+		WHERE user_id = $1 AND expires_at > now()
+	`
+
+	if err := tx.QueryRow(ctx, query, upload.UserID).Scan(&count); err != nil {
+		return err
+	}
+	if count >= limit {
+		return domain.ErrUploadLimit
+	}
+
+	query = `
+		INSERT INTO photo_uploads (user_id, journal_id, filename, size, checksum, caption, alt_text, peaks, expires_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9)
+		RETURNING id, uid, received, created_at
+	`
+
+	err = tx.QueryRow(ctx, query, upload.UserID, upload.JournalID, upload.Filename, upload.Size, upload.Checksum,
+		upload.Caption, upload.AltText, upload.Peaks, upload.ExpiresAt).
+		Scan(&upload.ID, &upload.Uid, &upload.Received, &upload.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetByUid loads an upload with its parts in order.
+func (u *upload) GetByUid(ctx context.Context, uid string) (*models.PhotoUpload, error) {
+	var upload models.PhotoUpload
+
+	query := `
+		SELECT ` + uploadColumns + `
+		FROM photo_uploads
+		WHERE uid = $1
+	`
+
+	err := scanUpload(u.pool.QueryRow(ctx, query, uid), &upload)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+
+	query = `
+		SELECT upload_id, start, storage_key, size
+		FROM photo_upload_parts
+		WHERE upload_id = $1
+		ORDER BY start
+	`
+
+	rows, err := u.pool.Query(ctx, query, upload.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var part models.PhotoUploadPart
+		if err := rows.Scan(&part.UploadID, &part.Start, &part.StorageKey, &part.Size); err != nil {
+			return nil, err
+		}
+		upload.Parts = append(upload.Parts, part)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &upload, nil
+}
+
+// AddPart records a stored chunk and moves the upload's offset past it, but
+// only if the chunk starts where the upload currently ends. Otherwise it
+// returns the current offset with domain.ErrUploadOffset, so of two clients
+// sending the same chunk only one wins.
+func (u *upload) AddPart(ctx context.Context, uploadID int64, part *models.PhotoUploadPart) (int64, error) {
+	tx, err := u.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var received int64
+	query := `
+		UPDATE photo_uploads
+		SET received = received + $3
+		WHERE id = $1 AND received = $2
+		RETURNING received
+	`
+
+	err = tx.QueryRow(ctx, query, uploadID, part.Start, part.Size).Scan(&received)
+	if errors.Is(err, sql.ErrNoRows) {
+		if err := tx.QueryRow(ctx, `SELECT received FROM photo_uploads WHERE id = $1`, uploadID).Scan(&received); err != nil {
+			return 0, err
+		}
+		return received, domain.ErrUploadOffset
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	query = `
+		INSERT INTO photo_upload_parts (upload_id, start, storage_key, size)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	if _, err := tx.Exec(ctx, query, uploadID, part.Start, part.StorageKey, part.Size); err != nil {
+		return 0, err
+	}
+
+	return received, tx.Commit(ctx)
+}
+
+// Claim marks an unexpired upload as being finalized. It returns
+// sql.ErrNoRows when the upload expired or another finalize holds a claim
+// younger than staleAfter, so only one of two concurrent calls goes on.
+func (u *upload) Claim(ctx context.Context, id int64, staleAfter time.Duration) error {
+	query := `
+		UPDATE photo_uploads
+		SET finalizing_at = now()
+		WHERE id = $1 AND expires_at > now()
+			AND (finalizing_at IS NULL OR finalizing_at < now() - make_interval(secs => $2))
+	`
+
+	result, err := u.pool.Exec(ctx, query, id, staleAfter.Seconds())
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Unclaim lets an upload whose finalize failed be finalized again.
+func (u *upload) Unclaim(ctx context.Context, id int64) error {
+	_, err := u.pool.Exec(ctx, `UPDATE photo_uploads SET finalizing_at = NULL WHERE id = $1`, id)
+	return err
+}
+
+// DeleteExpired removes an upload that expired before now, unless a finalize
+// claimed it less than staleAfter ago. It returns sql.ErrNoRows when the
+// upload is gone or still being finalized.
+func (u *upload) DeleteExpired(ctx context.Context, id int64, now time.Time, staleAfter time.Duration) error {
+	query := `
+		DELETE FROM photo_uploads
+		WHERE id = $1 AND expires_at < $2
+			AND (finalizing_at IS NULL OR finalizing_at < $2 - make_interval(secs => $3))
+	`
+
+	result, err := u.pool.Exec(ctx, query, id, now, staleAfter.Seconds())
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetExpired returns the uploads that expired before now, without their parts.
+func (u *upload) GetExpired(ctx context.Context, now time.Time) ([]models.PhotoUpload, error) {
+	var uploads []models.PhotoUpload
+	query := `
+		SELECT ` + uploadColumns + `
+		FROM photo_uploads
+		WHERE expires_at < $1
+		ORDER BY id
+	`
+
+	rows, err := u.pool.Query(ctx, query, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var upload models.PhotoUpload
+		if err := scanUpload(rows, &upload); err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, upload)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return uploads, nil
+}
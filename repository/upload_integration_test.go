@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+	"timo/domain"
+	"timo/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadRepository_Parts(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUpload(testDB)
+
+	upload := &models.PhotoUpload{UserID: 14, JournalID: 17, Filename: "a.png", Size: 10, ExpiresAt: time.Now().Add(time.Hour)}
+	err := repo.Create(ctx, upload, 5)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, upload.Uid)
+	defer testDB.Exec(ctx, `DELETE FROM photo_uploads WHERE id = $1`, upload.ID)
+
+	err = repo.Create(ctx, &models.PhotoUpload{UserID: 14, JournalID: 17, Size: 10, ExpiresAt: time.Now().Add(time.Hour)}, 1)
+	assert.ErrorIs(t, err, domain.ErrUploadLimit)
+
+	received, err := repo.AddPart(ctx, upload.ID, &models.PhotoUploadPart{Start: 0, StorageKey: "a", Size: 4})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), received)
+
+	received, err = repo.AddPart(ctx, upload.ID, &models.PhotoUploadPart{Start: 0, StorageKey: "b", Size: 4})
+	assert.ErrorIs(t, err, domain.ErrUploadOffset)
+	assert.Equal(t, int64(4), received)
+
+	_, err = repo.AddPart(ctx, upload.ID, &models.PhotoUploadPart{Start: 4, StorageKey: "c", Size: 6})
+	assert.NoError(t, err)
+
+	got, err := repo.GetByUid(ctx, upload.Uid)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), got.Received)
+	assert.Equal(t, []string{"a", "c"}, []string{got.Parts[0].StorageKey, got.Parts[1].StorageKey})
+
+	err = repo.Claim(ctx, upload.ID, time.Minute)
+	assert.NoError(t, err)
+	err = repo.Claim(ctx, upload.ID, time.Minute)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	expired, err := repo.GetExpired(ctx, time.Now().Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, expired)
+
+	// A claimed upload is left alone until the claim goes stale.
+	err = repo.DeleteExpired(ctx, upload.ID, time.Now().Add(2*time.Hour), 3*time.Hour)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	err = repo.Unclaim(ctx, upload.ID)
+	assert.NoError(t, err)
+	err = repo.DeleteExpired(ctx, upload.ID, time.Now().Add(2*time.Hour), 3*time.Hour)
+	assert.NoError(t, err)
+
+	_, err = repo.GetByUid(ctx, upload.Uid)
+	assert.Equal(t, sql.ErrNoRows, err)
+}
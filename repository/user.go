@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"timo/domain"
+	"timo/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type user struct {
+	pool *pgxpool.Pool
+}
+
+func NewUser(pool *pgxpool.Pool) domain.UserRepository {
+	return &user{pool: pool}
+}
+
+func (u *user) GetByUID(ctx context.Context, uid string) (*models.User, error) {
+	var user models.User
+
+	query := `
+		SELECT id, uid, google_id, name, email, password_hash, timezone, memory_digest, weekly_goal, created_at, updated_at
+		FROM users
+		WHERE uid = $1
+	`
+
+	err := u.pool.QueryRow(ctx, query, uid).
+		Scan(&user.ID, &user.Uid, &user.GoogleID, &user.Name, &user.Email, &user.Password, &user.Timezone, &user.MemoryDigest, &user.WeeklyGoal, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (u *user) UpdateSettings(ctx context.Context, uid string, settings *models.UserSettings) error {
+	var sets []string
+	var args []any
+	set := func(column string, value any) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if settings.Timezone != nil {
+		set("timezone", *settings.Timezone)
+	}
+	if settings.MemoryDigest != nil {
+		set("memory_digest", *settings.MemoryDigest)
+	}
+	if settings.WeeklyGoal != nil {
+		set("weekly_goal", *settings.WeeklyGoal)
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+	set("updated_at", time.Now().UTC())
+
+	args = append(args, uid)
+	query := fmt.Sprintf(`
+		UPDATE users
+		SET %s
+		WHERE uid = $%d
+	`, strings.Join(sets, ", "), len(args))
+
+	result, err := u.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (u *user) GetDigestSubscribers(ctx context.Context) ([]models.User, error) {
+	var users []models.User
+
+	query := `
+		SELECT id, uid, name, email, timezone, memory_digest
+		FROM users
+		WHERE memory_digest
+		ORDER BY id
+	`
+
+	rows, err := u.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Uid, &user.Name, &user.Email, &user.Timezone, &user.MemoryDigest); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// ClaimDigest records that the digest for date is being sent to the user and
+// reports false when it was already claimed, so concurrent workers never send
+// the same digest twice.
+func (u *user) ClaimDigest(ctx context.Context, userID int64, date time.Time) (bool, error) {
+	query := `
+		UPDATE users
+		SET memory_digest_sent_on = $2
+		WHERE id = $1 AND memory_digest_sent_on IS DISTINCT FROM $2
+	`
+
+	result, err := u.pool.Exec(ctx, query, userID, date)
+	if err != nil {
+		return false, err
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+// ReleaseDigest gives up a claim on the digest for date after it could not
+// be sent, so that the next run tries again.
+func (u *user) ReleaseDigest(ctx context.Context, userID int64, date time.Time) error {
+	query := `
+		UPDATE users
+		SET memory_digest_sent_on = NULL
+		WHERE id = $1 AND memory_digest_sent_on = $2
+	`
+
+	_, err := u.pool.Exec(ctx, query, userID, date)
+	return err
+}
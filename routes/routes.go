@@ -8,7 +8,19 @@ import (
 )
 
 type Handlers struct {
-	AuthHandler handler.Auth
+	AuthHandler    handler.Auth
+	JournalHandler handler.Journal
+	SyncHandler    handler.Sync
+	UserHandler    handler.User
+	MoodHandler    handler.Mood
+	StatsHandler   handler.Stats
+	CheckinHandler handler.Checkin
+	PhotoHandler   handler.Photo
+	MediaHandler   handler.Media
+	UploadHandler  handler.Upload
+	ExportHandler  handler.Export
+	ImportHandler  handler.Import
+	AuthMiddleware gin.HandlerFunc
 }
 
 func SetupRoutes(r *gin.Engine, handlers *Handlers) {
@@ -19,4 +31,64 @@ func SetupRoutes(r *gin.Engine, handlers *Handlers) {
 	r.POST("/register", handlers.AuthHandler.Register)
 	r.POST("/login/password", handlers.AuthHandler.LoginWithPassword)
 	r.POST("/login/google", handlers.AuthHandler.LoginWithGoogle)
+
+	r.POST("/sync", handlers.AuthMiddleware, handlers.SyncHandler.Sync)
+
+	r.GET("/media/*key", handlers.MediaHandler.Download)
+	r.HEAD("/media/*key", handlers.MediaHandler.Download)
+
+	me := r.Group("/me", handlers.AuthMiddleware)
+	me.GET("", handlers.UserHandler.GetProfile)
+	me.PATCH("/settings", handlers.UserHandler.UpdateSettings)
+	me.GET("/stats", handlers.StatsHandler.GetStats)
+
+	moods := r.Group("/moods", handlers.AuthMiddleware)
+	moods.GET("", handlers.MoodHandler.GetList)
+	moods.POST("", handlers.MoodHandler.Create)
+	moods.PUT("/order", handlers.MoodHandler.Reorder)
+	moods.PATCH("/:id", handlers.MoodHandler.Update)
+	moods.DELETE("/:id", handlers.MoodHandler.Delete)
+
+	checkins := r.Group("/checkins", handlers.AuthMiddleware)
+	checkins.GET("", handlers.CheckinHandler.GetList)
+	checkins.POST("", handlers.CheckinHandler.Create)
+	checkins.GET("/:uid", handlers.CheckinHandler.GetByID)
+	checkins.PUT("/:uid", handlers.CheckinHandler.Update)
+	checkins.DELETE("/:uid", handlers.CheckinHandler.Delete)
+
+	insights := r.Group("/insights", handlers.AuthMiddleware)
+	insights.GET("/moods", handlers.StatsHandler.GetMoodDistribution)
+	insights.GET("/moods/trend", handlers.StatsHandler.GetMoodTrend)
+	insights.GET("/moods/weekday", handlers.StatsHandler.GetMoodByWeekday)
+
+	journals := r.Group("/journals", handlers.AuthMiddleware)
+	journals.GET("", handlers.JournalHandler.GetList)
+	journals.POST("", handlers.JournalHandler.Create)
+	journals.GET("/calendar", handlers.JournalHandler.GetCalendar)
+	journals.GET("/memories", handlers.JournalHandler.GetMemories)
+	journals.GET("/export", handlers.ExportHandler.Export)
+	journals.GET("/:uid", handlers.JournalHandler.GetByID)
+	journals.PUT("/:uid", handlers.JournalHandler.Update)
+	journals.PATCH("/:uid", handlers.JournalHandler.Patch)
+	journals.DELETE("/:uid", handlers.JournalHandler.Delete)
+	journals.PUT("/:uid/autosave", handlers.JournalHandler.Autosave)
+	journals.POST("/:uid/publish", handlers.JournalHandler.Publish)
+	journals.GET("/:uid/revisions", handlers.JournalHandler.GetRevisions)
+	journals.GET("/:uid/revisions/diff", handlers.JournalHandler.Diff)
+	journals.POST("/:uid/revisions/:revision/revert", handlers.JournalHandler.Revert)
+	// Recordings are attachments under /photos as well, see models.Photo.
+	journals.GET("/:uid/photos", handlers.PhotoHandler.GetList)
+	journals.POST("/:uid/photos", handlers.PhotoHandler.Upload)
+	journals.PUT("/:uid/photos/order", handlers.PhotoHandler.Reorder)
+	journals.PATCH("/:uid/photos/:id", handlers.PhotoHandler.Update)
+	journals.DELETE("/:uid/photos/:id", handlers.PhotoHandler.Delete)
+	journals.POST("/:uid/uploads", handlers.UploadHandler.Create)
+	journals.HEAD("/:uid/uploads/:id", handlers.UploadHandler.Offset)
+	journals.PATCH("/:uid/uploads/:id", handlers.UploadHandler.Append)
+	journals.POST("/:uid/uploads/:id/finalize", handlers.UploadHandler.Finalize)
+
+	imports := r.Group("/imports", handlers.AuthMiddleware)
+	imports.GET("", handlers.ImportHandler.GetList)
+	imports.POST("", handlers.ImportHandler.Create)
+	imports.GET("/:uid", handlers.ImportHandler.GetByID)
 }
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/models"
+)
+
+type checkin struct {
+	repo  domain.CheckinRepository
+	moods domain.MoodRepository
+}
+
+func NewCheckin(repo domain.CheckinRepository, moods domain.MoodRepository) domain.CheckinService {
+	return &checkin{repo: repo, moods: moods}
+}
+
+func (ch *checkin) GetList(ctx context.Context, userID int64, query *dto.CheckinListQuery) ([]dto.CheckinResponse, error) {
+	filter := &models.CheckinFilter{}
+	if query.From != "" {
+		from, _ := helper.ParseDate(query.From)
+		filter.From = &from
+	}
+	if query.To != "" {
+		to, _ := helper.ParseDate(query.To)
+		filter.To = &to
+	}
+
+	checkins, err := ch.repo.GetListByUserID(ctx, userID, filter)
+	if err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get check-ins", err)
+	}
+
+	resp := make([]dto.CheckinResponse, 0, len(checkins))
+	for _, c := range checkins {
+		resp = append(resp, toCheckinResponse(&c))
+	}
+
+	return resp, nil
+}
+
+func (ch *checkin) GetByID(ctx context.Context, userID int64, uid string) (*dto.CheckinResponse, error) {
+	c, err := ch.getOwned(ctx, userID, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := toCheckinResponse(c)
+	return &resp, nil
+}
+
+// Create logs the mood at checked_at, or now when the client leaves it out,
+// and files it under that moment's date in the user's timezone.
+func (ch *checkin) Create(ctx context.Context, userID int64, loc *time.Location, req *dto.CheckinRequest) (*dto.CheckinResponse, error) {
+	if err := checkMoods(ctx, ch.moods, userID, req.MoodID); err != nil {
+		return nil, err
+	}
+
+	c := &models.MoodCheckin{UserID: userID}
+	applyCheckin(c, loc, req)
+
+	if err := ch.repo.Create(ctx, c); err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to create check-in", err)
+	}
+
+	return ch.reload(ctx, c.Uid)
+}
+
+func (ch *checkin) Update(ctx context.Context, userID int64, loc *time.Location, uid string, req *dto.CheckinRequest) (*dto.CheckinResponse, error) {
+	c, err := ch.getOwned(ctx, userID, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.MoodID != c.MoodID {
+		if err := checkMoods(ctx, ch.moods, userID, req.MoodID); err != nil {
+			return nil, err
+		}
+	}
+
+	checkedAt := c.CheckedAt
+	applyCheckin(c, loc, req)
+	if req.CheckedAt == nil {
+		c.CheckedAt = checkedAt
+		c.EntryDate = helper.LocalDate(checkedAt, loc)
+	}
+
+	if err := ch.repo.Update(ctx, c); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helper.NewAppError(helper.NOT_FOUND, "check-in not found", err)
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to update check-in", err)
+	}
+
+	return ch.reload(ctx, c.Uid)
+}
+
+func (ch *checkin) Delete(ctx context.Context, userID int64, uid string) error {
+	if _, err := ch.getOwned(ctx, userID, uid); err != nil {
+		return err
+	}
+
+	if err := ch.repo.Delete(ctx, uid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return helper.NewAppError(helper.NOT_FOUND, "check-in not found", err)
+		}
+		return helper.NewAppError(helper.INTERNAL_ERROR, "failed to delete check-in", err)
+	}
+
+	return nil
+}
+
+// reload reads the check-in back so the response carries the mood label.
+func (ch *checkin) reload(ctx context.Context, uid string) (*dto.CheckinResponse, error) {
+	c, err := ch.repo.GetByID(ctx, uid)
+	if err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get check-in", err)
+	}
+
+	resp := toCheckinResponse(c)
+	return &resp, nil
+}
+
+func (ch *checkin) getOwned(ctx context.Context, userID int64, uid string) (*models.MoodCheckin, error) {
+	c, err := ch.repo.GetByID(ctx, uid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helper.NewAppError(helper.NOT_FOUND, "check-in not found", err)
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get check-in", err)
+	}
+
+	if c.UserID != userID {
+		return nil, helper.NewAppError(helper.NOT_FOUND, "check-in not found", nil)
+	}
+
+	return c, nil
+}
+
+func applyCheckin(c *models.MoodCheckin, loc *time.Location, req *dto.CheckinRequest) {
+	c.MoodID = req.MoodID
+	c.Intensity = req.Intensity
+	c.Note = req.Note
+	c.CheckedAt = time.Now().UTC()
+	if req.CheckedAt != nil {
+		c.CheckedAt = req.CheckedAt.UTC()
+	}
+	c.EntryDate = helper.LocalDate(c.CheckedAt, loc)
+}
+
+func toCheckinResponse(c *models.MoodCheckin) dto.CheckinResponse {
+	return dto.CheckinResponse{
+		Uid:       c.Uid,
+		MoodID:    c.MoodID,
+		MoodLabel: c.MoodLabel,
+		Intensity: c.Intensity,
+		Note:      c.Note,
+		CheckedAt: c.CheckedAt,
+		EntryDate: c.EntryDate.Format(helper.DATE_LAYOUT),
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+	"timo/dto"
+	"timo/helper"
+	"timo/mocks"
+	"timo/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCheckinService_Create(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	checkedAt := time.Date(2024, 3, 9, 20, 0, 0, 0, time.UTC)
+	intensity := 2
+
+	tests := []struct {
+		name       string
+		setupMocks func(repo *mocks.CheckinRepositoryMock, moods *mocks.MoodRepositoryMock)
+		wantErr    string
+	}{
+		{
+			name: "mood not selectable",
+			setupMocks: func(repo *mocks.CheckinRepositoryMock, moods *mocks.MoodRepositoryMock) {
+				moods.On("CountSelectable", mock.Anything, int64(1), []int64{10}).Return(0, nil)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name: "repo error",
+			setupMocks: func(repo *mocks.CheckinRepositoryMock, moods *mocks.MoodRepositoryMock) {
+				moods.On("CountSelectable", mock.Anything, int64(1), []int64{10}).Return(1, nil)
+				repo.On("Create", mock.Anything, mock.AnythingOfType("*models.MoodCheckin")).Return(sql.ErrConnDone)
+			},
+			wantErr: helper.INTERNAL_ERROR,
+		},
+		{
+			name: "success files under local date",
+			setupMocks: func(repo *mocks.CheckinRepositoryMock, moods *mocks.MoodRepositoryMock) {
+				moods.On("CountSelectable", mock.Anything, int64(1), []int64{10}).Return(1, nil)
+				repo.On("Create", mock.Anything, mock.MatchedBy(func(c *models.MoodCheckin) bool {
+					return c.UserID == 1 && c.CheckedAt.Equal(checkedAt) && c.EntryDate.Format(helper.DATE_LAYOUT) == "2024-03-10"
+				})).Run(func(args mock.Arguments) {
+					args.Get(1).(*models.MoodCheckin).Uid = "c-1"
+				}).Return(nil)
+				repo.On("GetByID", mock.Anything, "c-1").Return(&models.MoodCheckin{
+					Uid: "c-1", UserID: 1, MoodID: 10, MoodLabel: "tired", Intensity: &intensity,
+					CheckedAt: checkedAt, EntryDate: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC),
+				}, nil)
+			},
+			wantErr: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.CheckinRepositoryMock)
+			moods := new(mocks.MoodRepositoryMock)
+			tt.setupMocks(repo, moods)
+
+			svc := NewCheckin(repo, moods)
+			resp, err := svc.Create(context.Background(), 1, loc, &dto.CheckinRequest{MoodID: 10, Intensity: &intensity, CheckedAt: &checkedAt})
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, "c-1", resp.Uid)
+				assert.Equal(t, "tired", resp.MoodLabel)
+				assert.Equal(t, "2024-03-10", resp.EntryDate)
+			} else {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+			}
+			repo.AssertExpectations(t)
+			moods.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCheckinService_Update(t *testing.T) {
+	checkedAt := time.Date(2024, 3, 9, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		req        dto.CheckinRequest
+		setupMocks func(repo *mocks.CheckinRepositoryMock, moods *mocks.MoodRepositoryMock)
+		wantErr    string
+	}{
+		{
+			name: "not found",
+			req:  dto.CheckinRequest{MoodID: 10},
+			setupMocks: func(repo *mocks.CheckinRepositoryMock, moods *mocks.MoodRepositoryMock) {
+				repo.On("GetByID", mock.Anything, "c-1").Return(nil, sql.ErrNoRows)
+			},
+			wantErr: helper.NOT_FOUND,
+		},
+		{
+			name: "owned by another user",
+			req:  dto.CheckinRequest{MoodID: 10},
+			setupMocks: func(repo *mocks.CheckinRepositoryMock, moods *mocks.MoodRepositoryMock) {
+				repo.On("GetByID", mock.Anything, "c-1").Return(&models.MoodCheckin{Uid: "c-1", UserID: 2, MoodID: 10}, nil)
+			},
+			wantErr: helper.NOT_FOUND,
+		},
+		{
+			name: "keeps timestamp when omitted",
+			req:  dto.CheckinRequest{MoodID: 10},
+			setupMocks: func(repo *mocks.CheckinRepositoryMock, moods *mocks.MoodRepositoryMock) {
+				repo.On("GetByID", mock.Anything, "c-1").Return(&models.MoodCheckin{Uid: "c-1", UserID: 1, MoodID: 10, CheckedAt: checkedAt}, nil)
+				repo.On("Update", mock.Anything, mock.MatchedBy(func(c *models.MoodCheckin) bool {
+					return c.CheckedAt.Equal(checkedAt) && c.EntryDate.Format(helper.DATE_LAYOUT) == "2024-03-09"
+				})).Return(nil)
+			},
+			wantErr: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.CheckinRepositoryMock)
+			moods := new(mocks.MoodRepositoryMock)
+			tt.setupMocks(repo, moods)
+
+			svc := NewCheckin(repo, moods)
+			resp, err := svc.Update(context.Background(), 1, time.UTC, "c-1", &tt.req)
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, "c-1", resp.Uid)
+			} else {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+			}
+			repo.AssertExpectations(t)
+			moods.AssertExpectations(t)
+		})
+	}
+}
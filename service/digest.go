@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+	"timo/domain"
+	"timo/helper"
+	"timo/models"
+)
+
+// MemoryDigest sends each subscribed user their "on this day" memories once
+// per local day, as soon as their clock passes the configured hour.
+type MemoryDigest struct {
+	users    domain.UserRepository
+	journals domain.JournalRepository
+	notifier helper.Notifier
+	signer   *helper.URLSigner
+	hour     int
+}
+
+// digestLinkTTL keeps thumbnail links in a digest working for a week, since
+// notifications are often opened long after they are sent.
+const digestLinkTTL = 7 * 24 * time.Hour
+
+func NewMemoryDigest(users domain.UserRepository, journals domain.JournalRepository, notifier helper.Notifier, signer *helper.URLSigner, hour int) *MemoryDigest {
+	return &MemoryDigest{users: users, journals: journals, notifier: notifier, signer: signer, hour: hour}
+}
+
+// Run checks for due digests every interval until ctx is cancelled.
+func (d *MemoryDigest) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.Send(ctx, time.Now()); err != nil {
+			log.Printf("memory digest: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Send delivers the digest to every subscriber whose local time at now is
+// past the digest hour and who hasn't received today's digest yet. Users
+// without memories for the day are marked as done without a notification.
+// A digest that can't be sent is logged and tried again on the next run,
+// without holding up the other users.
+func (d *MemoryDigest) Send(ctx context.Context, now time.Time) error {
+	users, err := d.users.GetDigestSubscribers(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		loc := helper.UserLocation(user.Timezone)
+		if now.In(loc).Hour() < d.hour {
+			continue
+		}
+
+		today := helper.LocalDate(now, loc)
+		claimed, err := d.users.ClaimDigest(ctx, user.ID, today)
+		if err != nil {
+			log.Printf("memory digest: claim user %d: %v", user.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := d.send(ctx, &user, today, now); err != nil {
+			log.Printf("memory digest: user %d: %v", user.ID, err)
+			if err := d.users.ReleaseDigest(ctx, user.ID, today); err != nil {
+				log.Printf("memory digest: release user %d: %v", user.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// send notifies the user of today's memories, if there are any.
+func (d *MemoryDigest) send(ctx context.Context, user *models.User, today, now time.Time) error {
+	memories, err := d.journals.GetOnThisDay(ctx, user.ID, today)
+	if err != nil {
+		return err
+	}
+	if len(memories) == 0 {
+		return nil
+	}
+
+	notification := &helper.Notification{
+		UserID: user.ID,
+		Email:  user.Email,
+		Title:  "On this day",
+		Body:   fmt.Sprintf("%d entries written on this day in past years", len(memories)),
+		Data:   toMemoryResponses(memories, today, func(key string) string { return d.signer.URLFor(key, now, digestLinkTTL) }),
+	}
+	return d.notifier.Notify(ctx, notification)
+}
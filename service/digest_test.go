@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+	"timo/dto"
+	"timo/helper"
+	"timo/mocks"
+	"timo/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMemoryDigest_Send(t *testing.T) {
+	// 02:00 UTC is past the digest hour in Jakarta but not in UTC.
+	now := time.Date(2025, time.February, 28, 2, 0, 0, 0, time.UTC)
+	today := time.Date(2025, time.February, 28, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		setupMocks func(users *mocks.UserRepositoryMock, journals *mocks.JournalRepositoryMock, notifier *mocks.NotifierMock)
+		wantErr    bool
+	}{
+		{
+			name: "skips users before the digest hour",
+			setupMocks: func(users *mocks.UserRepositoryMock, journals *mocks.JournalRepositoryMock, notifier *mocks.NotifierMock) {
+				users.On("GetDigestSubscribers", mock.Anything).
+					Return([]models.User{{ID: 1, Timezone: "UTC"}}, nil)
+			},
+		},
+		{
+			name: "already sent today",
+			setupMocks: func(users *mocks.UserRepositoryMock, journals *mocks.JournalRepositoryMock, notifier *mocks.NotifierMock) {
+				users.On("GetDigestSubscribers", mock.Anything).
+					Return([]models.User{{ID: 1, Timezone: "Asia/Jakarta"}}, nil)
+				users.On("ClaimDigest", mock.Anything, int64(1), today).Return(false, nil)
+			},
+		},
+		{
+			name: "no memories",
+			setupMocks: func(users *mocks.UserRepositoryMock, journals *mocks.JournalRepositoryMock, notifier *mocks.NotifierMock) {
+				users.On("GetDigestSubscribers", mock.Anything).
+					Return([]models.User{{ID: 1, Timezone: "Asia/Jakarta"}}, nil)
+				users.On("ClaimDigest", mock.Anything, int64(1), today).Return(true, nil)
+				journals.On("GetOnThisDay", mock.Anything, int64(1), today).Return(nil, nil)
+			},
+		},
+		{
+			name: "sends digest",
+			setupMocks: func(users *mocks.UserRepositoryMock, journals *mocks.JournalRepositoryMock, notifier *mocks.NotifierMock) {
+				users.On("GetDigestSubscribers", mock.Anything).
+					Return([]models.User{{ID: 1, Email: "test@mail.com", Timezone: "Asia/Jakarta"}}, nil)
+				users.On("ClaimDigest", mock.Anything, int64(1), today).Return(true, nil)
+				journals.On("GetOnThisDay", mock.Anything, int64(1), today).Return([]models.Memory{
+					{Uid: "journalUID", Title: "leap day", EntryDate: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC), PhotoUrl: helper.Ptr("photo.jpg")},
+				}, nil)
+				notifier.On("Notify", mock.Anything, mock.MatchedBy(func(n *helper.Notification) bool {
+					memories := n.Data.([]dto.MemoryResponse)
+					return n.Email == "test@mail.com" && memories[0].YearsAgo == 1 && memories[0].ThumbnailUrl == "photo.jpg"
+				})).Return(nil)
+			},
+		},
+		{
+			name: "failed digests are released and the others still sent",
+			setupMocks: func(users *mocks.UserRepositoryMock, journals *mocks.JournalRepositoryMock, notifier *mocks.NotifierMock) {
+				users.On("GetDigestSubscribers", mock.Anything).Return([]models.User{
+					{ID: 1, Timezone: "Asia/Jakarta"},
+					{ID: 2, Timezone: "Asia/Jakarta"},
+					{ID: 3, Timezone: "Asia/Jakarta"},
+					{ID: 4, Timezone: "Asia/Jakarta"},
+				}, nil)
+				memories := []models.Memory{{Uid: "journalUID", EntryDate: time.Date(2024, time.February, 28, 0, 0, 0, 0, time.UTC)}}
+
+				users.On("ClaimDigest", mock.Anything, int64(1), today).Return(false, assert.AnError)
+
+				users.On("ClaimDigest", mock.Anything, int64(2), today).Return(true, nil)
+				journals.On("GetOnThisDay", mock.Anything, int64(2), today).Return(nil, assert.AnError)
+				users.On("ReleaseDigest", mock.Anything, int64(2), today).Return(nil)
+
+				users.On("ClaimDigest", mock.Anything, int64(3), today).Return(true, nil)
+				journals.On("GetOnThisDay", mock.Anything, int64(3), today).Return(memories, nil)
+				notifier.On("Notify", mock.Anything, mock.MatchedBy(func(n *helper.Notification) bool { return n.UserID == 3 })).Return(assert.AnError)
+				users.On("ReleaseDigest", mock.Anything, int64(3), today).Return(nil)
+
+				users.On("ClaimDigest", mock.Anything, int64(4), today).Return(true, nil)
+				journals.On("GetOnThisDay", mock.Anything, int64(4), today).Return(memories, nil)
+				notifier.On("Notify", mock.Anything, mock.MatchedBy(func(n *helper.Notification) bool { return n.UserID == 4 })).Return(nil)
+			},
+		},
+		{
+			name: "subscriber lookup fails",
+			setupMocks: func(users *mocks.UserRepositoryMock, journals *mocks.JournalRepositoryMock, notifier *mocks.NotifierMock) {
+				users.On("GetDigestSubscribers", mock.Anything).Return(nil, assert.AnError)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := new(mocks.UserRepositoryMock)
+			journals := new(mocks.JournalRepositoryMock)
+			notifier := new(mocks.NotifierMock)
+			tt.setupMocks(users, journals, notifier)
+
+			digest := NewMemoryDigest(users, journals, notifier, helper.NewURLSigner([]byte("key"), "http://media", time.Hour), 8)
+			err := digest.Send(context.Background(), now)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			users.AssertExpectations(t)
+			journals.AssertExpectations(t)
+			notifier.AssertExpectations(t)
+		})
+	}
+}
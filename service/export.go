@@ -0,0 +1,647 @@
+package service
+
+import (
+	"archive/zip"
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/models"
+	"unicode"
+)
+
+const (
+	EXPORT_MARKDOWN = "markdown"
+	EXPORT_JSON     = "json"
+	EXPORT_HTML     = "html"
+)
+
+// exportSlugMax bounds the title part of Markdown file names, in runes.
+const exportSlugMax = 60
+
+// hashtagPattern finds #tags in journal text. A tag needs a letter, so "#1"
+// is left alone, and must not follow a word character, so URL fragments and
+// "C#" aren't tags.
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&/#])#([\p{L}\p{N}_]*\p{L}[\p{L}\p{N}_]*)`)
+
+type export struct {
+	journals domain.JournalRepository
+	photos   domain.PhotoRepository
+	storage  helper.Storage
+}
+
+func NewExport(journals domain.JournalRepository, photos domain.PhotoRepository, storage helper.Storage) domain.ExportService {
+	return &export{journals: journals, photos: photos, storage: storage}
+}
+
+// exportEntry is a journal with what an export shows alongside it.
+type exportEntry struct {
+	journal     *models.Journal
+	attachments []models.Photo
+	tags        []string
+}
+
+// tagList is the entry's tags, empty rather than nil for encoding.
+func (e *exportEntry) tagList() []string {
+	if e.tags == nil {
+		return []string{}
+	}
+	return e.tags
+}
+
+// Export loads the user's published journals between query.From and
+// query.To, both optional and inclusive, and returns the archive to stream.
+// Loading happens up front so errors still reach the client as a status;
+// stored files are only read while writing.
+func (e *export) Export(ctx context.Context, userID int64, query *dto.ExportQuery) (*dto.Export, error) {
+	var from, to *time.Time
+	if query.From != "" {
+		date, _ := helper.ParseDate(query.From)
+		from = &date
+	}
+	if query.To != "" {
+		date, _ := helper.ParseDate(query.To)
+		to = &date
+	}
+	if from != nil && to != nil && to.Before(*from) {
+		return nil, helper.NewAppError(helper.VALIDATION_ERROR, "query validation failed", nil).
+			WithDetails([]helper.ValidatorError{{Field: "to", Message: "must not be before from"}})
+	}
+
+	journals, err := e.journals.GetForExport(ctx, userID, from, to)
+	if err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get journals", err)
+	}
+
+	entries := make([]exportEntry, len(journals))
+	index := make(map[int64]*exportEntry, len(journals))
+	ids := make([]int64, 0, len(journals))
+	for i := range journals {
+		entries[i] = exportEntry{journal: &journals[i], tags: hashtags(journals[i].Text)}
+		index[journals[i].ID] = &entries[i]
+		ids = append(ids, journals[i].ID)
+	}
+
+	if len(ids) > 0 {
+		attachments, err := e.photos.GetByJournalIDs(ctx, ids)
+		if err != nil {
+			return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get attachments", err)
+		}
+		for _, attachment := range attachments {
+			if entry, ok := index[attachment.JournalID]; ok {
+				entry.attachments = append(entry.attachments, attachment)
+			}
+		}
+	}
+
+	now := time.Now()
+	w := &exportWriter{ctx: ctx, storage: e.storage, now: now, files: make(map[int64]string), broken: make(map[int64]error)}
+	render := map[string]func(*zip.Writer, []exportEntry) error{
+		EXPORT_MARKDOWN: w.markdown,
+		EXPORT_JSON: func(zw *zip.Writer, entries []exportEntry) error {
+			return w.json(zw, entries, query)
+		},
+		EXPORT_HTML: func(zw *zip.Writer, entries []exportEntry) error {
+			return w.html(zw, entries, query)
+		},
+	}[query.Format]
+
+	return &dto.Export{
+		Filename: exportFilename(query, now),
+		Write: func(out io.Writer) error {
+			zw := zip.NewWriter(out)
+			if err := render(zw, entries); err != nil {
+				return err
+			}
+			if err := w.missingFiles(zw); err != nil {
+				return err
+			}
+			return zw.Close()
+		},
+	}, nil
+}
+
+// exportFilename names the archive after the exported range, or after the
+// day of the export when the range is open.
+func exportFilename(query *dto.ExportQuery, now time.Time) string {
+	name := "timo-journals"
+	if query.From != "" {
+		name += "-from-" + query.From
+	}
+	if query.To != "" {
+		name += "-to-" + query.To
+	}
+	if query.From == "" && query.To == "" {
+		name += "-" + now.Format(helper.DATE_LAYOUT)
+	}
+	return name + ".zip"
+}
+
+// exportWriter renders one archive. Files shared by several attachments
+// are written once. Attachments whose file can't be read are left out and
+// listed in missing.txt, so one lost object doesn't cost the whole export.
+type exportWriter struct {
+	ctx     context.Context
+	storage helper.Storage
+	now     time.Time
+	files   map[int64]string // archive path by blob ID
+	broken  map[int64]error  // read error by blob ID
+	missing []string
+}
+
+// markdown writes an entries/ file with YAML front matter per journal, and
+// the attachments under media/ for the entries to link to.
+func (w *exportWriter) markdown(zw *zip.Writer, entries []exportEntry) error {
+	names := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		j := entry.journal
+
+		var b strings.Builder
+		b.WriteString("---\n")
+		fmt.Fprintf(&b, "uid: %s\n", j.Uid)
+		fmt.Fprintf(&b, "title: %s\n", yamlValue(j.Title))
+		fmt.Fprintf(&b, "date: %s\n", j.EntryDate.Format(helper.DATE_LAYOUT))
+		fmt.Fprintf(&b, "mood: %s\n", yamlValue(j.MoodLabel))
+		if len(j.Moods) > 0 {
+			b.WriteString("moods:\n")
+			for _, m := range j.Moods {
+				fmt.Fprintf(&b, "  - label: %s\n    intensity: %d\n", yamlValue(m.MoodLabel), m.Intensity)
+			}
+		}
+		fmt.Fprintf(&b, "tags: %s\n", yamlValue(entry.tagList()))
+		b.WriteString("---\n\n")
+		b.WriteString(strings.TrimRight(j.Text, "\n"))
+		b.WriteString("\n")
+
+		for _, a := range entry.attachments {
+			file, err := w.media(zw, j, &a)
+			if err != nil {
+				return err
+			}
+			if file == "" {
+				continue
+			}
+
+			b.WriteString("\n")
+			if a.MediaType == models.MEDIA_AUDIO {
+				fmt.Fprintf(&b, "[%s](../%s)\n", markdownText(audioLabel(&a)), file)
+			} else {
+				fmt.Fprintf(&b, "![%s](../%s)\n", markdownText(helper.Deref(a.AltText)), file)
+			}
+			if a.Caption != nil && a.MediaType != models.MEDIA_AUDIO {
+				fmt.Fprintf(&b, "\n*%s*\n", markdownText(*a.Caption))
+			}
+		}
+
+		name := uniqueName(names, "entries/"+j.EntryDate.Format(helper.DATE_LAYOUT)+"-"+cmp.Or(slug(j.Title), j.Uid), ".md")
+		if err := w.writeFile(zw, name, zip.Deflate, strings.NewReader(b.String())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// json writes all journals as one journals.json, with the attachments under
+// media/.
+func (w *exportWriter) json(zw *zip.Writer, entries []exportEntry, query *dto.ExportQuery) error {
+	doc := dto.ExportDocument{
+		ExportedAt: w.now,
+		From:       query.From,
+		To:         query.To,
+		Journals:   make([]dto.ExportJournal, 0, len(entries)),
+	}
+
+	for _, entry := range entries {
+		j := entry.journal
+		item := dto.ExportJournal{
+			Uid:         j.Uid,
+			Title:       j.Title,
+			Text:        j.Text,
+			EntryDate:   j.EntryDate.Format(helper.DATE_LAYOUT),
+			Mood:        j.MoodLabel,
+			Moods:       make([]dto.JournalMoodResponse, 0, len(j.Moods)),
+			Tags:        entry.tagList(),
+			Attachments: make([]dto.ExportAttachment, 0, len(entry.attachments)),
+			CreatedAt:   j.CreatedAt,
+			UpdatedAt:   j.UpdatedAt,
+		}
+		for _, m := range j.Moods {
+			item.Moods = append(item.Moods, dto.JournalMoodResponse{MoodID: m.MoodID, MoodLabel: m.MoodLabel, Intensity: m.Intensity})
+		}
+
+		for _, a := range entry.attachments {
+			file, err := w.media(zw, j, &a)
+			if err != nil {
+				return err
+			}
+			if file == "" {
+				continue
+			}
+			item.Attachments = append(item.Attachments, dto.ExportAttachment{
+				MediaType:   cmp.Or(a.MediaType, models.MEDIA_IMAGE),
+				File:        file,
+				ContentType: a.ContentType,
+				Caption:     a.Caption,
+				AltText:     a.AltText,
+				Width:       a.Width,
+				Height:      a.Height,
+				TakenAt:     a.TakenAt,
+				DurationMs:  a.DurationMs,
+				Peaks:       a.Peaks,
+			})
+		}
+
+		doc.Journals = append(doc.Journals, item)
+	}
+
+	f, err := w.createFile(zw, "journals.json", zip.Deflate)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// media copies an attachment's stored file into media/ and returns its
+// path in the archive. Attachments without a stored file, kept from before
+// files had storage keys, are skipped with an empty path, as are those whose
+// file can't be read.
+func (w *exportWriter) media(zw *zip.Writer, j *models.Journal, a *models.Photo) (string, error) {
+	if a.StorageKey == "" {
+		return "", nil
+	}
+	if file, ok := w.files[a.BlobID]; ok {
+		return file, nil
+	}
+	if err, ok := w.broken[a.BlobID]; ok {
+		return "", w.skip(j, a, err)
+	}
+
+	ext := path.Ext(a.StorageKey)
+	if ext == "" {
+		ext = helper.MediaExtension(a.ContentType)
+	}
+	file := fmt.Sprintf("media/%s%s", cmp.Or(a.Checksum, fmt.Sprint(a.BlobID)), ext)
+
+	body, err := w.storage.Get(w.ctx, a.StorageKey)
+	if err != nil {
+		return "", w.skip(j, a, err)
+	}
+	defer body.Close()
+
+	// Images and recordings are compressed already. A read that fails
+	// halfway leaves the file cut short, so it isn't linked or written again.
+	src := &sourceReader{Reader: body}
+	if err := w.writeFile(zw, file, zip.Store, src); err != nil {
+		if src.err == nil {
+			return "", err
+		}
+		w.broken[a.BlobID] = src.err
+		return "", w.skip(j, a, src.err)
+	}
+
+	w.files[a.BlobID] = file
+	return file, nil
+}
+
+// skip notes an attachment whose file could not be read. It only returns an
+// error when the export itself was cancelled.
+func (w *exportWriter) skip(j *models.Journal, a *models.Photo, err error) error {
+	if w.ctx.Err() != nil {
+		return w.ctx.Err()
+	}
+
+	title := cmp.Or(j.Title, j.Uid)
+	w.missing = append(w.missing, fmt.Sprintf("%s %q: %s %d (%v)",
+		j.EntryDate.Format(helper.DATE_LAYOUT), title, cmp.Or(a.MediaType, models.MEDIA_IMAGE), a.ID, err))
+	return nil
+}
+
+// missingFiles writes missing.txt listing the attachments that were left out.
+func (w *exportWriter) missingFiles(zw *zip.Writer) error {
+	if len(w.missing) == 0 {
+		return nil
+	}
+
+	text := "These attachments could not be read and are not part of the export:\n\n" + strings.Join(w.missing, "\n") + "\n"
+	return w.writeFile(zw, "missing.txt", zip.Deflate, strings.NewReader(text))
+}
+
+// sourceReader remembers a failed read, to tell a broken stored file from a
+// broken connection to the client when copying one into the other.
+type sourceReader struct {
+	io.Reader
+	err error
+}
+
+func (s *sourceReader) Read(p []byte) (int, error) {
+	n, err := s.Reader.Read(p)
+	if err != nil && err != io.EOF {
+		s.err = err
+	}
+	return n, err
+}
+
+func (w *exportWriter) createFile(zw *zip.Writer, name string, method uint16) (io.Writer, error) {
+	return zw.CreateHeader(&zip.FileHeader{Name: name, Method: method, Modified: w.now})
+}
+
+func (w *exportWriter) writeFile(zw *zip.Writer, name string, method uint16, r io.Reader) error {
+	f, err := w.createFile(zw, name, method)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	return err
+}
+
+// bookTemplate lays out the HTML book. Every entry starts on a new page when
+// printed.
+var bookTemplate = template.Must(template.New("book").Parse(`
+{{- define "head" -}}
+<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: Georgia, serif; max-width: 40em; margin: 2em auto; padding: 0 1em; line-height: 1.5; color: #222; }
+header, nav { page-break-after: always; }
+header { text-align: center; margin-top: 30vh; }
+nav ol { list-style: none; padding: 0; }
+nav li { display: flex; gap: .5em; }
+nav li a { flex: 1; color: inherit; }
+article { page-break-before: always; }
+.meta { color: #666; font-style: italic; }
+.text p { white-space: pre-line; }
+.tags { color: #666; }
+figure { margin: 1em 0; }
+figure img { max-width: 100%; }
+figcaption { color: #666; font-size: .9em; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<p>{{.Range}}</p>
+</header>
+<nav>
+<h2>Contents</h2>
+<ol>
+{{- range .Contents}}
+<li><a href="#{{.Anchor}}">{{.Title}}</a><span>{{.Date}}</span></li>
+{{- end}}
+</ol>
+</nav>
+{{end}}
+
+{{- define "entry" -}}
+<article id="{{.Anchor}}">
+<h2>{{.Title}}</h2>
+<p class="meta">{{.Date}}{{with .Moods}} · {{.}}{{end}}</p>
+<div class="text">
+{{- range .Paragraphs}}
+<p>{{.}}</p>
+{{- end}}
+</div>
+{{- range .Photos}}
+<figure>
+<img src="{{.Src}}" alt="{{.Alt}}">
+{{- with .Caption}}
+<figcaption>{{.}}</figcaption>
+{{- end}}
+</figure>
+{{- end}}
+{{- range .Recordings}}
+<p class="meta">{{.}}</p>
+{{- end}}
+{{- with .Tags}}
+<p class="tags">{{range $i, $tag := .}}{{if $i}} {{end}}#{{$tag}}{{end}}</p>
+{{- end}}
+</article>
+{{end}}
+
+{{- define "foot" -}}
+</body>
+</html>
+{{end}}`))
+
+type bookHead struct {
+	Title    string
+	Range    string
+	Contents []bookEntry
+}
+
+type bookEntry struct {
+	Anchor     string
+	Title      string
+	Date       string
+	Moods      string
+	Paragraphs []string
+	Photos     []bookPhoto
+	Recordings []string
+	Tags       []string
+}
+
+type bookPhoto struct {
+	Src     template.URL
+	Alt     string
+	Caption string
+}
+
+// html writes a single book.html with a table of contents. Thumbnails are
+// embedded as data URIs so the file stands on its own. Entries are rendered
+// one at a time, so only one entry's thumbnails are held in memory.
+func (w *exportWriter) html(zw *zip.Writer, entries []exportEntry, query *dto.ExportQuery) error {
+	head := bookHead{Title: "Journal", Contents: make([]bookEntry, 0, len(entries))}
+	head.Range = bookRange(query.From, query.To)
+	for i, entry := range entries {
+		head.Contents = append(head.Contents, bookEntry{
+			Anchor: fmt.Sprintf("entry-%d", i+1),
+			Title:  cmp.Or(entry.journal.Title, "Untitled"),
+			Date:   entry.journal.EntryDate.Format(helper.DATE_LAYOUT),
+		})
+	}
+
+	f, err := w.createFile(zw, "book.html", zip.Deflate)
+	if err != nil {
+		return err
+	}
+	if err := bookTemplate.ExecuteTemplate(f, "head", head); err != nil {
+		return err
+	}
+
+	for i, entry := range entries {
+		j := entry.journal
+		item := head.Contents[i]
+		item.Date = j.EntryDate.Format("Monday, 2 January 2006")
+		item.Tags = entry.tags
+		for _, paragraph := range strings.Split(strings.ReplaceAll(j.Text, "\r\n", "\n"), "\n\n") {
+			if paragraph = strings.Trim(paragraph, "\n"); paragraph != "" {
+				item.Paragraphs = append(item.Paragraphs, paragraph)
+			}
+		}
+
+		var moods []string
+		for _, m := range j.Moods {
+			moods = append(moods, m.MoodLabel)
+		}
+		item.Moods = cmp.Or(strings.Join(moods, ", "), j.MoodLabel)
+
+		for _, a := range entry.attachments {
+			if a.MediaType == models.MEDIA_AUDIO {
+				item.Recordings = append(item.Recordings, audioLabel(&a))
+				continue
+			}
+			src, err := w.thumbnail(j, &a)
+			if err != nil {
+				return err
+			}
+			if src != "" {
+				item.Photos = append(item.Photos, bookPhoto{Src: src, Alt: helper.Deref(a.AltText), Caption: helper.Deref(a.Caption)})
+			}
+		}
+
+		// The zip entry stays open across entries, so f is still the book.
+		if err := bookTemplate.ExecuteTemplate(f, "entry", item); err != nil {
+			return err
+		}
+	}
+
+	return bookTemplate.ExecuteTemplate(f, "foot", nil)
+}
+
+// thumbnail reads a photo's medium variant, or its small one, as a data
+// URI. Photos that were never processed have neither and are left out, as
+// are those whose variant can't be read.
+func (w *exportWriter) thumbnail(j *models.Journal, a *models.Photo) (template.URL, error) {
+	var variant *models.PhotoVariant
+	for i := range a.Variants {
+		switch a.Variants[i].Name {
+		case "medium":
+			variant = &a.Variants[i]
+		case "small":
+			if variant == nil {
+				variant = &a.Variants[i]
+			}
+		}
+	}
+	if variant == nil {
+		return "", nil
+	}
+
+	body, err := w.storage.Get(w.ctx, variant.StorageKey)
+	if err != nil {
+		return "", w.skip(j, a, err)
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", w.skip(j, a, err)
+	}
+
+	return template.URL("data:" + variant.ContentType + ";base64," + base64.StdEncoding.EncodeToString(data)), nil
+}
+
+// bookRange describes the exported dates for the title page.
+func bookRange(from, to string) string {
+	switch {
+	case from != "" && to != "":
+		return from + " – " + to
+	case from != "":
+		return "Since " + from
+	case to != "":
+		return "Until " + to
+	}
+	return "All entries"
+}
+
+// audioLabel names a recording in text exports, e.g. "Voice note (1:02)".
+func audioLabel(a *models.Photo) string {
+	label := cmp.Or(helper.Deref(a.Caption), "Voice note")
+	if a.DurationMs == nil {
+		return label
+	}
+	seconds := (*a.DurationMs + 500) / 1000
+	return fmt.Sprintf("%s (%d:%02d)", label, seconds/60, seconds%60)
+}
+
+// hashtags returns the distinct #tags of a text, lowercased, in the order
+// they first appear. Journals have no tag list of their own.
+func hashtags(text string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, match := range hashtagPattern.FindAllStringSubmatch(text, -1) {
+		tag := strings.ToLower(match[1])
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// slug turns a title into a file name part: lowercase letters and digits
+// separated by single dashes.
+func slug(title string) string {
+	var b strings.Builder
+	dash := false
+	n := 0
+	for _, r := range strings.ToLower(title) {
+		if n == exportSlugMax {
+			break
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+				n++
+			}
+			b.WriteRune(r)
+			n++
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// uniqueName adds a counter to base until the name hasn't been used yet.
+func uniqueName(used map[string]bool, base, ext string) string {
+	name := base + ext
+	for i := 2; used[name]; i++ {
+		name = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+	used[name] = true
+	return name
+}
+
+// yamlValue renders v as JSON, which YAML reads as a double-quoted string or
+// a flow sequence.
+func yamlValue(v any) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+var markdownEscaper = strings.NewReplacer("\\", "\\\\", "[", "\\[", "]", "\\]", "*", "\\*", "_", "\\_", "\n", " ")
+
+// markdownText escapes text for link labels and emphasis.
+func markdownText(text string) string {
+	return markdownEscaper.Replace(text)
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+	"timo/dto"
+	"timo/helper"
+	"timo/mocks"
+	"timo/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestExportService_Export(t *testing.T) {
+	journals := []models.Journal{
+		{
+			ID: 1, Uid: "uid-1", Title: "Beach day", Text: "Swam at dawn #Travel with the #family\n\nSunset later #travel, see page #3",
+			MoodLabel: "happy", EntryDate: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			Moods: []models.JournalMood{{MoodID: 1, MoodLabel: "happy", Intensity: 4}, {MoodID: 2, MoodLabel: "calm", Intensity: 2}},
+		},
+		{
+			ID: 2, Uid: "uid-2", Title: "", Text: "Same photo again", MoodLabel: "calm",
+			EntryDate: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	image := models.Photo{
+		JournalID: 1, BlobID: 10, StorageKey: "users/1/photos/aaa.jpg", Checksum: "aaa", ContentType: "image/jpeg",
+		MediaType: models.MEDIA_IMAGE, AltText: helper.Ptr("sunset"), Caption: helper.Ptr("From the pier"),
+		Variants: []models.PhotoVariant{
+			{Name: "medium", StorageKey: "users/1/photos/aaa_medium.jpg", ContentType: "image/jpeg"},
+			{Name: "small", StorageKey: "users/1/photos/aaa_small.jpg", ContentType: "image/jpeg"},
+		},
+	}
+	recording := models.Photo{
+		JournalID: 1, BlobID: 11, StorageKey: "users/1/audio/bbb.m4a", Checksum: "bbb", ContentType: "audio/mp4",
+		MediaType: models.MEDIA_AUDIO, DurationMs: helper.Ptr(2400), Peaks: []int{1, 2},
+	}
+	shared := image
+	shared.JournalID = 2
+	attachments := []models.Photo{image, recording, shared}
+
+	object := func(content string) io.ReadCloser { return io.NopCloser(strings.NewReader(content)) }
+
+	tests := []struct {
+		name         string
+		query        dto.ExportQuery
+		setupMocks   func(repo *mocks.JournalRepositoryMock, photos *mocks.PhotoRepositoryMock, storage *mocks.StorageMock)
+		wantErr      string
+		wantFiles    []string
+		wantContents map[string][]string
+		wantAbsent   map[string][]string
+	}{
+		{
+			name:  "to before from",
+			query: dto.ExportQuery{Format: EXPORT_JSON, From: "2024-03-02", To: "2024-03-01"},
+			setupMocks: func(repo *mocks.JournalRepositoryMock, photos *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name:  "internal server error",
+			query: dto.ExportQuery{Format: EXPORT_JSON},
+			setupMocks: func(repo *mocks.JournalRepositoryMock, photos *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("GetForExport", mock.Anything, int64(1), (*time.Time)(nil), (*time.Time)(nil)).Return(nil, assert.AnError)
+			},
+			wantErr: helper.INTERNAL_ERROR,
+		},
+		{
+			name:  "markdown",
+			query: dto.ExportQuery{Format: EXPORT_MARKDOWN, From: "2024-03-01", To: "2024-03-31"},
+			setupMocks: func(repo *mocks.JournalRepositoryMock, photos *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("GetForExport", mock.Anything, int64(1), mock.MatchedBy(func(from *time.Time) bool {
+					return from.Format(helper.DATE_LAYOUT) == "2024-03-01"
+				}), mock.MatchedBy(func(to *time.Time) bool {
+					return to.Format(helper.DATE_LAYOUT) == "2024-03-31"
+				})).Return(journals, nil)
+				photos.On("GetByJournalIDs", mock.Anything, []int64{1, 2}).Return(attachments, nil)
+				storage.On("Get", mock.Anything, "users/1/photos/aaa.jpg").Return(object("jpeg"), nil).Once()
+				storage.On("Get", mock.Anything, "users/1/audio/bbb.m4a").Return(object("m4a"), nil).Once()
+			},
+			wantFiles: []string{"entries/2024-03-01-beach-day.md", "entries/2024-03-02-uid-2.md", "media/aaa.jpg", "media/bbb.m4a"},
+			wantContents: map[string][]string{
+				"entries/2024-03-01-beach-day.md": {
+					"---\nuid: uid-1\ntitle: \"Beach day\"\ndate: 2024-03-01\nmood: \"happy\"\n",
+					"  - label: \"calm\"\n    intensity: 2\n",
+					`tags: ["travel","family"]`,
+					"![sunset](../media/aaa.jpg)\n\n*From the pier*\n",
+					"[Voice note (0:02)](../media/bbb.m4a)",
+				},
+				"entries/2024-03-02-uid-2.md": {"tags: []", "![sunset](../media/aaa.jpg)"},
+				"media/aaa.jpg":               {"jpeg"},
+			},
+		},
+		{
+			name:  "json",
+			query: dto.ExportQuery{Format: EXPORT_JSON},
+			setupMocks: func(repo *mocks.JournalRepositoryMock, photos *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("GetForExport", mock.Anything, int64(1), (*time.Time)(nil), (*time.Time)(nil)).Return(journals, nil)
+				photos.On("GetByJournalIDs", mock.Anything, []int64{1, 2}).Return(attachments, nil)
+				storage.On("Get", mock.Anything, "users/1/photos/aaa.jpg").Return(object("jpeg"), nil).Once()
+				storage.On("Get", mock.Anything, "users/1/audio/bbb.m4a").Return(object("m4a"), nil).Once()
+			},
+			wantFiles: []string{"journals.json", "media/aaa.jpg", "media/bbb.m4a"},
+			wantContents: map[string][]string{
+				"journals.json": {
+					`"uid": "uid-1"`,
+					`"file": "media/bbb.m4a"`,
+					`"duration_ms": 2400`,
+					"\"tags\": [\n        \"travel\",\n        \"family\"\n      ]",
+				},
+			},
+		},
+		{
+			name:  "markdown leaves out a missing file",
+			query: dto.ExportQuery{Format: EXPORT_MARKDOWN},
+			setupMocks: func(repo *mocks.JournalRepositoryMock, photos *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("GetForExport", mock.Anything, int64(1), (*time.Time)(nil), (*time.Time)(nil)).Return(journals, nil)
+				photos.On("GetByJournalIDs", mock.Anything, []int64{1, 2}).Return(attachments, nil)
+				storage.On("Get", mock.Anything, "users/1/photos/aaa.jpg").Return(nil, helper.ErrObjectNotFound).Twice()
+				storage.On("Get", mock.Anything, "users/1/audio/bbb.m4a").Return(object("m4a"), nil).Once()
+			},
+			wantFiles: []string{"entries/2024-03-01-beach-day.md", "entries/2024-03-02-uid-2.md", "media/bbb.m4a", "missing.txt"},
+			wantContents: map[string][]string{
+				"entries/2024-03-01-beach-day.md": {"[Voice note (0:02)](../media/bbb.m4a)"},
+				"missing.txt": {
+					"2024-03-01 \"Beach day\": image 0 (storage object not found)\n",
+					"2024-03-02 \"uid-2\": image 0 (storage object not found)\n",
+				},
+			},
+			wantAbsent: map[string][]string{
+				"entries/2024-03-01-beach-day.md": {"media/aaa.jpg"},
+				"entries/2024-03-02-uid-2.md":     {"media/aaa.jpg"},
+			},
+		},
+		{
+			name:  "json leaves out a file that fails while reading",
+			query: dto.ExportQuery{Format: EXPORT_JSON},
+			setupMocks: func(repo *mocks.JournalRepositoryMock, photos *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("GetForExport", mock.Anything, int64(1), (*time.Time)(nil), (*time.Time)(nil)).Return(journals, nil)
+				photos.On("GetByJournalIDs", mock.Anything, []int64{1, 2}).Return(attachments, nil)
+				broken := io.MultiReader(strings.NewReader("jp"), iotest.ErrReader(assert.AnError))
+				storage.On("Get", mock.Anything, "users/1/photos/aaa.jpg").Return(io.NopCloser(broken), nil).Once()
+				storage.On("Get", mock.Anything, "users/1/audio/bbb.m4a").Return(object("m4a"), nil).Once()
+			},
+			wantFiles: []string{"journals.json", "media/aaa.jpg", "media/bbb.m4a", "missing.txt"},
+			wantContents: map[string][]string{
+				"journals.json": {`"file": "media/bbb.m4a"`},
+				"missing.txt":   {"2024-03-01 \"Beach day\": image 0", "2024-03-02 \"uid-2\": image 0"},
+			},
+			wantAbsent: map[string][]string{
+				"journals.json": {"media/aaa.jpg"},
+			},
+		},
+		{
+			name:  "html book",
+			query: dto.ExportQuery{Format: EXPORT_HTML},
+			setupMocks: func(repo *mocks.JournalRepositoryMock, photos *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("GetForExport", mock.Anything, int64(1), (*time.Time)(nil), (*time.Time)(nil)).Return(journals, nil)
+				photos.On("GetByJournalIDs", mock.Anything, []int64{1, 2}).Return(attachments, nil)
+				storage.On("Get", mock.Anything, "users/1/photos/aaa_medium.jpg").Return(object("thumb"), nil).Once()
+				storage.On("Get", mock.Anything, "users/1/photos/aaa_medium.jpg").Return(object("thumb"), nil).Once()
+			},
+			wantFiles: []string{"book.html"},
+			wantContents: map[string][]string{
+				"book.html": {
+					`<li><a href="#entry-1">Beach day</a><span>2024-03-01</span></li>`,
+					`<li><a href="#entry-2">Untitled</a><span>2024-03-02</span></li>`,
+					`<article id="entry-1">`,
+					`<p class="meta">Friday, 1 March 2024 · happy, calm</p>`,
+					`<img src="data:image/jpeg;base64,` + base64.StdEncoding.EncodeToString([]byte("thumb")) + `" alt="sunset">`,
+					`<figcaption>From the pier</figcaption>`,
+					`<p class="meta">Voice note (0:02)</p>`,
+					`<p class="tags">#travel #family</p>`,
+					"</html>",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.JournalRepositoryMock)
+			photos := new(mocks.PhotoRepositoryMock)
+			storage := new(mocks.StorageMock)
+			tt.setupMocks(repo, photos, storage)
+
+			svc := NewExport(repo, photos, storage)
+			export, err := svc.Export(context.Background(), 1, &tt.query)
+
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Nil(t, export)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+				repo.AssertExpectations(t)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(export.Filename, "timo-"))
+
+			var buf bytes.Buffer
+			assert.NoError(t, export.Write(&buf))
+
+			archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			assert.NoError(t, err)
+
+			files := make(map[string]string)
+			var names []string
+			for _, f := range archive.File {
+				r, err := f.Open()
+				assert.NoError(t, err)
+				data, _ := io.ReadAll(r)
+				r.Close()
+				files[f.Name] = string(data)
+				names = append(names, f.Name)
+			}
+			slices.Sort(names)
+			assert.Equal(t, tt.wantFiles, names)
+			for name, wants := range tt.wantContents {
+				for _, want := range wants {
+					assert.Contains(t, files[name], want)
+				}
+			}
+			for name, absent := range tt.wantAbsent {
+				for _, text := range absent {
+					assert.NotContains(t, files[name], text)
+				}
+			}
+
+			repo.AssertExpectations(t)
+			photos.AssertExpectations(t)
+			storage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestExportService_Hashtags(t *testing.T) {
+	assert.Equal(t, []string{"travel", "café"}, hashtags("#Travel to the #café, C# and https://x.org/#anchor #2024 &#39; #travel"))
+	assert.Nil(t, hashtags("# Heading"))
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/models"
+)
+
+// ImportMaxSize is the largest export file an import accepts.
+const ImportMaxSize = 1 << 30
+
+const importListLimit = 50
+
+type journalImport struct {
+	repo    domain.ImportRepository
+	moods   domain.MoodRepository
+	storage helper.Storage
+	job     *ImportJob
+}
+
+func NewImport(repo domain.ImportRepository, moods domain.MoodRepository, storage helper.Storage, job *ImportJob) domain.ImportService {
+	return &journalImport{repo: repo, moods: moods, storage: storage, job: job}
+}
+
+// Create stores an uploaded export and queues it for the import job. The
+// file is only parsed by the job, which reports on each entry in turn.
+func (i *journalImport) Create(ctx context.Context, userID int64, upload *dto.ImportUpload) (*dto.ImportResponse, error) {
+	if upload.Size > ImportMaxSize {
+		return nil, helper.NewAppError(helper.FILE_TOO_LARGE, fmt.Sprintf("file must not be larger than %d MB", ImportMaxSize>>20), nil)
+	}
+	if upload.Size == 0 {
+		return nil, helper.NewAppError(helper.VALIDATION_ERROR, "file is empty", nil).
+			WithDetails([]helper.ValidatorError{{Field: "file", Message: "must not be empty"}})
+	}
+
+	imp := &models.Import{
+		UserID:   userID,
+		Format:   upload.Format,
+		Filename: upload.Filename,
+		Size:     upload.Size,
+	}
+	if upload.DefaultMoodID != 0 {
+		if err := checkMoods(ctx, i.moods, userID, upload.DefaultMoodID); err != nil {
+			if err.(*helper.AppError).Code == helper.VALIDATION_ERROR {
+				return nil, helper.NewAppError(helper.VALIDATION_ERROR, "mood not available", nil).
+					WithDetails([]helper.ValidatorError{{Field: "default_mood_id", Message: "must be one of your moods"}})
+			}
+			return nil, err
+		}
+		imp.DefaultMoodID = &upload.DefaultMoodID
+	}
+
+	key := helper.StorageKey(fmt.Sprintf("users/%d/imports", userID), upload.Filename)
+	if err := i.storage.Put(ctx, key, upload.Content, upload.Size, "application/octet-stream"); err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to store import", err)
+	}
+	imp.StorageKey = &key
+
+	if err := i.repo.Create(ctx, imp); err != nil {
+		removeObject(i.storage, key)
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to create import", err)
+	}
+
+	i.job.Enqueue()
+
+	resp := toImportResponse(imp)
+	return &resp, nil
+}
+
+// GetList returns the user's latest imports without their entries.
+func (i *journalImport) GetList(ctx context.Context, userID int64) ([]dto.ImportResponse, error) {
+	imports, err := i.repo.GetListByUserID(ctx, userID, importListLimit)
+	if err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get imports", err)
+	}
+
+	resp := make([]dto.ImportResponse, 0, len(imports))
+	for _, imp := range imports {
+		resp = append(resp, toImportResponse(&imp))
+	}
+
+	return resp, nil
+}
+
+// GetByID returns an import with the outcome of each entry handled so far.
+func (i *journalImport) GetByID(ctx context.Context, userID int64, uid string) (*dto.ImportResponse, error) {
+	imp, err := i.repo.GetByUid(ctx, uid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helper.NewAppError(helper.NOT_FOUND, "import not found", err)
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get import", err)
+	}
+
+	if imp.UserID != userID {
+		return nil, helper.NewAppError(helper.NOT_FOUND, "import not found", nil)
+	}
+
+	resp := toImportResponse(imp)
+	resp.Entries = make([]dto.ImportEntryResponse, 0, len(imp.Entries))
+	for _, entry := range imp.Entries {
+		resp.Entries = append(resp.Entries, dto.ImportEntryResponse{
+			Position:   entry.Position,
+			SourceID:   entry.SourceID,
+			Title:      entry.Title,
+			Status:     entry.Status,
+			JournalUid: entry.JournalUid,
+			Message:    entry.Message,
+		})
+	}
+
+	return &resp, nil
+}
+
+func toImportResponse(imp *models.Import) dto.ImportResponse {
+	return dto.ImportResponse{
+		Uid:        imp.Uid,
+		Format:     imp.Format,
+		Filename:   imp.Filename,
+		Status:     imp.Status,
+		Total:      imp.Total,
+		Imported:   imp.Imported,
+		Skipped:    imp.Skipped,
+		Failed:     imp.Failed,
+		Error:      imp.Error,
+		CreatedAt:  imp.CreatedAt,
+		FinishedAt: imp.FinishedAt,
+	}
+}
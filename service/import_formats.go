@@ -0,0 +1,811 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"timo/helper"
+	"timo/models"
+	"unicode/utf8"
+)
+
+const (
+	// importDocumentMax bounds how much of one JSON, Markdown or CSV file is
+	// read, whatever its archive claims.
+	importDocumentMax = 256 << 20
+	// importDocumentsMax bounds the number of documents in an archive.
+	importDocumentsMax = 20000
+	// importUnpackedMax bounds the bytes decompressed from an archive in one
+	// pass over it, counted as they are read.
+	importUnpackedMax = 4 << 30
+	// importTitleMax bounds titles taken from the first line of a text, in
+	// runes.
+	importTitleMax = 80
+)
+
+var (
+	markdownHeadingLine = regexp.MustCompile(`^#{1,6}\s+(.+?)\s*#*$`)
+	markdownImageLine   = regexp.MustCompile(`^!\[((?:\\.|[^\]\\])*)\]\(<?([^)>\s]+)>?(?:\s+"[^"]*")?\)$`)
+	markdownLinkLine    = regexp.MustCompile(`^\[((?:\\.|[^\]\\])*)\]\(<?([^)>\s]+)>?\)$`)
+	markdownCaptionLine = regexp.MustCompile(`^\*((?:\\.|[^*\\])+)\*$`)
+	markdownEscape      = regexp.MustCompile(`\\([!-/:-@\[-` + "`" + `{-~])`)
+	blankLines          = regexp.MustCompile(`\n{3,}`)
+	audioDurationSuffix = regexp.MustCompile(`\s*\(\d+:\d{2}\)$`)
+	filenameDate        = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}`)
+	// dayOneMoment is how Day One places a photo or recording in the text.
+	dayOneMoment = regexp.MustCompile(`!\[[^\]]*\]\(dayone-moment:[^)]*\)`)
+)
+
+// importDateLayouts are the dates understood in front matter and CSV. Only
+// the calendar date is kept, as written.
+var importDateLayouts = []string{
+	helper.DATE_LAYOUT,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+var audioFileExtensions = []string{".m4a", ".mp3", ".ogg", ".oga", ".opus"}
+
+// importEntry is an entry read from an import, ready to become a journal.
+// Moods are named by label, the first one being the primary mood. Problems
+// don't stop the entry from being imported and are reported with it, while
+// an entry with a fault is not imported at all.
+type importEntry struct {
+	sourceID string
+	title    string
+	text     string
+	date     time.Time
+	moods    []importMood
+	tags     []string
+	media    []importMedia
+	problems []string
+	fault    string
+}
+
+type importMood struct {
+	label     string
+	intensity int
+}
+
+// importMedia is a photo or recording bundled with an entry.
+type importMedia struct {
+	file    *zip.File
+	bundle  *importBundle
+	caption string
+	altText string
+}
+
+// open reads the file from its bundle, within the bundle's budget.
+func (m *importMedia) open() (io.ReadCloser, error) {
+	return m.bundle.open(m.file)
+}
+
+// complete takes a leading heading as the title when the entry has none, and
+// identifies the entry by the ID the other app gave it or else by a hash of
+// its content, so the same entry is recognised when imported again.
+func (e *importEntry) complete(format, id string) {
+	e.text = strings.TrimSpace(e.text)
+	if e.title == "" {
+		if first, rest, _ := strings.Cut(e.text, "\n"); markdownHeadingLine.MatchString(first) {
+			e.title = markdownHeadingLine.FindStringSubmatch(first)[1]
+			e.text = strings.TrimSpace(rest)
+		}
+	}
+	if e.title == "" {
+		e.title = excerpt(e.text)
+	}
+	if e.title == "" && !e.date.IsZero() {
+		e.title = e.date.Format("Monday, 2 January 2006")
+	}
+
+	if id = strings.TrimSpace(id); id != "" {
+		e.sourceID = format + ":" + id
+		return
+	}
+	sum := sha256.Sum256([]byte(e.date.Format(helper.DATE_LAYOUT) + "\n" + e.title + "\n" + e.text))
+	e.sourceID = "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// addMood adds a mood by label unless the entry has it already.
+func (e *importEntry) addMood(mood importMood) {
+	mood.label = strings.TrimSpace(mood.label)
+	if mood.label == "" {
+		return
+	}
+	if slices.ContainsFunc(e.moods, func(m importMood) bool { return strings.EqualFold(m.label, mood.label) }) {
+		return
+	}
+	e.moods = append(e.moods, mood)
+}
+
+// excerpt is the first line of text without Markdown markers, shortened to
+// importTitleMax runes.
+func excerpt(text string) string {
+	first, _, _ := strings.Cut(text, "\n")
+	first = strings.TrimSpace(strings.TrimLeft(first, "#>*-_ \t"))
+	if utf8.RuneCountInString(first) <= importTitleMax {
+		return first
+	}
+	runes := []rune(first)
+	return strings.TrimSpace(string(runes[:importTitleMax-1])) + "…"
+}
+
+// importBundle is an uploaded file: either a ZIP with documents and the
+// photos they refer to, or a single document. Everything read from the
+// archive counts against importUnpackedMax, so a bundle is opened afresh for
+// each pass over it.
+type importBundle struct {
+	name     string
+	data     io.ReaderAt
+	size     int64
+	files    []*zip.File          // in name order
+	paths    map[string]*zip.File // by cleaned path, nil for a single document
+	bases    map[string]*zip.File // by lowercased file name
+	unpacked int64
+}
+
+// importDoc is a JSON, Markdown or CSV file of a bundle, read on demand.
+type importDoc struct {
+	name string
+	file *zip.File // nil for a single document
+}
+
+var errImportUnpackedMax = fmt.Errorf("archive must not unpack to more than %d GB", importUnpackedMax>>30)
+
+func openImportBundle(name string, data io.ReaderAt, size int64) (*importBundle, error) {
+	b := &importBundle{name: name, data: data, size: size}
+
+	magic := make([]byte, 4)
+	if _, err := data.ReadAt(magic, 0); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if string(magic) != "PK\x03\x04" {
+		return b, nil
+	}
+
+	archive, err := zip.NewReader(data, size)
+	if err != nil {
+		return nil, errors.New("file is not a valid ZIP archive")
+	}
+
+	b.paths = make(map[string]*zip.File, len(archive.File))
+	b.bases = make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		name := path.Clean(strings.TrimPrefix(f.Name, "/"))
+		if f.FileInfo().IsDir() || strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), ".") {
+			continue
+		}
+		b.files = append(b.files, f)
+		b.paths[name] = f
+		b.bases[strings.ToLower(path.Base(name))] = f
+	}
+	slices.SortFunc(b.files, func(x, y *zip.File) int { return strings.Compare(x.Name, y.Name) })
+
+	return b, nil
+}
+
+// documents lists the files with one of exts, in name order, without
+// reading them. A single document is listed whatever its name.
+func (b *importBundle) documents(exts ...string) ([]importDoc, error) {
+	if b.paths == nil {
+		return []importDoc{{name: b.name}}, nil
+	}
+
+	var docs []importDoc
+	for _, f := range b.files {
+		if !slices.Contains(exts, strings.ToLower(path.Ext(f.Name))) {
+			continue
+		}
+		if len(docs) == importDocumentsMax {
+			return nil, fmt.Errorf("archive must not contain more than %d %s files", importDocumentsMax, strings.Join(exts, " or "))
+		}
+		docs = append(docs, importDoc{name: path.Clean(f.Name), file: f})
+	}
+
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("archive contains no %s file", strings.Join(exts, " or "))
+	}
+
+	return docs, nil
+}
+
+// read loads a document, up to importDocumentMax bytes.
+func (b *importBundle) read(doc importDoc) ([]byte, error) {
+	var r io.Reader = io.NewSectionReader(b.data, 0, b.size)
+	if doc.file != nil {
+		rc, err := b.open(doc.file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", doc.name, err)
+		}
+		defer rc.Close()
+		r = rc
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, importDocumentMax+1))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", doc.name, err)
+	}
+	if len(data) > importDocumentMax {
+		return nil, fmt.Errorf("%s: file must not be larger than %d MB", doc.name, importDocumentMax>>20)
+	}
+	return data, nil
+}
+
+// open reads a bundled file, counting the bytes it actually decompresses
+// rather than the size the archive claims.
+func (b *importBundle) open(f *zip.File) (io.ReadCloser, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{&unpackReader{r: rc, b: b}, rc}, nil
+}
+
+type unpackReader struct {
+	r io.Reader
+	b *importBundle
+}
+
+func (u *unpackReader) Read(p []byte) (int, error) {
+	left := importUnpackedMax - u.b.unpacked
+	if left <= 0 {
+		return 0, errImportUnpackedMax
+	}
+	if int64(len(p)) > left {
+		p = p[:left]
+	}
+
+	n, err := u.r.Read(p)
+	u.b.unpacked += int64(n)
+	return n, err
+}
+
+// resolve finds the bundled file a document refers to by a relative link,
+// falling back to any file of that name for archives that were flattened.
+func (b *importBundle) resolve(doc, target string) *zip.File {
+	if b.paths == nil || !isRelativeLink(target) {
+		return nil
+	}
+	if unescaped, err := url.PathUnescape(target); err == nil {
+		target = unescaped
+	}
+
+	if f, ok := b.paths[path.Join(path.Dir(doc), target)]; ok {
+		return f
+	}
+	return b.bases[strings.ToLower(path.Base(target))]
+}
+
+// attach adds the bundled file a document refers to as media of e, or notes
+// that it's missing.
+func (b *importBundle) attach(e *importEntry, doc, target string, media importMedia) {
+	media.file, media.bundle = b.resolve(doc, target), b
+	if media.file == nil {
+		e.problems = append(e.problems, fmt.Sprintf("%s: not in the archive", target))
+		return
+	}
+	e.media = append(e.media, media)
+}
+
+func isRelativeLink(target string) bool {
+	return target != "" && !strings.Contains(target, ":") && !strings.HasPrefix(target, "/") && !strings.HasPrefix(target, "#")
+}
+
+// parseImport reads the entries of an upload in the given format, one
+// document at a time, and hands those of each document to fn. An error
+// from fn is returned as is; any other error describes what is wrong with
+// the file.
+func parseImport(format string, b *importBundle, fn func([]importEntry) error) error {
+	var exts []string
+	var parse func(*importBundle, importDoc, []byte) ([]importEntry, error)
+	switch format {
+	case models.IMPORT_DAYONE:
+		exts, parse = []string{".json"}, parseDayOne
+	case models.IMPORT_MARKDOWN:
+		exts, parse = []string{".md", ".markdown"}, parseMarkdown
+	case models.IMPORT_CSV:
+		exts, parse = []string{".csv"}, parseCSV
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+
+	docs, err := b.documents(exts...)
+	if err != nil {
+		return err
+	}
+
+	for _, doc := range docs {
+		data, err := b.read(doc)
+		if err != nil {
+			return err
+		}
+		entries, err := parse(b, doc, data)
+		if err != nil {
+			return err
+		}
+		if err := fn(entries); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dayOneExport is the JSON file of a Day One export. The ZIP export keeps
+// photos as photos/<md5>.<type> and recordings as audios/<md5>.<format>
+// next to it.
+type dayOneExport struct {
+	Entries []dayOneEntry `json:"entries"`
+}
+
+type dayOneEntry struct {
+	UUID         string        `json:"uuid"`
+	CreationDate time.Time     `json:"creationDate"`
+	TimeZone     string        `json:"timeZone"`
+	Text         string        `json:"text"`
+	Tags         []string      `json:"tags"`
+	Photos       []dayOneMedia `json:"photos"`
+	Audios       []dayOneMedia `json:"audios"`
+}
+
+type dayOneMedia struct {
+	MD5    string `json:"md5"`
+	Type   string `json:"type"`
+	Format string `json:"format"`
+}
+
+func parseDayOne(b *importBundle, doc importDoc, data []byte) ([]importEntry, error) {
+	var export dayOneExport
+	if err := json.Unmarshal(data, &export); err != nil || export.Entries == nil {
+		return nil, fmt.Errorf("%s: not a Day One JSON export", path.Base(doc.name))
+	}
+
+	entries := make([]importEntry, 0, len(export.Entries))
+	for _, de := range export.Entries {
+		e := importEntry{tags: de.Tags}
+		if de.CreationDate.IsZero() {
+			e.fault = "entry has no creation date"
+		} else {
+			e.date = helper.LocalDate(de.CreationDate, helper.UserLocation(de.TimeZone))
+		}
+
+		// Day One escapes Markdown characters in plain text.
+		text := dayOneMoment.ReplaceAllString(de.Text, "")
+		e.text = blankLines.ReplaceAllString(markdownEscape.ReplaceAllString(text, "$1"), "\n\n")
+
+		for _, photo := range de.Photos {
+			b.attach(&e, doc.name, path.Join("photos", photo.MD5+"."+cmp.Or(photo.Type, "jpeg")), importMedia{})
+		}
+		for _, audio := range de.Audios {
+			b.attach(&e, doc.name, path.Join("audios", audio.MD5+"."+cmp.Or(audio.Format, "m4a")), importMedia{})
+		}
+
+		e.complete(models.IMPORT_DAYONE, de.UUID)
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+// parseMarkdown reads one entry per Markdown file. Front matter may give the
+// title, date, mood, moods, tags and an id; the date can also lead the file
+// name. Images and recordings linked on a line of their own are attached
+// when the archive has them, with an emphasized line below as the caption.
+func parseMarkdown(b *importBundle, doc importDoc, data []byte) ([]importEntry, error) {
+	var e importEntry
+
+	matter, body, err := splitFrontMatter(string(data))
+	if err != nil {
+		e.fault = err.Error()
+	}
+
+	e.title = matter.text("title")
+	date := cmp.Or(matter.text("date", "created", "created_at", "entry_date"), filenameDate.FindString(path.Base(doc.name)))
+	switch {
+	case date == "":
+		e.fault = cmp.Or(e.fault, "entry has no date")
+	default:
+		if e.date, err = parseImportDate(date); err != nil {
+			e.fault = cmp.Or(e.fault, fmt.Sprintf("date %q is not understood", date))
+		}
+	}
+
+	// Our own export repeats the first mood as "mood"; the list carries
+	// the intensities, so it goes first.
+	for _, item := range matter.list("moods") {
+		switch v := item.(type) {
+		case string:
+			e.addMood(parseImportMood(v))
+		case map[string]string:
+			intensity, _ := strconv.Atoi(v["intensity"])
+			e.addMood(importMood{label: v["label"], intensity: intensity})
+		}
+	}
+	if mood := matter.text("mood"); mood != "" {
+		e.addMood(parseImportMood(mood))
+	}
+	for _, item := range matter.list("tags") {
+		if tag, ok := item.(string); ok {
+			e.tags = append(e.tags, tag)
+		}
+	}
+
+	e.text = b.extractMedia(&e, doc.name, body)
+	e.complete(models.IMPORT_MARKDOWN, matter.text("uid", "id"))
+
+	return []importEntry{e}, nil
+}
+
+// extractMedia attaches the bundled images and recordings linked on a line
+// of their own and returns the text without them.
+func (b *importBundle) extractMedia(e *importEntry, doc, text string) string {
+	lines := strings.Split(text, "\n")
+	kept := make([]string, 0, len(lines))
+
+	for n := 0; n < len(lines); n++ {
+		line := strings.TrimSpace(lines[n])
+
+		if m := markdownImageLine.FindStringSubmatch(line); m != nil && b.resolve(doc, m[2]) != nil {
+			media := importMedia{altText: unescapeMarkdown(m[1])}
+			next := n + 1
+			for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+				next++
+			}
+			if next < len(lines) {
+				if c := markdownCaptionLine.FindStringSubmatch(strings.TrimSpace(lines[next])); c != nil {
+					media.caption = unescapeMarkdown(c[1])
+					n = next
+				}
+			}
+			b.attach(e, doc, m[2], media)
+			continue
+		}
+
+		if m := markdownLinkLine.FindStringSubmatch(line); m != nil && isAudioFile(m[2]) && b.resolve(doc, m[2]) != nil {
+			// Recordings are linked with their caption, or "Voice note", and
+			// their duration.
+			caption := audioDurationSuffix.ReplaceAllString(unescapeMarkdown(m[1]), "")
+			if caption == "Voice note" {
+				caption = ""
+			}
+			b.attach(e, doc, m[2], importMedia{caption: caption})
+			continue
+		}
+
+		kept = append(kept, lines[n])
+	}
+
+	return blankLines.ReplaceAllString(strings.Join(kept, "\n"), "\n\n")
+}
+
+// csvColumns maps the header names understood in CSV files to their field.
+var csvColumns = map[string]string{
+	"date":          "date",
+	"entry_date":    "date",
+	"entry date":    "date",
+	"created":       "date",
+	"created_at":    "date",
+	"creation date": "date",
+	"title":         "title",
+	"subject":       "title",
+	"text":          "text",
+	"body":          "text",
+	"content":       "text",
+	"entry":         "text",
+	"note":          "text",
+	"mood":          "mood",
+	"moods":         "moods",
+	"tags":          "tags",
+	"tag":           "tags",
+	"id":            "id",
+	"uid":           "id",
+	"uuid":          "id",
+	"photos":        "photos",
+	"photo":         "photos",
+	"attachments":   "photos",
+	"media":         "photos",
+}
+
+// parseCSV reads one entry per row of a CSV file with a header row. A date
+// and a text or title column are required. Moods, tags and photos hold
+// lists separated by commas, semicolons or bars; a mood may carry its
+// intensity as "calm:2", and photos are paths in the archive.
+func parseCSV(b *importBundle, doc importDoc, data []byte) ([]importEntry, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path.Base(doc.name), err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		if field, ok := csvColumns[strings.ToLower(strings.TrimSpace(name))]; ok {
+			if _, seen := columns[field]; !seen {
+				columns[field] = i
+			}
+		}
+	}
+	_, hasText := columns["text"]
+	_, hasTitle := columns["title"]
+	if _, ok := columns["date"]; !ok || !hasText && !hasTitle {
+		return nil, fmt.Errorf("%s: needs a date column and a text or title column", path.Base(doc.name))
+	}
+
+	var entries []importEntry
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path.Base(doc.name), err)
+		}
+		if len(entries) == importMaxEntries {
+			return nil, fmt.Errorf("%s: file must not contain more than %d entries", path.Base(doc.name), importMaxEntries)
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		e := importEntry{title: field("title"), text: field("text")}
+		if date := field("date"); date == "" {
+			e.fault = "entry has no date"
+		} else if e.date, err = parseImportDate(date); err != nil {
+			e.fault = fmt.Sprintf("date %q is not understood", date)
+		}
+
+		if mood := field("mood"); mood != "" {
+			e.addMood(parseImportMood(mood))
+		}
+		for _, mood := range splitList(field("moods")) {
+			e.addMood(parseImportMood(mood))
+		}
+		e.tags = splitList(field("tags"))
+		for _, photo := range splitList(field("photos")) {
+			b.attach(&e, doc.name, photo, importMedia{})
+		}
+
+		e.complete(models.IMPORT_CSV, field("id"))
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+// parseImportDate reads the calendar date of a date or timestamp, in the
+// time zone it was written in.
+func parseImportDate(s string) (time.Time, error) {
+	for _, layout := range importDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return helper.LocalDate(t, t.Location()), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown date format %q", s)
+}
+
+// parseImportMood reads a mood label with an optional ":intensity" suffix.
+func parseImportMood(s string) importMood {
+	if label, level, ok := strings.Cut(s, ":"); ok {
+		if intensity, err := strconv.Atoi(strings.TrimSpace(level)); err == nil {
+			return importMood{label: strings.TrimSpace(label), intensity: intensity}
+		}
+	}
+	return importMood{label: strings.TrimSpace(s)}
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' || r == '|' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func isAudioFile(name string) bool {
+	return slices.Contains(audioFileExtensions, strings.ToLower(path.Ext(name)))
+}
+
+func unescapeMarkdown(s string) string {
+	return markdownEscape.ReplaceAllString(s, "$1")
+}
+
+// frontMatter holds the keys of a YAML front matter block, lowercased. A
+// value is a string, or a list of strings and flat mappings.
+type frontMatter map[string]any
+
+// text returns the first of keys that holds a string.
+func (m frontMatter) text(keys ...string) string {
+	for _, key := range keys {
+		if s, ok := m[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// list returns a list value. A string is read as a comma separated list.
+func (m frontMatter) list(key string) []any {
+	switch v := m[key].(type) {
+	case []any:
+		return v
+	case string:
+		var items []any
+		for _, item := range splitList(v) {
+			items = append(items, strings.TrimPrefix(item, "#"))
+		}
+		return items
+	}
+	return nil
+}
+
+// splitFrontMatter splits YAML front matter off a Markdown document. Only
+// the YAML that journaling apps write is understood: scalars, flow lists and
+// block lists, whose items may be flat mappings.
+func splitFrontMatter(doc string) (frontMatter, string, error) {
+	doc = strings.ReplaceAll(strings.TrimPrefix(doc, "\ufeff"), "\r\n", "\n")
+	lines := strings.Split(doc, "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return frontMatter{}, doc, nil
+	}
+
+	end := slices.IndexFunc(lines[1:], func(line string) bool {
+		line = strings.TrimSpace(line)
+		return line == "---" || line == "..."
+	})
+	if end < 0 {
+		return frontMatter{}, doc, nil
+	}
+	head, body := lines[1:end+1], strings.Join(lines[end+2:], "\n")
+
+	matter := make(frontMatter)
+	var key string
+	for n, line := range head {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		invalid := fmt.Errorf("front matter line %d is not understood", n+2)
+
+		if line[0] != ' ' && line[0] != '\t' && line[0] != '-' {
+			k, v, ok := strings.Cut(line, ":")
+			if !ok {
+				return matter, body, invalid
+			}
+			key = strings.ToLower(strings.TrimSpace(k))
+			matter[key] = yamlFlow(strings.TrimSpace(v))
+			continue
+		}
+
+		if key == "" {
+			return matter, body, invalid
+		}
+		list, _ := matter[key].([]any)
+		if item, ok := strings.CutPrefix(trimmed, "-"); ok && (item == "" || item[0] == ' ') {
+			item = strings.TrimSpace(item)
+			if k, v, ok := yamlMapping(item); ok {
+				matter[key] = append(list, map[string]string{k: v})
+			} else {
+				matter[key] = append(list, yamlScalar(item))
+			}
+			continue
+		}
+
+		// Further keys of the mapping started by the last item.
+		if len(list) > 0 {
+			if item, ok := list[len(list)-1].(map[string]string); ok {
+				if k, v, ok := yamlMapping(trimmed); ok {
+					item[k] = v
+					continue
+				}
+			}
+		}
+		return matter, body, invalid
+	}
+
+	return matter, body, nil
+}
+
+// yamlFlow reads a value that follows a key on the same line: a scalar, a
+// flow list, or nothing when a block list follows.
+func yamlFlow(v string) any {
+	if v == "" {
+		return []any{}
+	}
+	if !strings.HasPrefix(v, "[") || !strings.HasSuffix(v, "]") {
+		return yamlScalar(v)
+	}
+
+	var items []any
+	var decoded []any
+	if err := json.Unmarshal([]byte(v), &decoded); err == nil {
+		for _, item := range decoded {
+			items = append(items, strings.TrimPrefix(fmt.Sprint(item), "#"))
+		}
+		return items
+	}
+	for _, item := range strings.Split(v[1:len(v)-1], ",") {
+		if item = yamlScalar(strings.TrimSpace(item)); item != "" {
+			items = append(items, strings.TrimPrefix(item, "#"))
+		}
+	}
+	return items
+}
+
+// yamlMapping reads "key: value" as one entry of a mapping.
+func yamlMapping(s string) (string, string, bool) {
+	k, v, ok := strings.Cut(s, ":")
+	if !ok || k == "" || strings.ContainsAny(k, " \"'") || (v != "" && v[0] != ' ') {
+		return "", "", false
+	}
+	return strings.ToLower(k), yamlScalar(strings.TrimSpace(v)), true
+}
+
+func yamlScalar(v string) string {
+	switch {
+	case strings.HasPrefix(v, `"`):
+		v = v[:quotedEnd(v)]
+		var s string
+		if err := json.Unmarshal([]byte(v), &s); err == nil {
+			return s
+		}
+		if s, err := strconv.Unquote(v); err == nil {
+			return s
+		}
+		return strings.Trim(v, `"`)
+	case strings.HasPrefix(v, "'") && len(v) > 1:
+		v = v[:quotedEnd(v)]
+		return strings.ReplaceAll(strings.Trim(v, "'"), "''", "'")
+	}
+
+	if i := strings.Index(v, " #"); i >= 0 {
+		v = v[:i]
+	}
+	v = strings.TrimSpace(v)
+	if v == "~" || v == "null" {
+		return ""
+	}
+	return v
+}
+
+// quotedEnd returns the index just past the closing quote of the quoted
+// scalar v starts with, so that a trailing comment is left out.
+func quotedEnd(v string) int {
+	quote := v[0]
+	for i := 1; i < len(v); i++ {
+		switch {
+		case quote == '"' && v[i] == '\\':
+			i++
+		case v[i] == quote && quote == '\'' && i+1 < len(v) && v[i+1] == '\'':
+			i++
+		case v[i] == quote:
+			return i + 1
+		}
+	}
+	return len(v)
+}
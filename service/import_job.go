@@ -0,0 +1,563 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/models"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	importBatchSize  = 1
+	importStaleAfter = 15 * time.Minute
+	importMaxEntries = 20000
+	importMoodMax    = 32
+	importMoodsMax   = 8
+)
+
+// ImportJob turns uploaded exports of other journaling apps into journals.
+// Each entry goes through the journal and photo services like one written in
+// the app, and its outcome is recorded as it's done, so an import that was
+// interrupted carries on with the entries it hasn't recorded yet.
+type ImportJob struct {
+	repo     domain.ImportRepository
+	moods    domain.MoodRepository
+	journals domain.JournalService
+	photos   domain.PhotoService
+	storage  helper.Storage
+	limits   PhotoLimits
+	wake     chan struct{}
+}
+
+func NewImportJob(repo domain.ImportRepository, moods domain.MoodRepository, journals domain.JournalService, photos domain.PhotoService, storage helper.Storage, limits PhotoLimits) *ImportJob {
+	return &ImportJob{repo: repo, moods: moods, journals: journals, photos: photos, storage: storage, limits: limits, wake: make(chan struct{}, 1)}
+}
+
+// Enqueue wakes the worker for a new import without blocking the caller.
+func (j *ImportJob) Enqueue() {
+	select {
+	case j.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run processes pending imports whenever Enqueue is called, and every
+// interval to pick up leftovers, until ctx is cancelled.
+func (j *ImportJob) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.ProcessPending(ctx); err != nil {
+			log.Printf("import job: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-j.wake:
+		}
+	}
+}
+
+// ProcessPending claims and processes imports until no pending one is left.
+// An import that fails on the database stays claimed and is picked up again
+// once its claim is stale.
+func (j *ImportJob) ProcessPending(ctx context.Context) error {
+	for {
+		imports, err := j.repo.ClaimPending(ctx, importBatchSize, importStaleAfter)
+		if err != nil {
+			return err
+		}
+		if len(imports) == 0 {
+			return nil
+		}
+
+		for i := range imports {
+			if err := j.process(ctx, &imports[i]); err != nil {
+				return fmt.Errorf("import %s: %w", imports[i].Uid, err)
+			}
+		}
+	}
+}
+
+// process reads the uploaded file twice, one document at a time: first to
+// check it and count its entries, then to import them. Nothing is imported
+// from a file that is wrong as a whole.
+func (j *ImportJob) process(ctx context.Context, imp *models.Import) error {
+	if imp.StorageKey == nil {
+		return j.finish(ctx, imp, models.IMPORT_FAILED, "uploaded file is missing")
+	}
+
+	file, err := j.download(ctx, *imp.StorageKey)
+	if errors.Is(err, helper.ErrObjectNotFound) {
+		return j.finish(ctx, imp, models.IMPORT_FAILED, "uploaded file is missing")
+	}
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	total := 0
+	err = j.parse(imp, file, func(entries []importEntry) error {
+		total += len(entries)
+		if total > importMaxEntries {
+			return fmt.Errorf("file must not contain more than %d entries", importMaxEntries)
+		}
+		return nil
+	})
+	if err != nil {
+		return j.finish(ctx, imp, models.IMPORT_FAILED, err.Error())
+	}
+
+	if err := j.repo.SetTotal(ctx, imp.ID, total); err != nil {
+		return err
+	}
+
+	// Entries recorded by an earlier, interrupted run are done, unless that
+	// run stopped while creating their journal.
+	current, err := j.repo.GetByUid(ctx, imp.Uid)
+	if err != nil {
+		return err
+	}
+	done := make(map[int]*models.ImportEntry, len(current.Entries))
+	for n := range current.Entries {
+		done[current.Entries[n].Position] = &current.Entries[n]
+	}
+
+	moods, err := j.loadMoods(ctx, imp.UserID)
+	if err != nil {
+		return err
+	}
+
+	position := 0
+	imported := make(map[string]string)
+	var importErr error
+	err = j.parse(imp, file, func(entries []importEntry) error {
+		importErr = j.importEntries(ctx, imp, entries, position, done, imported, moods)
+		position += len(entries)
+		return importErr
+	})
+	if importErr != nil {
+		return importErr
+	}
+	if err != nil {
+		return j.finish(ctx, imp, models.IMPORT_FAILED, err.Error())
+	}
+
+	return j.finish(ctx, imp, models.IMPORT_DONE, "")
+}
+
+// importEntries imports the entries of one document, the first being at
+// position in the file, and records the outcome of each.
+func (j *ImportJob) importEntries(ctx context.Context, imp *models.Import, entries []importEntry, position int, done map[int]*models.ImportEntry, imported map[string]string, moods *importMoods) error {
+	sourceIDs := make([]string, 0, len(entries))
+	for _, e := range entries {
+		sourceIDs = append(sourceIDs, e.sourceID)
+	}
+	found, err := j.repo.FindImported(ctx, imp.UserID, sourceIDs)
+	if err != nil {
+		return err
+	}
+	maps.Copy(imported, found)
+
+	for n := range entries {
+		e := &entries[n]
+		entry := &models.ImportEntry{ImportID: imp.ID, Position: position + n, SourceID: e.sourceID, Title: e.title}
+
+		prev := done[entry.Position]
+		if prev != nil && prev.Status != models.IMPORT_ENTRY_PENDING {
+			continue
+		}
+
+		// A pending entry with a uid stopped around the creation of its
+		// journal, which is created again under that uid if it's missing.
+		var uid string
+		if prev != nil && prev.JournalUid != nil {
+			uid = *prev.JournalUid
+		}
+		created, err := j.journalExists(ctx, imp.UserID, uid)
+		if err != nil {
+			return err
+		}
+
+		if created {
+			entry.Status = models.IMPORT_ENTRY_IMPORTED
+			entry.JournalUid = &uid
+			entry.Message = helper.Ptr("import was interrupted, attachments may be missing")
+		} else if err := j.importEntry(ctx, imp, e, entry, uid, imported, moods); err != nil {
+			return err
+		}
+
+		if entry.Status == models.IMPORT_ENTRY_IMPORTED {
+			imported[e.sourceID] = *entry.JournalUid
+		}
+		if err := j.repo.AddEntry(ctx, entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// journalExists reports whether the user has the journal uid, if any.
+func (j *ImportJob) journalExists(ctx context.Context, userID int64, uid string) (bool, error) {
+	if uid == "" {
+		return false, nil
+	}
+
+	_, err := j.journals.GetByID(ctx, userID, uid)
+	var appErr *helper.AppError
+	if errors.As(err, &appErr) && appErr.Code == helper.NOT_FOUND {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// download copies the uploaded file to a temporary file, since ZIP archives
+// are read out of order.
+func (j *ImportJob) download(ctx context.Context, key string) (*os.File, error) {
+	body, err := j.storage.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	file, err := os.CreateTemp("", "timo-import-*")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(file, body); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// parse opens the file afresh, so that each pass over it has the full
+// importUnpackedMax to read, and hands its entries to fn document by
+// document.
+func (j *ImportJob) parse(imp *models.Import, file *os.File, fn func([]importEntry) error) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	bundle, err := openImportBundle(imp.Filename, file, info.Size())
+	if err != nil {
+		return err
+	}
+
+	return parseImport(imp.Format, bundle, fn)
+}
+
+// finish records the final status and removes the uploaded file.
+func (j *ImportJob) finish(ctx context.Context, imp *models.Import, status, message string) error {
+	if err := j.repo.Finish(ctx, imp.ID, status, helper.PtrOrNil(message)); err != nil {
+		return err
+	}
+
+	if imp.StorageKey != nil {
+		removeObject(j.storage, *imp.StorageKey)
+	}
+
+	return nil
+}
+
+// importEntry creates the journal of one entry, unless it was imported
+// before, attaches its media and sets the outcome on entry. Photos that
+// can't be attached are noted on the entry but don't fail it. The entry is
+// recorded as pending with the uid of its journal first, so that a run that
+// stops halfway doesn't create the journal again; uid is the one an earlier
+// run recorded, or empty for a new one.
+func (j *ImportJob) importEntry(ctx context.Context, imp *models.Import, e *importEntry, entry *models.ImportEntry, uid string, imported map[string]string, moods *importMoods) error {
+	if e.fault != "" {
+		setImportFailure(entry, e.fault)
+		return nil
+	}
+	if uid, ok := imported[e.sourceID]; ok {
+		entry.Status = models.IMPORT_ENTRY_SKIPPED
+		entry.JournalUid = &uid
+		entry.Message = helper.Ptr("already imported")
+		return nil
+	}
+
+	req, err := j.journalRequest(ctx, imp, e, moods)
+	if err != nil {
+		setImportFailure(entry, err.Error())
+		return nil
+	}
+
+	req.Uid = uid
+	if req.Uid == "" {
+		req.Uid = helper.NewUUID()
+	}
+	pending := *entry
+	pending.Status = models.IMPORT_ENTRY_PENDING
+	pending.JournalUid = &req.Uid
+	if err := j.repo.AddEntry(ctx, &pending); err != nil {
+		return err
+	}
+
+	journal, err := j.journals.Create(ctx, imp.UserID, time.UTC, req)
+	if err != nil {
+		setImportFailure(entry, appErrorMessage(err))
+		return nil
+	}
+
+	problems := e.problems
+	for _, media := range e.media {
+		if err := j.attach(ctx, imp.UserID, journal.Uid, &media); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", media.file.Name, err))
+		}
+	}
+
+	entry.Status = models.IMPORT_ENTRY_IMPORTED
+	entry.JournalUid = &journal.Uid
+	if len(problems) > 0 {
+		entry.Message = helper.Ptr(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// journalRequest maps the entry's moods to the user's moods and adds its
+// tags to the text as hashtags. An entry without moods gets the first of
+// its tags that names a mood, or else the import's default mood.
+func (j *ImportJob) journalRequest(ctx context.Context, imp *models.Import, e *importEntry, moods *importMoods) (*dto.JournalRequest, error) {
+	req := &dto.JournalRequest{
+		Title:     e.title,
+		Text:      e.text,
+		EntryDate: e.date.Format(helper.DATE_LAYOUT),
+	}
+
+	var tags []string
+	for _, tag := range e.tags {
+		if len(e.moods) == 0 && req.MoodID == 0 {
+			if mood := moods.find(tag); mood != nil && mood.ArchivedAt == nil {
+				req.MoodID = mood.ID
+				continue
+			}
+		}
+		tags = append(tags, tag)
+	}
+	req.Text = appendHashtags(req.Text, tags)
+
+	if len(e.moods) > importMoodsMax {
+		return nil, fmt.Errorf("entry must not have more than %d moods", importMoodsMax)
+	}
+	for _, m := range e.moods {
+		mood, err := moods.resolve(ctx, m.label)
+		if err != nil {
+			return nil, err
+		}
+		// Labels differing only in case or spacing name the same mood, which
+		// a journal takes once.
+		if slices.ContainsFunc(req.Moods, func(jm dto.JournalMoodRequest) bool { return jm.MoodID == mood.ID }) {
+			continue
+		}
+		intensity := m.intensity
+		if intensity < 1 || intensity > 5 {
+			intensity = models.DEFAULT_MOOD_INTENSITY
+		}
+		req.Moods = append(req.Moods, dto.JournalMoodRequest{MoodID: mood.ID, Intensity: intensity})
+	}
+
+	if req.MoodID == 0 && len(req.Moods) == 0 {
+		if imp.DefaultMoodID == nil {
+			return nil, errors.New("entry has no mood and the import has no default mood")
+		}
+		req.MoodID = *imp.DefaultMoodID
+	}
+
+	// The entry must pass the rules a client's request is held to.
+	if errs, err := helper.Validate(req); err != nil {
+		if len(errs) == 0 {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s %s", strings.ToLower(errs[0].Field), errs[0].Message)
+	}
+
+	return req, nil
+}
+
+// attach uploads one bundled file to the journal.
+func (j *ImportJob) attach(ctx context.Context, userID int64, journalUid string, media *importMedia) error {
+	if int64(media.file.UncompressedSize64) > j.limits.MaxFileSize() {
+		return fmt.Errorf("file must not be larger than %d MB", j.limits.MaxFileSize()>>20)
+	}
+
+	r, err := media.open()
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return err
+	}
+
+	upload := &dto.PhotoUpload{
+		Filename: path.Base(media.file.Name),
+		Size:     int64(len(data)),
+		Content:  bytes.NewReader(data),
+		Caption:  media.caption,
+		AltText:  media.altText,
+	}
+	if _, err := j.photos.Upload(ctx, userID, journalUid, upload); err != nil {
+		return errors.New(appErrorMessage(err))
+	}
+
+	return nil
+}
+
+// importMoods looks up the user's moods by label, ignoring case, and
+// creates custom moods for labels the user doesn't have yet.
+type importMoods struct {
+	repo    domain.MoodRepository
+	userID  int64
+	byLabel map[string]*models.Mood
+}
+
+func (j *ImportJob) loadMoods(ctx context.Context, userID int64) (*importMoods, error) {
+	m := &importMoods{repo: j.moods, userID: userID}
+	if err := m.load(ctx); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// load reads the moods the user may pick by their lower-cased label.
+func (m *importMoods) load(ctx context.Context) error {
+	moods, err := m.repo.GetVisible(ctx, m.userID, true, nil)
+	if err != nil {
+		return err
+	}
+
+	m.byLabel = make(map[string]*models.Mood, len(moods))
+	for i := range moods {
+		label := strings.ToLower(moods[i].Label)
+		// The user's own mood wins over a default one of the same name.
+		if _, ok := m.byLabel[label]; !ok || moods[i].UserID != nil {
+			m.byLabel[label] = &moods[i]
+		}
+	}
+	return nil
+}
+
+func (m *importMoods) find(label string) *models.Mood {
+	return m.byLabel[strings.ToLower(strings.TrimSpace(label))]
+}
+
+// resolve returns the mood of label, creating a mood of the user for a label
+// nobody has. A mood created elsewhere since the moods were loaded is looked
+// up again rather than failing the entry.
+func (m *importMoods) resolve(ctx context.Context, label string) (*models.Mood, error) {
+	if mood := m.find(label); mood != nil {
+		return usableMood(mood)
+	}
+
+	if utf8.RuneCountInString(label) > importMoodMax {
+		return nil, fmt.Errorf("mood %q must be at most %d characters", label, importMoodMax)
+	}
+
+	mood := &models.Mood{UserID: &m.userID, Label: label}
+	err := m.repo.Create(ctx, mood)
+	if errors.Is(err, domain.ErrMoodDuplicate) {
+		if err := m.load(ctx); err != nil {
+			return nil, err
+		}
+		if mood := m.find(label); mood != nil {
+			return usableMood(mood)
+		}
+		// Default moods are only loaded under their stored label, so this
+		// is one of their translations.
+		return nil, fmt.Errorf("mood %q is a translation of a default mood", label)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mood %q could not be created", label)
+	}
+	m.byLabel[strings.ToLower(label)] = mood
+	return mood, nil
+}
+
+func usableMood(mood *models.Mood) (*models.Mood, error) {
+	if mood.ArchivedAt != nil {
+		return nil, fmt.Errorf("mood %q is archived", mood.Label)
+	}
+	return mood, nil
+}
+
+// appendHashtags adds the tags the text doesn't mention yet as a line of
+// hashtags, which is how journals carry tags.
+func appendHashtags(text string, tags []string) string {
+	present := make(map[string]bool)
+	for _, tag := range hashtags(text) {
+		present[tag] = true
+	}
+
+	var missing []string
+	for _, tag := range tags {
+		tag = hashtagText(tag)
+		if tag == "" || present[strings.ToLower(tag)] {
+			continue
+		}
+		present[strings.ToLower(tag)] = true
+		missing = append(missing, "#"+tag)
+	}
+
+	if len(missing) == 0 {
+		return text
+	}
+	if text == "" {
+		return strings.Join(missing, " ")
+	}
+	return text + "\n\n" + strings.Join(missing, " ")
+}
+
+// hashtagText makes a tag usable as a hashtag by dropping what can't be
+// part of one, or returns "" if nothing usable is left.
+func hashtagText(tag string) string {
+	tag = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || r == '_' {
+			return r
+		}
+		return -1
+	}, tag)
+	if hashtags("#"+tag) == nil {
+		return ""
+	}
+	return tag
+}
+
+func setImportFailure(entry *models.ImportEntry, message string) {
+	entry.Status = models.IMPORT_ENTRY_FAILED
+	entry.Message = &message
+}
+
+// appErrorMessage is the message of an error from another service, without
+// the wrapped cause meant for logs.
+func appErrorMessage(err error) string {
+	var appErr *helper.AppError
+	if errors.As(err, &appErr) {
+		return appErr.Message
+	}
+	return err.Error()
+}
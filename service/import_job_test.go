@@ -0,0 +1,465 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+	"time"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/mocks"
+	"timo/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// zipFiles builds an archive of name and content pairs.
+func zipFiles(files ...string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i := 0; i < len(files); i += 2 {
+		f, _ := zw.Create(files[i])
+		io.WriteString(f, files[i+1])
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestImportJob_ProcessPending(t *testing.T) {
+	moods := []models.Mood{
+		{ID: 1, Label: "happy"},
+		{ID: 2, Label: "calm"},
+		{ID: 5, Label: "sad"},
+		{ID: 20, UserID: helper.Ptr(int64(1)), Label: "Bored", ArchivedAt: helper.Ptr(time.Now())},
+	}
+	claimed := func(format, filename string, defaultMood *int64) []models.Import {
+		return []models.Import{{
+			ID: 5, Uid: importUID, UserID: 1, Format: format, Filename: filename,
+			StorageKey: helper.Ptr("users/1/imports/abc"), DefaultMoodID: defaultMood, Status: models.IMPORT_PROCESSING,
+		}}
+	}
+	object := func(data []byte) io.ReadCloser { return io.NopCloser(bytes.NewReader(data)) }
+	// journalRequest matches a request up to the uid the job picks for it.
+	journalRequest := func(want dto.JournalRequest) any {
+		return mock.MatchedBy(func(req *dto.JournalRequest) bool {
+			want.Uid = req.Uid
+			return req.Uid != "" && assert.ObjectsAreEqual(&want, req)
+		})
+	}
+
+	markdown := zipFiles(
+		"export/entries/2024-03-01-beach-day.md", "---\n"+
+			"uid: uid-1\n"+
+			"title: \"Beach day\"\n"+
+			"date: 2024-03-01\n"+
+			"mood: \"happy\"\n"+
+			"moods:\n"+
+			"  - label: \"happy\"\n"+
+			"    intensity: 4\n"+
+			"  - label: \"calm\"\n"+
+			"    intensity: 2\n"+
+			"tags: [\"travel\",\"family\"]\n"+
+			"---\n\n"+
+			"Swam at dawn #travel\n\n"+
+			"![sunset](../media/aaa.jpg)\n\n*From the pier*\n\n"+
+			"[Voice note (0:02)](../media/bbb.m4a)\n",
+		"export/entries/2024-03-02-market.md", "---\nmood: Grateful\ntags: market, food\n---\n# Market\n\nFresh figs.\n",
+		"export/entries/2024-03-03.md", "No mood here.\n",
+		"export/entries/2024-03-04-again.md", "---\nuid: uid-4\ndate: 2024-03-04\nmood: calm\n---\nSeen before.\n",
+		"export/entries/notes.md", "---\ndate: yesterday\nmood: calm\n---\nUndated.\n",
+		"export/media/aaa.jpg", "jpeg",
+		"export/media/bbb.m4a", "m4a",
+	)
+
+	dayOne := []byte(`{
+		"metadata": {"version": "1.0"},
+		"entries": [
+			{
+				"uuid": "D1",
+				"creationDate": "2023-12-31T23:30:00Z",
+				"timeZone": "Europe/Berlin",
+				"text": "# New year\\!\n\nFireworks at 1.30\\. ![](dayone-moment://P1)\n",
+				"tags": ["Sad", "Road trip"],
+				"photos": [{"identifier": "P1", "md5": "f00d", "type": "jpeg"}]
+			},
+			{"uuid": "D2", "text": "Lost date"}
+		]
+	}`)
+
+	csvFile := zipFiles(
+		"journal.csv", "\ufeffDate,Title,Body,Mood,Tags,Photos\n"+
+			"2024-01-05,Hike,Up the hill,happy:5,outdoors;Hills,photos/a.jpg\n"+
+			"2024-01-05,Hike,Up the hill,happy:5,outdoors;Hills,photos/a.jpg\n"+
+			"05.01.2024,Bad,Broken date,happy,,\n",
+		"photos/a.jpg", "jpeg",
+	)
+
+	tests := []struct {
+		name        string
+		setupMocks  func(repo *mocks.ImportRepositoryMock, moodRepo *mocks.MoodRepositoryMock, journals *mocks.JournalServiceMock, photos *mocks.PhotoServiceMock, storage *mocks.StorageMock)
+		wantErr     bool
+		wantEntries []models.ImportEntry
+	}{
+		{
+			name: "claim error",
+			setupMocks: func(repo *mocks.ImportRepositoryMock, moodRepo *mocks.MoodRepositoryMock, journals *mocks.JournalServiceMock, photos *mocks.PhotoServiceMock, storage *mocks.StorageMock) {
+				repo.On("ClaimPending", mock.Anything, importBatchSize, importStaleAfter).Return(nil, assert.AnError)
+			},
+			wantErr: true,
+		},
+		{
+			name: "unreadable file fails the import",
+			setupMocks: func(repo *mocks.ImportRepositoryMock, moodRepo *mocks.MoodRepositoryMock, journals *mocks.JournalServiceMock, photos *mocks.PhotoServiceMock, storage *mocks.StorageMock) {
+				repo.On("ClaimPending", mock.Anything, importBatchSize, importStaleAfter).Return(claimed(models.IMPORT_DAYONE, "Journal.json", nil), nil).Once()
+				repo.On("ClaimPending", mock.Anything, importBatchSize, importStaleAfter).Return([]models.Import{}, nil).Once()
+				storage.On("Get", mock.Anything, "users/1/imports/abc").Return(object([]byte("{}")), nil)
+				repo.On("Finish", mock.Anything, int64(5), models.IMPORT_FAILED, helper.Ptr("Journal.json: not a Day One JSON export")).Return(nil)
+				storage.On("Delete", mock.Anything, "users/1/imports/abc").Return(nil)
+			},
+		},
+		{
+			name: "markdown archive",
+			setupMocks: func(repo *mocks.ImportRepositoryMock, moodRepo *mocks.MoodRepositoryMock, journals *mocks.JournalServiceMock, photos *mocks.PhotoServiceMock, storage *mocks.StorageMock) {
+				repo.On("ClaimPending", mock.Anything, importBatchSize, importStaleAfter).Return(claimed(models.IMPORT_MARKDOWN, "export.zip", nil), nil).Once()
+				repo.On("ClaimPending", mock.Anything, importBatchSize, importStaleAfter).Return([]models.Import{}, nil).Once()
+				storage.On("Get", mock.Anything, "users/1/imports/abc").Return(object(markdown), nil)
+				repo.On("SetTotal", mock.Anything, int64(5), 5).Return(nil)
+				repo.On("GetByUid", mock.Anything, importUID).Return(&models.Import{ID: 5, Uid: importUID}, nil)
+				repo.On("FindImported", mock.Anything, int64(1), mock.Anything).Return(map[string]string{"markdown:uid-4": "journal4"}, nil)
+				moodRepo.On("GetVisible", mock.Anything, int64(1), true, []string(nil)).Return(moods, nil)
+
+				journals.On("Create", mock.Anything, int64(1), time.UTC, journalRequest(dto.JournalRequest{
+					Title:     "Beach day",
+					Text:      "Swam at dawn #travel\n\n#family",
+					Moods:     []dto.JournalMoodRequest{{MoodID: 1, Intensity: 4}, {MoodID: 2, Intensity: 2}},
+					EntryDate: "2024-03-01",
+				})).Return(&dto.JournalResponse{Uid: "journal1"}, nil)
+				photos.On("Upload", mock.Anything, int64(1), "journal1", mock.MatchedBy(func(u *dto.PhotoUpload) bool {
+					data, _ := io.ReadAll(u.Content)
+					return u.Filename == "aaa.jpg" && u.AltText == "sunset" && u.Caption == "From the pier" && string(data) == "jpeg"
+				})).Return(&dto.PhotoResponse{}, nil)
+				photos.On("Upload", mock.Anything, int64(1), "journal1", mock.MatchedBy(func(u *dto.PhotoUpload) bool {
+					return u.Filename == "bbb.m4a" && u.Caption == ""
+				})).Return(nil, helper.NewAppError(helper.VALIDATION_ERROR, "unsupported audio format", nil))
+
+				moodRepo.On("Create", mock.Anything, mock.MatchedBy(func(m *models.Mood) bool {
+					return *m.UserID == 1 && m.Label == "Grateful"
+				})).Run(func(args mock.Arguments) {
+					args.Get(1).(*models.Mood).ID = 30
+				}).Return(nil)
+				journals.On("Create", mock.Anything, int64(1), time.UTC, journalRequest(dto.JournalRequest{
+					Title:     "Market",
+					Text:      "Fresh figs.\n\n#market #food",
+					Moods:     []dto.JournalMoodRequest{{MoodID: 30, Intensity: models.DEFAULT_MOOD_INTENSITY}},
+					EntryDate: "2024-03-02",
+				})).Return(&dto.JournalResponse{Uid: "journal2"}, nil)
+
+				repo.On("Finish", mock.Anything, int64(5), models.IMPORT_DONE, (*string)(nil)).Return(nil)
+				storage.On("Delete", mock.Anything, "users/1/imports/abc").Return(nil)
+			},
+			wantEntries: []models.ImportEntry{
+				{Position: 0, SourceID: "markdown:uid-1", Title: "Beach day", Status: models.IMPORT_ENTRY_IMPORTED, JournalUid: helper.Ptr("journal1"),
+					Message: helper.Ptr("export/media/bbb.m4a: unsupported audio format")},
+				{Position: 1, Title: "Market", Status: models.IMPORT_ENTRY_IMPORTED, JournalUid: helper.Ptr("journal2")},
+				{Position: 2, Title: "No mood here.", Status: models.IMPORT_ENTRY_FAILED,
+					Message: helper.Ptr("entry has no mood and the import has no default mood")},
+				{Position: 3, SourceID: "markdown:uid-4", Title: "Seen before.", Status: models.IMPORT_ENTRY_SKIPPED, JournalUid: helper.Ptr("journal4"),
+					Message: helper.Ptr("already imported")},
+				{Position: 4, Title: "Undated.", Status: models.IMPORT_ENTRY_FAILED, Message: helper.Ptr(`date "yesterday" is not understood`)},
+			},
+		},
+		{
+			name: "day one export",
+			setupMocks: func(repo *mocks.ImportRepositoryMock, moodRepo *mocks.MoodRepositoryMock, journals *mocks.JournalServiceMock, photos *mocks.PhotoServiceMock, storage *mocks.StorageMock) {
+				repo.On("ClaimPending", mock.Anything, importBatchSize, importStaleAfter).Return(claimed(models.IMPORT_DAYONE, "Journal.json", helper.Ptr(int64(2))), nil).Once()
+				repo.On("ClaimPending", mock.Anything, importBatchSize, importStaleAfter).Return([]models.Import{}, nil).Once()
+				storage.On("Get", mock.Anything, "users/1/imports/abc").Return(object(dayOne), nil)
+				repo.On("SetTotal", mock.Anything, int64(5), 2).Return(nil)
+				repo.On("GetByUid", mock.Anything, importUID).Return(&models.Import{ID: 5, Uid: importUID}, nil)
+				repo.On("FindImported", mock.Anything, int64(1), []string{"dayone:D1", "dayone:D2"}).Return(map[string]string{}, nil)
+				moodRepo.On("GetVisible", mock.Anything, int64(1), true, []string(nil)).Return(moods, nil)
+
+				journals.On("Create", mock.Anything, int64(1), time.UTC, journalRequest(dto.JournalRequest{
+					Title:     "New year!",
+					Text:      "Fireworks at 1.30.\n\n#Roadtrip",
+					MoodID:    5,
+					EntryDate: "2024-01-01",
+				})).Return(&dto.JournalResponse{Uid: "journal1"}, nil)
+
+				repo.On("Finish", mock.Anything, int64(5), models.IMPORT_DONE, (*string)(nil)).Return(nil)
+				storage.On("Delete", mock.Anything, "users/1/imports/abc").Return(nil)
+			},
+			wantEntries: []models.ImportEntry{
+				{Position: 0, SourceID: "dayone:D1", Title: "New year!", Status: models.IMPORT_ENTRY_IMPORTED, JournalUid: helper.Ptr("journal1"),
+					Message: helper.Ptr("photos/f00d.jpeg: not in the archive")},
+				{Position: 1, SourceID: "dayone:D2", Title: "Lost date", Status: models.IMPORT_ENTRY_FAILED, Message: helper.Ptr("entry has no creation date")},
+			},
+		},
+		{
+			name: "csv resumes and skips duplicates",
+			setupMocks: func(repo *mocks.ImportRepositoryMock, moodRepo *mocks.MoodRepositoryMock, journals *mocks.JournalServiceMock, photos *mocks.PhotoServiceMock, storage *mocks.StorageMock) {
+				repo.On("ClaimPending", mock.Anything, importBatchSize, importStaleAfter).Return(claimed(models.IMPORT_CSV, "journal.zip", nil), nil).Once()
+				repo.On("ClaimPending", mock.Anything, importBatchSize, importStaleAfter).Return([]models.Import{}, nil).Once()
+				storage.On("Get", mock.Anything, "users/1/imports/abc").Return(object(csvFile), nil)
+				repo.On("SetTotal", mock.Anything, int64(5), 3).Return(nil)
+				// The job was interrupted before it created the first journal.
+				repo.On("GetByUid", mock.Anything, importUID).Return(&models.Import{ID: 5, Uid: importUID, Entries: []models.ImportEntry{
+					{Position: 0, Status: models.IMPORT_ENTRY_PENDING},
+					{Position: 2, Status: models.IMPORT_ENTRY_FAILED},
+				}}, nil)
+				repo.On("FindImported", mock.Anything, int64(1), mock.Anything).Return(map[string]string{}, nil)
+				moodRepo.On("GetVisible", mock.Anything, int64(1), true, []string(nil)).Return(moods, nil)
+
+				journals.On("Create", mock.Anything, int64(1), time.UTC, journalRequest(dto.JournalRequest{
+					Title:     "Hike",
+					Text:      "Up the hill\n\n#outdoors #Hills",
+					Moods:     []dto.JournalMoodRequest{{MoodID: 1, Intensity: 5}},
+					EntryDate: "2024-01-05",
+				})).Return(&dto.JournalResponse{Uid: "journal1"}, nil).Once()
+				photos.On("Upload", mock.Anything, int64(1), "journal1", mock.AnythingOfType("*dto.PhotoUpload")).Return(&dto.PhotoResponse{}, nil).Once()
+
+				repo.On("Finish", mock.Anything, int64(5), models.IMPORT_DONE, (*string)(nil)).Return(nil)
+				storage.On("Delete", mock.Anything, "users/1/imports/abc").Return(nil)
+			},
+			wantEntries: []models.ImportEntry{
+				{Position: 0, Title: "Hike", Status: models.IMPORT_ENTRY_IMPORTED, JournalUid: helper.Ptr("journal1")},
+				{Position: 1, Title: "Hike", Status: models.IMPORT_ENTRY_SKIPPED, JournalUid: helper.Ptr("journal1"), Message: helper.Ptr("already imported")},
+			},
+		},
+		{
+			name: "csv keeps a journal created before the job was interrupted",
+			setupMocks: func(repo *mocks.ImportRepositoryMock, moodRepo *mocks.MoodRepositoryMock, journals *mocks.JournalServiceMock, photos *mocks.PhotoServiceMock, storage *mocks.StorageMock) {
+				repo.On("ClaimPending", mock.Anything, importBatchSize, importStaleAfter).Return(claimed(models.IMPORT_CSV, "journal.zip", nil), nil).Once()
+				repo.On("ClaimPending", mock.Anything, importBatchSize, importStaleAfter).Return([]models.Import{}, nil).Once()
+				storage.On("Get", mock.Anything, "users/1/imports/abc").Return(object(csvFile), nil)
+				repo.On("SetTotal", mock.Anything, int64(5), 3).Return(nil)
+				repo.On("GetByUid", mock.Anything, importUID).Return(&models.Import{ID: 5, Uid: importUID, Entries: []models.ImportEntry{
+					{Position: 0, Status: models.IMPORT_ENTRY_PENDING, JournalUid: helper.Ptr("journal0")},
+					{Position: 2, Status: models.IMPORT_ENTRY_FAILED},
+				}}, nil)
+				repo.On("FindImported", mock.Anything, int64(1), mock.Anything).Return(map[string]string{}, nil)
+				moodRepo.On("GetVisible", mock.Anything, int64(1), true, []string(nil)).Return(moods, nil)
+				journals.On("GetByID", mock.Anything, int64(1), "journal0").Return(&dto.JournalResponse{Uid: "journal0"}, nil)
+
+				repo.On("Finish", mock.Anything, int64(5), models.IMPORT_DONE, (*string)(nil)).Return(nil)
+				storage.On("Delete", mock.Anything, "users/1/imports/abc").Return(nil)
+			},
+			wantEntries: []models.ImportEntry{
+				{Position: 0, Title: "Hike", Status: models.IMPORT_ENTRY_IMPORTED, JournalUid: helper.Ptr("journal0"),
+					Message: helper.Ptr("import was interrupted, attachments may be missing")},
+				{Position: 1, Title: "Hike", Status: models.IMPORT_ENTRY_SKIPPED, JournalUid: helper.Ptr("journal0"), Message: helper.Ptr("already imported")},
+			},
+		},
+		{
+			name: "csv creates a journal the interrupted job recorded but never created",
+			setupMocks: func(repo *mocks.ImportRepositoryMock, moodRepo *mocks.MoodRepositoryMock, journals *mocks.JournalServiceMock, photos *mocks.PhotoServiceMock, storage *mocks.StorageMock) {
+				repo.On("ClaimPending", mock.Anything, importBatchSize, importStaleAfter).Return(claimed(models.IMPORT_CSV, "journal.zip", nil), nil).Once()
+				repo.On("ClaimPending", mock.Anything, importBatchSize, importStaleAfter).Return([]models.Import{}, nil).Once()
+				storage.On("Get", mock.Anything, "users/1/imports/abc").Return(object(csvFile), nil)
+				repo.On("SetTotal", mock.Anything, int64(5), 3).Return(nil)
+				repo.On("GetByUid", mock.Anything, importUID).Return(&models.Import{ID: 5, Uid: importUID, Entries: []models.ImportEntry{
+					{Position: 0, Status: models.IMPORT_ENTRY_PENDING, JournalUid: helper.Ptr("journal0")},
+					{Position: 2, Status: models.IMPORT_ENTRY_FAILED},
+				}}, nil)
+				repo.On("FindImported", mock.Anything, int64(1), mock.Anything).Return(map[string]string{}, nil)
+				moodRepo.On("GetVisible", mock.Anything, int64(1), true, []string(nil)).Return(moods, nil)
+				journals.On("GetByID", mock.Anything, int64(1), "journal0").
+					Return(nil, helper.NewAppError(helper.NOT_FOUND, "journal not found", nil))
+
+				journals.On("Create", mock.Anything, int64(1), time.UTC, mock.MatchedBy(func(req *dto.JournalRequest) bool {
+					return req.Uid == "journal0" && req.Title == "Hike"
+				})).Return(&dto.JournalResponse{Uid: "journal0"}, nil).Once()
+				photos.On("Upload", mock.Anything, int64(1), "journal0", mock.AnythingOfType("*dto.PhotoUpload")).Return(&dto.PhotoResponse{}, nil).Once()
+
+				repo.On("Finish", mock.Anything, int64(5), models.IMPORT_DONE, (*string)(nil)).Return(nil)
+				storage.On("Delete", mock.Anything, "users/1/imports/abc").Return(nil)
+			},
+			wantEntries: []models.ImportEntry{
+				{Position: 0, Title: "Hike", Status: models.IMPORT_ENTRY_IMPORTED, JournalUid: helper.Ptr("journal0")},
+				{Position: 1, Title: "Hike", Status: models.IMPORT_ENTRY_SKIPPED, JournalUid: helper.Ptr("journal0"), Message: helper.Ptr("already imported")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.ImportRepositoryMock)
+			moodRepo := new(mocks.MoodRepositoryMock)
+			journals := new(mocks.JournalServiceMock)
+			photos := new(mocks.PhotoServiceMock)
+			storage := new(mocks.StorageMock)
+			tt.setupMocks(repo, moodRepo, journals, photos, storage)
+
+			var entries, pending []models.ImportEntry
+			repo.On("AddEntry", mock.Anything, mock.AnythingOfType("*models.ImportEntry")).Run(func(args mock.Arguments) {
+				entry := *args.Get(1).(*models.ImportEntry)
+				if entry.Status == models.IMPORT_ENTRY_PENDING {
+					pending = append(pending, entry)
+					return
+				}
+				entries = append(entries, entry)
+			}).Return(nil).Maybe()
+
+			job := NewImportJob(repo, moodRepo, journals, photos, storage, DefaultPhotoLimits)
+			err := job.ProcessPending(context.Background())
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			// Each journal is announced by a pending entry with its uid.
+			var creates []mock.Call
+			for _, call := range journals.Calls {
+				if call.Method == "Create" {
+					creates = append(creates, call)
+				}
+			}
+			assert.Len(t, pending, len(creates))
+			for i, call := range creates {
+				if i < len(pending) {
+					assert.Equal(t, call.Arguments.Get(3).(*dto.JournalRequest).Uid, *pending[i].JournalUid)
+				}
+			}
+
+			assert.Len(t, entries, len(tt.wantEntries))
+			for i, want := range tt.wantEntries {
+				if i >= len(entries) {
+					break
+				}
+				got := entries[i]
+				assert.Equal(t, int64(5), got.ImportID)
+				assert.NotEmpty(t, got.SourceID)
+				if want.SourceID == "" {
+					got.SourceID = ""
+				}
+				got.ImportID = 0
+				assert.Equal(t, want, got)
+			}
+
+			repo.AssertExpectations(t)
+			moodRepo.AssertExpectations(t)
+			journals.AssertExpectations(t)
+			photos.AssertExpectations(t)
+			storage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestImportJob_JournalRequest(t *testing.T) {
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	imp := &models.Import{UserID: 1}
+	visible := []models.Mood{{ID: 1, Label: "happy"}, {ID: 2, Label: "Calm"}}
+
+	tests := []struct {
+		name       string
+		entry      importEntry
+		setupMocks func(moodRepo *mocks.MoodRepositoryMock)
+		want       *dto.JournalRequest
+		wantErr    string
+	}{
+		{
+			name:    "empty text is required like in a request",
+			entry:   importEntry{title: "Empty", date: date, moods: []importMood{{label: "happy", intensity: 3}}},
+			wantErr: "text is required",
+		},
+		{
+			name:  "labels of the same mood are taken once",
+			entry: importEntry{title: "Calm", text: "Quiet day", date: date, moods: []importMood{{label: "calm", intensity: 2}, {label: "Calm ", intensity: 4}}},
+			want: &dto.JournalRequest{Title: "Calm", Text: "Quiet day", EntryDate: "2024-03-01",
+				Moods: []dto.JournalMoodRequest{{MoodID: 2, Intensity: 2}}},
+		},
+		{
+			name:  "mood created since loading is looked up again",
+			entry: importEntry{title: "Rest", text: "Slept in", date: date, moods: []importMood{{label: "Rested", intensity: 5}}},
+			setupMocks: func(moodRepo *mocks.MoodRepositoryMock) {
+				moodRepo.On("Create", mock.Anything, mock.MatchedBy(func(m *models.Mood) bool {
+					return m.Label == "Rested"
+				})).Return(domain.ErrMoodDuplicate)
+				moodRepo.On("GetVisible", mock.Anything, int64(1), true, []string(nil)).
+					Return(append(slices.Clone(visible), models.Mood{ID: 30, UserID: helper.Ptr(int64(1)), Label: "rested"}), nil).Once()
+			},
+			want: &dto.JournalRequest{Title: "Rest", Text: "Slept in", EntryDate: "2024-03-01",
+				Moods: []dto.JournalMoodRequest{{MoodID: 30, Intensity: 5}}},
+		},
+		{
+			name:  "translated default mood is not created",
+			entry: importEntry{title: "Sunny", text: "Walk", date: date, moods: []importMood{{label: "senang", intensity: 4}}},
+			setupMocks: func(moodRepo *mocks.MoodRepositoryMock) {
+				moodRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Mood")).Return(domain.ErrMoodDuplicate)
+				moodRepo.On("GetVisible", mock.Anything, int64(1), true, []string(nil)).Return(visible, nil).Once()
+			},
+			wantErr: `mood "senang" is a translation of a default mood`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			moodRepo := new(mocks.MoodRepositoryMock)
+			moodRepo.On("GetVisible", mock.Anything, int64(1), true, []string(nil)).Return(visible, nil).Once()
+			if tt.setupMocks != nil {
+				tt.setupMocks(moodRepo)
+			}
+
+			job := NewImportJob(new(mocks.ImportRepositoryMock), moodRepo, new(mocks.JournalServiceMock), new(mocks.PhotoServiceMock), new(mocks.StorageMock), DefaultPhotoLimits)
+			moods, err := job.loadMoods(context.Background(), 1)
+			assert.NoError(t, err)
+
+			req, err := job.journalRequest(context.Background(), imp, &tt.entry, moods)
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, req)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+			moodRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSplitFrontMatter(t *testing.T) {
+	matter, body, err := splitFrontMatter("---\r\ntitle: 'It''s here' # comment\r\ntags:\r\n  - one\r\n  - \"two\"\r\nmoods: [calm, \"happy\"]\r\n---\r\nBody\r\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "It's here", matter.text("title"))
+	assert.Equal(t, []any{"one", "two"}, matter.list("tags"))
+	assert.Equal(t, []any{"calm", "happy"}, matter.list("moods"))
+	assert.Equal(t, "Body\n", body)
+
+	_, body, err = splitFrontMatter("No front matter\n---\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "No front matter\n---\n", body)
+
+	_, _, err = splitFrontMatter("---\ntitle\n---\n")
+	assert.Error(t, err)
+}
+
+func TestImportBundle_Limits(t *testing.T) {
+	files := make([]string, 0, 2*(importDocumentsMax+1))
+	for i := range importDocumentsMax + 1 {
+		files = append(files, fmt.Sprintf("entries/%05d.md", i), "")
+	}
+	many := zipFiles(files...)
+	b, err := openImportBundle("export.zip", bytes.NewReader(many), int64(len(many)))
+	assert.NoError(t, err)
+	_, err = b.documents(".md")
+	assert.ErrorContains(t, err, "must not contain more than")
+
+	one := zipFiles("entries/2024-01-01.md", "---\nmood: calm\n---\nText\n")
+	b, err = openImportBundle("export.zip", bytes.NewReader(one), int64(len(one)))
+	assert.NoError(t, err)
+	docs, err := b.documents(".md")
+	assert.NoError(t, err)
+
+	// What was unpacked before counts against the budget.
+	b.unpacked = importUnpackedMax - 4
+	_, err = b.read(docs[0])
+	assert.ErrorIs(t, err, errImportUnpackedMax)
+}
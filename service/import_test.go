@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"timo/dto"
+	"timo/helper"
+	"timo/mocks"
+	"timo/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const importUID = "6a0f3c1e-2b7d-4f0a-9c3e-5d8b1a2c4e6f"
+
+func TestImportService_Create(t *testing.T) {
+	isImportKey := mock.MatchedBy(func(key string) bool {
+		return strings.HasPrefix(key, "users/1/imports/") && strings.HasSuffix(key, ".zip")
+	})
+	upload := func(defaultMood int64) *dto.ImportUpload {
+		return &dto.ImportUpload{
+			ImportRequest: dto.ImportRequest{Format: models.IMPORT_DAYONE, DefaultMoodID: defaultMood},
+			Filename:      "Export.zip",
+			Size:          4,
+			Content:       strings.NewReader("PK.."),
+		}
+	}
+
+	tests := []struct {
+		name       string
+		upload     *dto.ImportUpload
+		setupMocks func(repo *mocks.ImportRepositoryMock, moods *mocks.MoodRepositoryMock, storage *mocks.StorageMock)
+		wantErr    string
+	}{
+		{
+			name: "file too large",
+			upload: func() *dto.ImportUpload {
+				u := upload(0)
+				u.Size = ImportMaxSize + 1
+				return u
+			}(),
+			setupMocks: func(repo *mocks.ImportRepositoryMock, moods *mocks.MoodRepositoryMock, storage *mocks.StorageMock) {},
+			wantErr:    helper.FILE_TOO_LARGE,
+		},
+		{
+			name:   "default mood not available",
+			upload: upload(9),
+			setupMocks: func(repo *mocks.ImportRepositoryMock, moods *mocks.MoodRepositoryMock, storage *mocks.StorageMock) {
+				moods.On("CountSelectable", mock.Anything, int64(1), []int64{9}).Return(0, nil)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name:   "create error removes the file",
+			upload: upload(0),
+			setupMocks: func(repo *mocks.ImportRepositoryMock, moods *mocks.MoodRepositoryMock, storage *mocks.StorageMock) {
+				storage.On("Put", mock.Anything, isImportKey, int64(4), "application/octet-stream").Return(nil)
+				repo.On("Create", mock.Anything, mock.AnythingOfType("*models.Import")).Return(assert.AnError)
+				storage.On("Delete", mock.Anything, isImportKey).Return(nil)
+			},
+			wantErr: helper.INTERNAL_ERROR,
+		},
+		{
+			name:   "success",
+			upload: upload(3),
+			setupMocks: func(repo *mocks.ImportRepositoryMock, moods *mocks.MoodRepositoryMock, storage *mocks.StorageMock) {
+				moods.On("CountSelectable", mock.Anything, int64(1), []int64{3}).Return(1, nil)
+				storage.On("Put", mock.Anything, isImportKey, int64(4), "application/octet-stream").Return(nil)
+				repo.On("Create", mock.Anything, mock.MatchedBy(func(imp *models.Import) bool {
+					return imp.UserID == 1 && imp.Format == models.IMPORT_DAYONE && imp.Filename == "Export.zip" &&
+						*imp.DefaultMoodID == 3 && strings.HasPrefix(*imp.StorageKey, "users/1/imports/")
+				})).Run(func(args mock.Arguments) {
+					imp := args.Get(1).(*models.Import)
+					imp.Uid = importUID
+					imp.Status = models.IMPORT_PENDING
+				}).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.ImportRepositoryMock)
+			moods := new(mocks.MoodRepositoryMock)
+			storage := new(mocks.StorageMock)
+			tt.setupMocks(repo, moods, storage)
+
+			job := NewImportJob(repo, moods, nil, nil, storage, DefaultPhotoLimits)
+			svc := NewImport(repo, moods, storage, job)
+			resp, err := svc.Create(context.Background(), 1, tt.upload)
+
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, importUID, resp.Uid)
+				assert.Equal(t, models.IMPORT_PENDING, resp.Status)
+				assert.Len(t, job.wake, 1)
+			}
+
+			repo.AssertExpectations(t)
+			moods.AssertExpectations(t)
+			storage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestImportService_GetByID(t *testing.T) {
+	imp := &models.Import{
+		ID: 5, Uid: importUID, UserID: 1, Format: models.IMPORT_CSV, Status: models.IMPORT_DONE, Total: helper.Ptr(2),
+		Imported: 1, Failed: 1,
+		Entries: []models.ImportEntry{
+			{Position: 0, SourceID: "csv:1", Title: "Hike", Status: models.IMPORT_ENTRY_IMPORTED, JournalUid: helper.Ptr("journalUID")},
+			{Position: 1, SourceID: "csv:2", Status: models.IMPORT_ENTRY_FAILED, Message: helper.Ptr("entry has no date")},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		userID     int64
+		setupMocks func(repo *mocks.ImportRepositoryMock)
+		wantErr    string
+	}{
+		{
+			name:   "not found",
+			userID: 1,
+			setupMocks: func(repo *mocks.ImportRepositoryMock) {
+				repo.On("GetByUid", mock.Anything, importUID).Return(nil, sql.ErrNoRows)
+			},
+			wantErr: helper.NOT_FOUND,
+		},
+		{
+			name:   "import of another user",
+			userID: 2,
+			setupMocks: func(repo *mocks.ImportRepositoryMock) {
+				repo.On("GetByUid", mock.Anything, importUID).Return(imp, nil)
+			},
+			wantErr: helper.NOT_FOUND,
+		},
+		{
+			name:   "success",
+			userID: 1,
+			setupMocks: func(repo *mocks.ImportRepositoryMock) {
+				repo.On("GetByUid", mock.Anything, importUID).Return(imp, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.ImportRepositoryMock)
+			tt.setupMocks(repo)
+
+			svc := NewImport(repo, nil, nil, nil)
+			resp, err := svc.GetByID(context.Background(), tt.userID, importUID)
+
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, 2, *resp.Total)
+				assert.Equal(t, 1, resp.Failed)
+				assert.Len(t, resp.Entries, 2)
+				assert.Equal(t, "journalUID", *resp.Entries[0].JournalUid)
+				assert.Equal(t, "entry has no date", *resp.Entries[1].Message)
+			}
+
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,694 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"slices"
+	"sync"
+	"time"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/models"
+)
+
+type journal struct {
+	repo      domain.JournalRepository
+	moods     domain.MoodRepository
+	photos    domain.PhotoRepository
+	streaks   streakTracker
+	autosaver *helper.Debouncer
+	drafts    *draftPatches
+	signer    *helper.URLSigner
+}
+
+func NewJournal(repo domain.JournalRepository, moods domain.MoodRepository, photos domain.PhotoRepository, stats domain.StatsRepository, autosaver *helper.Debouncer, signer *helper.URLSigner) domain.JournalService {
+	return &journal{repo: repo, moods: moods, photos: photos, streaks: streakTracker{repo: stats}, autosaver: autosaver, drafts: newDraftPatches(), signer: signer}
+}
+
+func (j *journal) GetList(ctx context.Context, userID int64, query *dto.JournalListQuery) ([]dto.JournalResponse, error) {
+	filter := &models.JournalFilter{Status: query.Status}
+	if filter.Status == "" {
+		filter.Status = models.JOURNAL_PUBLISHED
+	}
+	if query.From != "" {
+		from, _ := helper.ParseDate(query.From)
+		filter.From = &from
+	}
+	if query.To != "" {
+		to, _ := helper.ParseDate(query.To)
+		filter.To = &to
+	}
+	if query.MoodID != 0 {
+		filter.MoodID = &query.MoodID
+		filter.MinIntensity = max(query.MinIntensity, 1)
+	}
+
+	journals, err := j.repo.GetListByUserID(ctx, userID, filter)
+	if err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get journals", err)
+	}
+
+	now := time.Now()
+	resp := make([]dto.JournalResponse, 0, len(journals))
+	for _, journal := range journals {
+		item := toJournalResponse(&journal)
+		if cover := journal.Cover; cover != nil {
+			item.Cover = &dto.JournalCoverResponse{PhotoID: cover.PhotoID, Url: cover.Url, AltText: cover.AltText}
+			if cover.StorageKey != nil {
+				item.Cover.Url = j.signer.URL(*cover.StorageKey, now)
+			}
+		}
+		resp = append(resp, item)
+	}
+
+	return resp, nil
+}
+
+// GetByID returns the journal with its attachments, so a player can show
+// the duration and waveform of recordings without another request.
+func (j *journal) GetByID(ctx context.Context, userID int64, uid string) (*dto.JournalResponse, error) {
+	// A pending autosave is part of what the editor expects to read back.
+	j.autosaver.Flush(uid)
+
+	journal, err := j.getOwned(ctx, userID, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments, err := j.photos.GetByJournalID(ctx, journal.ID)
+	if err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get attachments", err)
+	}
+
+	resp := toJournalResponse(journal)
+	for _, attachment := range attachments {
+		resp.Attachments = append(resp.Attachments, toPhotoResponse(j.signer, &attachment))
+	}
+
+	return &resp, nil
+}
+
+// GetCalendar returns the days of a month that have entries, defaulting to
+// the current month in the user's timezone.
+func (j *journal) GetCalendar(ctx context.Context, userID int64, loc *time.Location, query *dto.CalendarQuery) (*dto.CalendarResponse, error) {
+	from := helper.LocalDate(time.Now(), loc)
+	from = from.AddDate(0, 0, 1-from.Day())
+	if query.Month != "" {
+		from, _ = time.Parse(helper.MONTH_LAYOUT, query.Month)
+	}
+
+	days, err := j.repo.GetCalendar(ctx, userID, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get calendar", err)
+	}
+
+	resp := &dto.CalendarResponse{
+		Month: from.Format(helper.MONTH_LAYOUT),
+		Days:  make([]dto.CalendarDayResponse, 0, len(days)),
+	}
+	for _, d := range days {
+		resp.Days = append(resp.Days, dto.CalendarDayResponse{
+			Date:         d.Date.Format(helper.DATE_LAYOUT),
+			Count:        d.Count,
+			DominantMood: d.DominantMood,
+			JournalUids:  d.JournalUids,
+			CheckinCount: d.CheckinCount,
+		})
+	}
+
+	return resp, nil
+}
+
+func (j *journal) GetMemories(ctx context.Context, userID int64, loc *time.Location) ([]dto.MemoryResponse, error) {
+	today := helper.LocalDate(time.Now(), loc)
+
+	memories, err := j.repo.GetOnThisDay(ctx, userID, today)
+	if err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get memories", err)
+	}
+
+	now := time.Now()
+	return toMemoryResponses(memories, today, func(key string) string { return j.signer.URL(key, now) }), nil
+}
+
+// toMemoryResponses builds the memory list, linking thumbnails through sign.
+// Photos stored before signed links existed keep their plain url.
+func toMemoryResponses(memories []models.Memory, today time.Time, sign func(key string) string) []dto.MemoryResponse {
+	resp := make([]dto.MemoryResponse, 0, len(memories))
+	for _, m := range memories {
+		memory := dto.MemoryResponse{
+			Uid:       m.Uid,
+			Title:     m.Title,
+			MoodLabel: m.MoodLabel,
+			EntryDate: m.EntryDate.Format(helper.DATE_LAYOUT),
+			YearsAgo:  today.Year() - m.EntryDate.Year(),
+		}
+		if m.PhotoKey != nil {
+			memory.ThumbnailUrl = sign(*m.PhotoKey)
+		} else if m.PhotoUrl != nil {
+			memory.ThumbnailUrl = *m.PhotoUrl
+		}
+		resp = append(resp, memory)
+	}
+
+	return resp
+}
+
+// Create files the journal under the given entry date, or under today's date
+// in the author's timezone when the client doesn't backdate it.
+func (j *journal) Create(ctx context.Context, userID int64, loc *time.Location, req *dto.JournalRequest) (*dto.JournalResponse, error) {
+	primary, moods := journalMoods(req.MoodID, req.Moods)
+	journal := &models.Journal{
+		Uid:       req.Uid,
+		UserID:    userID,
+		Title:     req.Title,
+		Text:      req.Text,
+		MoodID:    primary,
+		Moods:     moods,
+		Status:    models.JOURNAL_PUBLISHED,
+		EntryDate: helper.LocalDate(time.Now(), loc),
+	}
+	if req.EntryDate != "" {
+		journal.EntryDate, _ = helper.ParseDate(req.EntryDate)
+	}
+	if req.Draft {
+		journal.Status = models.JOURNAL_DRAFT
+	}
+
+	if err := checkMoods(ctx, j.moods, userID, moodIDs(primary, moods)...); err != nil {
+		return nil, err
+	}
+
+	if err := j.repo.Create(ctx, journal); err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to create journal", err)
+	}
+
+	if journal.Status == models.JOURNAL_PUBLISHED {
+		j.streaks.record(ctx, userID, journal.EntryDate)
+	}
+
+	return j.GetByID(ctx, userID, journal.Uid)
+}
+
+func (j *journal) Update(ctx context.Context, userID int64, uid string, version int64, req *dto.JournalRequest) (*dto.JournalResponse, error) {
+	j.autosaver.Flush(uid)
+
+	journal, err := j.getOwned(ctx, userID, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if version != 0 && version != journal.Version {
+		return nil, versionMismatch(journal)
+	}
+
+	primary, moods := journalMoods(req.MoodID, req.Moods)
+	if added := addedMoods(journal, moodIDs(primary, moods)); len(added) > 0 {
+		if err := checkMoods(ctx, j.moods, userID, added...); err != nil {
+			return nil, err
+		}
+	}
+
+	entryDate := journal.EntryDate
+	journal.Title = req.Title
+	journal.Text = req.Text
+	journal.MoodID = primary
+	journal.Moods = moods
+	if req.EntryDate != "" {
+		journal.EntryDate, _ = helper.ParseDate(req.EntryDate)
+	}
+
+	resp, err := j.save(ctx, journal)
+	if err == nil && journal.Status == models.JOURNAL_PUBLISHED && !journal.EntryDate.Equal(entryDate) {
+		j.streaks.refresh(ctx, userID)
+	}
+
+	return resp, err
+}
+
+func (j *journal) Patch(ctx context.Context, userID int64, uid string, version int64, req *dto.JournalPatchRequest) (*dto.JournalResponse, error) {
+	j.autosaver.Flush(uid)
+
+	journal, err := j.getOwned(ctx, userID, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if version != 0 && version != journal.Version {
+		return nil, versionMismatch(journal)
+	}
+
+	patch, err := j.moodPatch(ctx, journal, req)
+	if err != nil {
+		return nil, err
+	}
+	patch.Title = req.Title
+	patch.Text = req.Text
+	if req.EntryDate != nil {
+		entryDate, _ := helper.ParseDate(*req.EntryDate)
+		patch.EntryDate = &entryDate
+	}
+
+	err = j.repo.Patch(ctx, uid, journal.Version, patch)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helper.NewAppError(helper.NOT_FOUND, "journal not found", err)
+		}
+		if errors.Is(err, domain.ErrVersionMismatch) {
+			return nil, j.currentVersionError(ctx, userID, uid)
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to update journal", err)
+	}
+
+	if patch.EntryDate != nil && journal.Status == models.JOURNAL_PUBLISHED && !patch.EntryDate.Equal(journal.EntryDate) {
+		j.streaks.refresh(ctx, userID)
+	}
+
+	return j.GetByID(ctx, userID, uid)
+}
+
+func (j *journal) Delete(ctx context.Context, userID int64, uid string, version int64) error {
+	j.autosaver.Flush(uid)
+
+	journal, err := j.getOwned(ctx, userID, uid)
+	if err != nil {
+		return err
+	}
+
+	if version != 0 && version != journal.Version {
+		return versionMismatch(journal)
+	}
+
+	err = j.repo.Delete(ctx, uid, journal.Version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return helper.NewAppError(helper.NOT_FOUND, "journal not found", err)
+		}
+		if errors.Is(err, domain.ErrVersionMismatch) {
+			return j.currentVersionError(ctx, userID, uid)
+		}
+		return helper.NewAppError(helper.INTERNAL_ERROR, "failed to delete journal", err)
+	}
+
+	if journal.Status == models.JOURNAL_PUBLISHED {
+		j.streaks.refresh(ctx, userID)
+	}
+
+	return nil
+}
+
+// Autosave validates the request right away but coalesces the write itself,
+// so an editor calling it every few seconds causes one write per pause. The
+// patches of a pause are merged, later fields winning, and saved together.
+// The write bumps the version, which is only known once it ran: writes and
+// reads flush it first, so the editor learns the new ETag from its next read.
+func (j *journal) Autosave(ctx context.Context, userID int64, uid string, req *dto.JournalPatchRequest) error {
+	journal, err := j.getOwned(ctx, userID, uid)
+	if err != nil {
+		return err
+	}
+
+	if journal.Status != models.JOURNAL_DRAFT {
+		return helper.NewAppError(helper.VALIDATION_ERROR, "only drafts can be autosaved", nil)
+	}
+
+	patch, err := j.moodPatch(ctx, journal, req)
+	if err != nil {
+		return err
+	}
+	patch.Title = req.Title
+	patch.Text = req.Text
+	if req.EntryDate != nil {
+		entryDate, _ := helper.ParseDate(*req.EntryDate)
+		patch.EntryDate = &entryDate
+	}
+
+	j.drafts.add(uid, patch, time.Now())
+	j.autosaver.Trigger(uid, func() {
+		j.drafts.save(uid, func(patch *models.JournalPatch, savedAt time.Time) {
+			if err := j.repo.Autosave(context.Background(), uid, patch, savedAt); err != nil {
+				log.Printf("autosave of journal %s failed: %v", uid, err)
+			}
+		})
+	})
+
+	return nil
+}
+
+func (j *journal) Publish(ctx context.Context, userID int64, uid string) (*dto.JournalResponse, error) {
+	journal, err := j.getOwned(ctx, userID, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if journal.Status != models.JOURNAL_DRAFT {
+		return nil, helper.NewAppError(helper.VALIDATION_ERROR, "journal is already published", nil)
+	}
+
+	j.autosaver.Flush(uid)
+
+	err = j.repo.Publish(ctx, uid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helper.NewAppError(helper.NOT_FOUND, "draft not found", err)
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to publish journal", err)
+	}
+
+	j.streaks.record(ctx, userID, journal.EntryDate)
+
+	return j.GetByID(ctx, userID, uid)
+}
+
+func (j *journal) GetRevisions(ctx context.Context, userID int64, uid string) ([]dto.JournalRevisionResponse, error) {
+	journal, err := j.getOwned(ctx, userID, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := j.repo.GetRevisions(ctx, journal.ID)
+	if err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get revisions", err)
+	}
+
+	resp := make([]dto.JournalRevisionResponse, 0, len(revisions))
+	for _, r := range revisions {
+		resp = append(resp, dto.JournalRevisionResponse{
+			Revision:  r.Revision,
+			Title:     r.Title,
+			Text:      r.Text,
+			MoodID:    r.MoodID,
+			MoodLabel: r.MoodLabel,
+			CreatedAt: r.CreatedAt,
+		})
+	}
+
+	return resp, nil
+}
+
+// Diff compares two revisions of a journal. A zero revision refers to the
+// current content of the journal.
+func (j *journal) Diff(ctx context.Context, userID int64, uid string, from, to int) (*dto.JournalDiffResponse, error) {
+	journal, err := j.getOwned(ctx, userID, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	older, err := j.getRevisionOrCurrent(ctx, journal, from)
+	if err != nil {
+		return nil, err
+	}
+
+	newer, err := j.getRevisionOrCurrent(ctx, journal, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.JournalDiffResponse{
+		From:     from,
+		To:       to,
+		Title:    helper.LineDiff(older.Title, newer.Title),
+		Text:     helper.LineDiff(older.Text, newer.Text),
+		FromMood: older.MoodLabel,
+		ToMood:   newer.MoodLabel,
+	}, nil
+}
+
+func (j *journal) Revert(ctx context.Context, userID int64, uid string, revision int) (*dto.JournalResponse, error) {
+	journal, err := j.getOwned(ctx, userID, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	rev, err := j.getRevisionOrCurrent(ctx, journal, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	entryDate := journal.EntryDate
+	journal.Title = rev.Title
+	journal.Text = rev.Text
+	journal.MoodID = rev.MoodID
+	// Revisions saved before moods and entry dates were recorded have neither,
+	// so reverting to one only swaps the primary mood and keeps the date.
+	journal.Moods = rev.Moods
+	if rev.EntryDate != nil {
+		journal.EntryDate = *rev.EntryDate
+	}
+
+	resp, err := j.save(ctx, journal)
+	if err == nil && journal.Status == models.JOURNAL_PUBLISHED && !journal.EntryDate.Equal(entryDate) {
+		j.streaks.refresh(ctx, userID)
+	}
+
+	return resp, err
+}
+
+func (j *journal) save(ctx context.Context, journal *models.Journal) (*dto.JournalResponse, error) {
+	err := j.repo.Update(ctx, journal)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helper.NewAppError(helper.NOT_FOUND, "journal not found", err)
+		}
+		if errors.Is(err, domain.ErrVersionMismatch) {
+			return nil, j.currentVersionError(ctx, journal.UserID, journal.Uid)
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to update journal", err)
+	}
+
+	return j.GetByID(ctx, journal.UserID, journal.Uid)
+}
+
+// moodPatch resolves the mood members of a patch and checks any mood the
+// journal doesn't have yet. A moods list without mood_id keeps the current
+// primary mood if it is still listed.
+func (j *journal) moodPatch(ctx context.Context, journal *models.Journal, req *dto.JournalPatchRequest) (*models.JournalPatch, error) {
+	patch := &models.JournalPatch{}
+	if req.MoodID == nil && req.Moods == nil {
+		return patch, nil
+	}
+
+	var moodID int64
+	if req.MoodID != nil {
+		moodID = *req.MoodID
+	}
+
+	var moods []dto.JournalMoodRequest
+	if req.Moods != nil {
+		moods = *req.Moods
+		if moodID == 0 && slices.ContainsFunc(moods, func(m dto.JournalMoodRequest) bool { return m.MoodID == journal.MoodID }) {
+			moodID = journal.MoodID
+		}
+	}
+
+	primary, list := journalMoods(moodID, moods)
+	if added := addedMoods(journal, moodIDs(primary, list)); len(added) > 0 {
+		if err := checkMoods(ctx, j.moods, journal.UserID, added...); err != nil {
+			return nil, err
+		}
+	}
+
+	patch.MoodID = &primary
+	patch.Moods = list
+	return patch, nil
+}
+
+// currentVersionError reloads a journal that changed underneath a write so
+// the client receives the server version alongside the 412.
+func (j *journal) currentVersionError(ctx context.Context, userID int64, uid string) error {
+	journal, err := j.getOwned(ctx, userID, uid)
+	if err != nil {
+		return err
+	}
+
+	return versionMismatch(journal)
+}
+
+func (j *journal) getOwned(ctx context.Context, userID int64, uid string) (*models.Journal, error) {
+	journal, err := j.repo.GetByID(ctx, uid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helper.NewAppError(helper.NOT_FOUND, "journal not found", err)
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get journal", err)
+	}
+
+	if journal.UserID != userID {
+		return nil, helper.NewAppError(helper.NOT_FOUND, "journal not found", nil)
+	}
+
+	return journal, nil
+}
+
+func (j *journal) getRevisionOrCurrent(ctx context.Context, journal *models.Journal, revision int) (*models.JournalRevision, error) {
+	if revision == 0 {
+		return &models.JournalRevision{
+			JournalID: journal.ID,
+			Title:     journal.Title,
+			Text:      journal.Text,
+			MoodID:    journal.MoodID,
+			MoodLabel: journal.MoodLabel,
+			EntryDate: &journal.EntryDate,
+			Moods:     journal.Moods,
+			CreatedAt: journal.UpdatedAt,
+		}, nil
+	}
+
+	rev, err := j.repo.GetRevision(ctx, journal.ID, revision)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helper.NewAppError(helper.NOT_FOUND, "revision not found", err)
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get revision", err)
+	}
+
+	return rev, nil
+}
+
+func versionMismatch(journal *models.Journal) *helper.AppError {
+	return helper.NewAppError(helper.PRECONDITION_FAILED, "journal has been modified", domain.ErrVersionMismatch).
+		WithDetails(toJournalResponse(journal))
+}
+
+func toJournalResponse(journal *models.Journal) dto.JournalResponse {
+	resp := dto.JournalResponse{
+		Uid:       journal.Uid,
+		Title:     journal.Title,
+		Text:      journal.Text,
+		MoodID:    journal.MoodID,
+		MoodLabel: journal.MoodLabel,
+		Version:   journal.Version,
+		Status:    journal.Status,
+		EntryDate: journal.EntryDate.Format(helper.DATE_LAYOUT),
+		CreatedAt: journal.CreatedAt,
+		UpdatedAt: journal.UpdatedAt,
+	}
+	for _, m := range journal.Moods {
+		resp.Moods = append(resp.Moods, dto.JournalMoodResponse{MoodID: m.MoodID, MoodLabel: m.MoodLabel, Intensity: m.Intensity})
+	}
+
+	return resp
+}
+
+// journalMoods resolves the primary mood and the mood list of a request. The
+// list stays nil when the client only sent mood_id, and always contains the
+// primary mood otherwise.
+func journalMoods(moodID int64, moods []dto.JournalMoodRequest) (int64, []models.JournalMood) {
+	if len(moods) == 0 {
+		return moodID, nil
+	}
+
+	primary := moodID
+	if primary == 0 {
+		primary = moods[0].MoodID
+	}
+
+	list := make([]models.JournalMood, 0, len(moods)+1)
+	for _, m := range moods {
+		list = append(list, models.JournalMood{MoodID: m.MoodID, Intensity: m.Intensity})
+	}
+	if !slices.ContainsFunc(list, func(m models.JournalMood) bool { return m.MoodID == primary }) {
+		list = append([]models.JournalMood{{MoodID: primary, Intensity: models.DEFAULT_MOOD_INTENSITY}}, list...)
+	}
+
+	return primary, list
+}
+
+func moodIDs(primary int64, moods []models.JournalMood) []int64 {
+	if moods == nil {
+		return []int64{primary}
+	}
+
+	ids := make([]int64, 0, len(moods))
+	for _, m := range moods {
+		ids = append(ids, m.MoodID)
+	}
+	return ids
+}
+
+// addedMoods returns the moods the journal doesn't carry yet. Only those need
+// to be selectable, so a mood archived after it was picked can stay.
+func addedMoods(journal *models.Journal, ids []int64) []int64 {
+	var added []int64
+	for _, id := range ids {
+		if id != journal.MoodID && !slices.ContainsFunc(journal.Moods, func(m models.JournalMood) bool { return m.MoodID == id }) {
+			added = append(added, id)
+		}
+	}
+	return added
+}
+
+// draftPatches merges the autosaves of each draft until the debouncer writes
+// them. Writes of the same draft run one after the other, so a write never
+// overtakes an earlier one whose patch it doesn't contain.
+type draftPatches struct {
+	mu      sync.Mutex
+	pending map[string]*draftPatch
+	writing map[string]chan struct{}
+}
+
+type draftPatch struct {
+	patch   models.JournalPatch
+	savedAt time.Time
+}
+
+func newDraftPatches() *draftPatches {
+	return &draftPatches{pending: make(map[string]*draftPatch), writing: make(map[string]chan struct{})}
+}
+
+// add merges patch into the one pending for uid.
+func (d *draftPatches) add(uid string, patch *models.JournalPatch, savedAt time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	draft, ok := d.pending[uid]
+	if !ok {
+		draft = &draftPatch{}
+		d.pending[uid] = draft
+	}
+	if patch.Title != nil {
+		draft.patch.Title = patch.Title
+	}
+	if patch.Text != nil {
+		draft.patch.Text = patch.Text
+	}
+	if patch.MoodID != nil {
+		draft.patch.MoodID = patch.MoodID
+		draft.patch.Moods = patch.Moods
+	}
+	if patch.EntryDate != nil {
+		draft.patch.EntryDate = patch.EntryDate
+	}
+	draft.savedAt = savedAt
+}
+
+// save hands the patch pending for uid to write, once the write before it
+// has finished.
+func (d *draftPatches) save(uid string, write func(patch *models.JournalPatch, savedAt time.Time)) {
+	d.mu.Lock()
+	draft, ok := d.pending[uid]
+	if !ok {
+		d.mu.Unlock()
+		return
+	}
+	delete(d.pending, uid)
+	previous := d.writing[uid]
+	done := make(chan struct{})
+	d.writing[uid] = done
+	d.mu.Unlock()
+
+	if previous != nil {
+		<-previous
+	}
+	write(&draft.patch, draft.savedAt)
+
+	d.mu.Lock()
+	if d.writing[uid] == done {
+		delete(d.writing, uid)
+	}
+	d.mu.Unlock()
+	close(done)
+}
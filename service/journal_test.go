@@ -0,0 +1,631 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/mocks"
+	"timo/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// noAttachments stands in for the photo repository of journals without
+// attachments, which every write reloads.
+func noAttachments() *mocks.PhotoRepositoryMock {
+	photos := new(mocks.PhotoRepositoryMock)
+	photos.On("GetByJournalID", mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	return photos
+}
+
+func TestJournalService_GetByID(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupMocks func(repo *mocks.JournalRepositoryMock, photos *mocks.PhotoRepositoryMock)
+		wantErr    string
+	}{
+		{
+			name: "journal not found",
+			setupMocks: func(repo *mocks.JournalRepositoryMock, photos *mocks.PhotoRepositoryMock) {
+				repo.On("GetByID", mock.Anything, "journalUID").Return(nil, sql.ErrNoRows)
+			},
+			wantErr: helper.NOT_FOUND,
+		},
+		{
+			name: "journal owned by another user",
+			setupMocks: func(repo *mocks.JournalRepositoryMock, photos *mocks.PhotoRepositoryMock) {
+				repo.On("GetByID", mock.Anything, "journalUID").
+					Return(&models.Journal{ID: 1, Uid: "journalUID", UserID: 2}, nil)
+			},
+			wantErr: helper.NOT_FOUND,
+		},
+		{
+			name: "internal server error",
+			setupMocks: func(repo *mocks.JournalRepositoryMock, photos *mocks.PhotoRepositoryMock) {
+				repo.On("GetByID", mock.Anything, "journalUID").Return(nil, assert.AnError)
+			},
+			wantErr: helper.INTERNAL_ERROR,
+		},
+		{
+			name: "attachments error",
+			setupMocks: func(repo *mocks.JournalRepositoryMock, photos *mocks.PhotoRepositoryMock) {
+				repo.On("GetByID", mock.Anything, "journalUID").
+					Return(&models.Journal{ID: 1, Uid: "journalUID", UserID: 1, Title: "title test"}, nil)
+				photos.On("GetByJournalID", mock.Anything, int64(1)).Return(nil, assert.AnError)
+			},
+			wantErr: helper.INTERNAL_ERROR,
+		},
+		{
+			name: "success",
+			setupMocks: func(repo *mocks.JournalRepositoryMock, photos *mocks.PhotoRepositoryMock) {
+				repo.On("GetByID", mock.Anything, "journalUID").
+					Return(&models.Journal{ID: 1, Uid: "journalUID", UserID: 1, Title: "title test"}, nil)
+				photos.On("GetByJournalID", mock.Anything, int64(1)).Return([]models.Photo{{
+					ID: 3, JournalID: 1, MediaType: models.MEDIA_AUDIO, StorageKey: "users/1/audio/a.m4a",
+					DurationMs: helper.Ptr(61500), Peaks: []int{12, 200, 80},
+				}}, nil)
+			},
+			wantErr: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.JournalRepositoryMock)
+			photos := new(mocks.PhotoRepositoryMock)
+			tt.setupMocks(repo, photos)
+
+			moods := new(mocks.MoodRepositoryMock)
+			stats := new(mocks.StatsRepositoryMock)
+			svc := NewJournal(repo, moods, photos, stats, helper.NewDebouncer(time.Millisecond, time.Millisecond), helper.NewURLSigner([]byte("key"), "http://media", time.Hour))
+			resp, err := svc.GetByID(context.Background(), 1, "journalUID")
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, "journalUID", resp.Uid)
+				assert.Len(t, resp.Attachments, 1)
+				assert.Equal(t, models.MEDIA_AUDIO, resp.Attachments[0].MediaType)
+				assert.Equal(t, 61500, *resp.Attachments[0].DurationMs)
+				assert.Equal(t, []int{12, 200, 80}, resp.Attachments[0].Peaks)
+				assert.Contains(t, resp.Attachments[0].Url, "http://media/users/1/audio/a.m4a?")
+			} else {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+			}
+			repo.AssertExpectations(t)
+			photos.AssertExpectations(t)
+		})
+	}
+}
+
+func TestJournalService_Diff(t *testing.T) {
+	current := &models.Journal{ID: 1, Uid: "journalUID", UserID: 1, Title: "title", Text: "line 1\nline 2 edited\nline 3", MoodLabel: "sad"}
+
+	tests := []struct {
+		name       string
+		from       int
+		to         int
+		setupMocks func(repo *mocks.JournalRepositoryMock)
+		wantErr    string
+	}{
+		{
+			name: "revision not found",
+			from: 3,
+			setupMocks: func(repo *mocks.JournalRepositoryMock) {
+				repo.On("GetByID", mock.Anything, "journalUID").Return(current, nil)
+				repo.On("GetRevision", mock.Anything, int64(1), 3).Return(nil, sql.ErrNoRows)
+			},
+			wantErr: helper.NOT_FOUND,
+		},
+		{
+			name: "diff against current",
+			from: 1,
+			setupMocks: func(repo *mocks.JournalRepositoryMock) {
+				repo.On("GetByID", mock.Anything, "journalUID").Return(current, nil)
+				repo.On("GetRevision", mock.Anything, int64(1), 1).
+					Return(&models.JournalRevision{Revision: 1, Title: "title", Text: "line 1\nline 2\nline 3", MoodLabel: "happy"}, nil)
+			},
+			wantErr: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.JournalRepositoryMock)
+			tt.setupMocks(repo)
+
+			moods := new(mocks.MoodRepositoryMock)
+			stats := new(mocks.StatsRepositoryMock)
+			svc := NewJournal(repo, moods, noAttachments(), stats, helper.NewDebouncer(time.Millisecond, time.Millisecond), helper.NewURLSigner([]byte("key"), "http://media", time.Hour))
+			resp, err := svc.Diff(context.Background(), 1, "journalUID", tt.from, tt.to)
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, []helper.DiffLine{
+					{Op: helper.DIFF_EQUAL, Text: "line 1"},
+					{Op: helper.DIFF_DELETE, Text: "line 2"},
+					{Op: helper.DIFF_INSERT, Text: "line 2 edited"},
+					{Op: helper.DIFF_EQUAL, Text: "line 3"},
+				}, resp.Text)
+				assert.Equal(t, "happy", resp.FromMood)
+				assert.Equal(t, "sad", resp.ToMood)
+			} else {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestJournalService_Revert(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupMocks func(repo *mocks.JournalRepositoryMock)
+		wantErr    string
+	}{
+		{
+			name: "failed to update journal",
+			setupMocks: func(repo *mocks.JournalRepositoryMock) {
+				repo.On("GetByID", mock.Anything, "journalUID").
+					Return(&models.Journal{ID: 1, Uid: "journalUID", UserID: 1, Title: "new", Text: "new", MoodID: 2}, nil)
+				repo.On("GetRevision", mock.Anything, int64(1), 1).
+					Return(&models.JournalRevision{Revision: 1, Title: "old", Text: "old", MoodID: 1}, nil)
+				repo.On("Update", mock.Anything, mock.AnythingOfType("*models.Journal")).Return(assert.AnError)
+			},
+			wantErr: helper.INTERNAL_ERROR,
+		},
+		{
+			name: "success",
+			setupMocks: func(repo *mocks.JournalRepositoryMock) {
+				repo.On("GetByID", mock.Anything, "journalUID").
+					Return(&models.Journal{ID: 1, Uid: "journalUID", UserID: 1, Title: "new", Text: "new", MoodID: 2}, nil)
+				repo.On("GetRevision", mock.Anything, int64(1), 1).
+					Return(&models.JournalRevision{Revision: 1, Title: "old", Text: "old", MoodID: 1}, nil)
+				repo.On("Update", mock.Anything, mock.MatchedBy(func(j *models.Journal) bool {
+					return j.Title == "old" && j.Text == "old" && j.MoodID == 1
+				})).Return(nil)
+			},
+			wantErr: "",
+		},
+		{
+			name: "restores every mood and the entry date",
+			setupMocks: func(repo *mocks.JournalRepositoryMock) {
+				repo.On("GetByID", mock.Anything, "journalUID").
+					Return(&models.Journal{
+						ID: 1, Uid: "journalUID", UserID: 1, Title: "new", Text: "new", MoodID: 2,
+						Moods:     []models.JournalMood{{MoodID: 2, Intensity: 3}},
+						EntryDate: time.Date(2024, time.March, 2, 0, 0, 0, 0, time.UTC),
+					}, nil)
+				entryDate := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+				repo.On("GetRevision", mock.Anything, int64(1), 1).
+					Return(&models.JournalRevision{
+						Revision: 1, Title: "old", Text: "old", MoodID: 1,
+						Moods:     []models.JournalMood{{MoodID: 1, Intensity: 2}, {MoodID: 5, Intensity: 4}},
+						EntryDate: &entryDate,
+					}, nil)
+				repo.On("Update", mock.Anything, mock.MatchedBy(func(j *models.Journal) bool {
+					return j.MoodID == 1 && j.EntryDate.Equal(entryDate) && len(j.Moods) == 2 &&
+						j.Moods[0] == models.JournalMood{MoodID: 1, Intensity: 2} &&
+						j.Moods[1] == models.JournalMood{MoodID: 5, Intensity: 4}
+				})).Return(nil)
+			},
+			wantErr: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.JournalRepositoryMock)
+			tt.setupMocks(repo)
+
+			moods := new(mocks.MoodRepositoryMock)
+			stats := new(mocks.StatsRepositoryMock)
+			svc := NewJournal(repo, moods, noAttachments(), stats, helper.NewDebouncer(time.Millisecond, time.Millisecond), helper.NewURLSigner([]byte("key"), "http://media", time.Hour))
+			resp, err := svc.Revert(context.Background(), 1, "journalUID", 1)
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				assert.NotNil(t, resp)
+			} else {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestJournalService_GetCalendar(t *testing.T) {
+	feb := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+	mar := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		setupMocks func(repo *mocks.JournalRepositoryMock)
+		wantErr    string
+	}{
+		{
+			name: "internal server error",
+			setupMocks: func(repo *mocks.JournalRepositoryMock) {
+				repo.On("GetCalendar", mock.Anything, int64(1), feb, mar).Return(nil, assert.AnError)
+			},
+			wantErr: helper.INTERNAL_ERROR,
+		},
+		{
+			name: "success",
+			setupMocks: func(repo *mocks.JournalRepositoryMock) {
+				repo.On("GetCalendar", mock.Anything, int64(1), feb, mar).Return([]models.CalendarDay{
+					{Date: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC), Count: 2, DominantMood: "happy", JournalUids: []string{"a", "b"}},
+				}, nil)
+			},
+			wantErr: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.JournalRepositoryMock)
+			tt.setupMocks(repo)
+
+			moods := new(mocks.MoodRepositoryMock)
+			stats := new(mocks.StatsRepositoryMock)
+			svc := NewJournal(repo, moods, noAttachments(), stats, helper.NewDebouncer(time.Millisecond, time.Millisecond), helper.NewURLSigner([]byte("key"), "http://media", time.Hour))
+			resp, err := svc.GetCalendar(context.Background(), 1, time.UTC, &dto.CalendarQuery{Month: "2024-02"})
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, "2024-02", resp.Month)
+				assert.Equal(t, "2024-02-29", resp.Days[0].Date)
+				assert.Equal(t, []string{"a", "b"}, resp.Days[0].JournalUids)
+			} else {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestJournalService_Create(t *testing.T) {
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+
+	tests := []struct {
+		name          string
+		req           *dto.JournalRequest
+		setupStats    func(stats *mocks.StatsRepositoryMock)
+		wantEntryDate string
+	}{
+		{
+			name: "backdated entry recomputes streak",
+			req:  &dto.JournalRequest{Title: "title", Text: "text", MoodID: 1, EntryDate: "2024-02-29"},
+			setupStats: func(stats *mocks.StatsRepositoryMock) {
+				stats.On("AdvanceStreak", mock.Anything, int64(1), time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)).Return(false, nil)
+				stats.On("RecomputeStreak", mock.Anything, int64(1)).Return(&models.Streak{UserID: 1}, nil)
+			},
+			wantEntryDate: "2024-02-29",
+		},
+		{
+			name: "defaults to today in user timezone",
+			req:  &dto.JournalRequest{Title: "title", Text: "text", MoodID: 1},
+			setupStats: func(stats *mocks.StatsRepositoryMock) {
+				stats.On("AdvanceStreak", mock.Anything, int64(1), mock.AnythingOfType("time.Time")).Return(true, nil)
+			},
+			wantEntryDate: time.Now().In(jakarta).Format(helper.DATE_LAYOUT),
+		},
+		{
+			name:          "draft leaves streak alone",
+			req:           &dto.JournalRequest{Title: "title", Text: "text", MoodID: 1, Draft: true},
+			setupStats:    func(stats *mocks.StatsRepositoryMock) {},
+			wantEntryDate: time.Now().In(jakarta).Format(helper.DATE_LAYOUT),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.JournalRepositoryMock)
+			var created *models.Journal
+			repo.On("Create", mock.Anything, mock.AnythingOfType("*models.Journal")).
+				Run(func(args mock.Arguments) {
+					created = args.Get(1).(*models.Journal)
+					created.Uid = "journalUID"
+				}).Return(nil)
+			repo.On("GetByID", mock.Anything, "journalUID").
+				Return(&models.Journal{ID: 1, Uid: "journalUID", UserID: 1}, nil)
+
+			moods := new(mocks.MoodRepositoryMock)
+			moods.On("CountSelectable", mock.Anything, int64(1), []int64{1}).Return(1, nil)
+			stats := new(mocks.StatsRepositoryMock)
+			tt.setupStats(stats)
+
+			svc := NewJournal(repo, moods, noAttachments(), stats, helper.NewDebouncer(time.Millisecond, time.Millisecond), helper.NewURLSigner([]byte("key"), "http://media", time.Hour))
+			_, err := svc.Create(context.Background(), 1, jakarta, tt.req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantEntryDate, created.EntryDate.Format(helper.DATE_LAYOUT))
+			repo.AssertExpectations(t)
+			moods.AssertExpectations(t)
+			stats.AssertExpectations(t)
+		})
+	}
+}
+
+func TestJournalService_CreateWithUnavailableMood(t *testing.T) {
+	repo := new(mocks.JournalRepositoryMock)
+	moods := new(mocks.MoodRepositoryMock)
+	moods.On("CountSelectable", mock.Anything, int64(1), []int64{42}).Return(0, nil)
+	stats := new(mocks.StatsRepositoryMock)
+
+	svc := NewJournal(repo, moods, noAttachments(), stats, helper.NewDebouncer(time.Millisecond, time.Millisecond), helper.NewURLSigner([]byte("key"), "http://media", time.Hour))
+	resp, err := svc.Create(context.Background(), 1, time.UTC, &dto.JournalRequest{Title: "title", Text: "text", MoodID: 42})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, helper.VALIDATION_ERROR, err.(*helper.AppError).Code)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	moods.AssertExpectations(t)
+}
+
+func TestJournalService_CreateWithMoods(t *testing.T) {
+	repo := new(mocks.JournalRepositoryMock)
+	var created *models.Journal
+	repo.On("Create", mock.Anything, mock.AnythingOfType("*models.Journal")).
+		Run(func(args mock.Arguments) {
+			created = args.Get(1).(*models.Journal)
+			created.Uid = "journalUID"
+		}).Return(nil)
+	repo.On("GetByID", mock.Anything, "journalUID").
+		Return(&models.Journal{ID: 1, Uid: "journalUID", UserID: 1, Status: models.JOURNAL_DRAFT}, nil)
+
+	moods := new(mocks.MoodRepositoryMock)
+	moods.On("CountSelectable", mock.Anything, int64(1), []int64{6, 2, 5}).Return(3, nil)
+	stats := new(mocks.StatsRepositoryMock)
+
+	svc := NewJournal(repo, moods, noAttachments(), stats, helper.NewDebouncer(time.Millisecond, time.Millisecond), helper.NewURLSigner([]byte("key"), "http://media", time.Hour))
+	_, err := svc.Create(context.Background(), 1, time.UTC, &dto.JournalRequest{
+		Title:  "title",
+		Text:   "text",
+		MoodID: 6,
+		Moods:  []dto.JournalMoodRequest{{MoodID: 2, Intensity: 4}, {MoodID: 5, Intensity: 1}},
+		Draft:  true,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), created.MoodID)
+	assert.Equal(t, []models.JournalMood{
+		{MoodID: 6, Intensity: models.DEFAULT_MOOD_INTENSITY},
+		{MoodID: 2, Intensity: 4},
+		{MoodID: 5, Intensity: 1},
+	}, created.Moods)
+	repo.AssertExpectations(t)
+	moods.AssertExpectations(t)
+}
+
+func TestJournalService_PatchMoods(t *testing.T) {
+	journal := &models.Journal{
+		ID: 1, Uid: "journalUID", UserID: 1, MoodID: 1, Version: 2,
+		Moods: []models.JournalMood{{MoodID: 1, Intensity: 3}, {MoodID: 2, Intensity: 4}},
+	}
+
+	repo := new(mocks.JournalRepositoryMock)
+	repo.On("GetByID", mock.Anything, "journalUID").Return(journal, nil)
+	repo.On("Patch", mock.Anything, "journalUID", int64(2), mock.MatchedBy(func(p *models.JournalPatch) bool {
+		return *p.MoodID == 2 && len(p.Moods) == 2 && p.Moods[1] == models.JournalMood{MoodID: 7, Intensity: 2}
+	})).Return(nil)
+
+	// Mood 2 is already on the journal, so only mood 7 has to be selectable.
+	moods := new(mocks.MoodRepositoryMock)
+	moods.On("CountSelectable", mock.Anything, int64(1), []int64{7}).Return(1, nil)
+	stats := new(mocks.StatsRepositoryMock)
+
+	svc := NewJournal(repo, moods, noAttachments(), stats, helper.NewDebouncer(time.Millisecond, time.Millisecond), helper.NewURLSigner([]byte("key"), "http://media", time.Hour))
+	_, err := svc.Patch(context.Background(), 1, "journalUID", 2, &dto.JournalPatchRequest{
+		Moods: &[]dto.JournalMoodRequest{{MoodID: 2, Intensity: 5}, {MoodID: 7, Intensity: 2}},
+	})
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+	moods.AssertExpectations(t)
+}
+
+func TestJournalService_Update(t *testing.T) {
+	req := &dto.JournalRequest{Title: "title update", Text: "text update", MoodID: 1}
+
+	tests := []struct {
+		name       string
+		version    int64
+		setupMocks func(repo *mocks.JournalRepositoryMock)
+		wantErr    string
+	}{
+		{
+			name:    "stale if-match version",
+			version: 1,
+			setupMocks: func(repo *mocks.JournalRepositoryMock) {
+				repo.On("GetByID", mock.Anything, "journalUID").
+					Return(&models.Journal{ID: 1, Uid: "journalUID", UserID: 1, MoodID: 1, Version: 2}, nil)
+			},
+			wantErr: helper.PRECONDITION_FAILED,
+		},
+		{
+			name:    "concurrent update between read and write",
+			version: 2,
+			setupMocks: func(repo *mocks.JournalRepositoryMock) {
+				repo.On("GetByID", mock.Anything, "journalUID").
+					Return(&models.Journal{ID: 1, Uid: "journalUID", UserID: 1, MoodID: 1, Version: 2}, nil)
+				repo.On("Update", mock.Anything, mock.AnythingOfType("*models.Journal")).Return(domain.ErrVersionMismatch)
+			},
+			wantErr: helper.PRECONDITION_FAILED,
+		},
+		{
+			name:    "success",
+			version: 2,
+			setupMocks: func(repo *mocks.JournalRepositoryMock) {
+				repo.On("GetByID", mock.Anything, "journalUID").
+					Return(&models.Journal{ID: 1, Uid: "journalUID", UserID: 1, MoodID: 1, Version: 2}, nil)
+				repo.On("Update", mock.Anything, mock.MatchedBy(func(j *models.Journal) bool {
+					return j.Version == 2 && j.Title == "title update"
+				})).Return(nil)
+			},
+			wantErr: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.JournalRepositoryMock)
+			tt.setupMocks(repo)
+
+			moods := new(mocks.MoodRepositoryMock)
+			stats := new(mocks.StatsRepositoryMock)
+			svc := NewJournal(repo, moods, noAttachments(), stats, helper.NewDebouncer(time.Millisecond, time.Millisecond), helper.NewURLSigner([]byte("key"), "http://media", time.Hour))
+			resp, err := svc.Update(context.Background(), 1, "journalUID", tt.version, req)
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				assert.NotNil(t, resp)
+			} else {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+				assert.IsType(t, dto.JournalResponse{}, err.(*helper.AppError).Details)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestJournalService_Autosave(t *testing.T) {
+	repo := new(mocks.JournalRepositoryMock)
+	repo.On("GetByID", mock.Anything, "journalUID").
+		Return(&models.Journal{ID: 1, Uid: "journalUID", UserID: 1, Status: models.JOURNAL_DRAFT, Version: 3}, nil)
+
+	saved := make(chan *models.JournalPatch, 3)
+	repo.On("Autosave", mock.Anything, "journalUID", mock.AnythingOfType("*models.JournalPatch"), mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) {
+			saved <- args.Get(2).(*models.JournalPatch)
+		}).
+		Return(nil)
+
+	moods := new(mocks.MoodRepositoryMock)
+	stats := new(mocks.StatsRepositoryMock)
+	svc := NewJournal(repo, moods, noAttachments(), stats, helper.NewDebouncer(50*time.Millisecond, time.Second), helper.NewURLSigner([]byte("key"), "http://media", time.Hour))
+
+	// Disjoint patches of one pause are saved together.
+	requests := []*dto.JournalPatchRequest{
+		{Title: helper.Ptr("Beach"), EntryDate: helper.Ptr("2024-05-01")},
+		{Text: helper.Ptr("t")},
+		{Text: helper.Ptr("text")},
+	}
+	for _, req := range requests {
+		err := svc.Autosave(context.Background(), 1, "journalUID", req)
+		assert.NoError(t, err)
+	}
+
+	select {
+	case patch := <-saved:
+		assert.Equal(t, "Beach", *patch.Title)
+		assert.Equal(t, "text", *patch.Text)
+		assert.Equal(t, "2024-05-01", patch.EntryDate.Format(time.DateOnly))
+		assert.Nil(t, patch.MoodID)
+	case <-time.After(time.Second):
+		t.Fatal("autosave was not flushed")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, saved)
+	repo.AssertNumberOfCalls(t, "Autosave", 1)
+
+	// The next pause starts from an empty patch.
+	err := svc.Autosave(context.Background(), 1, "journalUID", &dto.JournalPatchRequest{Text: helper.Ptr("more text")})
+	assert.NoError(t, err)
+	_, err = svc.GetByID(context.Background(), 1, "journalUID")
+	assert.NoError(t, err)
+
+	select {
+	case patch := <-saved:
+		assert.Nil(t, patch.Title)
+		assert.Equal(t, "more text", *patch.Text)
+	default:
+		t.Fatal("reading the journal did not flush the autosave")
+	}
+}
+
+func TestJournalService_Publish(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupMocks func(repo *mocks.JournalRepositoryMock, stats *mocks.StatsRepositoryMock)
+		wantErr    string
+	}{
+		{
+			name: "already published",
+			setupMocks: func(repo *mocks.JournalRepositoryMock, stats *mocks.StatsRepositoryMock) {
+				repo.On("GetByID", mock.Anything, "journalUID").
+					Return(&models.Journal{ID: 1, Uid: "journalUID", UserID: 1, Status: models.JOURNAL_PUBLISHED}, nil)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name: "success",
+			setupMocks: func(repo *mocks.JournalRepositoryMock, stats *mocks.StatsRepositoryMock) {
+				repo.On("GetByID", mock.Anything, "journalUID").
+					Return(&models.Journal{ID: 1, Uid: "journalUID", UserID: 1, Status: models.JOURNAL_DRAFT}, nil)
+				repo.On("Publish", mock.Anything, "journalUID").Return(nil)
+				stats.On("AdvanceStreak", mock.Anything, int64(1), mock.AnythingOfType("time.Time")).Return(true, nil)
+			},
+			wantErr: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.JournalRepositoryMock)
+			moods := new(mocks.MoodRepositoryMock)
+			stats := new(mocks.StatsRepositoryMock)
+			tt.setupMocks(repo, stats)
+
+			svc := NewJournal(repo, moods, noAttachments(), stats, helper.NewDebouncer(time.Millisecond, time.Millisecond), helper.NewURLSigner([]byte("key"), "http://media", time.Hour))
+			resp, err := svc.Publish(context.Background(), 1, "journalUID")
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				assert.NotNil(t, resp)
+			} else {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+			}
+			repo.AssertExpectations(t)
+			stats.AssertExpectations(t)
+		})
+	}
+}
+
+func TestJournalService_GetListCover(t *testing.T) {
+	repo := new(mocks.JournalRepositoryMock)
+	repo.On("GetListByUserID", mock.Anything, int64(1), mock.AnythingOfType("*models.JournalFilter")).Return([]models.Journal{
+		{Uid: "signed", Cover: &models.JournalCover{PhotoID: 3, Url: "http://old/a.jpg", StorageKey: helper.Ptr("users/1/photos/a_medium.jpg")}},
+		{Uid: "legacy", Cover: &models.JournalCover{PhotoID: 4, Url: "http://old/b.jpg"}},
+		{Uid: "none"},
+	}, nil)
+	moods := new(mocks.MoodRepositoryMock)
+	stats := new(mocks.StatsRepositoryMock)
+
+	svc := NewJournal(repo, moods, noAttachments(), stats, helper.NewDebouncer(time.Millisecond, time.Millisecond), helper.NewURLSigner([]byte("key"), "http://media", time.Hour))
+	resp, err := svc.GetList(context.Background(), 1, &dto.JournalListQuery{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), resp[0].Cover.PhotoID)
+	assert.True(t, strings.HasPrefix(resp[0].Cover.Url, "http://media/users/1/photos/a_medium.jpg?"))
+	assert.Equal(t, "http://old/b.jpg", resp[1].Cover.Url)
+	assert.Nil(t, resp[2].Cover)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+)
+
+type media struct {
+	storage helper.Storage
+	signer  *helper.URLSigner
+}
+
+func NewMedia(storage helper.Storage, signer *helper.URLSigner) domain.MediaService {
+	return &media{storage: storage, signer: signer}
+}
+
+// Open checks the link's signature and expiry and opens the object behind it.
+// The content is fetched lazily, so a Range request only reads what it sends.
+func (m *media) Open(ctx context.Context, key, expires, sig string) (*dto.MediaObject, error) {
+	expiry, err := m.signer.Verify(key, expires, sig, time.Now())
+	if err != nil {
+		return nil, helper.NewAppError(helper.FORBIDDEN, "link is invalid or has expired", err)
+	}
+
+	info, err := m.storage.Stat(ctx, key)
+	if err != nil {
+		if errors.Is(err, helper.ErrObjectNotFound) || errors.Is(err, helper.ErrInvalidKey) {
+			return nil, helper.NewAppError(helper.NOT_FOUND, "file not found", err)
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to open file", err)
+	}
+
+	return &dto.MediaObject{
+		Content:     helper.NewObjectReader(ctx, m.storage, key, info.Size),
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		ModTime:     info.ModTime,
+		ETag:        info.ETag,
+		Expires:     expiry,
+	}, nil
+}
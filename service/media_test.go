@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	"timo/helper"
+	"timo/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMediaService_Open(t *testing.T) {
+	signer := helper.NewURLSigner([]byte("key"), "http://api/media", time.Hour)
+	link, _ := url.Parse(signer.URL("users/1/photos/a.jpg", time.Now()))
+	expires, sig := link.Query().Get("expires"), link.Query().Get("sig")
+	expired, _ := url.Parse(signer.URLFor("users/1/photos/a.jpg", time.Now().Add(-2*time.Hour), time.Hour))
+
+	tests := []struct {
+		name       string
+		key        string
+		expires    string
+		sig        string
+		setupMocks func(storage *mocks.StorageMock)
+		wantErr    string
+	}{
+		{
+			name:       "signature for another key",
+			key:        "users/2/photos/a.jpg",
+			expires:    expires,
+			sig:        sig,
+			setupMocks: func(storage *mocks.StorageMock) {},
+			wantErr:    helper.FORBIDDEN,
+		},
+		{
+			name:       "expired",
+			key:        "users/1/photos/a.jpg",
+			expires:    expired.Query().Get("expires"),
+			sig:        expired.Query().Get("sig"),
+			setupMocks: func(storage *mocks.StorageMock) {},
+			wantErr:    helper.FORBIDDEN,
+		},
+		{
+			name:    "missing object",
+			key:     "users/1/photos/a.jpg",
+			expires: expires,
+			sig:     sig,
+			setupMocks: func(storage *mocks.StorageMock) {
+				storage.On("Stat", mock.Anything, "users/1/photos/a.jpg").Return(nil, helper.ErrObjectNotFound)
+			},
+			wantErr: helper.NOT_FOUND,
+		},
+		{
+			name:    "success reads only the requested range",
+			key:     "users/1/photos/a.jpg",
+			expires: expires,
+			sig:     sig,
+			setupMocks: func(storage *mocks.StorageMock) {
+				storage.On("Stat", mock.Anything, "users/1/photos/a.jpg").Return(&helper.ObjectInfo{Size: 10, ContentType: "image/jpeg"}, nil)
+				storage.On("GetRange", mock.Anything, "users/1/photos/a.jpg", int64(6), int64(4)).Return(io.NopCloser(strings.NewReader("6789")), nil)
+			},
+			wantErr: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := new(mocks.StorageMock)
+			tt.setupMocks(storage)
+
+			svc := NewMedia(storage, signer)
+			object, err := svc.Open(context.Background(), tt.key, tt.expires, tt.sig)
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, "image/jpeg", object.ContentType)
+				object.Content.Seek(6, io.SeekStart)
+				data, err := io.ReadAll(object.Content)
+				assert.NoError(t, err)
+				assert.Equal(t, "6789", string(data))
+			} else {
+				assert.Error(t, err)
+				assert.Nil(t, object)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+			}
+			storage.AssertExpectations(t)
+		})
+	}
+}
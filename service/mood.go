@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/models"
+)
+
+type mood struct {
+	repo domain.MoodRepository
+}
+
+func NewMood(repo domain.MoodRepository) domain.MoodService {
+	return &mood{repo: repo}
+}
+
+func (m *mood) GetList(ctx context.Context, userID int64, locales []string, query *dto.MoodListQuery) ([]dto.MoodResponse, error) {
+	moods, err := m.repo.GetVisible(ctx, userID, query.IncludeArchived, locales)
+	if err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get moods", err)
+	}
+
+	resp := make([]dto.MoodResponse, 0, len(moods))
+	for _, mood := range moods {
+		resp = append(resp, toMoodResponse(&mood))
+	}
+
+	return resp, nil
+}
+
+func (m *mood) Create(ctx context.Context, userID int64, req *dto.MoodRequest) (*dto.MoodResponse, error) {
+	mood := &models.Mood{
+		UserID:  &userID,
+		Label:   req.Label,
+		Emoji:   req.Emoji,
+		Color:   req.Color,
+		Valence: req.Valence,
+		Energy:  req.Energy,
+	}
+
+	if err := m.repo.Create(ctx, mood); err != nil {
+		if errors.Is(err, domain.ErrMoodDuplicate) {
+			return nil, helper.NewAppError(helper.VALIDATION_ERROR, "mood label already exists", err)
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to create mood", err)
+	}
+
+	resp := toMoodResponse(mood)
+	return &resp, nil
+}
+
+func (m *mood) Update(ctx context.Context, userID, id int64, req *dto.MoodUpdateRequest) (*dto.MoodResponse, error) {
+	mood, err := m.getOwned(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Label != nil {
+		mood.Label = *req.Label
+	}
+	if req.Emoji.Set {
+		mood.Emoji = req.Emoji.Value
+	}
+	if req.Color.Set {
+		mood.Color = req.Color.Value
+	}
+	if req.Valence.Set {
+		mood.Valence = req.Valence.Value
+	}
+	if req.Energy.Set {
+		mood.Energy = req.Energy.Value
+	}
+	if req.Archived != nil {
+		switch {
+		case !*req.Archived:
+			mood.ArchivedAt = nil
+		case mood.ArchivedAt == nil:
+			now := time.Now()
+			mood.ArchivedAt = &now
+		}
+	}
+
+	if err := m.repo.Update(ctx, mood); err != nil {
+		if errors.Is(err, domain.ErrMoodDuplicate) {
+			return nil, helper.NewAppError(helper.VALIDATION_ERROR, "mood label already exists", err)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helper.NewAppError(helper.NOT_FOUND, "mood not found", err)
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to update mood", err)
+	}
+
+	resp := toMoodResponse(mood)
+	return &resp, nil
+}
+
+// Reorder takes the full list of the user's own moods in their new order.
+// Global moods keep their place ahead of custom ones.
+func (m *mood) Reorder(ctx context.Context, userID int64, req *dto.MoodOrderRequest) ([]dto.MoodResponse, error) {
+	moods, err := m.repo.GetVisible(ctx, userID, true, nil)
+	if err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get moods", err)
+	}
+
+	owned := make(map[int64]bool)
+	for _, mood := range moods {
+		if mood.UserID != nil {
+			owned[mood.ID] = true
+		}
+	}
+
+	seen := make(map[int64]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		if !owned[id] || seen[id] {
+			return nil, helper.NewAppError(helper.VALIDATION_ERROR, "ids must list each of your moods once", nil)
+		}
+		seen[id] = true
+	}
+	if len(seen) != len(owned) {
+		return nil, helper.NewAppError(helper.VALIDATION_ERROR, "ids must list each of your moods once", nil)
+	}
+
+	if err := m.repo.Reorder(ctx, userID, req.IDs); err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to reorder moods", err)
+	}
+
+	return m.GetList(ctx, userID, nil, &dto.MoodListQuery{IncludeArchived: true})
+}
+
+// Delete removes one of the user's moods. Entries that use it are moved to
+// reassign_to, which has to be another mood the user can pick.
+func (m *mood) Delete(ctx context.Context, userID, id int64, query *dto.MoodDeleteQuery) error {
+	if _, err := m.getOwned(ctx, userID, id); err != nil {
+		return err
+	}
+
+	if query.ReassignTo != 0 {
+		if query.ReassignTo == id {
+			return helper.NewAppError(helper.VALIDATION_ERROR, "cannot reassign a mood to itself", nil)
+		}
+		if err := checkMoods(ctx, m.repo, userID, query.ReassignTo); err != nil {
+			return err
+		}
+	}
+
+	err := m.repo.Delete(ctx, id, query.ReassignTo)
+	if err != nil {
+		if errors.Is(err, domain.ErrMoodInUse) {
+			return helper.NewAppError(helper.VALIDATION_ERROR, "mood is used by journals or check-ins, reassign_to is required", err)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return helper.NewAppError(helper.NOT_FOUND, "mood not found", err)
+		}
+		return helper.NewAppError(helper.INTERNAL_ERROR, "failed to delete mood", err)
+	}
+
+	return nil
+}
+
+// getOwned loads a mood the user may edit. Global moods are read-only and
+// other users' moods are reported as missing.
+func (m *mood) getOwned(ctx context.Context, userID, id int64) (*models.Mood, error) {
+	mood, err := m.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helper.NewAppError(helper.NOT_FOUND, "mood not found", err)
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get mood", err)
+	}
+
+	if mood.UserID == nil {
+		return nil, helper.NewAppError(helper.VALIDATION_ERROR, "default moods cannot be changed", nil)
+	}
+	if *mood.UserID != userID {
+		return nil, helper.NewAppError(helper.NOT_FOUND, "mood not found", nil)
+	}
+
+	return mood, nil
+}
+
+// checkMoods makes sure every id is a mood the user can pick for an entry.
+func checkMoods(ctx context.Context, repo domain.MoodRepository, userID int64, ids ...int64) error {
+	unique := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		unique[id] = true
+	}
+
+	count, err := repo.CountSelectable(ctx, userID, ids)
+	if err != nil {
+		return helper.NewAppError(helper.INTERNAL_ERROR, "failed to check moods", err)
+	}
+
+	if count != len(unique) {
+		return helper.NewAppError(helper.VALIDATION_ERROR, "mood not available", nil).
+			WithDetails([]helper.ValidatorError{{Field: "mood_id", Message: "must be one of your moods"}})
+	}
+
+	return nil
+}
+
+func toMoodResponse(mood *models.Mood) dto.MoodResponse {
+	return dto.MoodResponse{
+		ID:       mood.ID,
+		Label:    mood.Label,
+		Emoji:    mood.Emoji,
+		Color:    mood.Color,
+		Valence:  mood.Valence,
+		Energy:   mood.Energy,
+		Custom:   mood.UserID != nil,
+		Position: mood.Position,
+		Archived: mood.ArchivedAt != nil,
+	}
+}
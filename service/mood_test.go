@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/mocks"
+	"timo/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMoodService_Update(t *testing.T) {
+	var owner, other int64 = 1, 2
+	label := "calm"
+	archived := true
+
+	tests := []struct {
+		name       string
+		setupMocks func(repo *mocks.MoodRepositoryMock)
+		wantErr    string
+	}{
+		{
+			name: "mood not found",
+			setupMocks: func(repo *mocks.MoodRepositoryMock) {
+				repo.On("GetByID", mock.Anything, int64(10)).Return(nil, sql.ErrNoRows)
+			},
+			wantErr: helper.NOT_FOUND,
+		},
+		{
+			name: "default mood",
+			setupMocks: func(repo *mocks.MoodRepositoryMock) {
+				repo.On("GetByID", mock.Anything, int64(10)).Return(&models.Mood{ID: 10, Label: "happy"}, nil)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name: "mood owned by another user",
+			setupMocks: func(repo *mocks.MoodRepositoryMock) {
+				repo.On("GetByID", mock.Anything, int64(10)).Return(&models.Mood{ID: 10, UserID: &other, Label: "meh"}, nil)
+			},
+			wantErr: helper.NOT_FOUND,
+		},
+		{
+			name: "duplicate label",
+			setupMocks: func(repo *mocks.MoodRepositoryMock) {
+				repo.On("GetByID", mock.Anything, int64(10)).Return(&models.Mood{ID: 10, UserID: &owner, Label: "meh"}, nil)
+				repo.On("Update", mock.Anything, mock.AnythingOfType("*models.Mood")).Return(domain.ErrMoodDuplicate)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name: "success",
+			setupMocks: func(repo *mocks.MoodRepositoryMock) {
+				repo.On("GetByID", mock.Anything, int64(10)).
+					Return(&models.Mood{ID: 10, UserID: &owner, Label: "meh", Emoji: helper.Ptr("😐"), Color: helper.Ptr("#112233")}, nil)
+				repo.On("Update", mock.Anything, mock.MatchedBy(func(m *models.Mood) bool {
+					return m.Label == "calm" && m.ArchivedAt != nil && m.Emoji == nil && *m.Color == "#112233"
+				})).Return(nil)
+			},
+			wantErr: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.MoodRepositoryMock)
+			tt.setupMocks(repo)
+
+			svc := NewMood(repo)
+			resp, err := svc.Update(context.Background(), owner, 10, &dto.MoodUpdateRequest{Label: &label, Archived: &archived,
+				Emoji: helper.Optional[string]{Set: true}})
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, dto.MoodResponse{ID: 10, Label: "calm", Color: helper.Ptr("#112233"), Custom: true, Archived: true}, *resp)
+			} else {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMoodService_Reorder(t *testing.T) {
+	var owner int64 = 1
+	visible := []models.Mood{{ID: 1, Label: "happy"}, {ID: 10, UserID: &owner}, {ID: 11, UserID: &owner}}
+
+	tests := []struct {
+		name       string
+		ids        []int64
+		setupMocks func(repo *mocks.MoodRepositoryMock)
+		wantErr    string
+	}{
+		{
+			name: "includes default mood",
+			ids:  []int64{1, 11, 10},
+			setupMocks: func(repo *mocks.MoodRepositoryMock) {
+				repo.On("GetVisible", mock.Anything, owner, true, []string(nil)).Return(visible, nil)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name: "missing own mood",
+			ids:  []int64{11},
+			setupMocks: func(repo *mocks.MoodRepositoryMock) {
+				repo.On("GetVisible", mock.Anything, owner, true, []string(nil)).Return(visible, nil)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name: "success",
+			ids:  []int64{11, 10},
+			setupMocks: func(repo *mocks.MoodRepositoryMock) {
+				repo.On("GetVisible", mock.Anything, owner, true, []string(nil)).Return(visible, nil)
+				repo.On("Reorder", mock.Anything, owner, []int64{11, 10}).Return(nil)
+			},
+			wantErr: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.MoodRepositoryMock)
+			tt.setupMocks(repo)
+
+			svc := NewMood(repo)
+			_, err := svc.Reorder(context.Background(), owner, &dto.MoodOrderRequest{IDs: tt.ids})
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMoodService_Delete(t *testing.T) {
+	var owner int64 = 1
+
+	tests := []struct {
+		name       string
+		reassignTo int64
+		setupMocks func(repo *mocks.MoodRepositoryMock)
+		wantErr    string
+	}{
+		{
+			name:       "reassign to itself",
+			reassignTo: 10,
+			setupMocks: func(repo *mocks.MoodRepositoryMock) {
+				repo.On("GetByID", mock.Anything, int64(10)).Return(&models.Mood{ID: 10, UserID: &owner}, nil)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name:       "reassign to unavailable mood",
+			reassignTo: 20,
+			setupMocks: func(repo *mocks.MoodRepositoryMock) {
+				repo.On("GetByID", mock.Anything, int64(10)).Return(&models.Mood{ID: 10, UserID: &owner}, nil)
+				repo.On("CountSelectable", mock.Anything, owner, []int64{20}).Return(0, nil)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name: "in use without reassignment",
+			setupMocks: func(repo *mocks.MoodRepositoryMock) {
+				repo.On("GetByID", mock.Anything, int64(10)).Return(&models.Mood{ID: 10, UserID: &owner}, nil)
+				repo.On("Delete", mock.Anything, int64(10), int64(0)).Return(domain.ErrMoodInUse)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name:       "success",
+			reassignTo: 1,
+			setupMocks: func(repo *mocks.MoodRepositoryMock) {
+				repo.On("GetByID", mock.Anything, int64(10)).Return(&models.Mood{ID: 10, UserID: &owner}, nil)
+				repo.On("CountSelectable", mock.Anything, owner, []int64{1}).Return(1, nil)
+				repo.On("Delete", mock.Anything, int64(10), int64(1)).Return(nil)
+			},
+			wantErr: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.MoodRepositoryMock)
+			tt.setupMocks(repo)
+
+			svc := NewMood(repo)
+			err := svc.Delete(context.Background(), owner, 10, &dto.MoodDeleteQuery{ReassignTo: tt.reassignTo})
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
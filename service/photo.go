@@ -0,0 +1,576 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"slices"
+	"time"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/models"
+	"unicode/utf8"
+)
+
+// PhotoLimits bounds what a single upload and a single user may store.
+type PhotoLimits struct {
+	MaxSize          int64         // bytes per image
+	MaxSide          int           // pixels along either side
+	MaxPixels        int           // width × height
+	AudioMaxSize     int64         // bytes per recording
+	AudioMaxDuration time.Duration // length of a recording
+	PerJournal       int           // attachments per journal
+	Quota            int64         // bytes per user, variants included
+}
+
+// MaxFileSize is the most any upload may take before its type is known.
+func (l PhotoLimits) MaxFileSize() int64 {
+	return max(l.MaxSize, l.AudioMaxSize)
+}
+
+const (
+	// photoTextMax matches the limit PhotoUpdateRequest puts on captions and
+	// alt text.
+	photoTextMax = 500
+	// audioPeaksMax and audioPeakMax match the limits UploadCreateRequest
+	// puts on waveform peaks.
+	audioPeaksMax = 512
+	audioPeakMax  = 255
+)
+
+var DefaultPhotoLimits = PhotoLimits{
+	MaxSize:          20 << 20,
+	MaxSide:          12000,
+	MaxPixels:        50_000_000,
+	AudioMaxSize:     50 << 20,
+	AudioMaxDuration: 30 * time.Minute,
+	PerJournal:       20,
+	Quota:            1 << 30,
+}
+
+// mediaInfo is what checkMedia learned about an uploaded file.
+type mediaInfo struct {
+	mediaType   string
+	contentType string
+	durationMs  *int
+}
+
+type photo struct {
+	repo      domain.PhotoRepository
+	journals  domain.JournalRepository
+	storage   helper.Storage
+	processor *PhotoProcessor
+	signer    *helper.URLSigner
+	limits    PhotoLimits
+}
+
+func NewPhoto(repo domain.PhotoRepository, journals domain.JournalRepository, storage helper.Storage, processor *PhotoProcessor, signer *helper.URLSigner, limits PhotoLimits) domain.PhotoService {
+	return &photo{repo: repo, journals: journals, storage: storage, processor: processor, signer: signer, limits: limits}
+}
+
+func (p *photo) GetList(ctx context.Context, userID int64, journalUid string) ([]dto.PhotoResponse, error) {
+	journal, err := p.getJournal(ctx, userID, journalUid)
+	if err != nil {
+		return nil, err
+	}
+
+	photos, err := p.repo.GetByJournalID(ctx, journal.ID)
+	if err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get photos", err)
+	}
+
+	resp := make([]dto.PhotoResponse, 0, len(photos))
+	for _, photo := range photos {
+		resp = append(resp, toPhotoResponse(p.signer, &photo))
+	}
+
+	return resp, nil
+}
+
+// Upload attaches an image or an audio recording to the journal. Files are
+// stored once per user by their SHA-256: a file the user already has, or just
+// its checksum, only adds a reference to the stored blob. A new file has its
+// real format and size checked against the limits and its space reserved in
+// the user's quota. Images are stored as pending for the processor, while
+// recordings are kept as uploaded. The object and the reservation are undone
+// if a later step fails.
+func (p *photo) Upload(ctx context.Context, userID int64, journalUid string, upload *dto.PhotoUpload) (*dto.PhotoResponse, error) {
+	journal, err := p.getJournal(ctx, userID, journalUid)
+	if err != nil {
+		return nil, err
+	}
+
+	if upload.Content != nil && upload.Size > p.limits.MaxFileSize() {
+		return nil, helper.NewAppError(helper.FILE_TOO_LARGE, fmt.Sprintf("file must not be larger than %d MB", p.limits.MaxFileSize()>>20), nil)
+	}
+
+	// Only an early answer before the file is read and stored, Create
+	// enforces the limit.
+	count, err := p.repo.CountByJournalID(ctx, journal.ID)
+	if err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to count photos", err)
+	}
+	if count >= p.limits.PerJournal {
+		return nil, p.limitError()
+	}
+
+	if utf8.RuneCountInString(upload.Caption) > photoTextMax || utf8.RuneCountInString(upload.AltText) > photoTextMax {
+		return nil, photoError(fmt.Sprintf("caption and alt text must be at most %d characters", photoTextMax))
+	}
+
+	if len(upload.Peaks) > audioPeaksMax || slices.ContainsFunc(upload.Peaks, func(v int) bool { return v < 0 || v > audioPeakMax }) {
+		return nil, peaksError(fmt.Sprintf("must be at most %d values between 0 and %d", audioPeaksMax, audioPeakMax))
+	}
+
+	checksum := upload.Checksum
+	if upload.Content != nil {
+		sum, err := hashContent(upload.Content)
+		if err != nil {
+			return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to read photo", err)
+		}
+		if checksum != "" && checksum != sum {
+			return nil, photoError("does not match the given sha256")
+		}
+		checksum = sum
+	}
+
+	blob, err := p.repo.GetBlob(ctx, userID, checksum)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get photo", err)
+	}
+	if blob != nil {
+		resp, err := p.attach(ctx, journal, blob, upload)
+		if err == nil && upload.UploadID != 0 {
+			p.releaseStorage(userID, upload.Size)
+		}
+		return resp, err
+	}
+	if upload.Content == nil {
+		return nil, helper.NewAppError(helper.NOT_FOUND, "no photo with this sha256, upload the file", err)
+	}
+
+	media, err := p.checkMedia(upload.Content, upload.Size)
+	if err != nil {
+		return nil, err
+	}
+	if media.mediaType != models.MEDIA_AUDIO && len(upload.Peaks) > 0 {
+		return nil, peaksError("are only accepted for audio")
+	}
+
+	if upload.UploadID == 0 {
+		used, err := p.repo.ReserveStorage(ctx, userID, upload.Size, p.limits.Quota)
+		if err != nil {
+			if errors.Is(err, domain.ErrQuotaExceeded) {
+				return nil, helper.NewAppError(helper.QUOTA_EXCEEDED, "storage quota exceeded", err).
+					WithDetails(dto.StorageUsage{Used: used, Quota: p.limits.Quota, Requested: upload.Size})
+			}
+			return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to reserve storage", err)
+		}
+	}
+
+	// Images wait under uploads/ for the processor, which writes the web
+	// sized copies next to the other photos. Recordings are served as they
+	// are. Keys are fresh for every blob, so removing the objects of a blob
+	// that was just deleted never hits a new upload of the same file.
+	key := helper.StorageKey(fmt.Sprintf("users/%d/uploads", userID), helper.MediaExtension(media.contentType))
+	status := models.PHOTO_PENDING
+	if media.mediaType == models.MEDIA_AUDIO {
+		key = helper.StorageKey(fmt.Sprintf("users/%d/audio", userID), helper.MediaExtension(media.contentType))
+		status = models.PHOTO_READY
+	}
+	if err := p.storage.Put(ctx, key, upload.Content, upload.Size, media.contentType); err != nil {
+		p.releaseReservation(userID, upload)
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to store photo", err)
+	}
+
+	photo := &models.Photo{
+		JournalID:   journal.ID,
+		Url:         p.storage.URL(key),
+		StorageKey:  key,
+		Size:        upload.Size,
+		ContentType: media.contentType,
+		Checksum:    checksum,
+		Status:      status,
+		MediaType:   media.mediaType,
+		DurationMs:  media.durationMs,
+		Peaks:       upload.Peaks,
+		Caption:     helper.PtrOrNil(upload.Caption),
+		AltText:     helper.PtrOrNil(upload.AltText),
+	}
+	created, err := p.repo.Create(ctx, photo, p.limits.PerJournal, upload.UploadID)
+	if err != nil {
+		removeObject(p.storage, key)
+		p.releaseReservation(userID, upload)
+		if errors.Is(err, domain.ErrPhotoLimit) {
+			return nil, p.limitError()
+		}
+		if errors.Is(err, domain.ErrUploadGone) {
+			return nil, uploadGoneError(err)
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to save photo", err)
+	}
+
+	if created {
+		if photo.Status == models.PHOTO_PENDING {
+			p.processor.Enqueue()
+		}
+	} else {
+		// A concurrent upload of the same file stored the blob first.
+		removeObject(p.storage, key)
+		p.releaseStorage(userID, upload.Size)
+	}
+
+	resp := toPhotoResponse(p.signer, photo)
+	return &resp, nil
+}
+
+// attach adds a photo sharing an already stored blob.
+func (p *photo) attach(ctx context.Context, journal *models.Journal, blob *models.PhotoBlob, upload *dto.PhotoUpload) (*dto.PhotoResponse, error) {
+	photo := &models.Photo{
+		JournalID: journal.ID,
+		BlobID:    blob.ID,
+		Caption:   helper.PtrOrNil(upload.Caption),
+		AltText:   helper.PtrOrNil(upload.AltText),
+	}
+	if _, err := p.repo.Create(ctx, photo, p.limits.PerJournal, upload.UploadID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helper.NewAppError(helper.NOT_FOUND, "no photo with this sha256, upload the file", err)
+		}
+		if errors.Is(err, domain.ErrPhotoLimit) {
+			return nil, p.limitError()
+		}
+		if errors.Is(err, domain.ErrUploadGone) {
+			return nil, uploadGoneError(err)
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to save photo", err)
+	}
+
+	resp := toPhotoResponse(p.signer, photo)
+	return &resp, nil
+}
+
+// Update changes a photo's caption, alt text or cover flag. Only one photo of
+// a journal is its cover at a time.
+func (p *photo) Update(ctx context.Context, userID int64, journalUid string, id int64, req *dto.PhotoUpdateRequest) (*dto.PhotoResponse, error) {
+	journal, err := p.getJournal(ctx, userID, journalUid)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := p.getPhoto(ctx, journal, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Cover != nil && *req.Cover && current.MediaType == models.MEDIA_AUDIO {
+		return nil, helper.NewAppError(helper.VALIDATION_ERROR, "invalid photo", nil).
+			WithDetails([]helper.ValidatorError{{Field: "cover", Message: "must be an image"}})
+	}
+
+	patch := &models.PhotoPatch{Caption: photoText(req.Caption), AltText: photoText(req.AltText), Cover: req.Cover}
+	if err := p.repo.Update(ctx, id, patch); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helper.NewAppError(helper.NOT_FOUND, "photo not found", err)
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to update photo", err)
+	}
+
+	photo, err := p.getPhoto(ctx, journal, id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := toPhotoResponse(p.signer, photo)
+	return &resp, nil
+}
+
+// Reorder takes the full list of the journal's photos in their new order.
+func (p *photo) Reorder(ctx context.Context, userID int64, journalUid string, req *dto.PhotoOrderRequest) ([]dto.PhotoResponse, error) {
+	journal, err := p.getJournal(ctx, userID, journalUid)
+	if err != nil {
+		return nil, err
+	}
+
+	photos, err := p.repo.GetByJournalID(ctx, journal.ID)
+	if err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get photos", err)
+	}
+
+	current := make(map[int64]bool, len(photos))
+	for _, photo := range photos {
+		current[photo.ID] = true
+	}
+
+	seen := make(map[int64]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		if !current[id] || seen[id] {
+			return nil, helper.NewAppError(helper.VALIDATION_ERROR, "ids must list each photo of the journal once", nil)
+		}
+		seen[id] = true
+	}
+	if len(seen) != len(current) {
+		return nil, helper.NewAppError(helper.VALIDATION_ERROR, "ids must list each photo of the journal once", nil)
+	}
+
+	if err := p.repo.Reorder(ctx, journal.ID, req.IDs); err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to reorder photos", err)
+	}
+
+	return p.GetList(ctx, userID, journalUid)
+}
+
+func (p *photo) Delete(ctx context.Context, userID int64, journalUid string, id int64) error {
+	journal, err := p.getJournal(ctx, userID, journalUid)
+	if err != nil {
+		return err
+	}
+
+	photo, err := p.getPhoto(ctx, journal, id)
+	if err != nil {
+		return err
+	}
+
+	blob, err := p.repo.Delete(ctx, photo.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return helper.NewAppError(helper.NOT_FOUND, "photo not found", err)
+		}
+		return helper.NewAppError(helper.INTERNAL_ERROR, "failed to delete photo", err)
+	}
+
+	// Other photos still share the blob unless this was the last one.
+	if blob == nil {
+		return nil
+	}
+	if blob.StorageKey != "" {
+		removeObject(p.storage, blob.StorageKey)
+	}
+	for _, variant := range blob.Variants {
+		removeObject(p.storage, variant.StorageKey)
+	}
+
+	return nil
+}
+
+// checkMedia sniffs the format from the magic bytes and checks the file
+// against the limits for its type. It returns what it learned with r rewound
+// to the start.
+func (p *photo) checkMedia(r io.ReadSeeker, size int64) (*mediaInfo, error) {
+	header := make([]byte, 512)
+	n, err := io.ReadFull(r, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to read photo", err)
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to read photo", err)
+	}
+
+	var m *mediaInfo
+	if contentType, ok := helper.SniffImage(header[:n]); ok {
+		m, err = p.checkImage(r, size, contentType)
+	} else if _, ok := helper.SniffAudio(header[:n]); ok {
+		m, err = p.checkAudio(r, size)
+	} else {
+		return nil, photoError("must be a JPEG, PNG or GIF image, or M4A, Ogg or MP3 audio")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to read photo", err)
+	}
+
+	return m, nil
+}
+
+// checkImage reads just enough of the header to check the pixel dimensions.
+func (p *photo) checkImage(r io.Reader, size int64, contentType string) (*mediaInfo, error) {
+	if size > p.limits.MaxSize {
+		return nil, helper.NewAppError(helper.FILE_TOO_LARGE, fmt.Sprintf("photo must not be larger than %d MB", p.limits.MaxSize>>20), nil)
+	}
+
+	config, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, photoError("could not be read as an image")
+	}
+	if config.Width > p.limits.MaxSide || config.Height > p.limits.MaxSide || config.Width*config.Height > p.limits.MaxPixels {
+		return nil, photoError(fmt.Sprintf("must be at most %d pixels per side and %d megapixels", p.limits.MaxSide, p.limits.MaxPixels/1_000_000))
+	}
+
+	return &mediaInfo{mediaType: models.MEDIA_IMAGE, contentType: contentType}, nil
+}
+
+// checkAudio reads the duration from the container header. The audio
+// itself is never decoded.
+func (p *photo) checkAudio(r io.ReadSeeker, size int64) (*mediaInfo, error) {
+	if size > p.limits.AudioMaxSize {
+		return nil, helper.NewAppError(helper.FILE_TOO_LARGE, fmt.Sprintf("audio must not be larger than %d MB", p.limits.AudioMaxSize>>20), nil)
+	}
+
+	info, err := helper.ReadAudioInfo(r, size)
+	if err != nil {
+		if errors.Is(err, helper.ErrAudioInvalid) {
+			return nil, photoError("could not be read as audio")
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to read photo", err)
+	}
+	if info.Duration > p.limits.AudioMaxDuration {
+		return nil, photoError(fmt.Sprintf("must be at most %d minutes long", int(p.limits.AudioMaxDuration.Minutes())))
+	}
+
+	durationMs := int(info.Duration.Milliseconds())
+	return &mediaInfo{mediaType: models.MEDIA_AUDIO, contentType: info.ContentType, durationMs: &durationMs}, nil
+}
+
+// hashContent returns the hex SHA-256 of r and rewinds it.
+func hashContent(r io.ReadSeeker) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func photoError(message string) *helper.AppError {
+	return helper.NewAppError(helper.VALIDATION_ERROR, "invalid photo", nil).
+		WithDetails([]helper.ValidatorError{{Field: "photo", Message: message}})
+}
+
+// photoText turns a patched caption or alt text into the PhotoPatch field,
+// where a removed one is empty.
+func photoText(text helper.Optional[string]) *string {
+	if !text.Set {
+		return nil
+	}
+	return helper.Ptr(helper.Deref(text.Value))
+}
+
+func (p *photo) limitError() *helper.AppError {
+	return photoError(fmt.Sprintf("journal already has the maximum of %d photos", p.limits.PerJournal))
+}
+
+// uploadGoneError reports a resumable upload that was collected as expired
+// while it was being finalized, which released its reservation.
+func uploadGoneError(err error) *helper.AppError {
+	return helper.NewAppError(helper.NOT_FOUND, "upload not found", err)
+}
+
+func peaksError(message string) *helper.AppError {
+	return helper.NewAppError(helper.VALIDATION_ERROR, "invalid photo", nil).
+		WithDetails([]helper.ValidatorError{{Field: "peaks", Message: message}})
+}
+
+// releaseReservation undoes the reservation a failed upload made itself. One
+// the caller made stays with the caller.
+func (p *photo) releaseReservation(userID int64, upload *dto.PhotoUpload) {
+	if upload.UploadID == 0 {
+		p.releaseStorage(userID, upload.Size)
+	}
+}
+
+// releaseStorage hands a reservation back after a failed upload. It runs
+// detached from the request so a cancelled upload still releases its space.
+func (p *photo) releaseStorage(userID, size int64) {
+	if err := p.repo.ReleaseStorage(context.Background(), userID, size); err != nil {
+		log.Printf("failed to release %d bytes of storage for user %d: %v", size, userID, err)
+	}
+}
+
+// removeObject deletes a stored object on a best-effort basis; a leftover
+// object only costs space, so failures are logged rather than returned.
+func removeObject(storage helper.Storage, key string) {
+	if err := storage.Delete(context.Background(), key); err != nil && !errors.Is(err, helper.ErrObjectNotFound) {
+		log.Printf("failed to delete stored object %s: %v", key, err)
+	}
+}
+
+// getPhoto loads a photo of the journal. Photos of other journals are
+// reported as missing.
+func (p *photo) getPhoto(ctx context.Context, journal *models.Journal, id int64) (*models.Photo, error) {
+	photo, err := p.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helper.NewAppError(helper.NOT_FOUND, "photo not found", err)
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get photo", err)
+	}
+
+	if photo.JournalID != journal.ID {
+		return nil, helper.NewAppError(helper.NOT_FOUND, "photo not found", nil)
+	}
+
+	return photo, nil
+}
+
+func (p *photo) getJournal(ctx context.Context, userID int64, uid string) (*models.Journal, error) {
+	return ownJournal(ctx, p.journals, userID, uid)
+}
+
+// ownJournal loads a journal of the user. Other users' journals are reported
+// as missing.
+func ownJournal(ctx context.Context, journals domain.JournalRepository, userID int64, uid string) (*models.Journal, error) {
+	journal, err := journals.GetByID(ctx, uid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helper.NewAppError(helper.NOT_FOUND, "journal not found", err)
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get journal", err)
+	}
+
+	if journal.UserID != userID {
+		return nil, helper.NewAppError(helper.NOT_FOUND, "journal not found", nil)
+	}
+
+	return journal, nil
+}
+
+// toPhotoResponse links the photo and its variants through signed URLs.
+// Photos stored before signed links existed keep their plain url.
+func toPhotoResponse(signer *helper.URLSigner, photo *models.Photo) dto.PhotoResponse {
+	now := time.Now()
+	url := photo.Url
+	if photo.StorageKey != "" {
+		url = signer.URL(photo.StorageKey, now)
+	}
+
+	var variants []dto.PhotoVariantResponse
+	for _, v := range photo.Variants {
+		variants = append(variants, dto.PhotoVariantResponse{
+			Name:   v.Name,
+			Url:    signer.URL(v.StorageKey, now),
+			Width:  v.Width,
+			Height: v.Height,
+		})
+	}
+
+	return dto.PhotoResponse{
+		ID:          photo.ID,
+		MediaType:   photo.MediaType,
+		Url:         url,
+		ContentType: photo.ContentType,
+		Size:        photo.Size,
+		Checksum:    photo.Checksum,
+		Status:      photo.Status,
+		Width:       photo.Width,
+		Height:      photo.Height,
+		TakenAt:     photo.TakenAt,
+		DurationMs:  photo.DurationMs,
+		Peaks:       photo.Peaks,
+		Variants:    variants,
+		Position:    photo.Position,
+		Caption:     photo.Caption,
+		AltText:     photo.AltText,
+		Cover:       photo.Cover,
+		CreatedAt:   photo.CreatedAt,
+	}
+}
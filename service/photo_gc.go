@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"path"
+	"strings"
+	"time"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+)
+
+const photoGCBatchSize = 500
+
+// PhotoGCOptions controls a garbage collection pass. Objects modified within
+// OlderThan are left alone, since uploads and processing store their objects
+// before recording them. A DryRun only reports.
+type PhotoGCOptions struct {
+	DryRun    bool
+	OlderThan time.Duration
+}
+
+// PhotoCollector cleans up after deletes that Postgres can't follow into
+// storage: resumable uploads past their expiry, stored objects no row points
+// at, such as those of deleted journals, and uploads that were never
+// recorded.
+type PhotoCollector struct {
+	repo    domain.PhotoRepository
+	uploads domain.UploadRepository
+	storage helper.Storage
+}
+
+func NewPhotoCollector(repo domain.PhotoRepository, uploads domain.UploadRepository, storage helper.Storage) *PhotoCollector {
+	return &PhotoCollector{repo: repo, uploads: uploads, storage: storage}
+}
+
+// Run collects every interval until ctx is cancelled and logs each report.
+func (c *PhotoCollector) Run(ctx context.Context, interval time.Duration, opts PhotoGCOptions) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := c.Collect(ctx, time.Now(), opts)
+		if err != nil {
+			log.Printf("photo gc: %v", err)
+		}
+		if report != nil {
+			logReport(report)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Collect removes expired uploads first, so the parts only they referenced
+// are swept along with the other objects without a row. On error the report
+// covers what was done until then.
+func (c *PhotoCollector) Collect(ctx context.Context, now time.Time, opts PhotoGCOptions) (*dto.PhotoGCReport, error) {
+	report := &dto.PhotoGCReport{DryRun: opts.DryRun}
+
+	if err := c.collectUploads(ctx, now, opts, report); err != nil {
+		return report, err
+	}
+
+	if err := c.collectObjects(ctx, now.Add(-opts.OlderThan), opts, report); err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
+
+// collectUploads drops expired resumable uploads and releases the space they
+// reserved. Their parts are left to the object sweep. An upload still being
+// finalized is left for the next run.
+func (c *PhotoCollector) collectUploads(ctx context.Context, now time.Time, opts PhotoGCOptions, report *dto.PhotoGCReport) error {
+	uploads, err := c.uploads.GetExpired(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for _, upload := range uploads {
+		if opts.DryRun {
+			report.ExpiredUploads = append(report.ExpiredUploads, upload.Uid)
+			continue
+		}
+		err := c.uploads.DeleteExpired(ctx, upload.ID, now, uploadFinalizeStaleAfter)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		report.ExpiredUploads = append(report.ExpiredUploads, upload.Uid)
+		if err := c.repo.ReleaseStorage(ctx, upload.UserID, upload.Size); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// collectObjects lists the stored objects last modified before cutoff and
+// removes those no row points at, in batches.
+func (c *PhotoCollector) collectObjects(ctx context.Context, cutoff time.Time, opts PhotoGCOptions, report *dto.PhotoGCReport) error {
+	sizes := make(map[string]int64, photoGCBatchSize)
+	flush := func() error {
+		if len(sizes) == 0 {
+			return nil
+		}
+
+		keys := make([]string, 0, len(sizes))
+		for key := range sizes {
+			keys = append(keys, key)
+		}
+		unreferenced, err := c.repo.UnreferencedKeys(ctx, keys)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range unreferenced {
+			if isUpload(key) {
+				report.AbandonedUploads = append(report.AbandonedUploads, key)
+			} else {
+				report.OrphanObjects = append(report.OrphanObjects, key)
+			}
+			report.Bytes += sizes[key]
+			if !opts.DryRun {
+				removeObject(c.storage, key)
+			}
+		}
+
+		clear(sizes)
+		return nil
+	}
+
+	err := c.storage.List(ctx, "users/", func(obj *helper.ObjectInfo) error {
+		if !obj.ModTime.Before(cutoff) {
+			return nil
+		}
+
+		sizes[obj.Key] = obj.Size
+		if len(sizes) < photoGCBatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	return flush()
+}
+
+// isUpload reports whether key is an upload that never became a blob: an
+// original in a user's uploads area, or a temporary file left by an
+// interrupted write to local storage.
+func isUpload(key string) bool {
+	return strings.Contains(key, "/uploads/") || strings.HasPrefix(path.Base(key), ".upload-")
+}
+
+func logReport(report *dto.PhotoGCReport) {
+	action := "removed"
+	if report.DryRun {
+		action = "would remove"
+	}
+
+	log.Printf("photo gc: %s %d expired uploads, %d orphaned objects and %d abandoned uploads (%d bytes)",
+		action, len(report.ExpiredUploads), len(report.OrphanObjects), len(report.AbandonedUploads), report.Bytes)
+	for _, key := range report.OrphanObjects {
+		log.Printf("photo gc: %s orphaned object %s", action, key)
+	}
+	for _, key := range report.AbandonedUploads {
+		log.Printf("photo gc: %s abandoned upload %s", action, key)
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+	"timo/dto"
+	"timo/helper"
+	"timo/mocks"
+	"timo/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPhotoCollector_Collect(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-48 * time.Hour)
+	objects := []helper.ObjectInfo{
+		{Key: "users/1/photos/abc.jpg", Size: 100, ModTime: old},
+		{Key: "users/1/photos/lost.jpg", Size: 10, ModTime: old},
+		{Key: "users/1/uploads/def.png", Size: 20, ModTime: old},
+		{Key: "users/1/uploads/.upload-123", Size: 5, ModTime: old},
+		{Key: "users/1/uploads/fresh.png", Size: 30, ModTime: now.Add(-time.Minute)},
+	}
+	expired := []models.PhotoUpload{{ID: 2, Uid: "3f1c2a0e-8c47-4a8e-9a53-2f4f0d1f7b10", UserID: 1, Size: 40, ExpiresAt: now.Add(-time.Hour)}}
+	// the batch is built from a map, so its order is not fixed.
+	oldKeys := mock.MatchedBy(func(keys []string) bool {
+		sorted := slices.Sorted(slices.Values(keys))
+		return slices.Equal(sorted, []string{objects[0].Key, objects[1].Key, objects[3].Key, objects[2].Key})
+	})
+
+	tests := []struct {
+		name       string
+		opts       PhotoGCOptions
+		setupMocks func(repo *mocks.PhotoRepositoryMock, uploads *mocks.UploadRepositoryMock, storage *mocks.StorageMock)
+		wantReport *dto.PhotoGCReport
+		wantErr    bool
+	}{
+		{
+			name: "dry run only reports",
+			opts: PhotoGCOptions{DryRun: true, OlderThan: 24 * time.Hour},
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, uploads *mocks.UploadRepositoryMock, storage *mocks.StorageMock) {
+				uploads.On("GetExpired", mock.Anything, now).Return(expired, nil)
+				storage.On("List", mock.Anything, "users/").Return(objects, nil)
+				repo.On("UnreferencedKeys", mock.Anything, oldKeys).Return([]string{objects[1].Key, objects[2].Key, objects[3].Key}, nil)
+			},
+			wantReport: &dto.PhotoGCReport{
+				DryRun:           true,
+				ExpiredUploads:   []string{expired[0].Uid},
+				OrphanObjects:    []string{"users/1/photos/lost.jpg"},
+				AbandonedUploads: []string{"users/1/uploads/def.png", "users/1/uploads/.upload-123"},
+				Bytes:            35,
+			},
+		},
+		{
+			name: "removes expired uploads and orphaned objects",
+			opts: PhotoGCOptions{OlderThan: 24 * time.Hour},
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, uploads *mocks.UploadRepositoryMock, storage *mocks.StorageMock) {
+				uploads.On("GetExpired", mock.Anything, now).Return(expired, nil)
+				uploads.On("DeleteExpired", mock.Anything, int64(2), now, uploadFinalizeStaleAfter).Return(nil)
+				repo.On("ReleaseStorage", mock.Anything, int64(1), int64(40)).Return(nil)
+				storage.On("List", mock.Anything, "users/").Return(objects, nil)
+				repo.On("UnreferencedKeys", mock.Anything, oldKeys).Return([]string{objects[1].Key, objects[2].Key}, nil)
+				storage.On("Delete", mock.Anything, "users/1/photos/lost.jpg").Return(nil)
+				storage.On("Delete", mock.Anything, "users/1/uploads/def.png").Return(helper.ErrObjectNotFound)
+			},
+			wantReport: &dto.PhotoGCReport{
+				ExpiredUploads:   []string{expired[0].Uid},
+				OrphanObjects:    []string{"users/1/photos/lost.jpg"},
+				AbandonedUploads: []string{"users/1/uploads/def.png"},
+				Bytes:            30,
+			},
+		},
+		{
+			name: "list error",
+			opts: PhotoGCOptions{OlderThan: 24 * time.Hour},
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, uploads *mocks.UploadRepositoryMock, storage *mocks.StorageMock) {
+				uploads.On("GetExpired", mock.Anything, now).Return([]models.PhotoUpload{}, nil)
+				storage.On("List", mock.Anything, "users/").Return(nil, assert.AnError)
+			},
+			wantReport: &dto.PhotoGCReport{},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.PhotoRepositoryMock)
+			uploads := new(mocks.UploadRepositoryMock)
+			storage := new(mocks.StorageMock)
+			tt.setupMocks(repo, uploads, storage)
+
+			collector := NewPhotoCollector(repo, uploads, storage)
+			report, err := collector.Collect(context.Background(), now, tt.opts)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantReport, report)
+			repo.AssertExpectations(t)
+			uploads.AssertExpectations(t)
+			storage.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"path"
+	"strings"
+	"time"
+	"timo/domain"
+	"timo/helper"
+	"timo/models"
+)
+
+const (
+	photoMaxSide    = 2048
+	photoBatchSize  = 10
+	photoStaleAfter = 10 * time.Minute
+)
+
+// photoVariantSizes are the thumbnails kept next to the display image, largest
+// first.
+var photoVariantSizes = []struct {
+	name    string
+	maxSide int
+}{
+	{name: "medium", maxSide: 1024},
+	{name: "small", maxSide: 320},
+}
+
+// PhotoProcessor re-encodes uploaded originals into web-sized JPEGs without
+// EXIF data and renders their thumbnails. New blobs wait as pending rows, so
+// nothing is lost when the process restarts before handling them.
+type PhotoProcessor struct {
+	repo    domain.PhotoRepository
+	storage helper.Storage
+	wake    chan struct{}
+}
+
+func NewPhotoProcessor(repo domain.PhotoRepository, storage helper.Storage) *PhotoProcessor {
+	return &PhotoProcessor{repo: repo, storage: storage, wake: make(chan struct{}, 1)}
+}
+
+// Enqueue wakes the worker for a new upload without blocking the caller.
+func (p *PhotoProcessor) Enqueue() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run processes pending blobs whenever Enqueue is called, and every interval
+// to pick up leftovers, until ctx is cancelled.
+func (p *PhotoProcessor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.ProcessPending(ctx); err != nil {
+			log.Printf("photo processor: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-p.wake:
+		}
+	}
+}
+
+// ProcessPending claims and processes batches until no pending blob is
+// left. A blob that can't be processed is marked as failed and keeps its
+// original object.
+func (p *PhotoProcessor) ProcessPending(ctx context.Context) error {
+	for {
+		blobs, err := p.repo.ClaimPending(ctx, photoBatchSize, photoStaleAfter)
+		if err != nil {
+			return err
+		}
+		if len(blobs) == 0 {
+			return nil
+		}
+
+		for i := range blobs {
+			blob := &blobs[i]
+			if err := p.process(ctx, blob); err != nil {
+				log.Printf("photo processor: blob %d: %v", blob.ID, err)
+				if err := p.repo.MarkFailed(ctx, blob.ID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func (p *PhotoProcessor) process(ctx context.Context, blob *models.PhotoBlob) error {
+	body, err := p.storage.Get(ctx, blob.StorageKey)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return err
+	}
+
+	meta := helper.ReadImageMeta(data)
+	img, err := helper.DecodeImage(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	img = helper.Orient(img, meta.Orientation)
+
+	// Thumbnails go first so a failure never leaves the row pointing at a
+	// half-written set. The original is only removed once the processed blob
+	// is saved, so the display image of an older blob whose original is a
+	// .jpg gets a fresh key instead of overwriting it.
+	original := blob.StorageKey
+	base := photoBase(blob)
+	var written []string
+	variants := make([]models.PhotoVariant, 0, len(photoVariantSizes))
+	for _, size := range photoVariantSizes {
+		variant, err := p.put(ctx, base+"_"+size.name+".jpg", helper.Resize(img, size.maxSide))
+		if err != nil {
+			p.removeObjects(written)
+			return err
+		}
+		variant.Name = size.name
+		variants = append(variants, *variant)
+		written = append(written, variant.StorageKey)
+	}
+
+	displayKey := base + ".jpg"
+	if displayKey == original {
+		displayKey = helper.StorageKey(path.Dir(base), ".jpg")
+	}
+	display, err := p.put(ctx, displayKey, helper.Resize(img, photoMaxSide))
+	if err != nil {
+		p.removeObjects(written)
+		return err
+	}
+	written = append(written, display.StorageKey)
+
+	blob.Url = p.storage.URL(display.StorageKey)
+	blob.StorageKey = display.StorageKey
+	blob.Size = display.Size
+	blob.ContentType = display.ContentType
+	blob.Width = &display.Width
+	blob.Height = &display.Height
+	blob.TakenAt = meta.TakenAt
+	blob.Orientation = nil
+	if meta.Orientation > 0 {
+		blob.Orientation = &meta.Orientation
+	}
+	blob.Variants = variants
+
+	if err := p.repo.SaveProcessed(ctx, blob); err != nil {
+		p.removeObjects(written)
+		return err
+	}
+
+	removeObject(p.storage, original)
+
+	return nil
+}
+
+// photoBase is the key processed images are stored under, without the
+// extension. Blobs with a checksum go next to the other processed photos
+// under the name of their original, which is unique to the blob; older
+// blobs stay where their original is.
+func photoBase(blob *models.PhotoBlob) string {
+	name := strings.TrimSuffix(blob.StorageKey, path.Ext(blob.StorageKey))
+	if blob.Checksum != "" {
+		return fmt.Sprintf("users/%d/photos/%s", blob.UserID, path.Base(name))
+	}
+	return name
+}
+
+// put stores img as a JPEG under key and describes the stored object.
+func (p *PhotoProcessor) put(ctx context.Context, key string, img *image.RGBA) (*models.PhotoVariant, error) {
+	var buf bytes.Buffer
+	if err := helper.EncodeJPEG(&buf, img); err != nil {
+		return nil, err
+	}
+
+	size := int64(buf.Len())
+	if err := p.storage.Put(ctx, key, &buf, size, "image/jpeg"); err != nil {
+		return nil, err
+	}
+
+	return &models.PhotoVariant{
+		StorageKey:  key,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		Size:        size,
+		ContentType: "image/jpeg",
+	}, nil
+}
+
+func (p *PhotoProcessor) removeObjects(keys []string) {
+	for _, key := range keys {
+		removeObject(p.storage, key)
+	}
+}
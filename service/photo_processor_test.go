@@ -0,0 +1,149 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+	"timo/helper"
+	"timo/mocks"
+	"timo/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPhotoProcessor_ProcessPending(t *testing.T) {
+	var original bytes.Buffer
+	png.Encode(&original, image.NewRGBA(image.Rect(0, 0, 3000, 1500)))
+	// processing updates the claimed blob in place, so each case gets its own.
+	pending := func() []models.PhotoBlob {
+		return []models.PhotoBlob{{ID: 3, UserID: 1, Checksum: "abc", StorageKey: "users/1/uploads/abc.png", Status: models.PHOTO_PROCESSING}}
+	}
+	// blobs stored before checksums were kept in place of their original.
+	legacy := func() []models.PhotoBlob {
+		return []models.PhotoBlob{{ID: 3, UserID: 1, StorageKey: "users/1/photos/a.png", Status: models.PHOTO_PROCESSING}}
+	}
+	// the display image of a .jpg original gets a fresh key next to it.
+	display := mock.MatchedBy(func(key string) bool {
+		return strings.HasPrefix(key, "users/1/photos/") && strings.HasSuffix(key, ".jpg") &&
+			key != "users/1/photos/a.jpg" && !strings.Contains(key, "_")
+	})
+
+	tests := []struct {
+		name       string
+		setupMocks func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock)
+		wantErr    bool
+	}{
+		{
+			name: "claim error",
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("ClaimPending", mock.Anything, photoBatchSize, photoStaleAfter).Return(nil, assert.AnError)
+			},
+			wantErr: true,
+		},
+		{
+			name: "undecodable photo is marked failed",
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("ClaimPending", mock.Anything, photoBatchSize, photoStaleAfter).Return(pending(), nil).Once()
+				repo.On("ClaimPending", mock.Anything, photoBatchSize, photoStaleAfter).Return([]models.PhotoBlob{}, nil).Once()
+				storage.On("Get", mock.Anything, "users/1/uploads/abc.png").Return(io.NopCloser(strings.NewReader("not an image")), nil)
+				repo.On("MarkFailed", mock.Anything, int64(3)).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "variants are stored and the original removed",
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("ClaimPending", mock.Anything, photoBatchSize, photoStaleAfter).Return(pending(), nil).Once()
+				repo.On("ClaimPending", mock.Anything, photoBatchSize, photoStaleAfter).Return([]models.PhotoBlob{}, nil).Once()
+				storage.On("Get", mock.Anything, "users/1/uploads/abc.png").Return(io.NopCloser(bytes.NewReader(original.Bytes())), nil)
+				storage.On("Put", mock.Anything, "users/1/photos/abc_medium.jpg", mock.Anything, "image/jpeg").Return(nil)
+				storage.On("Put", mock.Anything, "users/1/photos/abc_small.jpg", mock.Anything, "image/jpeg").Return(nil)
+				storage.On("Put", mock.Anything, "users/1/photos/abc.jpg", mock.Anything, "image/jpeg").Return(nil)
+				storage.On("URL", "users/1/photos/abc.jpg").Return("http://media/abc.jpg")
+				repo.On("SaveProcessed", mock.Anything, mock.MatchedBy(func(b *models.PhotoBlob) bool {
+					return b.StorageKey == "users/1/photos/abc.jpg" && *b.Width == 2048 && *b.Height == 1024 &&
+						len(b.Variants) == 2 && b.Variants[1].Name == "small" && b.Variants[1].Width == 320
+				})).Return(nil)
+				storage.On("Delete", mock.Anything, "users/1/uploads/abc.png").Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "jpg original is kept until the display image is saved",
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				blobs := legacy()
+				blobs[0].StorageKey = "users/1/photos/a.jpg"
+				repo.On("ClaimPending", mock.Anything, photoBatchSize, photoStaleAfter).Return(blobs, nil).Once()
+				repo.On("ClaimPending", mock.Anything, photoBatchSize, photoStaleAfter).Return([]models.PhotoBlob{}, nil).Once()
+				storage.On("Get", mock.Anything, "users/1/photos/a.jpg").Return(io.NopCloser(bytes.NewReader(original.Bytes())), nil)
+				storage.On("Put", mock.Anything, "users/1/photos/a_medium.jpg", mock.Anything, "image/jpeg").Return(nil)
+				storage.On("Put", mock.Anything, "users/1/photos/a_small.jpg", mock.Anything, "image/jpeg").Return(nil)
+				storage.On("Put", mock.Anything, display, mock.Anything, "image/jpeg").Return(nil)
+				storage.On("URL", display).Return("http://media/display.jpg")
+				repo.On("SaveProcessed", mock.Anything, mock.MatchedBy(func(b *models.PhotoBlob) bool {
+					return b.StorageKey != "users/1/photos/a.jpg"
+				})).Return(nil)
+				storage.On("Delete", mock.Anything, "users/1/photos/a.jpg").Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "save error keeps the jpg original",
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				blobs := legacy()
+				blobs[0].StorageKey = "users/1/photos/a.jpg"
+				repo.On("ClaimPending", mock.Anything, photoBatchSize, photoStaleAfter).Return(blobs, nil).Once()
+				repo.On("ClaimPending", mock.Anything, photoBatchSize, photoStaleAfter).Return([]models.PhotoBlob{}, nil).Once()
+				storage.On("Get", mock.Anything, "users/1/photos/a.jpg").Return(io.NopCloser(bytes.NewReader(original.Bytes())), nil)
+				storage.On("Put", mock.Anything, mock.Anything, mock.Anything, "image/jpeg").Return(nil)
+				storage.On("URL", display).Return("http://media/display.jpg")
+				repo.On("SaveProcessed", mock.Anything, mock.AnythingOfType("*models.PhotoBlob")).Return(assert.AnError)
+				storage.On("Delete", mock.Anything, "users/1/photos/a_medium.jpg").Return(nil)
+				storage.On("Delete", mock.Anything, "users/1/photos/a_small.jpg").Return(nil)
+				storage.On("Delete", mock.Anything, display).Return(nil)
+				repo.On("MarkFailed", mock.Anything, int64(3)).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "save error removes written objects",
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("ClaimPending", mock.Anything, photoBatchSize, photoStaleAfter).Return(legacy(), nil).Once()
+				repo.On("ClaimPending", mock.Anything, photoBatchSize, photoStaleAfter).Return([]models.PhotoBlob{}, nil).Once()
+				storage.On("Get", mock.Anything, "users/1/photos/a.png").Return(io.NopCloser(bytes.NewReader(original.Bytes())), nil)
+				storage.On("Put", mock.Anything, mock.Anything, mock.Anything, "image/jpeg").Return(nil)
+				storage.On("URL", "users/1/photos/a.jpg").Return("http://media/a.jpg")
+				repo.On("SaveProcessed", mock.Anything, mock.AnythingOfType("*models.PhotoBlob")).Return(assert.AnError)
+				storage.On("Delete", mock.Anything, "users/1/photos/a_medium.jpg").Return(nil)
+				storage.On("Delete", mock.Anything, "users/1/photos/a_small.jpg").Return(nil)
+				storage.On("Delete", mock.Anything, "users/1/photos/a.jpg").Return(helper.ErrObjectNotFound)
+				repo.On("MarkFailed", mock.Anything, int64(3)).Return(nil)
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.PhotoRepositoryMock)
+			storage := new(mocks.StorageMock)
+			tt.setupMocks(repo, storage)
+
+			processor := NewPhotoProcessor(repo, storage)
+			err := processor.ProcessPending(context.Background())
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			repo.AssertExpectations(t)
+			storage.AssertExpectations(t)
+		})
+	}
+}
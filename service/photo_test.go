@@ -0,0 +1,567 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"image"
+	"image/png"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/mocks"
+	"timo/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPhotoService_Upload(t *testing.T) {
+	journal := &models.Journal{ID: 7, Uid: "journalUID", UserID: 1}
+	limits := PhotoLimits{MaxSize: 1 << 20, MaxSide: 100, MaxPixels: 5000, AudioMaxSize: 1 << 20, AudioMaxDuration: time.Second,
+		PerJournal: 2, Quota: 1 << 20}
+	signer := helper.NewURLSigner([]byte("key"), "http://api/media", time.Hour)
+
+	encode := func(w, h int) []byte {
+		var buf bytes.Buffer
+		png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)))
+		return buf.Bytes()
+	}
+	valid := encode(50, 40)
+	size := int64(len(valid))
+	sum := sha256.Sum256(valid)
+	checksum := hex.EncodeToString(sum[:])
+	// Every stored blob gets a fresh key.
+	key := mock.MatchedBy(func(k string) bool {
+		return strings.HasPrefix(k, "users/1/uploads/") && strings.HasSuffix(k, ".png") && !strings.Contains(k, checksum)
+	})
+	blob := &models.PhotoBlob{ID: 5, UserID: 1, Checksum: checksum, StorageKey: "users/1/photos/" + checksum + ".jpg", RefCount: 1}
+
+	// A constant bitrate MP3 of silent 128 kbit/s frames, 26 ms each.
+	encodeMP3 := func(frames int) []byte {
+		frame := make([]byte, 417)
+		copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+		return bytes.Repeat(frame, frames)
+	}
+	recording := encodeMP3(10)
+	audioSize := int64(len(recording))
+	audioSum := sha256.Sum256(recording)
+	audioKey := mock.MatchedBy(func(k string) bool {
+		return strings.HasPrefix(k, "users/1/audio/") && strings.HasSuffix(k, ".mp3") && !strings.Contains(k, hex.EncodeToString(audioSum[:]))
+	})
+	peaks := []int{0, 128, 255, 64}
+
+	tests := []struct {
+		name       string
+		content    []byte
+		size       int64
+		checksum   string
+		peaks      []int
+		uploadID   int64
+		setupMocks func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock)
+		wantErr    string
+		wantUsage  *dto.StorageUsage
+	}{
+		{
+			name:       "file too large",
+			content:    valid,
+			size:       limits.MaxSize + 1,
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {},
+			wantErr:    helper.FILE_TOO_LARGE,
+		},
+		{
+			name:    "journal full",
+			content: valid,
+			size:    size,
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("CountByJournalID", mock.Anything, int64(7)).Return(2, nil)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name:    "not an image despite content type",
+			content: []byte("%PDF-1.7"),
+			size:    8,
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("CountByJournalID", mock.Anything, int64(7)).Return(0, nil)
+				repo.On("GetBlob", mock.Anything, int64(1), mock.Anything).Return(nil, sql.ErrNoRows)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name:    "too many pixels",
+			content: encode(200, 10),
+			size:    size,
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("CountByJournalID", mock.Anything, int64(7)).Return(0, nil)
+				repo.On("GetBlob", mock.Anything, int64(1), mock.Anything).Return(nil, sql.ErrNoRows)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name:    "over quota",
+			content: valid,
+			size:    size,
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("CountByJournalID", mock.Anything, int64(7)).Return(0, nil)
+				repo.On("GetBlob", mock.Anything, int64(1), checksum).Return(nil, sql.ErrNoRows)
+				repo.On("ReserveStorage", mock.Anything, int64(1), size, limits.Quota).Return(limits.Quota-10, domain.ErrQuotaExceeded)
+			},
+			wantErr:   helper.QUOTA_EXCEEDED,
+			wantUsage: &dto.StorageUsage{Used: limits.Quota - 10, Quota: limits.Quota, Requested: size},
+		},
+		{
+			name:    "storage error releases reservation",
+			content: valid,
+			size:    size,
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("CountByJournalID", mock.Anything, int64(7)).Return(0, nil)
+				repo.On("GetBlob", mock.Anything, int64(1), checksum).Return(nil, sql.ErrNoRows)
+				repo.On("ReserveStorage", mock.Anything, int64(1), size, limits.Quota).Return(size, nil)
+				storage.On("Put", mock.Anything, key, size, "image/png").Return(assert.AnError)
+				repo.On("ReleaseStorage", mock.Anything, int64(1), size).Return(nil)
+			},
+			wantErr: helper.INTERNAL_ERROR,
+		},
+		{
+			name:    "row error removes stored object",
+			content: valid,
+			size:    size,
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("CountByJournalID", mock.Anything, int64(7)).Return(0, nil)
+				repo.On("GetBlob", mock.Anything, int64(1), checksum).Return(nil, sql.ErrNoRows)
+				repo.On("ReserveStorage", mock.Anything, int64(1), size, limits.Quota).Return(size, nil)
+				storage.On("Put", mock.Anything, key, size, "image/png").Return(nil)
+				storage.On("URL", key).Return("http://media/photo.jpg")
+				repo.On("Create", mock.Anything, mock.AnythingOfType("*models.Photo"), limits.PerJournal, int64(0)).Return(false, assert.AnError)
+				storage.On("Delete", mock.Anything, key).Return(nil)
+				repo.On("ReleaseStorage", mock.Anything, int64(1), size).Return(nil)
+			},
+			wantErr: helper.INTERNAL_ERROR,
+		},
+		{
+			name:    "journal filled up while storing",
+			content: valid,
+			size:    size,
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("CountByJournalID", mock.Anything, int64(7)).Return(1, nil)
+				repo.On("GetBlob", mock.Anything, int64(1), checksum).Return(nil, sql.ErrNoRows)
+				repo.On("ReserveStorage", mock.Anything, int64(1), size, limits.Quota).Return(size, nil)
+				storage.On("Put", mock.Anything, key, size, "image/png").Return(nil)
+				storage.On("URL", key).Return("http://media/photo.jpg")
+				repo.On("Create", mock.Anything, mock.AnythingOfType("*models.Photo"), limits.PerJournal, int64(0)).Return(false, domain.ErrPhotoLimit)
+				storage.On("Delete", mock.Anything, key).Return(nil)
+				repo.On("ReleaseStorage", mock.Anything, int64(1), size).Return(nil)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name:    "success",
+			content: valid,
+			size:    size,
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("CountByJournalID", mock.Anything, int64(7)).Return(1, nil)
+				repo.On("GetBlob", mock.Anything, int64(1), checksum).Return(nil, sql.ErrNoRows)
+				repo.On("ReserveStorage", mock.Anything, int64(1), size, limits.Quota).Return(size, nil)
+				storage.On("Put", mock.Anything, key, size, "image/png").Return(nil)
+				storage.On("URL", key).Return("http://media/photo.jpg")
+				repo.On("Create", mock.Anything, mock.MatchedBy(func(p *models.Photo) bool {
+					return p.JournalID == 7 && p.BlobID == 0 && p.Status == models.PHOTO_PENDING && p.ContentType == "image/png" &&
+						p.Checksum == checksum
+				}), limits.PerJournal, int64(0)).Return(true, nil)
+			},
+			wantErr: "",
+		},
+		{
+			name:    "recording is stored as uploaded",
+			content: recording,
+			size:    audioSize,
+			peaks:   peaks,
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("CountByJournalID", mock.Anything, int64(7)).Return(0, nil)
+				repo.On("GetBlob", mock.Anything, int64(1), mock.Anything).Return(nil, sql.ErrNoRows)
+				repo.On("ReserveStorage", mock.Anything, int64(1), audioSize, limits.Quota).Return(audioSize, nil)
+				storage.On("Put", mock.Anything, audioKey, audioSize, "audio/mpeg").Return(nil)
+				storage.On("URL", audioKey).Return("http://media/audio.mp3")
+				repo.On("Create", mock.Anything, mock.MatchedBy(func(p *models.Photo) bool {
+					return p.MediaType == models.MEDIA_AUDIO && p.Status == models.PHOTO_READY && p.ContentType == "audio/mpeg" &&
+						p.DurationMs != nil && *p.DurationMs == 260 && slices.Equal(p.Peaks, peaks)
+				}), limits.PerJournal, int64(0)).Return(true, nil)
+			},
+			wantErr: "",
+		},
+		{
+			name:    "recording too long",
+			content: encodeMP3(40),
+			size:    40 * 417,
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("CountByJournalID", mock.Anything, int64(7)).Return(0, nil)
+				repo.On("GetBlob", mock.Anything, int64(1), mock.Anything).Return(nil, sql.ErrNoRows)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name:    "frame header without frames",
+			content: recording[:8],
+			size:    8,
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("CountByJournalID", mock.Anything, int64(7)).Return(0, nil)
+				repo.On("GetBlob", mock.Anything, int64(1), mock.Anything).Return(nil, sql.ErrNoRows)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name:    "peaks on an image",
+			content: valid,
+			size:    size,
+			peaks:   peaks,
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("CountByJournalID", mock.Anything, int64(7)).Return(0, nil)
+				repo.On("GetBlob", mock.Anything, int64(1), checksum).Return(nil, sql.ErrNoRows)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name:    "peak out of range",
+			content: recording,
+			size:    audioSize,
+			peaks:   []int{0, 256},
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("CountByJournalID", mock.Anything, int64(7)).Return(0, nil)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name:     "checksum does not match the file",
+			content:  valid,
+			size:     size,
+			checksum: strings.Repeat("0", 64),
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("CountByJournalID", mock.Anything, int64(7)).Return(0, nil)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name:     "unknown checksum without file",
+			checksum: checksum,
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("CountByJournalID", mock.Anything, int64(7)).Return(0, nil)
+				repo.On("GetBlob", mock.Anything, int64(1), checksum).Return(nil, sql.ErrNoRows)
+			},
+			wantErr: helper.NOT_FOUND,
+		},
+		{
+			name:     "known checksum skips the upload",
+			checksum: checksum,
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("CountByJournalID", mock.Anything, int64(7)).Return(0, nil)
+				repo.On("GetBlob", mock.Anything, int64(1), checksum).Return(blob, nil)
+				repo.On("Create", mock.Anything, mock.MatchedBy(func(p *models.Photo) bool {
+					return p.JournalID == 7 && p.BlobID == 5
+				}), limits.PerJournal, int64(0)).Return(false, nil).Run(func(args mock.Arguments) {
+					args.Get(1).(*models.Photo).StorageKey = blob.StorageKey
+				})
+			},
+			wantErr: "",
+		},
+		{
+			name:     "journal filled up before a known checksum was added",
+			checksum: checksum,
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("CountByJournalID", mock.Anything, int64(7)).Return(1, nil)
+				repo.On("GetBlob", mock.Anything, int64(1), checksum).Return(blob, nil)
+				repo.On("Create", mock.Anything, mock.AnythingOfType("*models.Photo"), limits.PerJournal, int64(0)).Return(false, domain.ErrPhotoLimit)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name:    "known file is not stored again",
+			content: valid,
+			size:    size,
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("CountByJournalID", mock.Anything, int64(7)).Return(0, nil)
+				repo.On("GetBlob", mock.Anything, int64(1), checksum).Return(blob, nil)
+				repo.On("Create", mock.Anything, mock.MatchedBy(func(p *models.Photo) bool {
+					return p.BlobID == 5
+				}), limits.PerJournal, int64(0)).Return(false, nil).Run(func(args mock.Arguments) {
+					args.Get(1).(*models.Photo).StorageKey = blob.StorageKey
+				})
+			},
+			wantErr: "",
+		},
+		{
+			name:     "reserved upload of a known file releases the reservation",
+			content:  valid,
+			size:     size,
+			uploadID: 4,
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("CountByJournalID", mock.Anything, int64(7)).Return(0, nil)
+				repo.On("GetBlob", mock.Anything, int64(1), checksum).Return(blob, nil)
+				repo.On("Create", mock.Anything, mock.AnythingOfType("*models.Photo"), limits.PerJournal, int64(4)).Return(false, nil).Run(func(args mock.Arguments) {
+					args.Get(1).(*models.Photo).StorageKey = blob.StorageKey
+				})
+				repo.On("ReleaseStorage", mock.Anything, int64(1), size).Return(nil)
+			},
+			wantErr: "",
+		},
+		{
+			name:     "reserved upload collected as expired while finalizing",
+			content:  valid,
+			size:     size,
+			uploadID: 4,
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("CountByJournalID", mock.Anything, int64(7)).Return(0, nil)
+				repo.On("GetBlob", mock.Anything, int64(1), checksum).Return(blob, nil)
+				repo.On("Create", mock.Anything, mock.AnythingOfType("*models.Photo"), limits.PerJournal, int64(4)).Return(false, domain.ErrUploadGone)
+			},
+			wantErr: helper.NOT_FOUND,
+		},
+		{
+			name:     "reserved upload keeps the reservation on failure",
+			content:  valid,
+			size:     size,
+			uploadID: 4,
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("CountByJournalID", mock.Anything, int64(7)).Return(0, nil)
+				repo.On("GetBlob", mock.Anything, int64(1), checksum).Return(nil, sql.ErrNoRows)
+				storage.On("Put", mock.Anything, key, size, "image/png").Return(assert.AnError)
+			},
+			wantErr: helper.INTERNAL_ERROR,
+		},
+		{
+			name:    "concurrent upload of the same file stored it first",
+			content: valid,
+			size:    size,
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("CountByJournalID", mock.Anything, int64(7)).Return(0, nil)
+				repo.On("GetBlob", mock.Anything, int64(1), checksum).Return(nil, sql.ErrNoRows)
+				repo.On("ReserveStorage", mock.Anything, int64(1), size, limits.Quota).Return(size, nil)
+				storage.On("Put", mock.Anything, key, size, "image/png").Return(nil)
+				storage.On("URL", key).Return("http://media/photo.png")
+				repo.On("Create", mock.Anything, mock.AnythingOfType("*models.Photo"), limits.PerJournal, int64(0)).Return(false, nil).Run(func(args mock.Arguments) {
+					args.Get(1).(*models.Photo).StorageKey = blob.StorageKey
+				})
+				storage.On("Delete", mock.Anything, key).Return(nil)
+				repo.On("ReleaseStorage", mock.Anything, int64(1), size).Return(nil)
+			},
+			wantErr: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.PhotoRepositoryMock)
+			journals := new(mocks.JournalRepositoryMock)
+			journals.On("GetByID", mock.Anything, "journalUID").Return(journal, nil)
+			storage := new(mocks.StorageMock)
+			tt.setupMocks(repo, storage)
+
+			svc := NewPhoto(repo, journals, storage, NewPhotoProcessor(repo, storage), signer, limits)
+			upload := &dto.PhotoUpload{Checksum: tt.checksum, Peaks: tt.peaks, UploadID: tt.uploadID}
+			if tt.content != nil {
+				upload.Filename = "IMG_0001.JPG"
+				upload.ContentType = "image/jpeg"
+				upload.Size = tt.size
+				upload.Content = bytes.NewReader(tt.content)
+			}
+			resp, err := svc.Upload(context.Background(), 1, "journalUID", upload)
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				assert.True(t, strings.HasPrefix(resp.Url, "http://api/media/users/1/"))
+				assert.Contains(t, resp.Url, "sig=")
+			} else {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+			}
+			if tt.wantUsage != nil {
+				assert.Equal(t, *tt.wantUsage, err.(*helper.AppError).Details)
+			}
+			repo.AssertExpectations(t)
+			storage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPhotoService_Delete(t *testing.T) {
+	journal := &models.Journal{ID: 7, Uid: "journalUID", UserID: 1}
+	signer := helper.NewURLSigner([]byte("key"), "http://api/media", time.Hour)
+
+	tests := []struct {
+		name       string
+		setupMocks func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock)
+		wantErr    string
+	}{
+		{
+			name: "photo of another journal",
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("GetByID", mock.Anything, int64(3)).Return(&models.Photo{ID: 3, JournalID: 8}, nil)
+			},
+			wantErr: helper.NOT_FOUND,
+		},
+		{
+			name: "success",
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("GetByID", mock.Anything, int64(3)).Return(&models.Photo{ID: 3, JournalID: 7, StorageKey: "users/1/photos/a.jpg"}, nil)
+				repo.On("Delete", mock.Anything, int64(3)).Return(&models.PhotoBlob{ID: 5, StorageKey: "users/1/photos/a.jpg",
+					Variants: []models.PhotoVariant{{Name: "small", StorageKey: "users/1/photos/a_small.jpg"}}}, nil)
+				storage.On("Delete", mock.Anything, "users/1/photos/a.jpg").Return(helper.ErrObjectNotFound)
+				storage.On("Delete", mock.Anything, "users/1/photos/a_small.jpg").Return(nil)
+			},
+			wantErr: "",
+		},
+		{
+			name: "shared blob is kept",
+			setupMocks: func(repo *mocks.PhotoRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("GetByID", mock.Anything, int64(3)).Return(&models.Photo{ID: 3, JournalID: 7, StorageKey: "users/1/photos/a.jpg"}, nil)
+				repo.On("Delete", mock.Anything, int64(3)).Return(nil, nil)
+			},
+			wantErr: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.PhotoRepositoryMock)
+			journals := new(mocks.JournalRepositoryMock)
+			journals.On("GetByID", mock.Anything, "journalUID").Return(journal, nil)
+			storage := new(mocks.StorageMock)
+			tt.setupMocks(repo, storage)
+
+			svc := NewPhoto(repo, journals, storage, NewPhotoProcessor(repo, storage), signer, DefaultPhotoLimits)
+			err := svc.Delete(context.Background(), 1, "journalUID", 3)
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+			}
+			repo.AssertExpectations(t)
+			storage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPhotoService_Update(t *testing.T) {
+	journal := &models.Journal{ID: 7, Uid: "journalUID", UserID: 1}
+	signer := helper.NewURLSigner([]byte("key"), "http://api/media", time.Hour)
+	// A removed alt text reaches the repository as an empty one.
+	req := &dto.PhotoUpdateRequest{Caption: helper.Optional[string]{Set: true, Value: helper.Ptr("beach")},
+		AltText: helper.Optional[string]{Set: true}, Cover: helper.Ptr(true)}
+	wantPatch := &models.PhotoPatch{Caption: helper.Ptr("beach"), AltText: helper.Ptr(""), Cover: req.Cover}
+
+	tests := []struct {
+		name       string
+		setupMocks func(repo *mocks.PhotoRepositoryMock)
+		wantErr    string
+	}{
+		{
+			name: "photo of another journal",
+			setupMocks: func(repo *mocks.PhotoRepositoryMock) {
+				repo.On("GetByID", mock.Anything, int64(3)).Return(&models.Photo{ID: 3, JournalID: 8}, nil)
+			},
+			wantErr: helper.NOT_FOUND,
+		},
+		{
+			name: "success",
+			setupMocks: func(repo *mocks.PhotoRepositoryMock) {
+				repo.On("GetByID", mock.Anything, int64(3)).Return(&models.Photo{ID: 3, JournalID: 7}, nil).Once()
+				repo.On("Update", mock.Anything, int64(3), wantPatch).Return(nil)
+				repo.On("GetByID", mock.Anything, int64(3)).Return(&models.Photo{ID: 3, JournalID: 7, Caption: helper.Ptr("beach"), Cover: true}, nil).Once()
+			},
+			wantErr: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.PhotoRepositoryMock)
+			journals := new(mocks.JournalRepositoryMock)
+			journals.On("GetByID", mock.Anything, "journalUID").Return(journal, nil)
+			storage := new(mocks.StorageMock)
+			tt.setupMocks(repo)
+
+			svc := NewPhoto(repo, journals, storage, NewPhotoProcessor(repo, storage), signer, DefaultPhotoLimits)
+			resp, err := svc.Update(context.Background(), 1, "journalUID", 3, req)
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, "beach", *resp.Caption)
+				assert.True(t, resp.Cover)
+			} else {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPhotoService_Reorder(t *testing.T) {
+	journal := &models.Journal{ID: 7, Uid: "journalUID", UserID: 1}
+	signer := helper.NewURLSigner([]byte("key"), "http://api/media", time.Hour)
+	photos := []models.Photo{{ID: 3, JournalID: 7}, {ID: 4, JournalID: 7}}
+
+	tests := []struct {
+		name       string
+		ids        []int64
+		setupMocks func(repo *mocks.PhotoRepositoryMock)
+		wantErr    string
+	}{
+		{
+			name: "missing photo",
+			ids:  []int64{4},
+			setupMocks: func(repo *mocks.PhotoRepositoryMock) {
+				repo.On("GetByJournalID", mock.Anything, int64(7)).Return(photos, nil)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name: "photo of another journal",
+			ids:  []int64{4, 3, 9},
+			setupMocks: func(repo *mocks.PhotoRepositoryMock) {
+				repo.On("GetByJournalID", mock.Anything, int64(7)).Return(photos, nil)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name: "success",
+			ids:  []int64{4, 3},
+			setupMocks: func(repo *mocks.PhotoRepositoryMock) {
+				repo.On("GetByJournalID", mock.Anything, int64(7)).Return(photos, nil)
+				repo.On("Reorder", mock.Anything, int64(7), []int64{4, 3}).Return(nil)
+			},
+			wantErr: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.PhotoRepositoryMock)
+			journals := new(mocks.JournalRepositoryMock)
+			journals.On("GetByID", mock.Anything, "journalUID").Return(journal, nil)
+			storage := new(mocks.StorageMock)
+			tt.setupMocks(repo)
+
+			svc := NewPhoto(repo, journals, storage, NewPhotoProcessor(repo, storage), signer, DefaultPhotoLimits)
+			_, err := svc.Reorder(context.Background(), 1, "journalUID", &dto.PhotoOrderRequest{IDs: tt.ids})
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
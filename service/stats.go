@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"math"
+	"time"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/models"
+)
+
+type stats struct {
+	repo domain.StatsRepository
+}
+
+func NewStats(repo domain.StatsRepository) domain.StatsService {
+	return &stats{repo: repo}
+}
+
+// GetStats reports the cached streak, treating it as broken once a full local
+// day has passed without an entry, and progress toward the weekly goal for
+// the current Monday-based week.
+func (s *stats) GetStats(ctx context.Context, userID int64, loc *time.Location, weeklyGoal int) (*dto.StatsResponse, error) {
+	streak, err := s.repo.GetStreak(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		streak, err = s.repo.RecomputeStreak(ctx, userID)
+	}
+	if err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get streak", err)
+	}
+
+	today := helper.LocalDate(time.Now(), loc)
+	weekStart := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+
+	entries, err := s.repo.CountEntries(ctx, userID, weekStart, weekStart.AddDate(0, 0, 7))
+	if err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to count entries", err)
+	}
+
+	resp := &dto.StatsResponse{
+		LongestStreak: streak.LongestStreak,
+		WeeklyGoal:    weeklyGoal,
+		WeekStart:     weekStart.Format(helper.DATE_LAYOUT),
+		WeekEntries:   entries,
+	}
+	if streak.LastEntryDate != nil {
+		resp.LastEntryDate = streak.LastEntryDate.Format(helper.DATE_LAYOUT)
+		if !streak.LastEntryDate.Before(today.AddDate(0, 0, -1)) {
+			resp.CurrentStreak = streak.CurrentStreak
+		}
+	}
+	if weeklyGoal > 0 {
+		resp.GoalProgress = min(float64(entries)/float64(weeklyGoal), 1)
+		resp.GoalMet = entries >= weeklyGoal
+	}
+
+	return resp, nil
+}
+
+// streakTracker keeps the cached streak in step with journal writes. Errors
+// are only logged so a cache hiccup never fails the write itself.
+type streakTracker struct {
+	repo domain.StatsRepository
+}
+
+// record advances the streak for a new published entry, falling back to a
+// full recompute for backdated entries or a missing cache.
+func (s streakTracker) record(ctx context.Context, userID int64, date time.Time) {
+	advanced, err := s.repo.AdvanceStreak(ctx, userID, date)
+	if err != nil {
+		log.Printf("advance streak for user %d: %v", userID, err)
+	}
+	if !advanced {
+		s.refresh(ctx, userID)
+	}
+}
+
+func (s streakTracker) refresh(ctx context.Context, userID int64) {
+	if _, err := s.repo.RecomputeStreak(ctx, userID); err != nil {
+		log.Printf("recompute streak for user %d: %v", userID, err)
+	}
+}
+
+func (s *stats) GetMoodDistribution(ctx context.Context, userID int64, loc *time.Location, query *dto.MoodAnalyticsQuery) (*dto.MoodDistributionResponse, error) {
+	from, to, err := analyticsPeriod(query, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	// The previous period has the same number of days and ends the day before.
+	days := int(to.Sub(from).Hours()/24) + 1
+	prevTo := from.AddDate(0, 0, -1)
+	prevFrom := prevTo.AddDate(0, 0, 1-days)
+
+	current, err := s.repo.GetMoodDistribution(ctx, userID, from, to)
+	if err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get mood distribution", err)
+	}
+	previous, err := s.repo.GetMoodDistribution(ctx, userID, prevFrom, prevTo)
+	if err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get mood distribution", err)
+	}
+
+	resp := &dto.MoodDistributionResponse{
+		From:         from.Format(helper.DATE_LAYOUT),
+		To:           to.Format(helper.DATE_LAYOUT),
+		PreviousFrom: prevFrom.Format(helper.DATE_LAYOUT),
+		PreviousTo:   prevTo.Format(helper.DATE_LAYOUT),
+		Moods:        []dto.MoodShareResponse{},
+	}
+
+	var weight, previousWeight float64
+	index := make(map[int64]int)
+	for _, c := range current {
+		weight += c.Weight
+		index[c.MoodID] = len(resp.Moods)
+		resp.Moods = append(resp.Moods, dto.MoodShareResponse{MoodID: c.MoodID, MoodLabel: c.MoodLabel, Count: c.Count, Weight: c.Weight})
+	}
+	for _, c := range previous {
+		previousWeight += c.Weight
+		i, ok := index[c.MoodID]
+		if !ok {
+			i = len(resp.Moods)
+			resp.Moods = append(resp.Moods, dto.MoodShareResponse{MoodID: c.MoodID, MoodLabel: c.MoodLabel})
+		}
+		resp.Moods[i].PreviousCount = c.Count
+		resp.Moods[i].PreviousWeight = c.Weight
+	}
+	resp.Total = entryCount(current)
+	resp.PreviousTotal = entryCount(previous)
+	resp.Valence = averageValence(current)
+	resp.PreviousValence = averageValence(previous)
+	for i := range resp.Moods {
+		m := &resp.Moods[i]
+		m.Share = share(m.Weight, weight)
+		m.PreviousShare = share(m.PreviousWeight, previousWeight)
+		m.Change = m.Count - m.PreviousCount
+	}
+
+	return resp, nil
+}
+
+func (s *stats) GetMoodTrend(ctx context.Context, userID int64, loc *time.Location, query *dto.MoodAnalyticsQuery) (*dto.MoodTrendResponse, error) {
+	from, to, err := analyticsPeriod(query, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := query.Interval
+	if interval == "" {
+		interval = "week"
+	}
+
+	points, err := s.repo.GetMoodTrend(ctx, userID, from, to, interval)
+	if err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get mood trend", err)
+	}
+
+	resp := &dto.MoodTrendResponse{Interval: interval, Points: []dto.MoodTrendPointResponse{}}
+	var counts []models.MoodCount
+	for i, p := range points {
+		periodStart := p.PeriodStart.Format(helper.DATE_LAYOUT)
+		if n := len(resp.Points); n == 0 || resp.Points[n-1].PeriodStart != periodStart {
+			resp.Points = append(resp.Points, dto.MoodTrendPointResponse{PeriodStart: periodStart})
+			counts = counts[:0]
+		}
+		last := &resp.Points[len(resp.Points)-1]
+		last.Moods = append(last.Moods, toMoodCountResponse(p.MoodCount))
+
+		counts = append(counts, p.MoodCount)
+		if i == len(points)-1 || !points[i+1].PeriodStart.Equal(p.PeriodStart) {
+			last.Total = entryCount(counts)
+			last.Valence = averageValence(counts)
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *stats) GetMoodByWeekday(ctx context.Context, userID int64, loc *time.Location, query *dto.MoodAnalyticsQuery) ([]dto.MoodWeekdayResponse, error) {
+	from, to, err := analyticsPeriod(query, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	weekdays, err := s.repo.GetMoodByWeekday(ctx, userID, from, to)
+	if err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get mood by weekday", err)
+	}
+
+	resp := make([]dto.MoodWeekdayResponse, 7)
+	for i := range resp {
+		resp[i] = dto.MoodWeekdayResponse{
+			Weekday: i + 1,
+			Name:    time.Weekday((i + 1) % 7).String(),
+			Moods:   []dto.MoodCountResponse{},
+		}
+	}
+	counts := make([][]models.MoodCount, 7)
+	for _, w := range weekdays {
+		day := &resp[w.Weekday-1]
+		day.Moods = append(day.Moods, toMoodCountResponse(w.MoodCount))
+		counts[w.Weekday-1] = append(counts[w.Weekday-1], w.MoodCount)
+	}
+	for i := range resp {
+		resp[i].Total = entryCount(counts[i])
+		resp[i].Valence = averageValence(counts[i])
+	}
+
+	return resp, nil
+}
+
+// analyticsPeriod resolves the inclusive date range of an analytics query,
+// defaulting to the last 30 days in the user's timezone.
+func analyticsPeriod(query *dto.MoodAnalyticsQuery, loc *time.Location) (time.Time, time.Time, error) {
+	to := helper.LocalDate(time.Now(), loc)
+	if query.To != "" {
+		to, _ = helper.ParseDate(query.To)
+	}
+
+	from := to.AddDate(0, 0, -29)
+	if query.From != "" {
+		from, _ = helper.ParseDate(query.From)
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, helper.NewAppError(helper.VALIDATION_ERROR, "from must not be after to", nil)
+	}
+
+	return from, to, nil
+}
+
+// averageValence weighs each mood's valence by its weight, skipping moods
+// that have no valence. It returns nil when none of the moods carry one.
+func averageValence(counts []models.MoodCount) *float64 {
+	var sum, n float64
+	for _, c := range counts {
+		if c.Valence != nil {
+			sum += *c.Valence * c.Weight
+			n += c.Weight
+		}
+	}
+
+	if n == 0 {
+		return nil
+	}
+
+	avg := sum / n
+	return &avg
+}
+
+// entryCount turns mood weights back into the number of journals and
+// check-ins they came from, since every entry weighs one in total.
+func entryCount(counts []models.MoodCount) int {
+	var weight float64
+	for _, c := range counts {
+		weight += c.Weight
+	}
+	return int(math.Round(weight))
+}
+
+func share(weight, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return weight / total
+}
+
+func toMoodCountResponse(c models.MoodCount) dto.MoodCountResponse {
+	return dto.MoodCountResponse{MoodID: c.MoodID, MoodLabel: c.MoodLabel, Count: c.Count, Weight: c.Weight}
+}
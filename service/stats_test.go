@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+	"timo/dto"
+	"timo/helper"
+	"timo/mocks"
+	"timo/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStatsService_GetStats(t *testing.T) {
+	today := helper.LocalDate(time.Now(), time.UTC)
+	yesterday := today.AddDate(0, 0, -1)
+	lastWeek := today.AddDate(0, 0, -7)
+
+	tests := []struct {
+		name       string
+		weeklyGoal int
+		setupMocks func(repo *mocks.StatsRepositoryMock)
+		check      func(t *testing.T, resp *dto.StatsResponse)
+		wantErr    string
+	}{
+		{
+			name:       "streak still alive from yesterday",
+			weeklyGoal: 4,
+			setupMocks: func(repo *mocks.StatsRepositoryMock) {
+				repo.On("GetStreak", mock.Anything, int64(1)).
+					Return(&models.Streak{UserID: 1, CurrentStreak: 3, LongestStreak: 5, LastEntryDate: &yesterday}, nil)
+				repo.On("CountEntries", mock.Anything, int64(1), mock.Anything, mock.Anything).Return(2, nil)
+			},
+			check: func(t *testing.T, resp *dto.StatsResponse) {
+				assert.Equal(t, 3, resp.CurrentStreak)
+				assert.Equal(t, 5, resp.LongestStreak)
+				assert.Equal(t, 0.5, resp.GoalProgress)
+				assert.False(t, resp.GoalMet)
+			},
+		},
+		{
+			name:       "broken streak and goal met",
+			weeklyGoal: 1,
+			setupMocks: func(repo *mocks.StatsRepositoryMock) {
+				repo.On("GetStreak", mock.Anything, int64(1)).
+					Return(&models.Streak{UserID: 1, CurrentStreak: 3, LongestStreak: 5, LastEntryDate: &lastWeek}, nil)
+				repo.On("CountEntries", mock.Anything, int64(1), mock.Anything, mock.Anything).Return(2, nil)
+			},
+			check: func(t *testing.T, resp *dto.StatsResponse) {
+				assert.Equal(t, 0, resp.CurrentStreak)
+				assert.Equal(t, 1.0, resp.GoalProgress)
+				assert.True(t, resp.GoalMet)
+			},
+		},
+		{
+			name: "recomputes missing cache",
+			setupMocks: func(repo *mocks.StatsRepositoryMock) {
+				repo.On("GetStreak", mock.Anything, int64(1)).Return(nil, sql.ErrNoRows)
+				repo.On("RecomputeStreak", mock.Anything, int64(1)).
+					Return(&models.Streak{UserID: 1, CurrentStreak: 1, LongestStreak: 1, LastEntryDate: &today}, nil)
+				repo.On("CountEntries", mock.Anything, int64(1), mock.Anything, mock.Anything).Return(1, nil)
+			},
+			check: func(t *testing.T, resp *dto.StatsResponse) {
+				assert.Equal(t, 1, resp.CurrentStreak)
+				assert.Equal(t, 0.0, resp.GoalProgress)
+			},
+		},
+		{
+			name: "internal server error",
+			setupMocks: func(repo *mocks.StatsRepositoryMock) {
+				repo.On("GetStreak", mock.Anything, int64(1)).Return(nil, assert.AnError)
+			},
+			wantErr: helper.INTERNAL_ERROR,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.StatsRepositoryMock)
+			tt.setupMocks(repo)
+
+			svc := NewStats(repo)
+			resp, err := svc.GetStats(context.Background(), 1, time.UTC, tt.weeklyGoal)
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				tt.check(t, resp)
+			} else {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStatsService_GetMoodDistribution(t *testing.T) {
+	from := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC)
+	prevFrom := time.Date(2024, time.January, 30, 0, 0, 0, 0, time.UTC)
+	prevTo := time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		query      *dto.MoodAnalyticsQuery
+		setupMocks func(repo *mocks.StatsRepositoryMock)
+		wantErr    string
+	}{
+		{
+			name:       "from after to",
+			query:      &dto.MoodAnalyticsQuery{From: "2024-04-01", To: "2024-03-31"},
+			setupMocks: func(repo *mocks.StatsRepositoryMock) {},
+			wantErr:    helper.VALIDATION_ERROR,
+		},
+		{
+			name:  "internal server error",
+			query: &dto.MoodAnalyticsQuery{From: "2024-03-01", To: "2024-03-31"},
+			setupMocks: func(repo *mocks.StatsRepositoryMock) {
+				repo.On("GetMoodDistribution", mock.Anything, int64(1), from, to).Return(nil, assert.AnError)
+			},
+			wantErr: helper.INTERNAL_ERROR,
+		},
+		{
+			name:  "success",
+			query: &dto.MoodAnalyticsQuery{From: "2024-03-01", To: "2024-03-31"},
+			setupMocks: func(repo *mocks.StatsRepositoryMock) {
+				repo.On("GetMoodDistribution", mock.Anything, int64(1), from, to).
+					Return([]models.MoodCount{{MoodID: 1, MoodLabel: "happy", Count: 3, Weight: 2.5}, {MoodID: 5, MoodLabel: "sad", Count: 2, Weight: 1.5}}, nil)
+				repo.On("GetMoodDistribution", mock.Anything, int64(1), prevFrom, prevTo).
+					Return([]models.MoodCount{{MoodID: 5, MoodLabel: "sad", Count: 2, Weight: 2}, {MoodID: 6, MoodLabel: "tired", Count: 2, Weight: 2}}, nil)
+			},
+			wantErr: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.StatsRepositoryMock)
+			tt.setupMocks(repo)
+
+			svc := NewStats(repo)
+			resp, err := svc.GetMoodDistribution(context.Background(), 1, time.UTC, tt.query)
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, "2024-01-30", resp.PreviousFrom)
+				assert.Equal(t, 4, resp.Total)
+				assert.Equal(t, 4, resp.PreviousTotal)
+				assert.Equal(t, []dto.MoodShareResponse{
+					{MoodID: 1, MoodLabel: "happy", Count: 3, Weight: 2.5, Share: 0.625, Change: 3},
+					{MoodID: 5, MoodLabel: "sad", Count: 2, Weight: 1.5, Share: 0.375, PreviousCount: 2, PreviousWeight: 2, PreviousShare: 0.5, Change: 0},
+					{MoodID: 6, MoodLabel: "tired", PreviousCount: 2, PreviousWeight: 2, PreviousShare: 0.5, Change: -2},
+				}, resp.Moods)
+			} else {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStatsService_GetMoodTrend(t *testing.T) {
+	from := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC)
+	happy, sad := 0.8, -0.4
+
+	repo := new(mocks.StatsRepositoryMock)
+	repo.On("GetMoodTrend", mock.Anything, int64(1), from, to, "month").Return([]models.MoodTrendPoint{
+		{PeriodStart: from, MoodCount: models.MoodCount{MoodID: 1, MoodLabel: "happy", Valence: &happy, Count: 2, Weight: 1.5}},
+		{PeriodStart: from, MoodCount: models.MoodCount{MoodID: 5, MoodLabel: "sad", Valence: &sad, Count: 2, Weight: 1.5}},
+		{PeriodStart: from.AddDate(0, 2, 0), MoodCount: models.MoodCount{MoodID: 7, MoodLabel: "custom", Count: 4, Weight: 4}},
+	}, nil)
+
+	svc := NewStats(repo)
+	resp, err := svc.GetMoodTrend(context.Background(), 1, time.UTC, &dto.MoodAnalyticsQuery{From: "2024-01-01", To: "2024-03-31", Interval: "month"})
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.Points, 2)
+	assert.Equal(t, "2024-01-01", resp.Points[0].PeriodStart)
+	assert.Equal(t, 3, resp.Points[0].Total)
+	assert.InDelta(t, 0.2, *resp.Points[0].Valence, 1e-9)
+	assert.Equal(t, "2024-03-01", resp.Points[1].PeriodStart)
+	assert.Nil(t, resp.Points[1].Valence)
+	repo.AssertExpectations(t)
+}
+
+func TestStatsService_GetMoodByWeekday(t *testing.T) {
+	repo := new(mocks.StatsRepositoryMock)
+	repo.On("GetMoodByWeekday", mock.Anything, int64(1), mock.Anything, mock.Anything).Return([]models.MoodWeekday{
+		{Weekday: 1, MoodCount: models.MoodCount{MoodID: 6, MoodLabel: "tired", Count: 3, Weight: 3}},
+		{Weekday: 7, MoodCount: models.MoodCount{MoodID: 1, MoodLabel: "happy", Count: 2, Weight: 2}},
+	}, nil)
+
+	svc := NewStats(repo)
+	resp, err := svc.GetMoodByWeekday(context.Background(), 1, time.UTC, &dto.MoodAnalyticsQuery{})
+
+	assert.NoError(t, err)
+	assert.Len(t, resp, 7)
+	assert.Equal(t, "Monday", resp[0].Name)
+	assert.Equal(t, 3, resp[0].Total)
+	assert.Equal(t, "Sunday", resp[6].Name)
+	assert.Empty(t, resp[2].Moods)
+	repo.AssertExpectations(t)
+}
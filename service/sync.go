@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/models"
+)
+
+type journalSync struct {
+	repo    domain.SyncRepository
+	streaks streakTracker
+}
+
+func NewSync(repo domain.SyncRepository, stats domain.StatsRepository) domain.SyncService {
+	return &journalSync{repo: repo, streaks: streakTracker{repo: stats}}
+}
+
+func (s *journalSync) Sync(ctx context.Context, userID int64, loc *time.Location, req *dto.SyncRequest) (*dto.SyncResponse, error) {
+	var since int64
+	if req.Token != "" {
+		var err error
+		since, err = strconv.ParseInt(req.Token, 10, 64)
+		if err != nil || since < 0 {
+			return nil, helper.NewAppError(helper.VALIDATION_ERROR, "invalid sync token", err)
+		}
+	}
+
+	if details := validateChanges(req.Changes); len(details) > 0 {
+		return nil, helper.NewAppError(helper.VALIDATION_ERROR, "invalid change set", nil).WithDetails(details)
+	}
+
+	today := helper.LocalDate(time.Now(), loc)
+	changes := make([]models.JournalChange, 0, len(req.Changes))
+	for _, c := range req.Changes {
+		change := models.JournalChange{
+			Op:          c.Op,
+			Uid:         c.Uid,
+			BaseVersion: c.BaseVersion,
+			Title:       c.Title,
+			Text:        c.Text,
+			MoodID:      c.MoodID,
+		}
+		if c.EntryDate != "" {
+			entryDate, _ := helper.ParseDate(c.EntryDate)
+			change.EntryDate = &entryDate
+		} else if c.Op == models.CHANGE_CREATE {
+			change.EntryDate = &today
+		}
+		changes = append(changes, change)
+	}
+
+	result, err := s.repo.Sync(ctx, userID, since, changes)
+	if err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to sync journals", err)
+	}
+
+	resp := &dto.SyncResponse{
+		Token:     strconv.FormatInt(result.ChangeSeq, 10),
+		Applied:   []dto.SyncApplied{},
+		Conflicts: []dto.SyncConflict{},
+		Changes:   make([]dto.JournalResponse, 0, len(result.Journals)),
+		Deleted:   make([]dto.SyncDeletedEntry, 0, len(result.Tombstones)),
+	}
+
+	for _, r := range result.Results {
+		if r.Conflict == "" {
+			resp.Applied = append(resp.Applied, dto.SyncApplied{Uid: r.Uid, Op: r.Op, Version: r.Version})
+			continue
+		}
+
+		conflict := dto.SyncConflict{Uid: r.Uid, Op: r.Op, Reason: r.Conflict}
+		if r.Server != nil {
+			server := toJournalResponse(r.Server)
+			conflict.Server = &server
+		}
+		resp.Conflicts = append(resp.Conflicts, conflict)
+	}
+
+	if len(resp.Applied) > 0 {
+		s.streaks.refresh(ctx, userID)
+	}
+
+	for _, journal := range result.Journals {
+		resp.Changes = append(resp.Changes, toJournalResponse(&journal))
+	}
+
+	for _, t := range result.Tombstones {
+		resp.Deleted = append(resp.Deleted, dto.SyncDeletedEntry{Uid: t.Uid, DeletedAt: t.DeletedAt})
+	}
+
+	return resp, nil
+}
+
+func validateChanges(changes []dto.SyncChange) []helper.ValidatorError {
+	var errs []helper.ValidatorError
+	seen := make(map[string]bool, len(changes))
+
+	for i, c := range changes {
+		field := func(name string) string {
+			return fmt.Sprintf("changes[%d].%s", i, name)
+		}
+
+		if seen[c.Uid] {
+			errs = append(errs, helper.ValidatorError{Field: field("uid"), Message: "appears more than once"})
+		}
+		seen[c.Uid] = true
+
+		if c.Op != models.CHANGE_CREATE && c.BaseVersion < 1 {
+			errs = append(errs, helper.ValidatorError{Field: field("base_version"), Message: "is required"})
+		}
+
+		if c.Op != models.CHANGE_DELETE {
+			if c.Title == "" {
+				errs = append(errs, helper.ValidatorError{Field: field("title"), Message: "is required"})
+			}
+			if c.Text == "" {
+				errs = append(errs, helper.ValidatorError{Field: field("text"), Message: "is required"})
+			}
+			if c.MoodID < 1 {
+				errs = append(errs, helper.ValidatorError{Field: field("mood_id"), Message: "is required"})
+			}
+		}
+	}
+
+	return errs
+}
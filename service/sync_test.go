@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+	"timo/dto"
+	"timo/helper"
+	"timo/mocks"
+	"timo/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSyncService_Sync(t *testing.T) {
+	const uid = "550e8400-e29b-41d4-a716-446655440000"
+
+	tests := []struct {
+		name       string
+		req        *dto.SyncRequest
+		setupMocks func(repo *mocks.SyncRepositoryMock)
+		wantErr    string
+		check      func(t *testing.T, resp *dto.SyncResponse)
+	}{
+		{
+			name:       "invalid token",
+			req:        &dto.SyncRequest{Token: "abc"},
+			setupMocks: func(repo *mocks.SyncRepositoryMock) {},
+			wantErr:    helper.VALIDATION_ERROR,
+		},
+		{
+			name: "update without base version",
+			req: &dto.SyncRequest{Changes: []dto.SyncChange{
+				{Op: models.CHANGE_UPDATE, Uid: uid, Title: "title", Text: "text", MoodID: 1},
+			}},
+			setupMocks: func(repo *mocks.SyncRepositoryMock) {},
+			wantErr:    helper.VALIDATION_ERROR,
+		},
+		{
+			name: "repository error",
+			req:  &dto.SyncRequest{Token: "5"},
+			setupMocks: func(repo *mocks.SyncRepositoryMock) {
+				repo.On("Sync", mock.Anything, int64(1), int64(5), mock.Anything).Return(nil, assert.AnError)
+			},
+			wantErr: helper.INTERNAL_ERROR,
+		},
+		{
+			name: "conflicts and server changes",
+			req: &dto.SyncRequest{Token: "5", Changes: []dto.SyncChange{
+				{Op: models.CHANGE_UPDATE, Uid: uid, BaseVersion: 1, Title: "title", Text: "text", MoodID: 1},
+			}},
+			setupMocks: func(repo *mocks.SyncRepositoryMock) {
+				repo.On("Sync", mock.Anything, int64(1), int64(5), []models.JournalChange{
+					{Op: models.CHANGE_UPDATE, Uid: uid, BaseVersion: 1, Title: "title", Text: "text", MoodID: 1},
+				}).Return(&models.SyncResult{
+					ChangeSeq: 9,
+					Results: []models.JournalChangeResult{
+						{Uid: uid, Op: models.CHANGE_UPDATE, Conflict: models.CONFLICT_VERSION_MISMATCH, Server: &models.Journal{Uid: uid, Version: 2}},
+					},
+					Journals:   []models.Journal{{Uid: uid, Version: 2}},
+					Tombstones: []models.JournalTombstone{{Uid: "deletedUID", DeletedAt: time.Now()}},
+				}, nil)
+			},
+			check: func(t *testing.T, resp *dto.SyncResponse) {
+				assert.Equal(t, "9", resp.Token)
+				assert.Empty(t, resp.Applied)
+				assert.Len(t, resp.Conflicts, 1)
+				assert.Equal(t, models.CONFLICT_VERSION_MISMATCH, resp.Conflicts[0].Reason)
+				assert.Equal(t, int64(2), resp.Conflicts[0].Server.Version)
+				assert.Len(t, resp.Changes, 1)
+				assert.Equal(t, "deletedUID", resp.Deleted[0].Uid)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.SyncRepositoryMock)
+			tt.setupMocks(repo)
+
+			svc := NewSync(repo, new(mocks.StatsRepositoryMock))
+			resp, err := svc.Sync(context.Background(), 1, time.UTC, tt.req)
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				tt.check(t, resp)
+			} else {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/models"
+)
+
+const (
+	// uploadTTL is how long a resumable upload may take. The photo collector
+	// removes expired uploads and their parts.
+	uploadTTL = 24 * time.Hour
+	// uploadsMax is how many unexpired uploads a user may have at once.
+	uploadsMax = 5
+	// uploadChunkMin is the least every chunk but the last must carry, which
+	// also bounds the number of parts of an upload.
+	uploadChunkMin = 1 << 20
+	// uploadFinalizeStaleAfter is when a finalize that never finished is
+	// assumed dead and the upload may be finalized or collected again.
+	uploadFinalizeStaleAfter = 10 * time.Minute
+)
+
+type upload struct {
+	repo     domain.UploadRepository
+	journals domain.JournalRepository
+	quota    domain.PhotoRepository
+	storage  helper.Storage
+	photos   domain.PhotoService
+	limits   PhotoLimits
+}
+
+func NewUpload(repo domain.UploadRepository, journals domain.JournalRepository, quota domain.PhotoRepository, storage helper.Storage, photos domain.PhotoService, limits PhotoLimits) domain.UploadService {
+	return &upload{repo: repo, journals: journals, quota: quota, storage: storage, photos: photos, limits: limits}
+}
+
+// Create starts a resumable upload of a file of the announced size and
+// reserves that size in the user's quota until the upload is finalized or
+// expires. The file itself is only checked once the upload is finalized.
+func (u *upload) Create(ctx context.Context, userID int64, journalUid string, req *dto.UploadCreateRequest) (*dto.UploadResponse, error) {
+	journal, err := ownJournal(ctx, u.journals, userID, journalUid)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Size > u.limits.MaxFileSize() {
+		return nil, helper.NewAppError(helper.FILE_TOO_LARGE, fmt.Sprintf("file must not be larger than %d MB", u.limits.MaxFileSize()>>20), nil)
+	}
+
+	upload := &models.PhotoUpload{
+		UserID:    userID,
+		JournalID: journal.ID,
+		Filename:  req.Filename,
+		Size:      req.Size,
+		Checksum:  req.Checksum,
+		Caption:   req.Caption,
+		AltText:   req.AltText,
+		Peaks:     req.Peaks,
+		ExpiresAt: time.Now().Add(uploadTTL),
+	}
+
+	used, err := u.quota.ReserveStorage(ctx, userID, req.Size, u.limits.Quota)
+	if err != nil {
+		if errors.Is(err, domain.ErrQuotaExceeded) {
+			return nil, helper.NewAppError(helper.QUOTA_EXCEEDED, "storage quota exceeded", err).
+				WithDetails(dto.StorageUsage{Used: used, Quota: u.limits.Quota, Requested: req.Size})
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to reserve storage", err)
+	}
+
+	if err := u.repo.Create(ctx, upload, uploadsMax); err != nil {
+		u.releaseStorage(userID, req.Size)
+		if errors.Is(err, domain.ErrUploadLimit) {
+			return nil, helper.NewAppError(helper.CONFLICT, fmt.Sprintf("at most %d uploads may be in progress", uploadsMax), err)
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to create upload", err)
+	}
+
+	return toUploadResponse(upload), nil
+}
+
+func (u *upload) Get(ctx context.Context, userID int64, journalUid, uid string) (*dto.UploadResponse, error) {
+	upload, err := u.getUpload(ctx, userID, journalUid, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	return toUploadResponse(upload), nil
+}
+
+// Append stores a chunk as a new part. The chunk must start at the current
+// offset; a client that lost track asks for the offset and resumes from
+// there. Only the chunk that completes the upload may be smaller than
+// uploadChunkMin.
+func (u *upload) Append(ctx context.Context, userID int64, journalUid, uid string, chunk *dto.UploadChunk) (*dto.UploadResponse, error) {
+	upload, err := u.getUpload(ctx, userID, journalUid, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if chunk.Offset != upload.Received {
+		return nil, offsetError(upload.Received)
+	}
+	if chunk.Size > upload.Size-upload.Received {
+		return nil, helper.NewAppError(helper.VALIDATION_ERROR, "chunk goes past the end of the upload", nil)
+	}
+	if chunk.Size == 0 {
+		return toUploadResponse(upload), nil
+	}
+	if chunk.Size < uploadChunkMin && chunk.Size != upload.Size-upload.Received {
+		return nil, helper.NewAppError(helper.VALIDATION_ERROR, fmt.Sprintf("chunks must be at least %d MB, except the last", uploadChunkMin>>20), nil)
+	}
+
+	key := helper.StorageKey(uploadPrefix(upload), "")
+	if err := u.storage.Put(ctx, key, chunk.Content, chunk.Size, "application/octet-stream"); err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to store chunk", err)
+	}
+
+	part := &models.PhotoUploadPart{UploadID: upload.ID, Start: chunk.Offset, StorageKey: key, Size: chunk.Size}
+	received, err := u.repo.AddPart(ctx, upload.ID, part)
+	if err != nil {
+		removeObject(u.storage, key)
+		if errors.Is(err, domain.ErrUploadOffset) {
+			return nil, offsetError(received)
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to save chunk", err)
+	}
+
+	upload.Received = received
+	return toUploadResponse(upload), nil
+}
+
+// Finalize joins the parts of a complete upload and hands the file to the
+// photo service, which checks, deduplicates and attaches it like a direct
+// upload, using the space reserved by Create. The upload is claimed first, so
+// of two concurrent calls only one attaches a photo. The photo is saved in
+// the same transaction that deletes the upload, so the reservation can't be
+// released again by the GC. The upload is kept with its reservation when the
+// photo service fails, so it can be finalized again once e.g. a photo of the
+// journal has been removed.
+func (u *upload) Finalize(ctx context.Context, userID int64, journalUid, uid string) (*dto.PhotoResponse, error) {
+	upload, err := u.getUpload(ctx, userID, journalUid, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if upload.Received != upload.Size {
+		return nil, helper.NewAppError(helper.CONFLICT, "upload is not complete", nil).
+			WithDetails(dto.UploadOffset{Offset: upload.Received})
+	}
+
+	if err := u.repo.Claim(ctx, upload.ID, uploadFinalizeStaleAfter); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helper.NewAppError(helper.CONFLICT, "upload is already being finalized", err)
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to claim upload", err)
+	}
+
+	resp, err := u.finalize(ctx, userID, journalUid, upload)
+	if err != nil {
+		if err := u.repo.Unclaim(context.Background(), upload.ID); err != nil {
+			log.Printf("failed to unclaim upload %s: %v", upload.Uid, err)
+		}
+		return nil, err
+	}
+
+	for _, part := range upload.Parts {
+		removeObject(u.storage, part.StorageKey)
+	}
+
+	return resp, nil
+}
+
+// finalize attaches the joined file of a claimed upload.
+func (u *upload) finalize(ctx context.Context, userID int64, journalUid string, upload *models.PhotoUpload) (*dto.PhotoResponse, error) {
+	key, err := u.join(ctx, upload)
+	if err != nil {
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to join upload", err)
+	}
+	if len(upload.Parts) > 1 {
+		defer removeObject(u.storage, key)
+	}
+
+	content := helper.NewObjectReader(ctx, u.storage, key, upload.Size)
+	defer content.Close()
+
+	return u.photos.Upload(ctx, userID, journalUid, &dto.PhotoUpload{
+		Filename: upload.Filename,
+		Size:     upload.Size,
+		Content:  content,
+		Checksum: upload.Checksum,
+		Peaks:    upload.Peaks,
+		Caption:  helper.Deref(upload.Caption),
+		AltText:  helper.Deref(upload.AltText),
+		UploadID: upload.ID,
+	})
+}
+
+// join returns the key of a single object holding the whole file. A single
+// part already is one; several are copied into a new object.
+func (u *upload) join(ctx context.Context, upload *models.PhotoUpload) (string, error) {
+	if len(upload.Parts) == 1 {
+		return upload.Parts[0].StorageKey, nil
+	}
+
+	readers := make([]io.Reader, 0, len(upload.Parts))
+	for _, part := range upload.Parts {
+		r := helper.NewObjectReader(ctx, u.storage, part.StorageKey, part.Size)
+		defer r.Close()
+		readers = append(readers, r)
+	}
+
+	key := helper.StorageKey(uploadPrefix(upload), "")
+	if err := u.storage.Put(ctx, key, io.MultiReader(readers...), upload.Size, "application/octet-stream"); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// getUpload loads an unexpired upload of the user for the journal. Anything
+// else is reported as missing.
+func (u *upload) getUpload(ctx context.Context, userID int64, journalUid, uid string) (*models.PhotoUpload, error) {
+	journal, err := ownJournal(ctx, u.journals, userID, journalUid)
+	if err != nil {
+		return nil, err
+	}
+
+	upload, err := u.repo.GetByUid(ctx, uid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helper.NewAppError(helper.NOT_FOUND, "upload not found", err)
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get upload", err)
+	}
+
+	if upload.UserID != userID || upload.JournalID != journal.ID || !time.Now().Before(upload.ExpiresAt) {
+		return nil, helper.NewAppError(helper.NOT_FOUND, "upload not found", nil)
+	}
+
+	return upload, nil
+}
+
+// releaseStorage hands back the reservation of an upload that was not
+// created. It runs detached from the request like photo.releaseStorage.
+func (u *upload) releaseStorage(userID, size int64) {
+	if err := u.quota.ReleaseStorage(context.Background(), userID, size); err != nil {
+		log.Printf("failed to release %d bytes of storage for user %d: %v", size, userID, err)
+	}
+}
+
+// uploadPrefix keeps the parts of an upload together in the user's uploads
+// area, where the collector looks for abandoned files.
+func uploadPrefix(upload *models.PhotoUpload) string {
+	return fmt.Sprintf("users/%d/uploads/%s", upload.UserID, upload.Uid)
+}
+
+func offsetError(offset int64) *helper.AppError {
+	return helper.NewAppError(helper.CONFLICT, "chunk does not start at the upload offset", nil).
+		WithDetails(dto.UploadOffset{Offset: offset})
+}
+
+func toUploadResponse(upload *models.PhotoUpload) *dto.UploadResponse {
+	return &dto.UploadResponse{
+		ID:        upload.Uid,
+		Offset:    upload.Received,
+		Size:      upload.Size,
+		ExpiresAt: upload.ExpiresAt,
+	}
+}
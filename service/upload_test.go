@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"strings"
+	"testing"
+	"time"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/mocks"
+	"timo/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const uploadUID = "3f1c2a0e-8c47-4a8e-9a53-2f4f0d1f7b10"
+
+func TestUploadService_Create(t *testing.T) {
+	journal := &models.Journal{ID: 7, Uid: "journalUID", UserID: 1}
+	req := &dto.UploadCreateRequest{Filename: "IMG.PNG", Size: 11}
+
+	tests := []struct {
+		name       string
+		setupMocks func(repo *mocks.UploadRepositoryMock, quota *mocks.PhotoRepositoryMock)
+		wantErr    string
+	}{
+		{
+			name: "quota exceeded",
+			setupMocks: func(repo *mocks.UploadRepositoryMock, quota *mocks.PhotoRepositoryMock) {
+				quota.On("ReserveStorage", mock.Anything, int64(1), int64(11), DefaultPhotoLimits.Quota).Return(DefaultPhotoLimits.Quota, domain.ErrQuotaExceeded)
+			},
+			wantErr: helper.QUOTA_EXCEEDED,
+		},
+		{
+			name: "too many uploads releases the reservation",
+			setupMocks: func(repo *mocks.UploadRepositoryMock, quota *mocks.PhotoRepositoryMock) {
+				quota.On("ReserveStorage", mock.Anything, int64(1), int64(11), DefaultPhotoLimits.Quota).Return(int64(11), nil)
+				repo.On("Create", mock.Anything, mock.AnythingOfType("*models.PhotoUpload"), uploadsMax).Return(domain.ErrUploadLimit)
+				quota.On("ReleaseStorage", mock.Anything, int64(1), int64(11)).Return(nil)
+			},
+			wantErr: helper.CONFLICT,
+		},
+		{
+			name: "success",
+			setupMocks: func(repo *mocks.UploadRepositoryMock, quota *mocks.PhotoRepositoryMock) {
+				quota.On("ReserveStorage", mock.Anything, int64(1), int64(11), DefaultPhotoLimits.Quota).Return(int64(11), nil)
+				repo.On("Create", mock.Anything, mock.MatchedBy(func(u *models.PhotoUpload) bool {
+					return u.UserID == 1 && u.JournalID == 7 && u.Size == 11
+				}), uploadsMax).Return(nil).Run(func(args mock.Arguments) {
+					args.Get(1).(*models.PhotoUpload).Uid = uploadUID
+				})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.UploadRepositoryMock)
+			journals := new(mocks.JournalRepositoryMock)
+			journals.On("GetByID", mock.Anything, "journalUID").Return(journal, nil)
+			quota := new(mocks.PhotoRepositoryMock)
+			tt.setupMocks(repo, quota)
+
+			svc := NewUpload(repo, journals, quota, new(mocks.StorageMock), new(mocks.PhotoServiceMock), DefaultPhotoLimits)
+			resp, err := svc.Create(context.Background(), 1, "journalUID", req)
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, uploadUID, resp.ID)
+			} else {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+			}
+			repo.AssertExpectations(t)
+			quota.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUploadService_Append(t *testing.T) {
+	journal := &models.Journal{ID: 7, Uid: "journalUID", UserID: 1}
+	isPart := mock.MatchedBy(func(key string) bool {
+		return strings.HasPrefix(key, "users/1/uploads/"+uploadUID+"/")
+	})
+	pending := func() *models.PhotoUpload {
+		return &models.PhotoUpload{ID: 4, Uid: uploadUID, UserID: 1, JournalID: 7, Size: 10, Received: 5, ExpiresAt: time.Now().Add(time.Hour)}
+	}
+
+	tests := []struct {
+		name       string
+		offset     int64
+		setupMocks func(repo *mocks.UploadRepositoryMock, storage *mocks.StorageMock)
+		wantErr    string
+		wantOffset int64
+	}{
+		{
+			name:   "upload of another journal",
+			offset: 5,
+			setupMocks: func(repo *mocks.UploadRepositoryMock, storage *mocks.StorageMock) {
+				upload := pending()
+				upload.JournalID = 8
+				repo.On("GetByUid", mock.Anything, uploadUID).Return(upload, nil)
+			},
+			wantErr: helper.NOT_FOUND,
+		},
+		{
+			name:   "expired upload",
+			offset: 5,
+			setupMocks: func(repo *mocks.UploadRepositoryMock, storage *mocks.StorageMock) {
+				upload := pending()
+				upload.ExpiresAt = time.Now().Add(-time.Minute)
+				repo.On("GetByUid", mock.Anything, uploadUID).Return(upload, nil)
+			},
+			wantErr: helper.NOT_FOUND,
+		},
+		{
+			name:   "wrong offset",
+			offset: 0,
+			setupMocks: func(repo *mocks.UploadRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("GetByUid", mock.Anything, uploadUID).Return(pending(), nil)
+			},
+			wantErr:    helper.CONFLICT,
+			wantOffset: 5,
+		},
+		{
+			name:   "chunk too small",
+			offset: 5,
+			setupMocks: func(repo *mocks.UploadRepositoryMock, storage *mocks.StorageMock) {
+				upload := pending()
+				upload.Size = 2 << 20
+				repo.On("GetByUid", mock.Anything, uploadUID).Return(upload, nil)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name:   "concurrent chunk won",
+			offset: 5,
+			setupMocks: func(repo *mocks.UploadRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("GetByUid", mock.Anything, uploadUID).Return(pending(), nil)
+				storage.On("Put", mock.Anything, isPart, int64(5), "application/octet-stream").Return(nil)
+				repo.On("AddPart", mock.Anything, int64(4), mock.AnythingOfType("*models.PhotoUploadPart")).Return(int64(10), domain.ErrUploadOffset)
+				storage.On("Delete", mock.Anything, isPart).Return(nil)
+			},
+			wantErr:    helper.CONFLICT,
+			wantOffset: 10,
+		},
+		{
+			name:   "success",
+			offset: 5,
+			setupMocks: func(repo *mocks.UploadRepositoryMock, storage *mocks.StorageMock) {
+				repo.On("GetByUid", mock.Anything, uploadUID).Return(pending(), nil)
+				storage.On("Put", mock.Anything, isPart, int64(5), "application/octet-stream").Return(nil)
+				repo.On("AddPart", mock.Anything, int64(4), mock.MatchedBy(func(p *models.PhotoUploadPart) bool {
+					return p.Start == 5 && p.Size == 5
+				})).Return(int64(10), nil)
+			},
+			wantOffset: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.UploadRepositoryMock)
+			journals := new(mocks.JournalRepositoryMock)
+			journals.On("GetByID", mock.Anything, "journalUID").Return(journal, nil)
+			storage := new(mocks.StorageMock)
+			tt.setupMocks(repo, storage)
+
+			svc := NewUpload(repo, journals, new(mocks.PhotoRepositoryMock), storage, new(mocks.PhotoServiceMock), DefaultPhotoLimits)
+			resp, err := svc.Append(context.Background(), 1, "journalUID", uploadUID, &dto.UploadChunk{
+				Offset:  tt.offset,
+				Size:    5,
+				Content: strings.NewReader("world"),
+			})
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantOffset, resp.Offset)
+			} else {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+				if tt.wantErr == helper.CONFLICT {
+					assert.Equal(t, dto.UploadOffset{Offset: tt.wantOffset}, err.(*helper.AppError).Details)
+				}
+			}
+			repo.AssertExpectations(t)
+			storage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUploadService_Finalize(t *testing.T) {
+	journal := &models.Journal{ID: 7, Uid: "journalUID", UserID: 1}
+	isJoined := mock.MatchedBy(func(key string) bool {
+		return strings.HasPrefix(key, "users/1/uploads/"+uploadUID+"/") && !strings.HasSuffix(key, "/a") && !strings.HasSuffix(key, "/b")
+	})
+	upload := func(received int64) *models.PhotoUpload {
+		return &models.PhotoUpload{ID: 4, Uid: uploadUID, UserID: 1, JournalID: 7, Filename: "IMG.PNG", Size: 11, Received: received,
+			Caption: helper.Ptr("sunset"), ExpiresAt: time.Now().Add(time.Hour),
+			Parts: []models.PhotoUploadPart{
+				{UploadID: 4, Start: 0, StorageKey: "users/1/uploads/" + uploadUID + "/a", Size: 5},
+				{UploadID: 4, Start: 5, StorageKey: "users/1/uploads/" + uploadUID + "/b", Size: 6},
+			}}
+	}
+	body := func(s string) io.ReadCloser { return io.NopCloser(strings.NewReader(s)) }
+
+	tests := []struct {
+		name       string
+		setupMocks func(repo *mocks.UploadRepositoryMock, storage *mocks.StorageMock, photos *mocks.PhotoServiceMock)
+		wantErr    string
+	}{
+		{
+			name: "incomplete",
+			setupMocks: func(repo *mocks.UploadRepositoryMock, storage *mocks.StorageMock, photos *mocks.PhotoServiceMock) {
+				repo.On("GetByUid", mock.Anything, uploadUID).Return(upload(5), nil)
+			},
+			wantErr: helper.CONFLICT,
+		},
+		{
+			name: "not found",
+			setupMocks: func(repo *mocks.UploadRepositoryMock, storage *mocks.StorageMock, photos *mocks.PhotoServiceMock) {
+				repo.On("GetByUid", mock.Anything, uploadUID).Return(nil, sql.ErrNoRows)
+			},
+			wantErr: helper.NOT_FOUND,
+		},
+		{
+			name: "finalized concurrently",
+			setupMocks: func(repo *mocks.UploadRepositoryMock, storage *mocks.StorageMock, photos *mocks.PhotoServiceMock) {
+				repo.On("GetByUid", mock.Anything, uploadUID).Return(upload(11), nil)
+				repo.On("Claim", mock.Anything, int64(4), uploadFinalizeStaleAfter).Return(sql.ErrNoRows)
+			},
+			wantErr: helper.CONFLICT,
+		},
+		{
+			name: "rejected photo keeps the upload",
+			setupMocks: func(repo *mocks.UploadRepositoryMock, storage *mocks.StorageMock, photos *mocks.PhotoServiceMock) {
+				repo.On("GetByUid", mock.Anything, uploadUID).Return(upload(11), nil)
+				repo.On("Claim", mock.Anything, int64(4), uploadFinalizeStaleAfter).Return(nil)
+				repo.On("Unclaim", mock.Anything, int64(4)).Return(nil)
+				storage.On("GetRange", mock.Anything, "users/1/uploads/"+uploadUID+"/a", int64(0), int64(5)).Return(body("hello"), nil)
+				storage.On("GetRange", mock.Anything, "users/1/uploads/"+uploadUID+"/b", int64(0), int64(6)).Return(body(" world"), nil)
+				storage.On("Put", mock.Anything, isJoined, int64(11), "application/octet-stream").Return(nil)
+				photos.On("Upload", mock.Anything, int64(1), "journalUID", mock.Anything).
+					Return(nil, photoError("journal already has the maximum of 20 photos"))
+				storage.On("Delete", mock.Anything, isJoined).Return(nil)
+			},
+			wantErr: helper.VALIDATION_ERROR,
+		},
+		{
+			name: "success",
+			setupMocks: func(repo *mocks.UploadRepositoryMock, storage *mocks.StorageMock, photos *mocks.PhotoServiceMock) {
+				repo.On("GetByUid", mock.Anything, uploadUID).Return(upload(11), nil)
+				repo.On("Claim", mock.Anything, int64(4), uploadFinalizeStaleAfter).Return(nil)
+				storage.On("GetRange", mock.Anything, "users/1/uploads/"+uploadUID+"/a", int64(0), int64(5)).Return(body("hello"), nil)
+				storage.On("GetRange", mock.Anything, "users/1/uploads/"+uploadUID+"/b", int64(0), int64(6)).Return(body(" world"), nil)
+				storage.On("Put", mock.Anything, isJoined, int64(11), "application/octet-stream").Return(nil)
+				storage.On("GetRange", mock.Anything, isJoined, int64(0), int64(11)).Return(body("hello world"), nil)
+				photos.On("Upload", mock.Anything, int64(1), "journalUID", mock.MatchedBy(func(u *dto.PhotoUpload) bool {
+					return u.Filename == "IMG.PNG" && u.Size == 11 && u.Caption == "sunset" && u.UploadID == 4
+				})).Return(&dto.PhotoResponse{ID: 3}, nil).Run(func(args mock.Arguments) {
+					content, _ := io.ReadAll(args.Get(3).(*dto.PhotoUpload).Content)
+					assert.Equal(t, "hello world", string(content))
+				})
+				storage.On("Delete", mock.Anything, "users/1/uploads/"+uploadUID+"/a").Return(nil)
+				storage.On("Delete", mock.Anything, "users/1/uploads/"+uploadUID+"/b").Return(nil)
+				storage.On("Delete", mock.Anything, isJoined).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.UploadRepositoryMock)
+			journals := new(mocks.JournalRepositoryMock)
+			journals.On("GetByID", mock.Anything, "journalUID").Return(journal, nil)
+			storage := new(mocks.StorageMock)
+			photos := new(mocks.PhotoServiceMock)
+			tt.setupMocks(repo, storage, photos)
+
+			svc := NewUpload(repo, journals, new(mocks.PhotoRepositoryMock), storage, photos, DefaultPhotoLimits)
+			resp, err := svc.Finalize(context.Background(), 1, "journalUID", uploadUID)
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, int64(3), resp.ID)
+			} else {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+			}
+			repo.AssertExpectations(t)
+			storage.AssertExpectations(t)
+			photos.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"timo/domain"
+	"timo/dto"
+	"timo/helper"
+	"timo/models"
+)
+
+type user struct {
+	repo domain.UserRepository
+}
+
+func NewUser(repo domain.UserRepository) domain.UserService {
+	return &user{repo: repo}
+}
+
+func (u *user) GetProfile(ctx context.Context, uid string) (*dto.UserResponse, error) {
+	user, err := u.repo.GetByUID(ctx, uid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helper.NewAppError(helper.NOT_FOUND, "user not found", err)
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to get user", err)
+	}
+
+	return toUserResponse(user), nil
+}
+
+func (u *user) UpdateSettings(ctx context.Context, uid string, req *dto.UserSettingsRequest) (*dto.UserResponse, error) {
+	settings := &models.UserSettings{
+		Timezone:     req.Timezone,
+		MemoryDigest: req.MemoryDigest,
+		WeeklyGoal:   req.WeeklyGoal,
+	}
+
+	err := u.repo.UpdateSettings(ctx, uid, settings)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helper.NewAppError(helper.NOT_FOUND, "user not found", err)
+		}
+		return nil, helper.NewAppError(helper.INTERNAL_ERROR, "failed to update settings", err)
+	}
+
+	return u.GetProfile(ctx, uid)
+}
+
+func toUserResponse(user *models.User) *dto.UserResponse {
+	return &dto.UserResponse{
+		Uid:          user.Uid,
+		Name:         user.Name,
+		Email:        user.Email,
+		Timezone:     user.Timezone,
+		MemoryDigest: user.MemoryDigest,
+		WeeklyGoal:   user.WeeklyGoal,
+	}
+}
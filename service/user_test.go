@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"timo/dto"
+	"timo/helper"
+	"timo/mocks"
+	"timo/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUserService_UpdateSettings(t *testing.T) {
+	timezone := "Asia/Jakarta"
+
+	tests := []struct {
+		name       string
+		setupMocks func(repo *mocks.UserRepositoryMock)
+		wantErr    string
+	}{
+		{
+			name: "user not found",
+			setupMocks: func(repo *mocks.UserRepositoryMock) {
+				repo.On("UpdateSettings", mock.Anything, "userUID", &models.UserSettings{Timezone: &timezone}).Return(sql.ErrNoRows)
+			},
+			wantErr: helper.NOT_FOUND,
+		},
+		{
+			name: "internal server error",
+			setupMocks: func(repo *mocks.UserRepositoryMock) {
+				repo.On("UpdateSettings", mock.Anything, "userUID", mock.Anything).Return(assert.AnError)
+			},
+			wantErr: helper.INTERNAL_ERROR,
+		},
+		{
+			name: "success",
+			setupMocks: func(repo *mocks.UserRepositoryMock) {
+				repo.On("UpdateSettings", mock.Anything, "userUID", &models.UserSettings{Timezone: &timezone}).Return(nil)
+				repo.On("GetByUID", mock.Anything, "userUID").
+					Return(&models.User{Uid: "userUID", Timezone: timezone}, nil)
+			},
+			wantErr: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.UserRepositoryMock)
+			tt.setupMocks(repo)
+
+			svc := NewUser(repo)
+			resp, err := svc.UpdateSettings(context.Background(), "userUID", &dto.UserSettingsRequest{Timezone: &timezone})
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, timezone, resp.Timezone)
+			} else {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Equal(t, tt.wantErr, err.(*helper.AppError).Code)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}